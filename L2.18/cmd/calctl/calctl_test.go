@@ -0,0 +1,223 @@
+package main
+
+import (
+	"bytes"
+	"calendar/internal/app"
+	"calendar/internal/ical"
+	"calendar/internal/repository"
+	"calendar/internal/web"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type cli struct {
+	t      *testing.T
+	server string
+	config string
+}
+
+func newCLI(t *testing.T) *cli {
+	repo := repository.NewInMemoryRepo()
+	r := chi.NewRouter()
+	web.RegisterRoutes(r, web.NewCalendarHandler(repo, zap.NewNop()), nil, web.Limits{})
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+	return &cli{t: t, server: srv.URL, config: filepath.Join(t.TempDir(), "calctl", "config.yaml")}
+}
+
+// run запускает calctl с CALCTL_CONFIG и CALCTL_SERVER тестового сервера
+func (c *cli) run(stdin string, args ...string) (string, string, int) {
+	var stdout, stderr bytes.Buffer
+	env := map[string]string{"CALCTL_CONFIG": c.config, "CALCTL_SERVER": c.server}
+	code := run(context.Background(), args, strings.NewReader(stdin), &stdout, &stderr, func(k string) string { return env[k] })
+	return stdout.String(), stderr.String(), code
+}
+
+func (c *cli) ok(args ...string) string {
+	c.t.Helper()
+	out, errOut, code := c.run("", args...)
+	if code != 0 {
+		c.t.Fatalf("calctl %v: exit %d: %s", args, code, errOut)
+	}
+	return out
+}
+
+func (c *cli) event(args ...string) app.Event {
+	c.t.Helper()
+	var e app.Event
+	if err := json.Unmarshal([]byte(c.ok(append(args, "-o", "json")...)), &e); err != nil {
+		c.t.Fatalf("decode event: %v", err)
+	}
+	return e
+}
+
+func TestEventCommands(t *testing.T) {
+	c := newCLI(t)
+	c.ok("config", "set", "user_id", "1")
+
+	e := c.event("create", "-date", "2025-05-05", "-text", "planning", "-title", "Q3", "-tags", "Work,work,team")
+	if e.UserID != 1 || !e.AllDay || e.Title != "Q3" || strings.Join(e.Tags, ",") != "work,team" {
+		t.Fatalf("unexpected event %+v", e)
+	}
+	id := e.EventId.String()
+
+	// флаги после позиционного аргумента; незаданные поля не меняются
+	e = c.event("update", id, "-location", "Room 1")
+	if e.Location != "Room 1" || e.Title != "Q3" || e.EventText != "planning" || e.Version != 2 {
+		t.Fatalf("update changed more than asked: %+v", e)
+	}
+	if out := c.ok("day", "2025-05-05"); !strings.Contains(out, "EVENT") || !strings.Contains(out, id) || !strings.Contains(out, "2025-05-05 all day") {
+		t.Fatalf("unexpected table:\n%s", out)
+	}
+
+	series := c.event("create", "-tz", "Europe/Moscow", "-start", "2025-05-05 10:00", "-end", "2025-05-05 11:00", "-text", "standup", "-rrule", "FREQ=DAILY;COUNT=3")
+	if series.TimeZone != "Europe/Moscow" || series.Start.UTC().Hour() != 7 {
+		t.Fatalf("local time not converted: %+v", series)
+	}
+	if out := c.ok("week", "2025-05-05", "-tz", "Europe/Moscow"); !strings.Contains(out, "2025-05-06 10:00–11:00") {
+		t.Fatalf("occurrence not shown in -tz:\n%s", out)
+	}
+
+	// .ics диапазона: вхождения серии становятся отдельными событиями с разными UID
+	ics := c.ok("range", "-from", "2025-05-01", "-to", "2025-05-31", "-limit", "1", "-o", "ics")
+	parsed, err := ical.Decode(strings.NewReader(ics))
+	if err != nil {
+		t.Fatalf("decode ics: %v", err)
+	}
+	uids := map[string]bool{}
+	for _, v := range parsed {
+		if v.Err != nil || v.Request.RRule != nil || v.RecurrenceId != "" {
+			t.Fatalf("unexpected VEVENT %+v", v)
+		}
+		uids[v.UID] = true
+	}
+	if len(parsed) != 4 || len(uids) != 4 {
+		t.Fatalf("expected 4 standalone events across pages, got %d (%d uids):\n%s", len(parsed), len(uids), ics)
+	}
+
+	var hits []repository.SearchHit
+	json.Unmarshal([]byte(c.ok("search", "planning", "-tags", "team", "-o", "json")), &hits)
+	if len(hits) != 1 || hits[0].Event.EventId != e.EventId {
+		t.Fatalf("unexpected search hits %+v", hits)
+	}
+
+	if out := c.ok("delete", id); !strings.Contains(out, "deleted "+id) {
+		t.Fatalf("unexpected delete output %q", out)
+	}
+	_, errOut, code := c.run("", "get", id)
+	if code != 1 || !strings.Contains(errOut, "404") || !strings.Contains(errOut, "[not_found]") {
+		t.Fatalf("expected not found, got %d: %s", code, errOut)
+	}
+	if out := c.ok("history", id); !strings.Contains(out, "deleted") || !strings.Contains(out, "created") {
+		t.Fatalf("unexpected history:\n%s", out)
+	}
+	if e := c.event("restore", id); e.EventId.String() != id {
+		t.Fatalf("unexpected restored event %+v", e)
+	}
+	if e := c.event("get", id); e.Location != "Room 1" {
+		t.Fatalf("unexpected event after restore %+v", e)
+	}
+}
+
+func TestImportExport(t *testing.T) {
+	c := newCLI(t)
+	c.ok("config", "set", "user_id", "1")
+	c.ok("create", "-date", "2025-05-05", "-text", "planning", "-description", "first, second")
+
+	file := filepath.Join(t.TempDir(), "calendar.ics")
+	c.ok("export", "-file", file)
+	data, _ := os.ReadFile(file)
+	if !strings.Contains(string(data), "SUMMARY:planning") {
+		t.Fatalf("unexpected export:\n%s", data)
+	}
+
+	other := newCLI(t)
+	out, errOut, code := other.run(string(data), "import", "-user", "2", "-")
+	if code != 0 || !strings.Contains(out, "created") {
+		t.Fatalf("import failed: %d %s %s", code, out, errOut)
+	}
+	var list []*app.Event
+	json.Unmarshal([]byte(other.ok("day", "2025-05-05", "-user", "2", "-o", "json")), &list)
+	if len(list) != 1 || list[0].Description != "first, second" {
+		t.Fatalf("unexpected imported events %+v", list)
+	}
+
+	batch := `{"operations":[{"op":"create","event":{"date":"2025-05-06","event":"a"}},{"op":"delete","event":{"event_id":"` + list[0].EventId.String() + `"}}]}`
+	if _, errOut, code := other.run(batch, "batch", "-user", "2", "-atomic", "-"); code != 0 {
+		t.Fatalf("batch failed: %s", errOut)
+	}
+	if out := other.ok("month", "2025-05-01", "-user", "2"); strings.Contains(out, "planning") || !strings.Contains(out, " a ") {
+		t.Fatalf("batch not applied:\n%s", out)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	c := newCLI(t)
+	for _, tc := range []struct {
+		args []string
+		code int
+		want string
+	}{
+		{[]string{"create", "-text", "x"}, 1, "user is unknown"},
+		{[]string{"create", "-user", "1", "-text", "x"}, 1, "date: date or start is required"},
+		{[]string{"create", "-user", "1", "-date", "2025-05-05", "-attendees", "bob"}, 2, "invalid attendee"},
+		{[]string{"range", "-user", "1"}, 2, "-from and -to are required"},
+		{[]string{"freebusy", "-o", "ics", "-users", "1", "-from", "2025-05-05", "-to", "2025-05-06"}, 1, "ics output is only available for events"},
+		{[]string{"day", "-o", "yaml"}, 1, "unknown output"},
+		{[]string{"reboot"}, 2, "unknown command"},
+		{[]string{"config", "set", "colour", "red"}, 1, "unknown key"},
+	} {
+		_, errOut, code := c.run("", tc.args...)
+		if code != tc.code || !strings.Contains(errOut, tc.want) {
+			t.Errorf("calctl %v: expected %d with %q, got %d: %s", tc.args, tc.code, tc.want, code, errOut)
+		}
+	}
+}
+
+func TestConfig(t *testing.T) {
+	c := newCLI(t)
+	payload := base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"7"}`))
+	c.ok("config", "set", "token", "header."+payload+".signature")
+	c.ok("config", "set", "time_zone", "Europe/Moscow")
+	if info, err := os.Stat(c.config); err != nil || info.Mode().Perm() != 0o600 {
+		t.Fatalf("config not written with 0600: %v", err)
+	}
+	out := c.ok("config", "show", "-o", "json")
+	for _, want := range []string{"server: " + c.server, "user_id: \n", "time_zone: Europe/Moscow", "output: json", "token: header…ture"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("config show: %q not found in\n%s", want, out)
+		}
+	}
+	e := &env{cfg: Config{Token: "header." + payload + ".signature"}}
+	if id, err := e.userID(); err != nil || id != 7 {
+		t.Fatalf("user not taken from token: %d, %v", id, err)
+	}
+	c.ok("config", "unset", "token")
+	if strings.Contains(c.ok("config"), "header") {
+		t.Fatal("token not removed")
+	}
+}
+
+func TestCompletion(t *testing.T) {
+	c := newCLI(t)
+	bash := c.ok("completion", "bash")
+	for _, want := range []string{"complete -o default -F _calctl calctl", "webhook) words=\"list create", "-recurrence-id", "compgen -W \"table json ics\""} {
+		if !strings.Contains(bash, want) {
+			t.Errorf("bash completion: %q not found", want)
+		}
+	}
+	if fish := c.ok("completion", "fish"); !strings.Contains(fish, "-n '__fish_seen_subcommand_from create' -o title") {
+		t.Error("fish completion lacks command flags")
+	}
+	if zsh := c.ok("completion", "zsh"); !strings.HasPrefix(zsh, "#compdef calctl") {
+		t.Error("zsh completion lacks #compdef")
+	}
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"calendar/internal/app"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Client — клиент HTTP API календаря: успешные ответы разворачивает из {"result": ...}, ошибки переводит в *APIError
+type Client struct {
+	base  string
+	token string
+	http  *http.Client
+}
+
+func NewClient(server, token string) *Client {
+	return &Client{base: strings.TrimRight(server, "/"), token: token, http: &http.Client{Timeout: 30 * time.Second}}
+}
+
+// APIError — ответ сервера с ошибкой, поля как у ErrorResponse в internal/web
+type APIError struct {
+	Status    int              `json:"-"`
+	Message   string           `json:"error"`
+	Code      string           `json:"code"`
+	RequestID string           `json:"request_id,omitempty"`
+	Fields    []app.FieldError `json:"fields,omitempty"`
+	Conflicts []app.Conflict   `json:"conflicts,omitempty"`
+}
+
+func (e *APIError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d %s", e.Status, http.StatusText(e.Status))
+	if e.Message != "" {
+		b.WriteString(": " + e.Message)
+	}
+	if e.Code != "" {
+		fmt.Fprintf(&b, " [%s]", e.Code)
+	}
+	for _, f := range e.Fields {
+		fmt.Fprintf(&b, "\n  %s: %s", f.Field, f.Message)
+	}
+	for _, c := range e.Conflicts {
+		with := "resource " + c.Resource
+		if c.EventId != nil {
+			with = c.EventId.String()
+		}
+		fmt.Fprintf(&b, "\n  conflicts with %s (%s – %s)", with, c.Start.Format(time.RFC3339), c.End.Format(time.RFC3339))
+	}
+	if e.RequestID != "" {
+		fmt.Fprintf(&b, "\n  request_id: %s", e.RequestID)
+	}
+	return b.String()
+}
+
+// request — один вызов API; body кодируется в JSON, если не задан raw
+type request struct {
+	method string
+	path   string
+	query  url.Values
+	header http.Header
+	body   any
+	raw    io.Reader
+	ctype  string
+}
+
+func (c *Client) send(ctx context.Context, rq request) (*http.Response, error) {
+	u := c.base + rq.path
+	if len(rq.query) > 0 {
+		u += "?" + rq.query.Encode()
+	}
+	body, ctype := rq.raw, rq.ctype
+	if body == nil && rq.body != nil {
+		data, err := json.Marshal(rq.body)
+		if err != nil {
+			return nil, err
+		}
+		body, ctype = bytes.NewReader(data), "application/json"
+	}
+	req, err := http.NewRequestWithContext(ctx, rq.method, u, body)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range rq.header {
+		req.Header[k] = v
+	}
+	if ctype != "" {
+		req.Header.Set("Content-Type", ctype)
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		defer resp.Body.Close()
+		return nil, decodeError(resp)
+	}
+	return resp, nil
+}
+
+func decodeError(resp *http.Response) error {
+	apiErr := &APIError{Status: resp.StatusCode}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if json.Unmarshal(data, apiErr) != nil || apiErr.Message == "" {
+		// ответ не от API (прокси, 404 роутера): показываем текст как есть
+		apiErr.Message = strings.TrimSpace(string(data))
+	}
+	if apiErr.RequestID == "" {
+		apiErr.RequestID = resp.Header.Get("X-Request-Id")
+	}
+	return apiErr
+}
+
+// do выполняет запрос и разбирает result в out; out == nil — тело не нужно
+func (c *Client) do(ctx context.Context, rq request, out any) (http.Header, error) {
+	resp, err := c.send(ctx, rq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return resp.Header, nil
+	}
+	wrapper := struct {
+		Result any `json:"result"`
+	}{Result: out}
+	if err := json.NewDecoder(resp.Body).Decode(&wrapper); err != nil {
+		return nil, fmt.Errorf("decode response: %w", err)
+	}
+	return resp.Header, nil
+}
+
+// stream читает text/event-stream и вызывает fn для каждого сообщения, пока поток не закроется
+func (c *Client) stream(ctx context.Context, rq request, fn func(id, event string, data []byte) error) error {
+	// поток длится сколько угодно, общий таймаут клиента к нему не применяется
+	sc := *c
+	sc.http = &http.Client{}
+	resp, err := sc.send(ctx, rq)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var id, event string
+	var data []byte
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 4<<20)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			if data != nil {
+				if err := fn(id, event, data); err != nil {
+					return err
+				}
+			}
+			event, data = "", nil
+		case strings.HasPrefix(line, ":"):
+			// комментарий-heartbeat
+		default:
+			field, value, _ := strings.Cut(line, ":")
+			value = strings.TrimPrefix(value, " ")
+			switch field {
+			case "id":
+				id = value
+			case "event":
+				event = value
+			case "data":
+				if data != nil {
+					data = append(data, '\n')
+				} else {
+					data = []byte{}
+				}
+				data = append(data, value...)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil && ctx.Err() == nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+var shells = []string{"bash", "zsh", "fish"}
+
+// spec — то, что дополняется у команды: её флаги и варианты первого аргумента
+type spec struct {
+	cmd   *command
+	flags []*flag.Flag
+}
+
+// specs собирает флаги всех команд из их FlagSet, чтобы скрипты не расходились с кодом
+func specs() []spec {
+	sorted := append([]*command(nil), commands...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].name < sorted[j].name })
+	list := make([]spec, 0, len(sorted))
+	for _, c := range sorted {
+		e := &env{}
+		fs := commandFlags(c, e, io.Discard)
+		c.flags(fs, e)
+		s := spec{cmd: c}
+		fs.VisitAll(func(f *flag.Flag) { s.flags = append(s.flags, f) })
+		list = append(list, s)
+	}
+	return list
+}
+
+func globalFlagNames() []string {
+	fs := flag.NewFlagSet("calctl", flag.ContinueOnError)
+	(&env{}).globalFlags(fs)
+	var names []string
+	fs.VisitAll(func(f *flag.Flag) { names = append(names, "-"+f.Name) })
+	return names
+}
+
+// valueFlags — флаги с известным набором значений, fileFlags — флаги с путём к файлу
+var valueFlags = map[string]string{
+	"o":      "table json ics",
+	"scope":  "all this",
+	"status": "pending delivered dead",
+	"events": "created updated deleted",
+}
+
+var fileFlags = map[string]bool{"config": true, "f": true, "file": true}
+
+func bashCompletion(w io.Writer) {
+	var names []string
+	for _, c := range commands {
+		names = append(names, c.name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(w, "# bash completion for calctl")
+	fmt.Fprintln(w, "_calctl() {")
+	fmt.Fprintln(w, `	local cur="${COMP_WORDS[COMP_CWORD]}" prev="${COMP_WORDS[COMP_CWORD-1]}" cmd="" w words`)
+	fmt.Fprintf(w, "\tlocal commands=%q\n", strings.Join(append(names, "help"), " "))
+	fmt.Fprintln(w, `	for w in "${COMP_WORDS[@]:1:COMP_CWORD-1}"; do`)
+	fmt.Fprintln(w, `		case " $commands " in *" $w "*) cmd="$w"; break ;; esac`)
+	fmt.Fprintln(w, "\tdone")
+	fmt.Fprintln(w, `	case "$prev" in`)
+	for _, name := range sortedKeys(valueFlags) {
+		fmt.Fprintf(w, "\t-%s) COMPREPLY=($(compgen -W %q -- \"$cur\")); return ;;\n", name, valueFlags[name])
+	}
+	fmt.Fprintf(w, "\t-%s) COMPREPLY=($(compgen -f -- \"$cur\")); return ;;\n", strings.Join(sortedKeys(fileFlags), "|-"))
+	fmt.Fprintln(w, "\tesac")
+	fmt.Fprintln(w, `	case "$cmd" in`)
+	fmt.Fprintf(w, "\t\"\") words=\"$commands %s\" ;;\n", strings.Join(globalFlagNames(), " "))
+	fmt.Fprintf(w, "\thelp) words=\"$commands\" ;;\n")
+	for _, s := range specs() {
+		words := append([]string(nil), s.cmd.args...)
+		if s.cmd.name == "completion" {
+			words = append(words, shells...)
+		}
+		for _, f := range s.flags {
+			words = append(words, "-"+f.Name)
+		}
+		fmt.Fprintf(w, "\t%s) words=%q ;;\n", s.cmd.name, strings.Join(words, " "))
+	}
+	fmt.Fprintln(w, "\tesac")
+	fmt.Fprintln(w, `	COMPREPLY=($(compgen -W "$words" -- "$cur"))`)
+	fmt.Fprintln(w, "}")
+	// -o default: когда вариантов нет, дополняются пути к файлам
+	fmt.Fprintln(w, "complete -o default -F _calctl calctl")
+}
+
+func zshCompletion(w io.Writer) {
+	fmt.Fprintln(w, "#compdef calctl")
+	fmt.Fprintln(w, "autoload -U +X bashcompinit && bashcompinit")
+	bashCompletion(w)
+}
+
+func fishCompletion(w io.Writer) {
+	fmt.Fprintln(w, "# fish completion for calctl")
+	fmt.Fprintln(w, "complete -c calctl -f")
+	list := specs()
+	var names []string
+	for _, s := range list {
+		names = append(names, s.cmd.name)
+	}
+	names = append(names, "help")
+	fmt.Fprintf(w, "complete -c calctl -n 'not __fish_seen_subcommand_from %s' -a help -d 'Show help for a command'\n", strings.Join(names, " "))
+	for _, s := range list {
+		fmt.Fprintf(w, "complete -c calctl -n 'not __fish_seen_subcommand_from %s' -a %s -d %s\n",
+			strings.Join(names, " "), s.cmd.name, fishQuote(firstSentence(s.cmd.summary)))
+	}
+	for _, s := range list {
+		cond := "__fish_seen_subcommand_from " + s.cmd.name
+		args := s.cmd.args
+		if s.cmd.name == "completion" {
+			args = shells
+		}
+		if len(args) > 0 {
+			fmt.Fprintf(w, "complete -c calctl -n '%s' -a %s\n", cond, fishQuote(strings.Join(args, " ")))
+		}
+		if s.cmd.files {
+			fmt.Fprintf(w, "complete -c calctl -n '%s' -F\n", cond)
+		}
+		for _, f := range s.flags {
+			line := fmt.Sprintf("complete -c calctl -n '%s' -o %s -d %s", cond, f.Name, fishQuote(firstSentence(f.Usage)))
+			if values, ok := valueFlags[f.Name]; ok {
+				line += " -xa " + fishQuote(values)
+			} else if fileFlags[f.Name] {
+				line += " -rF"
+			} else if !isBoolFlag(f) {
+				line += " -x"
+			}
+			fmt.Fprintln(w, line)
+		}
+	}
+}
+
+func isBoolFlag(f *flag.Flag) bool {
+	b, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
+
+func firstSentence(s string) string {
+	if i := strings.IndexAny(s, ";("); i > 0 {
+		s = s[:i]
+	}
+	return strings.TrimSuffix(strings.TrimSpace(s), ".")
+}
+
+func fishQuote(s string) string {
+	return "'" + strings.ReplaceAll(strings.ReplaceAll(s, `\`, `\\`), "'", `\'`) + "'"
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func init() {
+	register(&command{
+		name:    "completion",
+		usage:   strings.Join(shells, "|"),
+		summary: "Print a shell completion script for bash, zsh or fish.",
+		flags: func(fs *flag.FlagSet, e *env) func(context.Context, []string) error {
+			return func(_ context.Context, args []string) error {
+				shell, err := oneArg(args, "shell")
+				if err != nil {
+					return err
+				}
+				switch shell {
+				case "bash":
+					bashCompletion(e.stdout)
+				case "zsh":
+					zshCompletion(e.stdout)
+				case "fish":
+					fishCompletion(e.stdout)
+				default:
+					return usagef("unknown shell %q, expected %s", shell, strings.Join(shells, ", "))
+				}
+				return nil
+			}
+		},
+	})
+}
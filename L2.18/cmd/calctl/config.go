@@ -0,0 +1,262 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultServer     = "http://localhost:8080"
+	defaultConfigHint = "$XDG_CONFIG_HOME/calctl/config.yaml"
+)
+
+// Config — настройки calctl; флаги -server, -token, -user, -o и -tz перекрывают их
+type Config struct {
+	Server   string `yaml:"server"`
+	Token    string `yaml:"token,omitempty"`
+	UserID   int    `yaml:"user_id,omitempty"`   // без него берётся из sub токена
+	TimeZone string `yaml:"time_zone,omitempty"` // IANA, для дат в запросах и таблиц
+	Output   string `yaml:"output,omitempty"`    // table | json | ics
+}
+
+// configKeys — ключи для calctl config set в порядке вывода
+var configKeys = []string{"server", "token", "user_id", "time_zone", "output"}
+
+func (c *Config) set(key, value string) error {
+	switch key {
+	case "server":
+		c.Server = strings.TrimRight(value, "/")
+	case "token":
+		c.Token = value
+	case "user_id":
+		if value == "" {
+			c.UserID = 0
+			return nil
+		}
+		id, err := strconv.Atoi(value)
+		if err != nil || id < 0 {
+			return fmt.Errorf("user_id must be a positive number")
+		}
+		c.UserID = id
+	case "time_zone":
+		if _, err := time.LoadLocation(value); err != nil {
+			return fmt.Errorf("invalid time_zone: %w", err)
+		}
+		c.TimeZone = value
+	case "output":
+		if value != "" && !validOutput(value) {
+			return fmt.Errorf("output must be table, json or ics")
+		}
+		c.Output = value
+	default:
+		return fmt.Errorf("unknown key %q, expected one of %s", key, strings.Join(configKeys, ", "))
+	}
+	return nil
+}
+
+func (c *Config) get(key string) string {
+	switch key {
+	case "server":
+		return c.Server
+	case "token":
+		return c.Token
+	case "user_id":
+		if c.UserID == 0 {
+			return ""
+		}
+		return strconv.Itoa(c.UserID)
+	case "time_zone":
+		return c.TimeZone
+	case "output":
+		return c.Output
+	}
+	return ""
+}
+
+func validOutput(s string) bool {
+	return s == outputTable || s == outputJSON || s == outputICS
+}
+
+func (e *env) defaultConfigPath() string {
+	if p := e.getenv("CALCTL_CONFIG"); p != "" {
+		return p
+	}
+	dir := e.getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		var err error
+		if dir, err = os.UserConfigDir(); err != nil {
+			return "calctl.yaml"
+		}
+	}
+	return filepath.Join(dir, "calctl", "config.yaml")
+}
+
+func readConfig(path string) (Config, error) {
+	var cfg Config
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, err
+	}
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("config %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// writeConfig сохраняет конфиг с правами 0600: в нём лежит токен
+func writeConfig(path string, cfg Config) error {
+	data, err := yaml.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o600)
+}
+
+// loadConfig собирает настройки: файл, затем CALCTL_SERVER и CALCTL_TOKEN, затем флаги
+func (e *env) loadConfig() error {
+	if e.configPath == "" {
+		e.configPath = e.defaultConfigPath()
+	}
+	cfg, err := readConfig(e.configPath)
+	if err != nil {
+		return err
+	}
+	for key, value := range map[string]string{"server": e.getenv("CALCTL_SERVER"), "token": e.getenv("CALCTL_TOKEN")} {
+		if value != "" {
+			cfg.set(key, value)
+		}
+	}
+	if e.server != "" {
+		cfg.Server = strings.TrimRight(e.server, "/")
+	}
+	if e.token != "" {
+		cfg.Token = e.token
+	}
+	if e.user != 0 {
+		cfg.UserID = e.user
+	}
+	if e.tz != "" {
+		cfg.TimeZone = e.tz
+	}
+	if e.output != "" {
+		cfg.Output = e.output
+	}
+	if cfg.Server == "" {
+		cfg.Server = defaultServer
+	}
+	if cfg.Output == "" {
+		cfg.Output = outputTable
+	}
+	if !validOutput(cfg.Output) {
+		return fmt.Errorf("unknown output %q, expected table, json or ics", cfg.Output)
+	}
+	if cfg.TimeZone != "" {
+		if _, err := time.LoadLocation(cfg.TimeZone); err != nil {
+			return fmt.Errorf("invalid time zone %q", cfg.TimeZone)
+		}
+	}
+	e.cfg = cfg
+	return nil
+}
+
+// userID — пользователь запросов: из -user или конфига, иначе из sub токена
+func (e *env) userID() (int, error) {
+	if e.cfg.UserID != 0 {
+		return e.cfg.UserID, nil
+	}
+	if e.cfg.Token != "" {
+		if id, ok := tokenSubject(e.cfg.Token); ok {
+			return id, nil
+		}
+	}
+	return 0, errors.New("user is unknown: pass -user, set user_id in the config or use a token")
+}
+
+// tokenSubject читает sub из JWT без проверки подписи: её проверяет сервер
+func tokenSubject(token string) (int, bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return 0, false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return 0, false
+	}
+	var claims struct {
+		Subject string `json:"sub"`
+	}
+	if json.Unmarshal(payload, &claims) != nil {
+		return 0, false
+	}
+	id, err := strconv.Atoi(claims.Subject)
+	return id, err == nil && id > 0
+}
+
+func maskToken(token string) string {
+	if len(token) <= 12 {
+		return strings.Repeat("*", len(token))
+	}
+	return token[:6] + "…" + token[len(token)-4:]
+}
+
+func init() {
+	register(&command{
+		name:    "config",
+		usage:   "[show | path | set <key> <value> | unset <key>]",
+		summary: "Show or change the config file (keys: " + strings.Join(configKeys, ", ") + ").",
+		args:    []string{"show", "path", "set", "unset"},
+		flags: func(fs *flag.FlagSet, e *env) func(context.Context, []string) error {
+			return func(_ context.Context, args []string) error {
+				action := "show"
+				if len(args) > 0 {
+					action = args[0]
+				}
+				switch {
+				case action == "show" && len(args) <= 1:
+					// итоговые настройки с учётом переменных окружения и флагов
+					for _, key := range configKeys {
+						value := e.cfg.get(key)
+						if key == "token" {
+							value = maskToken(value)
+						}
+						fmt.Fprintf(e.stdout, "%s: %s\n", key, value)
+					}
+					return nil
+				case action == "path" && len(args) == 1:
+					fmt.Fprintln(e.stdout, e.configPath)
+					return nil
+				case action == "set" && len(args) == 3, action == "unset" && len(args) == 2:
+					cfg, err := readConfig(e.configPath)
+					if err != nil {
+						return err
+					}
+					value := ""
+					if action == "set" {
+						value = args[2]
+					}
+					if err := cfg.set(args[1], value); err != nil {
+						return err
+					}
+					return writeConfig(e.configPath, cfg)
+				}
+				return usagef("unexpected arguments %q", args)
+			}
+		},
+	})
+}
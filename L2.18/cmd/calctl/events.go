@@ -0,0 +1,580 @@
+package main
+
+import (
+	"calendar/internal/app"
+	"calendar/internal/repository"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func (e *env) client() *Client {
+	return NewClient(e.cfg.Server, e.cfg.Token)
+}
+
+func (e *env) location() *time.Location {
+	loc, _ := app.LocationParser(e.cfg.TimeZone)
+	return loc
+}
+
+// requestUser — user_id для запроса; с токеном его можно не знать, сервер возьмёт пользователя из токена
+func (e *env) requestUser() (int, error) {
+	id, err := e.userID()
+	if err != nil && e.cfg.Token != "" {
+		return 0, nil
+	}
+	return id, err
+}
+
+func (e *env) userQuery() (url.Values, error) {
+	q := url.Values{}
+	id, err := e.requestUser()
+	if err != nil {
+		return nil, err
+	}
+	if id != 0 {
+		q.Set("user_id", strconv.Itoa(id))
+	}
+	if e.cfg.TimeZone != "" {
+		q.Set("tz", e.cfg.TimeZone)
+	}
+	return q, nil
+}
+
+// timestamp переводит "YYYY-MM-DD HH:MM" в RFC 3339 в поясе -tz; RFC 3339 и даты передаются как есть
+func (e *env) timestamp(s string) string {
+	for _, layout := range []string{"2006-01-02 15:04", "2006-01-02T15:04", "2006-01-02 15:04:05", "2006-01-02T15:04:05"} {
+		if t, err := time.ParseInLocation(layout, s, e.location()); err == nil {
+			return t.Format(time.RFC3339)
+		}
+	}
+	return s
+}
+
+// eventFlags — поля события для create и update; в запрос попадают только явно заданные флаги
+type eventFlags struct {
+	fs *flag.FlagSet
+
+	file                                 string
+	date, start, end                     string
+	allDay                               bool
+	text, title, description, location   string
+	resource, rrule, tags, exdates, with string
+}
+
+func newEventFlags(fs *flag.FlagSet) *eventFlags {
+	f := &eventFlags{fs: fs}
+	fs.StringVar(&f.file, "f", "", "read the event as JSON (app.EventRequest) from a file, - for stdin; flags override its fields")
+	fs.StringVar(&f.date, "date", "", "all-day event date, YYYY-MM-DD")
+	fs.StringVar(&f.start, "start", "", `start: RFC 3339 or "YYYY-MM-DD HH:MM" in -tz`)
+	fs.StringVar(&f.end, "end", "", `end: RFC 3339 or "YYYY-MM-DD HH:MM" in -tz`)
+	fs.BoolVar(&f.allDay, "all-day", false, "all-day event")
+	fs.StringVar(&f.text, "text", "", "event text")
+	fs.StringVar(&f.title, "title", "", "title, empty clears it")
+	fs.StringVar(&f.description, "description", "", "description, empty clears it")
+	fs.StringVar(&f.location, "location", "", "location, empty clears it")
+	fs.StringVar(&f.resource, "resource", "", "shared resource the event occupies, empty releases it")
+	fs.StringVar(&f.rrule, "rrule", "", "RFC 5545 recurrence rule, e.g. FREQ=WEEKLY;BYDAY=MO; empty stops the series")
+	fs.StringVar(&f.exdates, "exdates", "", "comma-separated excluded occurrences of the series")
+	fs.StringVar(&f.tags, "tags", "", "comma-separated tags, empty removes all")
+	fs.StringVar(&f.with, "attendees", "", "comma-separated attendee user IDs, ID:edit lets them edit; empty removes all")
+	return f
+}
+
+func (f *eventFlags) request(e *env) (*app.EventRequest, error) {
+	er := &app.EventRequest{}
+	if f.file != "" {
+		if err := readJSON(e, f.file, er); err != nil {
+			return nil, err
+		}
+	}
+	var err error
+	f.fs.Visit(func(fl *flag.Flag) {
+		switch fl.Name {
+		case "date":
+			er.Date = f.date
+		case "start":
+			er.Start = e.timestamp(f.start)
+		case "end":
+			er.End = e.timestamp(f.end)
+		case "all-day":
+			er.AllDay = &f.allDay
+		case "text":
+			er.EventText = f.text
+		case "title":
+			er.Title = &f.title
+		case "description":
+			er.Description = &f.description
+		case "location":
+			er.Location = &f.location
+		case "resource":
+			er.Resource = &f.resource
+		case "rrule":
+			er.RRule = &f.rrule
+		case "exdates":
+			er.ExDates = splitList(f.exdates)
+		case "tags":
+			er.Tags = splitList(f.tags)
+		case "attendees":
+			if er.Attendees, err = parseAttendees(f.with); err != nil {
+				err = usagef("%v", err)
+			}
+		}
+	})
+	// -tz задаёт и пояс самого события
+	if e.tz != "" {
+		er.TimeZone = e.tz
+	}
+	return er, err
+}
+
+func parseAttendees(s string) ([]app.Attendee, error) {
+	list := []app.Attendee{}
+	for _, item := range splitList(s) {
+		id, mode, _ := strings.Cut(item, ":")
+		uid, err := strconv.Atoi(id)
+		if err != nil || (mode != "" && mode != "edit") {
+			return nil, fmt.Errorf("invalid attendee %q, expected ID or ID:edit", item)
+		}
+		list = append(list, app.Attendee{UserID: uid, CanEdit: mode == "edit"})
+	}
+	return list, nil
+}
+
+// readJSON читает JSON из файла или stdin, неизвестные поля — ошибка, как на сервере
+func readJSON(e *env, path string, v any) error {
+	r := e.stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("read %s: %w", path, err)
+	}
+	return nil
+}
+
+// conditions — заголовки If-Match и Idempotency-Key
+func conditions(ifMatch, key string) http.Header {
+	h := http.Header{}
+	if ifMatch != "" {
+		h.Set("If-Match", ifMatch)
+	}
+	if key != "" {
+		h.Set("Idempotency-Key", key)
+	}
+	return h
+}
+
+// printETag сообщает ETag в stderr, чтобы stdout оставался чистым для -o json и ics
+func printETag(e *env, header http.Header) {
+	if etag := header.Get("ETag"); etag != "" {
+		fmt.Fprintln(e.stderr, "etag:", etag)
+	}
+}
+
+func oneArg(args []string, name string) (string, error) {
+	if len(args) != 1 {
+		return "", usagef("expected %s", name)
+	}
+	return args[0], nil
+}
+
+func init() {
+	register(&command{
+		name:    "create",
+		summary: "Create an event (POST /create_event).",
+		flags: func(fs *flag.FlagSet, e *env) func(context.Context, []string) error {
+			ef := newEventFlags(fs)
+			key := fs.String("idempotency-key", "", "repeating the request with the same key returns the original event")
+			return func(ctx context.Context, args []string) error {
+				if len(args) != 0 {
+					return usagef("unexpected arguments %q", args)
+				}
+				er, err := ef.request(e)
+				if err != nil {
+					return err
+				}
+				if er.UserID == 0 {
+					if er.UserID, err = e.requestUser(); err != nil {
+						return err
+					}
+				}
+				if er.TimeZone == "" {
+					er.TimeZone = e.cfg.TimeZone
+				}
+				var ev app.Event
+				h, err := e.client().do(ctx, request{method: http.MethodPost, path: "/create_event", body: er, header: conditions("", *key)}, &ev)
+				if err != nil {
+					return err
+				}
+				printETag(e, h)
+				return e.printer().event(&ev)
+			}
+		},
+	})
+	register(&command{
+		name:    "update",
+		usage:   "<event_id>",
+		summary: "Change an event (POST /update_event); only the given flags are sent.",
+		flags: func(fs *flag.FlagSet, e *env) func(context.Context, []string) error {
+			ef := newEventFlags(fs)
+			scope := fs.String("scope", "", "for a series: all (default) or this occurrence")
+			occurrence := fs.String("recurrence-id", "", "occurrence of the series for -scope this, RFC 3339")
+			ifMatch := fs.String("if-match", "", "only update if the event ETag matches")
+			return func(ctx context.Context, args []string) error {
+				id, err := oneArg(args, "event_id")
+				if err != nil {
+					return err
+				}
+				er, err := ef.request(e)
+				if err != nil {
+					return err
+				}
+				if er.UserID == 0 {
+					if er.UserID, err = e.requestUser(); err != nil {
+						return err
+					}
+				}
+				er.EventId, er.Scope = id, *scope
+				if *occurrence != "" {
+					er.RecurrenceId = e.timestamp(*occurrence)
+				}
+				var ev app.Event
+				h, err := e.client().do(ctx, request{method: http.MethodPost, path: "/update_event", body: er, header: conditions(*ifMatch, "")}, &ev)
+				if err != nil {
+					return err
+				}
+				printETag(e, h)
+				return e.printer().event(&ev)
+			}
+		},
+	})
+	register(&command{
+		name:    "delete",
+		usage:   "<event_id>",
+		summary: "Delete an event, a series or one occurrence (POST /delete_event).",
+		flags: func(fs *flag.FlagSet, e *env) func(context.Context, []string) error {
+			scope := fs.String("scope", "", "for a series: all (default) or this occurrence")
+			occurrence := fs.String("recurrence-id", "", "occurrence of the series for -scope this, RFC 3339")
+			ifMatch := fs.String("if-match", "", "only delete if the event ETag matches")
+			return func(ctx context.Context, args []string) error {
+				id, err := oneArg(args, "event_id")
+				if err != nil {
+					return err
+				}
+				er := &app.EventRequest{EventId: id, Scope: *scope}
+				if er.UserID, err = e.requestUser(); err != nil {
+					return err
+				}
+				if *occurrence != "" {
+					er.RecurrenceId = e.timestamp(*occurrence)
+				}
+				var deleted app.EventRequest
+				if _, err := e.client().do(ctx, request{method: http.MethodPost, path: "/delete_event", body: er, header: conditions(*ifMatch, "")}, &deleted); err != nil {
+					return err
+				}
+				p := e.printer()
+				if p.format == outputJSON {
+					return p.json(deleted)
+				}
+				fmt.Fprintln(e.stdout, "deleted", deleted.EventId)
+				return nil
+			}
+		},
+	})
+	register(&command{
+		name:    "get",
+		usage:   "<event_id>",
+		summary: "Show an event (GET /v2/users/{user_id}/events/{event_id}).",
+		flags: func(fs *flag.FlagSet, e *env) func(context.Context, []string) error {
+			return func(ctx context.Context, args []string) error {
+				id, err := oneArg(args, "event_id")
+				if err != nil {
+					return err
+				}
+				user, err := e.userID()
+				if err != nil {
+					return err
+				}
+				var ev app.Event
+				path := fmt.Sprintf("/v2/users/%d/events/%s", user, url.PathEscape(id))
+				h, err := e.client().do(ctx, request{method: http.MethodGet, path: path}, &ev)
+				if err != nil {
+					return err
+				}
+				printETag(e, h)
+				return e.printer().event(&ev)
+			}
+		},
+	})
+	register(&command{
+		name:    "respond",
+		usage:   "<event_id> accepted|declined|tentative",
+		summary: "Answer an invitation (POST /respond_event).",
+		flags: func(fs *flag.FlagSet, e *env) func(context.Context, []string) error {
+			return func(ctx context.Context, args []string) error {
+				if len(args) != 2 {
+					return usagef("expected event_id and status")
+				}
+				rq := app.RSVPRequest{EventId: args[0], Status: args[1]}
+				var err error
+				if rq.UserID, err = e.requestUser(); err != nil {
+					return err
+				}
+				var ev app.Event
+				if _, err := e.client().do(ctx, request{method: http.MethodPost, path: "/respond_event", body: rq}, &ev); err != nil {
+					return err
+				}
+				return e.printer().event(&ev)
+			}
+		},
+	})
+	register(&command{
+		name:    "history",
+		usage:   "<event_id>",
+		summary: "Show the change history of an event (GET /events/{event_id}/history).",
+		flags: func(fs *flag.FlagSet, e *env) func(context.Context, []string) error {
+			return func(ctx context.Context, args []string) error {
+				id, err := oneArg(args, "event_id")
+				if err != nil {
+					return err
+				}
+				q, err := e.userQuery()
+				if err != nil {
+					return err
+				}
+				var entries []app.AuditEntry
+				rq := request{method: http.MethodGet, path: "/events/" + url.PathEscape(id) + "/history", query: q}
+				if _, err := e.client().do(ctx, rq, &entries); err != nil {
+					return err
+				}
+				p := e.printer()
+				rows := make([][]string, 0, len(entries))
+				for _, a := range entries {
+					version := ""
+					if a.After != nil {
+						version = strconv.Itoa(a.After.Version)
+					}
+					rows = append(rows, []string{p.timestamp(a.At), a.Action, strconv.Itoa(a.UserID), version})
+				}
+				return p.result(entries, []string{"AT", "ACTION", "USER", "VERSION"}, rows)
+			}
+		},
+	})
+	register(&command{
+		name:    "restore",
+		usage:   "<event_id>",
+		summary: "Restore a deleted event (POST /events/{event_id}/restore).",
+		flags: func(fs *flag.FlagSet, e *env) func(context.Context, []string) error {
+			return func(ctx context.Context, args []string) error {
+				id, err := oneArg(args, "event_id")
+				if err != nil {
+					return err
+				}
+				q, err := e.userQuery()
+				if err != nil {
+					return err
+				}
+				var ev app.Event
+				h, err := e.client().do(ctx, request{method: http.MethodPost, path: "/events/" + url.PathEscape(id) + "/restore", query: q}, &ev)
+				if err != nil {
+					return err
+				}
+				printETag(e, h)
+				return e.printer().event(&ev)
+			}
+		},
+	})
+
+	for _, period := range []string{"day", "week", "month"} {
+		register(&command{
+			name:    period,
+			usage:   "[YYYY-MM-DD]",
+			summary: fmt.Sprintf("List events of the %s containing the date, today by default (GET /events_for_%s).", period, period),
+			flags: func(fs *flag.FlagSet, e *env) func(context.Context, []string) error {
+				return func(ctx context.Context, args []string) error {
+					if len(args) > 1 {
+						return usagef("expected at most one date")
+					}
+					q, err := e.userQuery()
+					if err != nil {
+						return err
+					}
+					date := time.Now().In(e.location()).Format(dateLayout)
+					if len(args) == 1 {
+						date = args[0]
+					}
+					q.Set("date", date)
+					var list []*app.Event
+					if _, err := e.client().do(ctx, request{method: http.MethodGet, path: "/events_for_" + period, query: q}, &list); err != nil {
+						return err
+					}
+					return e.printer().events(list)
+				}
+			},
+		})
+	}
+
+	register(&command{
+		name:    "range",
+		summary: "List occurrences in [from, to) (GET /events_for_range), following next_cursor unless -cursor is given.",
+		flags: func(fs *flag.FlagSet, e *env) func(context.Context, []string) error {
+			from := fs.String("from", "", "interval start: RFC 3339 or YYYY-MM-DD (required)")
+			to := fs.String("to", "", "interval end: RFC 3339 (exclusive) or YYYY-MM-DD (inclusive) (required)")
+			text := fs.String("q", "", "only events whose text contains this substring")
+			limit := fs.Int("limit", 0, "page size, 1..1000 (server default 100)")
+			cursor := fs.String("cursor", "", "fetch only the page after this cursor and print the next one to stderr")
+			return func(ctx context.Context, args []string) error {
+				if len(args) != 0 || *from == "" || *to == "" {
+					return usagef("-from and -to are required")
+				}
+				q, err := e.userQuery()
+				if err != nil {
+					return err
+				}
+				q.Set("from", e.timestamp(*from))
+				q.Set("to", e.timestamp(*to))
+				if *text != "" {
+					q.Set("q", *text)
+				}
+				if *limit != 0 {
+					q.Set("limit", strconv.Itoa(*limit))
+				}
+				single := *cursor != ""
+				next := *cursor
+				var all []*app.Event
+				for {
+					if next != "" {
+						q.Set("cursor", next)
+					}
+					var page repository.EventPage
+					if _, err := e.client().do(ctx, request{method: http.MethodGet, path: "/events_for_range", query: q}, &page); err != nil {
+						return err
+					}
+					all = append(all, page.Events...)
+					next = page.NextCursor
+					if single || next == "" {
+						break
+					}
+				}
+				if single && next != "" {
+					fmt.Fprintln(e.stderr, "next cursor:", next)
+				}
+				return e.printer().events(all)
+			}
+		},
+	})
+	register(&command{
+		name:    "search",
+		usage:   "[words...]",
+		summary: "Full-text search over text, title, description, location and tags (GET /events/search).",
+		flags: func(fs *flag.FlagSet, e *env) func(context.Context, []string) error {
+			tags := fs.String("tags", "", "comma-separated tags the events must all have")
+			from := fs.String("from", "", "only events with an occurrence after: RFC 3339 or YYYY-MM-DD, together with -to")
+			to := fs.String("to", "", "only events with an occurrence before: RFC 3339 (exclusive) or YYYY-MM-DD (inclusive)")
+			limit := fs.Int("limit", 0, "max number of results, 1..1000 (server default 100)")
+			return func(ctx context.Context, args []string) error {
+				q, err := e.userQuery()
+				if err != nil {
+					return err
+				}
+				if len(args) > 0 {
+					q.Set("q", strings.Join(args, " "))
+				}
+				for _, tag := range splitList(*tags) {
+					q.Add("tag", tag)
+				}
+				if *from != "" || *to != "" {
+					q.Set("from", e.timestamp(*from))
+					q.Set("to", e.timestamp(*to))
+				}
+				if *limit != 0 {
+					q.Set("limit", strconv.Itoa(*limit))
+				}
+				var hits []repository.SearchHit
+				if _, err := e.client().do(ctx, request{method: http.MethodGet, path: "/events/search", query: q}, &hits); err != nil {
+					return err
+				}
+				p := e.printer()
+				switch p.format {
+				case outputJSON:
+					return p.json(hits)
+				case outputICS:
+					list := make([]*app.Event, len(hits))
+					for i, h := range hits {
+						list[i] = h.Event
+					}
+					return p.events(list)
+				}
+				rows := make([][]string, 0, len(hits))
+				for _, h := range hits {
+					rows = append(rows, append([]string{strconv.FormatFloat(h.Score, 'f', 2, 64)}, p.eventRow(h.Event)...))
+				}
+				return p.table(append([]string{"SCORE"}, eventHeader...), rows)
+			}
+		},
+	})
+	register(&command{
+		name:    "freebusy",
+		summary: "Show busy time of several users and free slots for a meeting (GET /freebusy).",
+		flags: func(fs *flag.FlagSet, e *env) func(context.Context, []string) error {
+			users := fs.String("users", "", "comma-separated user IDs (required)")
+			from := fs.String("from", "", "interval start: RFC 3339 or YYYY-MM-DD (required)")
+			to := fs.String("to", "", "interval end: RFC 3339 (exclusive) or YYYY-MM-DD (inclusive) (required)")
+			duration := fs.Duration("duration", 0, "slot length, e.g. 45m (server default 30m)")
+			limit := fs.Int("limit", 0, "max number of slots, 1..100 (server default 10)")
+			return func(ctx context.Context, args []string) error {
+				if len(args) != 0 || *users == "" || *from == "" || *to == "" {
+					return usagef("-users, -from and -to are required")
+				}
+				q := url.Values{"user_ids": {strings.Join(splitList(*users), ",")}}
+				q.Set("from", e.timestamp(*from))
+				q.Set("to", e.timestamp(*to))
+				if e.cfg.TimeZone != "" {
+					q.Set("tz", e.cfg.TimeZone)
+				}
+				if *duration != 0 {
+					q.Set("duration", duration.String())
+				}
+				if *limit != 0 {
+					q.Set("limit", strconv.Itoa(*limit))
+				}
+				var fb struct {
+					From  time.Time      `json:"from"`
+					To    time.Time      `json:"to"`
+					Busy  []app.Interval `json:"busy"`
+					Free  []app.Interval `json:"free"`
+					Slots []app.Interval `json:"slots"`
+				}
+				if _, err := e.client().do(ctx, request{method: http.MethodGet, path: "/freebusy", query: q}, &fb); err != nil {
+					return err
+				}
+				p := e.printer()
+				var rows [][]string
+				for _, part := range []struct {
+					kind string
+					list []app.Interval
+				}{{"busy", fb.Busy}, {"free", fb.Free}, {"slot", fb.Slots}} {
+					for _, in := range part.list {
+						rows = append(rows, []string{part.kind, p.timestamp(in.Start), p.timestamp(in.End)})
+					}
+				}
+				return p.result(fb, []string{"KIND", "START", "END"}, rows)
+			}
+		},
+	})
+}
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"calendar/internal/app"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// importResult — итог импорта одного VEVENT, как ImportResult в internal/web
+type importResult struct {
+	UID          string `json:"uid"`
+	RecurrenceId string `json:"recurrence_id,omitempty"`
+	EventId      string `json:"event_id,omitempty"`
+	Status       string `json:"status"`
+	Error        string `json:"error,omitempty"`
+}
+
+// batchRequest и batchResponse повторяют тело и ответ POST /events/batch
+type batchRequest struct {
+	Atomic     bool             `json:"atomic"`
+	Operations []batchOperation `json:"operations"`
+}
+
+type batchOperation struct {
+	Op             string            `json:"op"`
+	Event          *app.EventRequest `json:"event"`
+	IfMatch        string            `json:"if_match,omitempty"`
+	IdempotencyKey string            `json:"idempotency_key,omitempty"`
+}
+
+type batchResponse struct {
+	Atomic  bool `json:"atomic"`
+	Applied int  `json:"applied"`
+	Failed  int  `json:"failed"`
+	Results []struct {
+		Index  int        `json:"index"`
+		Status int        `json:"status"`
+		Event  *app.Event `json:"event,omitempty"`
+		ETag   string     `json:"etag,omitempty"`
+		Error  *APIError  `json:"error,omitempty"`
+	} `json:"results"`
+}
+
+// streamMessage — сообщение /events/stream
+type streamMessage struct {
+	ID    string     `json:"id"`
+	Type  string     `json:"type"`
+	Event *app.Event `json:"event,omitempty"`
+}
+
+func init() {
+	register(&command{
+		name:    "export",
+		summary: "Download the whole calendar as .ics (GET /calendar.ics).",
+		flags: func(fs *flag.FlagSet, e *env) func(context.Context, []string) error {
+			file := fs.String("file", "", "write to this file instead of stdout")
+			return func(ctx context.Context, args []string) error {
+				if len(args) != 0 {
+					return usagef("unexpected arguments %q", args)
+				}
+				q, err := e.userQuery()
+				if err != nil {
+					return err
+				}
+				q.Del("tz")
+				resp, err := e.client().send(ctx, request{method: http.MethodGet, path: "/calendar.ics", query: q})
+				if err != nil {
+					return err
+				}
+				defer resp.Body.Close()
+				var w io.Writer = e.stdout
+				if *file != "" {
+					f, err := os.Create(*file)
+					if err != nil {
+						return err
+					}
+					defer f.Close()
+					w = f
+				}
+				_, err = io.Copy(w, resp.Body)
+				return err
+			}
+		},
+	})
+	register(&command{
+		name:    "import",
+		usage:   "<file.ics | ->",
+		summary: "Import events from .ics; a known UID updates the event (POST /import_ics).",
+		files:   true,
+		flags: func(fs *flag.FlagSet, e *env) func(context.Context, []string) error {
+			return func(ctx context.Context, args []string) error {
+				path, err := oneArg(args, "file")
+				if err != nil {
+					return err
+				}
+				q, err := e.userQuery()
+				if err != nil {
+					return err
+				}
+				q.Del("tz")
+				body := e.stdin
+				if path != "-" {
+					f, err := os.Open(path)
+					if err != nil {
+						return err
+					}
+					defer f.Close()
+					body = f
+				}
+				var results []importResult
+				rq := request{method: http.MethodPost, path: "/import_ics", query: q, raw: body, ctype: "text/calendar"}
+				if _, err := e.client().do(ctx, rq, &results); err != nil {
+					return err
+				}
+				p := e.printer()
+				failed := 0
+				rows := make([][]string, 0, len(results))
+				for _, r := range results {
+					if r.Status == "failed" {
+						failed++
+					}
+					rows = append(rows, []string{r.UID, r.RecurrenceId, r.Status, r.EventId, r.Error})
+				}
+				if err := p.result(results, []string{"UID", "RECURRENCE_ID", "STATUS", "EVENT_ID", "ERROR"}, rows); err != nil {
+					return err
+				}
+				if failed > 0 {
+					return fmt.Errorf("%d of %d events failed", failed, len(results))
+				}
+				return nil
+			}
+		},
+	})
+	register(&command{
+		name:    "batch",
+		usage:   "<file.json | ->",
+		summary: `Apply create/update/delete operations in one request (POST /events/batch); the file is {"atomic":..., "operations":[...]}.`,
+		files:   true,
+		flags: func(fs *flag.FlagSet, e *env) func(context.Context, []string) error {
+			atomic := fs.Bool("atomic", false, "all operations or none, overrides atomic from the file")
+			return func(ctx context.Context, args []string) error {
+				path, err := oneArg(args, "file")
+				if err != nil {
+					return err
+				}
+				var br batchRequest
+				if err := readJSON(e, path, &br); err != nil {
+					return err
+				}
+				fs.Visit(func(fl *flag.Flag) {
+					if fl.Name == "atomic" {
+						br.Atomic = *atomic
+					}
+				})
+				user, err := e.requestUser()
+				if err != nil {
+					return err
+				}
+				for _, op := range br.Operations {
+					if op.Event != nil && op.Event.UserID == 0 {
+						op.Event.UserID = user
+					}
+				}
+				var resp batchResponse
+				if _, err := e.client().do(ctx, request{method: http.MethodPost, path: "/events/batch", body: br}, &resp); err != nil {
+					return err
+				}
+				rows := make([][]string, 0, len(resp.Results))
+				for _, r := range resp.Results {
+					id, msg := "", ""
+					if r.Event != nil {
+						id = r.Event.EventId.String()
+					}
+					if r.Error != nil {
+						msg = r.Error.Message
+					}
+					rows = append(rows, []string{strconv.Itoa(r.Index), strconv.Itoa(r.Status), id, msg})
+				}
+				if err := e.printer().result(resp, []string{"INDEX", "STATUS", "EVENT_ID", "ERROR"}, rows); err != nil {
+					return err
+				}
+				if resp.Failed > 0 {
+					return fmt.Errorf("%d of %d operations failed, %d applied", resp.Failed, len(resp.Results), resp.Applied)
+				}
+				return nil
+			}
+		},
+	})
+	register(&command{
+		name:    "watch",
+		summary: "Print changes of the calendar as they happen (GET /events/stream) until interrupted.",
+		flags: func(fs *flag.FlagSet, e *env) func(context.Context, []string) error {
+			lastID := fs.String("last-id", "", "resume after this message id (Last-Event-ID)")
+			return func(ctx context.Context, args []string) error {
+				if len(args) != 0 {
+					return usagef("unexpected arguments %q", args)
+				}
+				p := e.printer()
+				if p.format == outputICS {
+					return errNoICS
+				}
+				q, err := e.userQuery()
+				if err != nil {
+					return err
+				}
+				q.Del("tz")
+				header := http.Header{}
+				if *lastID != "" {
+					header.Set("Last-Event-ID", *lastID)
+				}
+				rq := request{method: http.MethodGet, path: "/events/stream", query: q, header: header}
+				return e.client().stream(ctx, rq, func(id, _ string, data []byte) error {
+					m := streamMessage{ID: id}
+					if err := json.Unmarshal(data, &m); err != nil {
+						return fmt.Errorf("bad stream message %s: %w", data, err)
+					}
+					if p.format == outputJSON {
+						line, _ := json.Marshal(m)
+						_, err := fmt.Fprintf(p.w, "%s\n", line)
+						return err
+					}
+					// построчно и без выравнивания: поток не заканчивается
+					cols := []string{id, m.Type}
+					if m.Event != nil {
+						cols = append(cols, m.Event.EventId.String(), p.when(m.Event), m.Event.EventText)
+					}
+					_, err := fmt.Fprintln(p.w, strings.Join(cols, "\t"))
+					return err
+				})
+			}
+		},
+	})
+}
@@ -0,0 +1,193 @@
+// calctl — клиент командной строки для HTTP API календаря
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+)
+
+// command — подкоманда calctl; flags регистрирует её флаги и возвращает функцию, выполняющую команду
+type command struct {
+	name    string
+	usage   string // аргументы после имени
+	summary string
+	args    []string // варианты первого аргумента для автодополнения
+	files   bool     // аргумент — путь к файлу
+	flags   func(fs *flag.FlagSet, env *env) func(ctx context.Context, args []string) error
+}
+
+var commands []*command
+
+func register(c *command) {
+	commands = append(commands, c)
+}
+
+func findCommand(name string) *command {
+	for _, c := range commands {
+		if c.name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// env — всё, что нужно командам: конфиг после флагов, клиент API и вывод
+type env struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+	getenv func(string) string
+
+	configPath string
+	cfg        Config
+	// значения глобальных флагов; пустые не перекрывают конфиг
+	server, token, output, tz string
+	user                      int
+}
+
+// globalFlags — флаги, которые принимает любая команда, до или после её имени
+func (e *env) globalFlags(fs *flag.FlagSet) {
+	fs.StringVar(&e.configPath, "config", e.configPath, "config file (default $CALCTL_CONFIG or "+defaultConfigHint+")")
+	fs.StringVar(&e.server, "server", e.server, "API base URL, e.g. http://localhost:8080")
+	fs.StringVar(&e.token, "token", e.token, "JWT for Authorization: Bearer")
+	fs.IntVar(&e.user, "user", e.user, "user ID (default from config or token)")
+	fs.StringVar(&e.output, "o", e.output, "output format: table, json or ics")
+	fs.StringVar(&e.tz, "tz", e.tz, "IANA time zone for dates and the table output, e.g. Europe/Moscow")
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	os.Exit(run(ctx, os.Args[1:], os.Stdin, os.Stdout, os.Stderr, os.Getenv))
+}
+
+// run выполняет calctl с аргументами args и возвращает код выхода
+func run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer, getenv func(string) string) int {
+	e := &env{stdin: stdin, stdout: stdout, stderr: stderr, getenv: getenv}
+
+	top := flag.NewFlagSet("calctl", flag.ContinueOnError)
+	top.SetOutput(stderr)
+	e.globalFlags(top)
+	top.Usage = func() { usage(stderr, top) }
+	if err := top.Parse(args); err != nil {
+		return exitCode(err)
+	}
+	if top.NArg() == 0 {
+		usage(stderr, top)
+		return 2
+	}
+	name := top.Arg(0)
+	if name == "help" {
+		if c := findCommand(top.Arg(1)); c != nil {
+			fs := commandFlags(c, e, stdout)
+			c.flags(fs, e)
+			fs.Usage()
+			return 0
+		}
+		usage(stdout, top)
+		return 0
+	}
+	c := findCommand(name)
+	if c == nil {
+		fmt.Fprintf(stderr, "calctl: unknown command %q, see calctl help\n", name)
+		return 2
+	}
+
+	fs := commandFlags(c, e, stderr)
+	exec := c.flags(fs, e)
+	rest, err := parseInterspersed(fs, top.Args()[1:])
+	if err != nil {
+		return exitCode(err)
+	}
+	if err := e.loadConfig(); err != nil {
+		fmt.Fprintln(stderr, "calctl:", err)
+		return 1
+	}
+	if err := exec(ctx, rest); err != nil {
+		var usageErr usageError
+		if errors.As(err, &usageErr) {
+			fmt.Fprintf(stderr, "calctl %s: %v\n", c.name, err)
+			fs.Usage()
+			return 2
+		}
+		fmt.Fprintf(stderr, "calctl %s: %v\n", c.name, err)
+		return 1
+	}
+	return 0
+}
+
+// parseInterspersed разбирает флаги команды и до, и после позиционных аргументов: calctl get <id> -o json
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+func commandFlags(c *command, e *env, w io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet("calctl "+c.name, flag.ContinueOnError)
+	fs.SetOutput(w)
+	e.globalFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(w, "Usage: calctl %s [flags] %s\n\n%s\n\nFlags:\n", c.name, c.usage, c.summary)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+func usage(w io.Writer, top *flag.FlagSet) {
+	fmt.Fprintln(w, "calctl — command-line client for the calendar API")
+	fmt.Fprintln(w, "\nUsage: calctl [flags] <command> [command flags] [args]")
+	fmt.Fprintln(w, "\nCommands:")
+	sorted := append([]*command(nil), commands...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].name < sorted[j].name })
+	for _, c := range sorted {
+		fmt.Fprintf(w, "  %-13s %s\n", c.name, c.summary)
+	}
+	fmt.Fprintln(w, "\nGlobal flags (also accepted after the command):")
+	top.SetOutput(w)
+	top.PrintDefaults()
+	fmt.Fprintln(w, "\nRun \"calctl help <command>\" for the flags of a command.")
+}
+
+// usageError — неверные аргументы команды: печатается её справка и код выхода 2
+type usageError string
+
+func (e usageError) Error() string { return string(e) }
+
+func usagef(format string, args ...any) error {
+	return usageError(fmt.Sprintf(format, args...))
+}
+
+func exitCode(err error) int {
+	if errors.Is(err, flag.ErrHelp) {
+		return 0
+	}
+	return 2
+}
+
+// splitList делит значение флага через запятую; пустая строка даёт пустой, но не nil список
+func splitList(s string) []string {
+	list := []string{}
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
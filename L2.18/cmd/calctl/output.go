@@ -0,0 +1,157 @@
+package main
+
+import (
+	"calendar/internal/app"
+	"calendar/internal/ical"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+	outputICS   = "ics"
+
+	dateLayout  = "2006-01-02"
+	clockLayout = "15:04"
+)
+
+var errNoICS = errors.New("ics output is only available for events")
+
+// printer печатает результат команды в формате из -o
+type printer struct {
+	w      io.Writer
+	format string
+	loc    *time.Location // nil — время каждого события в его собственном поясе
+}
+
+func (e *env) printer() *printer {
+	p := &printer{w: e.stdout, format: e.cfg.Output}
+	if e.cfg.TimeZone != "" {
+		p.loc, _ = time.LoadLocation(e.cfg.TimeZone)
+	}
+	return p
+}
+
+func (p *printer) json(v any) error {
+	enc := json.NewEncoder(p.w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func (p *printer) table(header []string, rows [][]string) error {
+	tw := tabwriter.NewWriter(p.w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
+
+// result печатает v как JSON или как таблицу header/rows
+func (p *printer) result(v any, header []string, rows [][]string) error {
+	switch p.format {
+	case outputJSON:
+		return p.json(v)
+	case outputICS:
+		return errNoICS
+	}
+	return p.table(header, rows)
+}
+
+func (p *printer) events(list []*app.Event) error {
+	switch p.format {
+	case outputJSON:
+		if list == nil {
+			list = []*app.Event{}
+		}
+		return p.json(list)
+	case outputICS:
+		return ical.Encode(p.w, standalone(list))
+	}
+	rows := make([][]string, 0, len(list))
+	for _, e := range list {
+		rows = append(rows, p.eventRow(e))
+	}
+	return p.table(eventHeader, rows)
+}
+
+func (p *printer) event(e *app.Event) error {
+	if p.format == outputJSON {
+		return p.json(e)
+	}
+	return p.events([]*app.Event{e})
+}
+
+var eventHeader = []string{"ID", "WHEN", "EVENT", "TITLE", "LOCATION", "TAGS", "REPEAT"}
+
+func (p *printer) eventRow(e *app.Event) []string {
+	return []string{
+		e.EventId.String(),
+		p.when(e),
+		e.EventText,
+		e.Title,
+		e.Location,
+		strings.Join(e.Tags, ","),
+		e.RRule,
+	}
+}
+
+// when — время события для таблицы; у событий на весь день конец исключён, поэтому показывается последний день
+func (p *printer) when(e *app.Event) string {
+	if e.AllDay {
+		first, last := e.Start.Format(dateLayout), e.End.AddDate(0, 0, -1).Format(dateLayout)
+		if last <= first {
+			return first + " all day"
+		}
+		return first + " – " + last
+	}
+	loc := p.loc
+	if loc == nil {
+		var err error
+		if loc, err = time.LoadLocation(e.TimeZone); err != nil {
+			loc = time.UTC
+		}
+	}
+	start, end := e.Start.In(loc), e.End.In(loc)
+	if start.Format(dateLayout) == end.Format(dateLayout) {
+		return start.Format(dateLayout+" "+clockLayout) + "–" + end.Format(clockLayout)
+	}
+	return start.Format(dateLayout+" "+clockLayout) + " – " + end.Format(dateLayout+" "+clockLayout)
+}
+
+func (p *printer) timestamp(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	if p.loc != nil {
+		t = t.In(p.loc)
+	}
+	return t.Format(dateLayout + " " + clockLayout + ":05")
+}
+
+// standalone превращает раскрытые вхождения серий в самостоятельные события: с RRULE серии
+// клиент .ics размножил бы каждое вхождение ещё раз
+func standalone(list []*app.Event) []*app.Event {
+	out := make([]*app.Event, 0, len(list))
+	for _, e := range list {
+		if e.RecurrenceId == nil {
+			out = append(out, e)
+			continue
+		}
+		uid := e.EventId.String()
+		if e.UID != "" {
+			uid = e.UID
+		}
+		occ := *e
+		occ.UID = uid + "-" + e.RecurrenceId.UTC().Format("20060102T150405Z")
+		occ.RRule, occ.ExDates, occ.SeriesId, occ.RecurrenceId = "", nil, nil, nil
+		out = append(out, &occ)
+	}
+	return out
+}
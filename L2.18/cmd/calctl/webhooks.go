@@ -0,0 +1,125 @@
+package main
+
+import (
+	"calendar/internal/webhook"
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// webhookActions — подкоманды calctl webhook; их имена нужны и для автодополнения
+var webhookActions = []string{"list", "create", "delete", "deliveries", "dead-letters", "retry"}
+
+func deliveryRows(p *printer, list []webhook.Delivery) [][]string {
+	rows := make([][]string, 0, len(list))
+	for _, d := range list {
+		last := ""
+		if n := len(d.Attempts); n > 0 {
+			a := d.Attempts[n-1]
+			last = a.Error
+			if a.Status != 0 {
+				last = strconv.Itoa(a.Status)
+			}
+		}
+		next := ""
+		if d.NextAttempt != nil {
+			next = p.timestamp(*d.NextAttempt)
+		}
+		rows = append(rows, []string{d.ID, d.SubscriptionID, d.Type, d.EventId, d.Status,
+			fmt.Sprintf("%d/%d", len(d.Attempts), d.MaxAttempts), last, next})
+	}
+	return rows
+}
+
+var deliveryHeader = []string{"ID", "WEBHOOK", "TYPE", "EVENT_ID", "STATUS", "ATTEMPTS", "LAST", "NEXT"}
+
+func init() {
+	register(&command{
+		name:    "webhook",
+		usage:   strings.Join(webhookActions, "|") + " [args]",
+		summary: "Manage webhooks: list, create, delete <id>, deliveries <id>, dead-letters, retry <id> <delivery_id>.",
+		args:    webhookActions,
+		flags: func(fs *flag.FlagSet, e *env) func(context.Context, []string) error {
+			target := fs.String("url", "", "create: http(s) URL that receives the changes")
+			secret := fs.String("secret", "", "create: signing secret, 16..256 characters (generated by default)")
+			events := fs.String("events", "", "create: comma-separated event types: created, updated, deleted (all by default)")
+			status := fs.String("status", "", "deliveries: only pending, delivered or dead")
+			return func(ctx context.Context, args []string) error {
+				if len(args) == 0 {
+					return usagef("expected one of %s", strings.Join(webhookActions, ", "))
+				}
+				q, err := e.userQuery()
+				if err != nil {
+					return err
+				}
+				q.Del("tz")
+				c, p := e.client(), e.printer()
+				action, args := args[0], args[1:]
+				want := map[string]int{"list": 0, "create": 0, "delete": 1, "deliveries": 1, "dead-letters": 0, "retry": 2}
+				if n, ok := want[action]; !ok || len(args) != n {
+					return usagef("unexpected arguments for webhook %s", action)
+				}
+
+				switch action {
+				case "list":
+					var subs []webhook.Subscription
+					if _, err := c.do(ctx, request{method: http.MethodGet, path: "/webhooks", query: q}, &subs); err != nil {
+						return err
+					}
+					rows := make([][]string, 0, len(subs))
+					for _, s := range subs {
+						rows = append(rows, []string{s.ID, s.URL, strings.Join(s.Events, ","), p.timestamp(s.CreatedAt)})
+					}
+					return p.result(subs, []string{"ID", "URL", "EVENTS", "CREATED"}, rows)
+				case "create":
+					rq := webhook.SubscriptionRequest{URL: *target, Secret: *secret}
+					if *events != "" {
+						rq.Events = splitList(*events)
+					}
+					if rq.UserID, err = e.requestUser(); err != nil {
+						return err
+					}
+					var sub webhook.Subscription
+					if _, err := c.do(ctx, request{method: http.MethodPost, path: "/webhooks", body: rq}, &sub); err != nil {
+						return err
+					}
+					// секрет сервер отдаёт только сейчас
+					row := []string{sub.ID, sub.URL, strings.Join(sub.Events, ","), sub.Secret}
+					return p.result(sub, []string{"ID", "URL", "EVENTS", "SECRET"}, [][]string{row})
+				case "delete":
+					if _, err := c.do(ctx, request{method: http.MethodDelete, path: "/webhooks/" + url.PathEscape(args[0]), query: q}, nil); err != nil {
+						return err
+					}
+					if p.format != outputJSON {
+						fmt.Fprintln(e.stdout, "deleted", args[0])
+					}
+					return nil
+				case "deliveries", "dead-letters":
+					path := "/webhooks/dead_letters"
+					if action == "deliveries" {
+						path = "/webhooks/" + url.PathEscape(args[0]) + "/deliveries"
+						if *status != "" {
+							q.Set("status", *status)
+						}
+					}
+					var list []webhook.Delivery
+					if _, err := c.do(ctx, request{method: http.MethodGet, path: path, query: q}, &list); err != nil {
+						return err
+					}
+					return p.result(list, deliveryHeader, deliveryRows(p, list))
+				default:
+					path := "/webhooks/" + url.PathEscape(args[0]) + "/deliveries/" + url.PathEscape(args[1]) + "/retry"
+					var d webhook.Delivery
+					if _, err := c.do(ctx, request{method: http.MethodPost, path: path, query: q}, &d); err != nil {
+						return err
+					}
+					return p.result(d, deliveryHeader, deliveryRows(p, []webhook.Delivery{d}))
+				}
+			}
+		},
+	})
+}
@@ -4,26 +4,37 @@ import (
 	"calendar/internal/config"
 	"calendar/internal/di"
 	"calendar/internal/logger"
-	"calendar/internal/repository"
 	"calendar/internal/web"
 
 	"go.uber.org/fx"
 )
 
+// @title                      Calendar API
+// @version                    1.0
+// @description                HTTP-сервер календаря событий
+// @securityDefinitions.apikey BearerAuth
+// @in                         header
+// @name                       Authorization
+// @description                "Bearer <JWT>", обязателен, если в конфиге включён auth
 func main() {
 	app := fx.New(
 		fx.Provide(
 			config.MustLoad,
 			logger.ProvideLogger,
-			repository.NewInMemoryRepo,
-			func(repo *repository.InMemoryRepo) repository.Storage {
-				return repo
-			},
+			di.ProvideMetrics,
+			di.ProvideTracerProvider,
+			di.ProvideFeed,
+			di.ProvideWebhooks,
+			di.ProvideStorage,
+			di.ProvideAuthenticator,
 			web.NewCalendarHandler,
 		),
 
 		fx.Invoke(
 			di.StartHttpServer,
+			di.StartGrpcServer,
+			di.StartReminderScheduler,
+			di.StartConfigReloader,
 		),
 	)
 	app.Run()
@@ -1,62 +1,259 @@
 package app
 
 import (
-	"errors"
 	"fmt"
 	"github.com/google/uuid"
+	"strings"
 	"time"
 )
 
 type Event struct {
-	EventId   uuid.UUID `json:"event_id"`
-	UserID    int       `json:"user_id"`
-	Date      time.Time `json:"date"`
-	EventText string    `json:"event"`
+	EventId   uuid.UUID  `json:"event_id"`
+	UID       string     `json:"uid,omitempty"` // UID iCalendar, заданный клиентом CalDAV; иначе UID — event_id
+	Version   int        `json:"version"`       // растёт при каждом изменении, см. ETag
+	UserID    int        `json:"user_id"`
+	Date      time.Time  `json:"date"` // дата начала события в его часовом поясе
+	Start     time.Time  `json:"start"`
+	End       time.Time  `json:"end"`
+	AllDay    bool       `json:"all_day"`
+	TimeZone  string     `json:"time_zone"`
+	EventText string     `json:"event"`
+	Resource  string     `json:"resource,omitempty"`  // общий ресурс (переговорная и т.п.), который занимает событие
+	Attendees []Attendee `json:"attendees,omitempty"` // приглашённые; UserID — организатор
+
+	Title       string   `json:"title,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Location    string   `json:"location,omitempty"`
+	Tags        []string `json:"tags,omitempty"` // в нижнем регистре, без повторов
+
+	RRule        string      `json:"rrule,omitempty"`         // правило повторения RFC 5545
+	ExDates      []time.Time `json:"exdates,omitempty"`       // исключённые вхождения серии
+	SeriesId     *uuid.UUID  `json:"series_id,omitempty"`     // серия, из которой выделено это вхождение
+	RecurrenceId *time.Time  `json:"recurrence_id,omitempty"` // исходное начало вхождения серии
 }
 
 type EventRequest struct {
-	EventId   string `json:"event_id"`
-	UserID    int    `json:"user_id"`
-	Date      string `json:"date"`
-	EventText string `json:"event"`
+	EventId   string     `json:"event_id"`
+	UID       string     `json:"-"` // только при создании через CalDAV
+	UserID    int        `json:"user_id"`
+	Date      string     `json:"date"`                // YYYY-MM-DD, событие на весь день
+	Start     string     `json:"start,omitempty"`     // RFC 3339
+	End       string     `json:"end,omitempty"`       // RFC 3339
+	AllDay    *bool      `json:"all_day,omitempty"`   // по умолчанию true, если передан только date
+	TimeZone  string     `json:"time_zone,omitempty"` // IANA, например Europe/Moscow; по умолчанию UTC
+	EventText string     `json:"event"`
+	Resource  *string    `json:"resource,omitempty"`  // пустая строка освобождает ресурс
+	Attendees []Attendee `json:"attendees,omitempty"` // новый список участников, status игнорируется; [] убирает всех
+
+	Title       *string  `json:"title,omitempty"` // пустая строка очищает поле, как и у description и location
+	Description *string  `json:"description,omitempty"`
+	Location    *string  `json:"location,omitempty"`
+	Tags        []string `json:"tags,omitempty"` // новый список тегов; [] убирает все
+
+	RRule        *string  `json:"rrule,omitempty"`         // FREQ/INTERVAL/BYDAY/COUNT/UNTIL, пустая строка отменяет повторение
+	ExDates      []string `json:"exdates,omitempty"`       // RFC 3339 или YYYY-MM-DD
+	Scope        string   `json:"scope,omitempty"`         // all (по умолчанию) или this
+	RecurrenceId string   `json:"recurrence_id,omitempty"` // вхождение серии для scope=this
+
+	IfMatch        string `json:"-"` // заголовок If-Match: изменить, только если ETag события совпадает
+	IdempotencyKey string `json:"-"` // заголовок Idempotency-Key: повтор создания с тем же ключом вернёт исходное событие
+	Replace        bool   `json:"-"` // PUT: не переданные поля получают значения по умолчанию, а не остаются прежними
+	ActorID        int    `json:"-"` // кто выполняет запрос (пользователь из токена), если не сам UserID, например администратор
+}
+
+// Actor — кто выполняет запрос, для истории изменений
+func (er *EventRequest) Actor() int {
+	if er.ActorID != 0 {
+		return er.ActorID
+	}
+	return er.UserID
 }
 
+const (
+	ScopeAll  = "all"
+	ScopeThis = "this"
+
+	maxUIDLen = 255
+)
+
 /*
 // Можно сделать UserID типом uid.UUID, но исходя из т/з такой необходимости нет
 */
 
-var (
-	ErrInvalidInput  = errors.New("invalid input")
-	ErrBusinessLogic = errors.New("business logic error")
-)
-
 func NewEvent(er *EventRequest) (*Event, error) {
-	t, err := TimeParser(er.Date)
-	if err != nil {
-		return nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+	if er.Date == "" && er.Start == "" {
+		return nil, &ValidationError{Fields: []FieldError{
+			{Field: "date", Message: "date or start is required"},
+			{Field: "start", Message: "date or start is required"},
+		}}
+	}
+	id := uuid.New()
+	if er.EventId != "" {
+		var err error
+		if id, err = uuid.Parse(er.EventId); err != nil {
+			return nil, InvalidField("event_id", err)
+		}
 	}
-	return &Event{
-		EventId:   uuid.New(),
+	if len(er.UID) > maxUIDLen {
+		return nil, InvalidField("uid", fmt.Sprintf("must be at most %d bytes", maxUIDLen))
+	}
+	e := &Event{
+		EventId:   id,
+		UID:       er.UID,
+		Version:   1,
 		UserID:    er.UserID,
-		Date:      t,
 		EventText: er.EventText,
-	}, nil
+	}
+	if err := e.Apply(er); err != nil {
+		return nil, err
+	}
+	return e, nil
 }
 
 func (e *Event) Update(Date string, EventText string) error {
-	if Date != "" {
-		t, err := time.Parse("2006-01-02", Date)
-		if err != nil {
-			return fmt.Errorf("%w: %v", ErrInvalidInput, err)
+	return e.Apply(&EventRequest{Date: Date, EventText: EventText})
+}
+
+// Apply применяет к событию непустые поля запроса. Событие меняется только если все поля валидны.
+func (e *Event) Apply(er *EventRequest) error {
+	next := *e
+	if er.Replace {
+		next = e.cleared(er.UserID)
+	}
+	if er.HasTiming() {
+		if err := next.applyTiming(er); err != nil {
+			return err
+		}
+	}
+	if er.RRule != nil || er.ExDates != nil {
+		if err := next.applyRecurrence(er); err != nil {
+			return err
 		}
-		e.Date = t
 	}
-	if EventText != "" {
-		e.EventText = EventText
+	if er.EventText != "" {
+		next.EventText = er.EventText
 	}
+	if er.Resource != nil {
+		next.Resource = strings.TrimSpace(*er.Resource)
+	}
+	if er.Attendees != nil {
+		if err := next.applyAttendees(er.Attendees); err != nil {
+			return err
+		}
+	}
+	if err := next.applyDetails(er); err != nil {
+		return err
+	}
+	*e = next
 	return nil
 }
 
+// cleared возвращает событие без содержимого: сохраняются идентификаторы, версия и привязка к серии.
+// Участников сбрасывает только организатор, редактору с can_edit они остаются.
+func (e *Event) cleared(by int) Event {
+	next := Event{
+		EventId:      e.EventId,
+		UID:          e.UID,
+		Version:      e.Version,
+		UserID:       e.UserID,
+		SeriesId:     e.SeriesId,
+		RecurrenceId: e.RecurrenceId,
+	}
+	if by != e.UserID {
+		next.Attendees = e.Attendees
+	}
+	return next
+}
+
+func (e *Event) applyTiming(er *EventRequest) error {
+	zone := e.TimeZone
+	if er.TimeZone != "" {
+		zone = er.TimeZone
+	}
+	loc, err := LocationParser(zone)
+	if err != nil {
+		return InvalidField("time_zone", err)
+	}
+
+	allDay := e.AllDay
+	if er.Date != "" {
+		allDay = true
+	}
+	if er.AllDay != nil {
+		allDay = *er.AllDay
+	}
+
+	start, end := e.Start.In(loc), e.End.In(loc)
+	if e.AllDay {
+		start, end = floatingDate(e.Start, loc), floatingDate(e.End, loc)
+	}
+	duration := e.End.Sub(e.Start)
+	switch {
+	case er.Start != "":
+		if start, err = timestampParser(er.Start, loc); err != nil {
+			return InvalidField("start", err)
+		}
+	case er.Date != "":
+		if start, err = DateParser(er.Date, loc); err != nil {
+			return InvalidField("date", err)
+		}
+	case e.AllDay && !allDay:
+		duration = 0
+	}
+	if er.End != "" {
+		if end, err = timestampParser(er.End, loc); err != nil {
+			return InvalidField("end", err)
+		}
+	} else {
+		end = start.Add(duration)
+	}
+
+	if allDay {
+		start = startOfDay(start)
+		end = startOfDay(end)
+		if !end.After(start) {
+			end = start.AddDate(0, 0, 1)
+		}
+	}
+	if end.Before(start) {
+		return InvalidField("end", "end is before start")
+	}
+
+	e.Start, e.End, e.AllDay, e.TimeZone = start, end, allDay, loc.String()
+	y, m, d := start.Date()
+	e.Date = time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	return nil
+}
+
+// Overlaps сообщает, пересекается ли событие с полуинтервалом [from, to).
+// События на весь день привязаны к календарной дате и сравниваются в часовом поясе интервала.
+func (e *Event) Overlaps(from, to time.Time) bool {
+	start, end := e.Start, e.End
+	if e.AllDay {
+		start, end = floatingDate(start, from.Location()), floatingDate(end, from.Location())
+	}
+	if !end.After(start) {
+		return !start.Before(from) && start.Before(to)
+	}
+	return start.Before(to) && end.After(from)
+}
+
+// HasChanges сообщает, есть ли в запросе изменяемые поля
+func (er *EventRequest) HasChanges() bool {
+	return er.HasTiming() || er.EventText != "" || er.Resource != nil || er.Attendees != nil || er.RRule != nil || er.ExDates != nil ||
+		er.Title != nil || er.Description != nil || er.Location != nil || er.Tags != nil
+}
+
+// Blocks сообщает, занимает ли событие время: события на весь день и нулевой длины не занимают
+func (e *Event) Blocks() bool {
+	return !e.AllDay && e.End.After(e.Start)
+}
+
+// HasTiming сообщает, меняет ли запрос время события
+func (er *EventRequest) HasTiming() bool {
+	return er.Date != "" || er.Start != "" || er.End != "" || er.AllDay != nil || er.TimeZone != ""
+}
+
 func TimeParser(date string) (time.Time, error) { // в Repo тоже парсится время, если мы решим изменить формат, то поменяем только в этой функции
 	t, err := time.Parse("2006-01-02", date)
 	return t, err
@@ -1,6 +1,9 @@
 package app
 
 import (
+	"errors"
+	"fmt"
+	"strings"
 	"testing"
 	"time"
 )
@@ -102,3 +105,160 @@ func TestEventUpdateBadDate(t *testing.T) {
 		t.Fatal("expected error for bad date in Update")
 	}
 }
+
+func TestNewEventTimedWithZone(t *testing.T) {
+	ev, err := NewEvent(&EventRequest{
+		UserID:   1,
+		Start:    "2025-03-10T14:00:00Z",
+		End:      "2025-03-10T15:30:00Z",
+		TimeZone: "Europe/Moscow",
+	})
+	if err != nil {
+		t.Fatalf("NewEvent failed: %v", err)
+	}
+	if ev.AllDay {
+		t.Fatal("timed event marked all-day")
+	}
+	if ev.TimeZone != "Europe/Moscow" || ev.Start.Hour() != 17 {
+		t.Fatalf("start not converted to event zone: %v (%s)", ev.Start, ev.TimeZone)
+	}
+	if ev.End.Sub(ev.Start) != 90*time.Minute {
+		t.Fatalf("unexpected duration %v", ev.End.Sub(ev.Start))
+	}
+}
+
+func TestNewEventInvalidTiming(t *testing.T) {
+	cases := []*EventRequest{
+		{UserID: 1},
+		{UserID: 1, Start: "2025-03-10T14:00:00Z", End: "2025-03-10T13:00:00Z"},
+		{UserID: 1, Start: "2025-03-10T14:00:00Z", TimeZone: "Mars/Olympus"},
+		{UserID: 1, Start: "14:00"},
+	}
+	for _, er := range cases {
+		if _, err := NewEvent(er); !errors.Is(err, ErrInvalidInput) {
+			t.Errorf("expected ErrInvalidInput for %+v, got %v", er, err)
+		}
+	}
+}
+
+func TestNewEventDateIsAllDay(t *testing.T) {
+	ev, err := NewEvent(&EventRequest{UserID: 1, Date: "2025-03-10", TimeZone: "America/New_York"})
+	if err != nil {
+		t.Fatalf("NewEvent failed: %v", err)
+	}
+	if !ev.AllDay || ev.End.Sub(ev.Start) != 24*time.Hour || ev.Start.Hour() != 0 {
+		t.Fatalf("expected all-day event, got %v - %v", ev.Start, ev.End)
+	}
+}
+
+func TestEventApplyKeepsDuration(t *testing.T) {
+	ev, _ := NewEvent(&EventRequest{UserID: 1, Start: "2025-03-10T14:00:00Z", End: "2025-03-10T15:00:00Z"})
+	if err := ev.Apply(&EventRequest{Start: "2025-03-11T09:00:00Z"}); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+	if ev.End.Sub(ev.Start) != time.Hour || ev.Start.Day() != 11 {
+		t.Fatalf("unexpected timing after move: %v - %v", ev.Start, ev.End)
+	}
+	if err := ev.Apply(&EventRequest{End: "2025-03-11T08:00:00Z"}); err == nil {
+		t.Fatal("expected error when end is before start")
+	}
+	if ev.End.Sub(ev.Start) != time.Hour {
+		t.Fatal("event changed by invalid update")
+	}
+}
+
+func TestEventOverlaps(t *testing.T) {
+	moscow, _ := time.LoadLocation("Europe/Moscow")
+	timed, _ := NewEvent(&EventRequest{UserID: 1, Start: "2025-03-10T22:00:00Z", End: "2025-03-10T23:00:00Z"})
+	allDay, _ := NewEvent(&EventRequest{UserID: 1, Date: "2025-03-10"})
+
+	day10, _ := DateParser("2025-03-10", moscow)
+	day11, _ := DateParser("2025-03-11", moscow)
+
+	if from, to := DayRange(day10); timed.Overlaps(from, to) {
+		t.Error("22:00 UTC is already the next day in Moscow")
+	}
+	if from, to := DayRange(day11); !timed.Overlaps(from, to) {
+		t.Error("expected overlap with Moscow 2025-03-11")
+	}
+	if from, to := DayRange(day10); !allDay.Overlaps(from, to) {
+		t.Error("all-day event must stay on its date in any zone")
+	}
+	if from, to := DayRange(day11); allDay.Overlaps(from, to) {
+		t.Error("all-day event leaked into the next day")
+	}
+}
+
+func TestRanges(t *testing.T) {
+	d, _ := TimeParser("2025-10-29") // среда
+	from, to := WeekRange(d)
+	if from.Weekday() != time.Monday || from.Day() != 27 || to.Sub(from) != 7*24*time.Hour {
+		t.Fatalf("unexpected week range %v - %v", from, to)
+	}
+	from, to = MonthRange(d)
+	if from.Day() != 1 || to.Month() != time.November {
+		t.Fatalf("unexpected month range %v - %v", from, to)
+	}
+}
+
+func TestValidationErrorFields(t *testing.T) {
+	rule := "FREQ=HOURLY"
+	tests := []struct {
+		name  string
+		er    EventRequest
+		field string
+	}{
+		{"no date", EventRequest{UserID: 1}, "date"},
+		{"bad event id", EventRequest{EventId: "x", Date: "2025-05-05"}, "event_id"},
+		{"bad zone", EventRequest{Date: "2025-05-05", TimeZone: "Mars/Base"}, "time_zone"},
+		{"bad start", EventRequest{Start: "yesterday"}, "start"},
+		{"end before start", EventRequest{Start: "2025-05-05T10:00:00Z", End: "2025-05-05T09:00:00Z"}, "end"},
+		{"bad rrule", EventRequest{Date: "2025-05-05", RRule: &rule}, "rrule"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewEvent(&tt.er)
+			if !errors.Is(err, ErrInvalidInput) {
+				t.Fatalf("expected ErrInvalidInput, got %v", err)
+			}
+			var verr *ValidationError
+			if !errors.As(err, &verr) || verr.Fields[0].Field != tt.field {
+				t.Fatalf("expected field %q, got %v", tt.field, err)
+			}
+		})
+	}
+	if errors.Is(ErrNotFound, ErrInvalidInput) || !errors.Is(ErrNotFound, ErrBusinessLogic) {
+		t.Fatal("ErrNotFound must be a business logic error")
+	}
+}
+
+func TestEventDetails(t *testing.T) {
+	title, empty := "  Ретро  ", ""
+	e, err := NewEvent(&EventRequest{UserID: 1, Date: "2025-05-05", EventText: "x", Title: &title,
+		Tags: []string{"Работа", "работа", "  Очень   важно "}})
+	if err != nil {
+		t.Fatalf("NewEvent failed: %v", err)
+	}
+	if e.Title != "Ретро" || len(e.Tags) != 2 || e.Tags[1] != "очень важно" || !e.HasTag("работа") {
+		t.Fatalf("unexpected details: %+v", e)
+	}
+	if err := e.Apply(&EventRequest{Title: &empty, Tags: []string{}}); err != nil || e.Title != "" || len(e.Tags) != 0 {
+		t.Fatalf("details not cleared: %+v, %v", e, err)
+	}
+
+	long := strings.Repeat("я", maxTitleLen+1)
+	var tooMany []string
+	for i := 0; i <= maxTags; i++ {
+		tooMany = append(tooMany, fmt.Sprint("tag", i))
+	}
+	for _, er := range []*EventRequest{
+		{Title: &long},
+		{Tags: []string{""}},
+		{Tags: []string{"a,b"}},
+		{Tags: tooMany},
+	} {
+		if err := e.Apply(er); !errors.Is(err, ErrInvalidInput) {
+			t.Errorf("expected invalid input for %+v, got %v", er, err)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package app
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Ответы участника на приглашение
+const (
+	RSVPNeedsAction = "needs_action"
+	RSVPAccepted    = "accepted"
+	RSVPDeclined    = "declined"
+	RSVPTentative   = "tentative"
+)
+
+// Attendee — приглашённый пользователь. Организатор события — его владелец UserID.
+type Attendee struct {
+	UserID  int    `json:"user_id"`
+	Status  string `json:"status,omitempty"`   // needs_action | accepted | declined | tentative, меняет только сам участник
+	CanEdit bool   `json:"can_edit,omitempty"` // организатор разрешил участнику менять событие
+}
+
+// RSVPRequest — ответ участника на приглашение
+type RSVPRequest struct {
+	EventId string `json:"event_id"`
+	UserID  int    `json:"user_id"`
+	Status  string `json:"status"` // accepted | declined | tentative
+	ActorID int    `json:"-"`      // кто выполняет запрос, как в EventRequest
+}
+
+// Actor — кто выполняет запрос, для истории изменений
+func (rsvp *RSVPRequest) Actor() int {
+	if rsvp.ActorID != 0 {
+		return rsvp.ActorID
+	}
+	return rsvp.UserID
+}
+
+// Attendee возвращает участника userID или nil
+func (e *Event) Attendee(userID int) *Attendee {
+	for i := range e.Attendees {
+		if e.Attendees[i].UserID == userID {
+			return &e.Attendees[i]
+		}
+	}
+	return nil
+}
+
+// VisibleTo сообщает, показывать ли событие в календаре пользователя: организатору всегда, участнику — пока он не отказался
+func (e *Event) VisibleTo(userID int) bool {
+	if e.UserID == userID {
+		return true
+	}
+	a := e.Attendee(userID)
+	return a != nil && a.Status != RSVPDeclined
+}
+
+// EditableBy сообщает, может ли пользователь менять событие
+func (e *Event) EditableBy(userID int) bool {
+	if e.UserID == userID {
+		return true
+	}
+	a := e.Attendee(userID)
+	return a != nil && a.CanEdit
+}
+
+// Respond записывает ответ участника. Список участников копируется: вхождения серии и выделенные
+// из неё события делят его с исходным событием.
+func (e *Event) Respond(userID int, status string) error {
+	switch status {
+	case RSVPAccepted, RSVPDeclined, RSVPTentative, RSVPNeedsAction:
+	default:
+		return InvalidField("status", fmt.Sprintf("unknown status %q", status))
+	}
+	if e.Attendee(userID) == nil {
+		return fmt.Errorf("%w: user %d is not invited", ErrNotFound, userID)
+	}
+	attendees := slices.Clone(e.Attendees)
+	for i := range attendees {
+		if attendees[i].UserID == userID {
+			attendees[i].Status = status
+		}
+	}
+	e.Attendees = attendees
+	return nil
+}
+
+// applyAttendees заменяет список участников; ответы уже приглашённых сохраняются, новые ждут ответа
+func (e *Event) applyAttendees(list []Attendee) error {
+	attendees := make([]Attendee, 0, len(list))
+	seen := make(map[int]bool)
+	for _, a := range list {
+		switch {
+		case a.UserID <= 0:
+			return InvalidField("attendees", fmt.Sprintf("invalid user_id %d", a.UserID))
+		case a.UserID == e.UserID:
+			return InvalidField("attendees", "the organizer cannot be an attendee")
+		case seen[a.UserID]:
+			return InvalidField("attendees", fmt.Sprintf("user %d is listed twice", a.UserID))
+		}
+		seen[a.UserID] = true
+		status := RSVPNeedsAction
+		if prev := e.Attendee(a.UserID); prev != nil {
+			status = prev.Status
+		}
+		attendees = append(attendees, Attendee{UserID: a.UserID, Status: status, CanEdit: a.CanEdit})
+	}
+	if len(attendees) == 0 {
+		attendees = nil
+	}
+	e.Attendees = attendees
+	return nil
+}
@@ -0,0 +1,50 @@
+package app
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestEventAttendees(t *testing.T) {
+	e, err := NewEvent(&EventRequest{
+		UserID: 1, Date: "2025-05-05", EventText: "planning",
+		Attendees: []Attendee{{UserID: 2, Status: RSVPAccepted, CanEdit: true}, {UserID: 3}},
+	})
+	if err != nil {
+		t.Fatalf("NewEvent failed: %v", err)
+	}
+	if a := e.Attendee(2); a == nil || a.Status != RSVPNeedsAction || !a.CanEdit {
+		t.Fatalf("status must not be set by the organizer: %+v", a)
+	}
+	if !e.EditableBy(1) || !e.EditableBy(2) || e.EditableBy(3) || e.EditableBy(4) {
+		t.Fatal("unexpected edit rights")
+	}
+
+	occ := *e
+	if err := e.Respond(3, RSVPDeclined); err != nil {
+		t.Fatalf("Respond failed: %v", err)
+	}
+	if e.VisibleTo(3) || !e.VisibleTo(2) || occ.Attendee(3).Status != RSVPNeedsAction {
+		t.Fatal("declined event must be hidden and copies must keep their own list")
+	}
+	if err := e.Respond(4, RSVPAccepted); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound for a stranger, got %v", err)
+	}
+	if err := e.Respond(2, "maybe"); !errors.Is(err, ErrInvalidInput) {
+		t.Fatalf("expected invalid status, got %v", err)
+	}
+
+	// ответы сохраняются при замене списка
+	if err := e.Apply(&EventRequest{Attendees: []Attendee{{UserID: 3}, {UserID: 5}}}); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+	if e.Attendee(2) != nil || e.Attendee(3).Status != RSVPDeclined || e.Attendee(5).Status != RSVPNeedsAction {
+		t.Fatalf("unexpected attendees %+v", e.Attendees)
+	}
+
+	for _, list := range [][]Attendee{{{UserID: 1}}, {{UserID: 2}, {UserID: 2}}, {{UserID: 0}}} {
+		if err := e.Apply(&EventRequest{Attendees: list}); !errors.Is(err, ErrInvalidInput) {
+			t.Errorf("%+v: expected invalid input, got %v", list, err)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package app
+
+import (
+	"github.com/google/uuid"
+	"slices"
+	"time"
+)
+
+// Действия в истории события
+const (
+	AuditCreated   = "created"
+	AuditUpdated   = "updated"
+	AuditResponded = "responded"
+	AuditDeleted   = "deleted"
+	AuditRestored  = "restored"
+)
+
+// AuditEntry — запись истории: кто и когда изменил событие и каким оно было до и после.
+// У созданного события нет Before, у удалённого — After.
+type AuditEntry struct {
+	EventId uuid.UUID `json:"event_id"`
+	Action  string    `json:"action"`
+	UserID  int       `json:"user_id"` // кто изменил: пользователь из токена (организатор, участник или администратор), без аутентификации — владелец запроса
+	At      time.Time `json:"at"`
+	Before  *Event    `json:"before,omitempty"`
+	After   *Event    `json:"after,omitempty"`
+}
+
+// Snapshot копирует событие вместе со срезами, чтобы последующие изменения не меняли историю
+func (e *Event) Snapshot() *Event {
+	if e == nil {
+		return nil
+	}
+	s := *e
+	s.Attendees = slices.Clone(e.Attendees)
+	s.ExDates = slices.Clone(e.ExDates)
+	s.Tags = slices.Clone(e.Tags)
+	return &s
+}
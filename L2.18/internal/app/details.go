@@ -0,0 +1,77 @@
+package app
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	maxTitleLen       = 255
+	maxLocationLen    = 255
+	maxDescriptionLen = 10000
+	maxTags           = 20
+	maxTagLen         = 64
+)
+
+// applyDetails меняет заголовок, описание, место и теги; пустая строка очищает поле, [] — теги
+func (e *Event) applyDetails(er *EventRequest) error {
+	fields := []struct {
+		name  string
+		value *string
+		max   int
+		dst   *string
+	}{
+		{"title", er.Title, maxTitleLen, &e.Title},
+		{"description", er.Description, maxDescriptionLen, &e.Description},
+		{"location", er.Location, maxLocationLen, &e.Location},
+	}
+	for _, f := range fields {
+		if f.value == nil {
+			continue
+		}
+		v := strings.TrimSpace(*f.value)
+		if utf8.RuneCountInString(v) > f.max {
+			return InvalidField(f.name, fmt.Sprintf("must be at most %d characters", f.max))
+		}
+		*f.dst = v
+	}
+	if er.Tags != nil {
+		tags, err := NormalizeTags(er.Tags)
+		if err != nil {
+			return err
+		}
+		e.Tags = tags
+	}
+	return nil
+}
+
+// NormalizeTags приводит теги к нижнему регистру, схлопывает пробелы и убирает повторы, сохраняя порядок.
+// Запятая в теге запрещена: она разделяет CATEGORIES в iCalendar.
+func NormalizeTags(list []string) ([]string, error) {
+	var tags []string
+	for _, t := range list {
+		t = strings.ToLower(strings.Join(strings.Fields(t), " "))
+		switch {
+		case t == "":
+			return nil, InvalidField("tags", "tag must not be empty")
+		case utf8.RuneCountInString(t) > maxTagLen:
+			return nil, InvalidField("tags", fmt.Sprintf("tag %q is longer than %d characters", t, maxTagLen))
+		case strings.Contains(t, ","):
+			return nil, InvalidField("tags", fmt.Sprintf("tag %q must not contain commas", t))
+		}
+		if !slices.Contains(tags, t) {
+			tags = append(tags, t)
+		}
+	}
+	if len(tags) > maxTags {
+		return nil, InvalidField("tags", fmt.Sprintf("at most %d tags", maxTags))
+	}
+	return tags, nil
+}
+
+// HasTag сообщает, помечено ли событие тегом (тег уже нормализован)
+func (e *Event) HasTag(tag string) bool {
+	return slices.Contains(e.Tags, tag)
+}
@@ -0,0 +1,114 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidInput  = errors.New("invalid input")
+	ErrBusinessLogic = errors.New("business logic error")
+
+	// ErrNotFound и ErrConflict — частные случаи ErrBusinessLogic
+	ErrNotFound = fmt.Errorf("%w: %v", ErrBusinessLogic, "event not found")
+	ErrConflict = fmt.Errorf("%w: %v", ErrBusinessLogic, "event already exists")
+	// ErrForbidden — пользователь видит событие, но менять его не вправе
+	ErrForbidden = fmt.Errorf("%w: %v", ErrBusinessLogic, "not allowed")
+	// ErrPreconditionFailed — событие изменилось с тех пор, как клиент его прочитал (If-Match)
+	ErrPreconditionFailed = fmt.Errorf("%w: %v", ErrBusinessLogic, "precondition failed")
+)
+
+// Коды ошибок API, одинаковые для HTTP и gRPC
+const (
+	CodeNotFound     = "not_found"
+	CodeConflict     = "conflict"
+	CodeForbidden    = "forbidden"
+	CodePrecondition = "precondition_failed"
+	CodeValidation   = "validation_failed"
+	CodeBusinessRule = "business_rule_violation"
+	CodeInternal     = "internal_error"
+)
+
+// ErrorCode относит ошибку хранилища или проверки к коду API. Для прочих ошибок возвращает CodeInternal:
+// их подробности клиенту не показываются.
+func ErrorCode(err error) string {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return CodeNotFound
+	case errors.Is(err, ErrPreconditionFailed):
+		return CodePrecondition
+	case errors.Is(err, ErrForbidden):
+		return CodeForbidden
+	case errors.Is(err, ErrConflict):
+		return CodeConflict
+	case errors.Is(err, ErrInvalidInput):
+		return CodeValidation
+	case errors.Is(err, ErrBusinessLogic):
+		return CodeBusinessRule
+	}
+	return CodeInternal
+}
+
+// FieldError — ошибка в одном поле запроса, Field совпадает с именем поля в JSON
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError — запрос не прошёл проверку; errors.Is(err, ErrInvalidInput) для неё истинно
+type ValidationError struct {
+	Fields []FieldError
+}
+
+// InvalidField создаёт ValidationError для одного поля; reason — ошибка или текст
+func InvalidField(field string, reason any) error {
+	return &ValidationError{Fields: []FieldError{{Field: field, Message: fmt.Sprint(reason)}}}
+}
+
+func (e *ValidationError) Error() string {
+	parts := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		parts[i] = f.Field + ": " + f.Message
+	}
+	return ErrInvalidInput.Error() + ": " + strings.Join(parts, "; ")
+}
+
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrInvalidInput
+}
+
+// Conflict — событие, с которым пересекается сохраняемое: у того же пользователя или на том же ресурсе.
+// Чужое событие, которое вызывающий не видит, описывается только ресурсом и занятым интервалом.
+type Conflict struct {
+	EventId  *uuid.UUID `json:"event_id,omitempty"`
+	UserID   int        `json:"user_id,omitempty"`
+	Resource string     `json:"resource,omitempty"`
+	Start    time.Time  `json:"start"`
+	End      time.Time  `json:"end"`
+}
+
+// OverlapError — событие пересекается с уже занятым временем; errors.Is(err, ErrConflict) для неё истинно
+type OverlapError struct {
+	Conflicts []Conflict
+}
+
+func (e *OverlapError) Error() string {
+	parts := make([]string, len(e.Conflicts))
+	for i, c := range e.Conflicts {
+		parts[i] = fmt.Sprintf("%s–%s", c.Start.Format(time.RFC3339), c.End.Format(time.RFC3339))
+		if c.EventId != nil {
+			parts[i] = c.EventId.String() + " " + parts[i]
+		}
+		if c.Resource != "" {
+			parts[i] += " (" + c.Resource + ")"
+		}
+	}
+	return "time slot is busy: overlaps " + strings.Join(parts, ", ")
+}
+
+func (e *OverlapError) Unwrap() error {
+	return ErrConflict
+}
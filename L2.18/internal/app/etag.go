@@ -0,0 +1,62 @@
+package app
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ETag — строгий ETag события: идентификатор и версия, чтобы ETag одного события не подошёл к другому
+func (e *Event) ETag() string {
+	return `"` + e.EventId.String() + "-" + strconv.Itoa(e.Version) + `"`
+}
+
+// ObjectName — имя ресурса CalDAV без .ics: UID клиента или event_id
+func (e *Event) ObjectName() string {
+	if e.UID != "" {
+		return e.UID
+	}
+	return e.EventId.String()
+}
+
+// ObjectETag — ETag ресурса CalDAV: серии (первой в events) вместе с выделенными вхождениями.
+// Меняется при изменении любого из них и не зависит от порядка вхождений.
+func ObjectETag(events []*Event) string {
+	tags := make([]string, len(events)-1)
+	for i, e := range events[1:] {
+		tags[i] = e.EventId.String() + "-" + strconv.Itoa(e.Version)
+	}
+	sort.Strings(tags)
+	sum := sha1.New()
+	fmt.Fprintf(sum, "%s-%d;", events[0].EventId, events[0].Version)
+	for _, tag := range tags {
+		fmt.Fprintf(sum, "%s;", tag)
+	}
+	return `"` + hex.EncodeToString(sum.Sum(nil)) + `"`
+}
+
+// MatchesETag проверяет заголовок If-Match (RFC 9110): "*" или список ETag через запятую.
+// Слабые ETag (W/"...") для If-Match не подходят.
+func (e *Event) MatchesETag(header string) bool {
+	if strings.TrimSpace(header) == "*" {
+		return true
+	}
+	etag := e.ETag()
+	for _, tag := range strings.Split(header, ",") {
+		if strings.TrimSpace(tag) == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckPrecondition возвращает ErrPreconditionFailed, если If-Match задан и не совпадает с ETag события
+func (er *EventRequest) CheckPrecondition(e *Event) error {
+	if er.IfMatch == "" || e.MatchesETag(er.IfMatch) {
+		return nil
+	}
+	return fmt.Errorf("%w: event is at %s", ErrPreconditionFailed, e.ETag())
+}
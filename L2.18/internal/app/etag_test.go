@@ -0,0 +1,28 @@
+package app
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestETagPreconditions(t *testing.T) {
+	e, _ := NewEvent(&EventRequest{UserID: 1, Date: "2025-05-05"})
+	other, _ := NewEvent(&EventRequest{UserID: 1, Date: "2025-05-05"})
+	etag := e.ETag()
+
+	for _, header := range []string{"", "*", etag, `"x", ` + etag} {
+		if err := (&EventRequest{IfMatch: header}).CheckPrecondition(e); err != nil {
+			t.Errorf("If-Match %q: unexpected %v", header, err)
+		}
+	}
+	for _, header := range []string{other.ETag(), "W/" + etag, `"1"`} {
+		if err := (&EventRequest{IfMatch: header}).CheckPrecondition(e); !errors.Is(err, ErrPreconditionFailed) {
+			t.Errorf("If-Match %q: expected ErrPreconditionFailed, got %v", header, err)
+		}
+	}
+
+	e.Version++
+	if e.MatchesETag(etag) {
+		t.Fatal("ETag must change with the version")
+	}
+}
@@ -0,0 +1,75 @@
+package app
+
+import (
+	"sort"
+	"time"
+)
+
+// Interval — полуинтервал [Start, End)
+type Interval struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// Busy объединяет занятое событиями время внутри [from, to) в непересекающиеся интервалы по возрастанию
+func Busy(events []*Event, from, to time.Time) []Interval {
+	var spans []Interval
+	for _, e := range events {
+		if !e.Blocks() || !e.Overlaps(from, to) {
+			continue
+		}
+		spans = append(spans, Interval{Start: maxTime(e.Start, from), End: minTime(e.End, to)})
+	}
+	sort.Slice(spans, func(i, j int) bool { return spans[i].Start.Before(spans[j].Start) })
+
+	var merged []Interval
+	for _, s := range spans {
+		if n := len(merged); n > 0 && !s.Start.After(merged[n-1].End) {
+			merged[n-1].End = maxTime(merged[n-1].End, s.End)
+			continue
+		}
+		merged = append(merged, s)
+	}
+	return merged
+}
+
+// Free возвращает промежутки между занятыми интервалами внутри [from, to) длиной не меньше d
+func Free(busy []Interval, from, to time.Time, d time.Duration) []Interval {
+	var free []Interval
+	cursor := from
+	for _, b := range append(busy, Interval{Start: to, End: to}) {
+		if b.Start.Sub(cursor) >= d && b.Start.After(cursor) {
+			free = append(free, Interval{Start: cursor, End: b.Start})
+		}
+		cursor = maxTime(cursor, b.End)
+	}
+	return free
+}
+
+// Slots нарезает свободные промежутки на слоты длины d подряд с начала каждого промежутка, не больше limit
+func Slots(free []Interval, d time.Duration, limit int) []Interval {
+	var slots []Interval
+	for _, f := range free {
+		for start := f.Start; !start.Add(d).After(f.End); start = start.Add(d) {
+			if len(slots) == limit {
+				return slots
+			}
+			slots = append(slots, Interval{Start: start, End: start.Add(d)})
+		}
+	}
+	return slots
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
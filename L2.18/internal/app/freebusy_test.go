@@ -0,0 +1,55 @@
+package app
+
+import (
+	"testing"
+	"time"
+)
+
+func at(hour, min int) time.Time {
+	return time.Date(2025, 5, 5, hour, min, 0, 0, time.UTC)
+}
+
+func TestBusyFreeSlots(t *testing.T) {
+	events := []*Event{
+		{Start: at(10, 0), End: at(11, 0)},
+		{Start: at(10, 30), End: at(12, 0)}, // пересекается с предыдущим
+		{Start: at(12, 0), End: at(12, 30)}, // стык — тоже склеивается
+		{Start: at(14, 0), End: at(14, 0)},  // нулевой длины не занимает время
+		{Start: at(0, 0), End: at(0, 0).AddDate(0, 0, 1), AllDay: true},
+		{Start: at(16, 45), End: at(19, 0)}, // обрезается по to
+	}
+	from, to := at(9, 0), at(17, 0)
+
+	busy := Busy(events, from, to)
+	wantBusy := []Interval{{at(10, 0), at(12, 30)}, {at(16, 45), at(17, 0)}}
+	if !equalIntervals(busy, wantBusy) {
+		t.Fatalf("Busy = %v, want %v", busy, wantBusy)
+	}
+
+	free := Free(busy, from, to, time.Hour)
+	wantFree := []Interval{{at(9, 0), at(10, 0)}, {at(12, 30), at(16, 45)}}
+	if !equalIntervals(free, wantFree) {
+		t.Fatalf("Free = %v, want %v", free, wantFree)
+	}
+
+	slots := Slots(free, time.Hour, 3)
+	wantSlots := []Interval{{at(9, 0), at(10, 0)}, {at(12, 30), at(13, 30)}, {at(13, 30), at(14, 30)}}
+	if !equalIntervals(slots, wantSlots) {
+		t.Fatalf("Slots = %v, want %v", slots, wantSlots)
+	}
+	if all := Slots(free, time.Hour, 100); len(all) != 5 {
+		t.Fatalf("expected 5 one-hour slots, got %v", all)
+	}
+}
+
+func equalIntervals(a, b []Interval) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !a[i].Start.Equal(b[i].Start) || !a[i].End.Equal(b[i].End) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,192 @@
+package app
+
+import (
+	"fmt"
+	"github.com/google/uuid"
+	"time"
+)
+
+// CheckScope проверяет scope запроса на изменение или удаление
+func (er *EventRequest) CheckScope() error {
+	switch er.Scope {
+	case "", ScopeAll:
+		return nil
+	case ScopeThis:
+		if er.RecurrenceId == "" {
+			return InvalidField("recurrence_id", "recurrence_id is required for scope=this")
+		}
+		return nil
+	default:
+		return InvalidField("scope", fmt.Sprintf("unknown scope %q", er.Scope))
+	}
+}
+
+func (e *Event) applyRecurrence(er *EventRequest) error {
+	if er.RRule != nil {
+		if *er.RRule != "" && e.SeriesId != nil {
+			return InvalidField("rrule", "a detached occurrence cannot recur")
+		}
+		e.RRule = ""
+		if *er.RRule != "" {
+			loc, err := LocationParser(e.TimeZone)
+			if err != nil {
+				return InvalidField("time_zone", err)
+			}
+			if _, err := ParseRRule(*er.RRule, loc); err != nil {
+				return InvalidField("rrule", err)
+			}
+			e.RRule = *er.RRule
+		}
+	}
+	if er.ExDates != nil {
+		exDates := make([]time.Time, 0, len(er.ExDates))
+		for _, s := range er.ExDates {
+			t, err := e.occurrenceTime(s)
+			if err != nil {
+				return InvalidField("exdates", fmt.Sprintf("invalid exdate %q: %v", s, err))
+			}
+			exDates = append(exDates, t)
+		}
+		e.ExDates = exDates
+	}
+	if e.RRule == "" {
+		e.ExDates = nil
+	}
+	return nil
+}
+
+// Occurrences возвращает вхождения события, пересекающиеся с [from, to).
+// Для повторяющегося события это копии с исходным EventId и заполненным RecurrenceId.
+func (e *Event) Occurrences(from, to time.Time) []*Event {
+	if e.RRule == "" {
+		if e.Overlaps(from, to) {
+			return []*Event{e}
+		}
+		return nil
+	}
+	rule, dtstart, err := e.rule()
+	if err != nil {
+		return nil
+	}
+	limit := to.Add(24 * time.Hour) // события на весь день сравниваются в «плавающем» времени
+	var result []*Event
+	rule.Each(dtstart, func(t time.Time) bool {
+		if !t.Before(limit) {
+			return false
+		}
+		if e.isExcluded(t) {
+			return true
+		}
+		if occ := e.occurrence(t); occ.Overlaps(from, to) {
+			result = append(result, occ)
+		}
+		return true
+	})
+	return result
+}
+
+// OccurrenceAt разбирает recurrence_id и проверяет, что серия действительно содержит такое вхождение
+func (e *Event) OccurrenceAt(recurrenceId string) (time.Time, error) {
+	if e.RRule == "" {
+		return time.Time{}, InvalidField("recurrence_id", "event is not recurring")
+	}
+	t, err := e.occurrenceTime(recurrenceId)
+	if err != nil {
+		return time.Time{}, InvalidField("recurrence_id", err)
+	}
+	rule, dtstart, err := e.rule()
+	if err != nil {
+		return time.Time{}, InvalidField("rrule", err)
+	}
+	found := false
+	rule.Each(dtstart, func(o time.Time) bool {
+		found = o.Equal(t)
+		return o.Before(t)
+	})
+	if !found || e.isExcluded(t) {
+		return time.Time{}, fmt.Errorf("%w: no occurrence at %v", ErrNotFound, t)
+	}
+	return t, nil
+}
+
+// Detach выделяет вхождение серии в самостоятельное событие и исключает его из серии
+func (e *Event) Detach(t time.Time) *Event {
+	occ := e.occurrence(t)
+	occ.EventId = uuid.New()
+	occ.Version = 1
+	occ.RRule = ""
+	occ.ExDates = nil
+	seriesId := e.EventId
+	occ.SeriesId = &seriesId
+	e.Exclude(t)
+	return occ
+}
+
+func (e *Event) Exclude(t time.Time) {
+	if !e.isExcluded(t) {
+		e.ExDates = append(e.ExDates, t)
+	}
+}
+
+func (e *Event) occurrence(t time.Time) *Event {
+	occ := *e
+	occ.ExDates = nil
+	if e.AllDay {
+		days := floatingDate(e.End, time.UTC).Sub(floatingDate(e.Start, time.UTC)) / (24 * time.Hour)
+		occ.Start, occ.End = t, t.AddDate(0, 0, int(days))
+	} else {
+		occ.Start, occ.End = t, t.Add(e.End.Sub(e.Start))
+	}
+	y, m, d := t.Date()
+	occ.Date = time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	occ.RecurrenceId = &t
+	return &occ
+}
+
+func (e *Event) isExcluded(t time.Time) bool {
+	for _, x := range e.ExDates {
+		if x.Equal(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// rule возвращает разобранное правило и начало серии в зоне события
+func (e *Event) rule() (*RRule, time.Time, error) {
+	loc, err := LocationParser(e.TimeZone)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	rule, err := ParseRRule(e.RRule, loc)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	dtstart := e.Start.In(loc)
+	if e.AllDay {
+		dtstart = floatingDate(e.Start, loc)
+	}
+	return rule, dtstart, nil
+}
+
+// occurrenceTime принимает RFC 3339 или дату; дата дополняется временем начала серии
+func (e *Event) occurrenceTime(s string) (time.Time, error) {
+	loc, err := LocationParser(e.TimeZone)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t.In(loc), nil
+	}
+	d, err := DateParser(s, loc)
+	if err != nil {
+		return time.Time{}, err
+	}
+	start := e.Start.In(loc)
+	if e.AllDay {
+		start = floatingDate(e.Start, loc)
+	}
+	hh, mm, ss := start.Clock()
+	y, m, day := d.Date()
+	return time.Date(y, m, day, hh, mm, ss, start.Nanosecond(), loc), nil
+}
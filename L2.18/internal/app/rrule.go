@@ -0,0 +1,234 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	FreqDaily   = "DAILY"
+	FreqWeekly  = "WEEKLY"
+	FreqMonthly = "MONTHLY"
+	FreqYearly  = "YEARLY"
+)
+
+// maxPeriods ограничивает развёртку правил, у которых в большинстве периодов нет вхождений
+const maxPeriods = 100000
+
+// WeekdayNum — элемент BYDAY: день недели с необязательным порядковым номером в месяце (1MO, -1FR)
+type WeekdayNum struct {
+	N   int
+	Day time.Weekday
+}
+
+// RRule — поддерживаемое подмножество RFC 5545: FREQ, INTERVAL, BYDAY, COUNT, UNTIL
+type RRule struct {
+	Freq     string
+	Interval int
+	ByDay    []WeekdayNum
+	Count    int
+	Until    time.Time
+}
+
+var weekdays = map[string]time.Weekday{
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+	"SU": time.Sunday,
+}
+
+// ParseRRule разбирает правило вида FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE. UNTIL без зоны трактуется в loc.
+func ParseRRule(s string, loc *time.Location) (*RRule, error) {
+	s = strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(s)), "RRULE:")
+	rr := &RRule{Interval: 1}
+	for _, part := range strings.Split(s, ";") {
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("rrule: malformed part %q", part)
+		}
+		var err error
+		switch key {
+		case "FREQ":
+			switch value {
+			case FreqDaily, FreqWeekly, FreqMonthly, FreqYearly:
+				rr.Freq = value
+			default:
+				return nil, fmt.Errorf("rrule: unsupported FREQ %q", value)
+			}
+		case "INTERVAL":
+			if rr.Interval, err = strconv.Atoi(value); err != nil || rr.Interval < 1 {
+				return nil, fmt.Errorf("rrule: invalid INTERVAL %q", value)
+			}
+		case "COUNT":
+			if rr.Count, err = strconv.Atoi(value); err != nil || rr.Count < 1 {
+				return nil, fmt.Errorf("rrule: invalid COUNT %q", value)
+			}
+		case "UNTIL":
+			if rr.Until, err = parseUntil(value, loc); err != nil {
+				return nil, fmt.Errorf("rrule: invalid UNTIL %q", value)
+			}
+		case "BYDAY":
+			for _, d := range strings.Split(value, ",") {
+				wd, err := parseWeekdayNum(d)
+				if err != nil {
+					return nil, err
+				}
+				rr.ByDay = append(rr.ByDay, wd)
+			}
+		case "WKST":
+			// неделя всегда начинается с понедельника
+		default:
+			return nil, fmt.Errorf("rrule: unsupported part %q", key)
+		}
+	}
+	if rr.Freq == "" {
+		return nil, errors.New("rrule: FREQ is required")
+	}
+	if rr.Count > 0 && !rr.Until.IsZero() {
+		return nil, errors.New("rrule: COUNT and UNTIL are mutually exclusive")
+	}
+	for _, wd := range rr.ByDay {
+		if wd.N != 0 && rr.Freq != FreqMonthly {
+			return nil, fmt.Errorf("rrule: ordinal BYDAY is supported only for %s", FreqMonthly)
+		}
+	}
+	if len(rr.ByDay) > 0 && rr.Freq == FreqYearly {
+		return nil, fmt.Errorf("rrule: BYDAY is not supported for %s", FreqYearly)
+	}
+	return rr, nil
+}
+
+func parseWeekdayNum(s string) (WeekdayNum, error) {
+	if len(s) < 2 {
+		return WeekdayNum{}, fmt.Errorf("rrule: invalid BYDAY %q", s)
+	}
+	day, ok := weekdays[s[len(s)-2:]]
+	if !ok {
+		return WeekdayNum{}, fmt.Errorf("rrule: invalid BYDAY %q", s)
+	}
+	wd := WeekdayNum{Day: day}
+	if num := s[:len(s)-2]; num != "" {
+		n, err := strconv.Atoi(num)
+		if err != nil || n == 0 || n < -5 || n > 5 {
+			return WeekdayNum{}, fmt.Errorf("rrule: invalid BYDAY %q", s)
+		}
+		wd.N = n
+	}
+	return wd, nil
+}
+
+func parseUntil(s string, loc *time.Location) (time.Time, error) {
+	if t, err := time.Parse("20060102T150405Z", s); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("20060102T150405", s, loc); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("20060102", s, loc); err == nil {
+		return t.AddDate(0, 0, 1).Add(-time.Nanosecond), nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
+
+// Each вызывает fn для каждого вхождения по порядку, начиная с dtstart, пока fn возвращает true.
+// Вхождения строятся по настенному времени в зоне dtstart, поэтому переход на летнее время не сдвигает их.
+func (rr *RRule) Each(dtstart time.Time, fn func(time.Time) bool) {
+	n := 0
+	for period := 0; period < maxPeriods; period++ {
+		for _, t := range rr.candidates(dtstart, period*rr.Interval) {
+			if t.Before(dtstart) {
+				continue
+			}
+			if !rr.Until.IsZero() && t.After(rr.Until) {
+				return
+			}
+			if rr.Count > 0 && n >= rr.Count {
+				return
+			}
+			n++
+			if !fn(t) {
+				return
+			}
+		}
+	}
+}
+
+func (rr *RRule) candidates(dtstart time.Time, offset int) []time.Time {
+	loc := dtstart.Location()
+	y, m, d := dtstart.Date()
+	hh, mm, ss := dtstart.Clock()
+	at := func(y int, m time.Month, d int) time.Time {
+		return time.Date(y, m, d, hh, mm, ss, dtstart.Nanosecond(), loc)
+	}
+
+	var result []time.Time
+	switch rr.Freq {
+	case FreqDaily:
+		t := at(y, m, d+offset)
+		if len(rr.ByDay) == 0 || rr.hasWeekday(t.Weekday()) {
+			result = append(result, t)
+		}
+	case FreqWeekly:
+		monday := d - (int(dtstart.Weekday())+6)%7 + offset*7
+		if len(rr.ByDay) == 0 {
+			return []time.Time{at(y, m, d+offset*7)}
+		}
+		for _, wd := range rr.ByDay {
+			result = append(result, at(y, m, monday+(int(wd.Day)+6)%7))
+		}
+	case FreqMonthly:
+		first := time.Date(y, m+time.Month(offset), 1, 0, 0, 0, 0, loc)
+		fy, fm, _ := first.Date()
+		days := daysIn(fy, fm)
+		if len(rr.ByDay) == 0 {
+			if d <= days {
+				result = append(result, at(fy, fm, d))
+			}
+			break
+		}
+		for _, wd := range rr.ByDay {
+			firstDay := 1 + (int(wd.Day)-int(first.Weekday())+7)%7
+			switch {
+			case wd.N > 0:
+				if day := firstDay + (wd.N-1)*7; day <= days {
+					result = append(result, at(fy, fm, day))
+				}
+			case wd.N < 0:
+				last := firstDay + (days-firstDay)/7*7
+				if day := last + (wd.N+1)*7; day >= 1 {
+					result = append(result, at(fy, fm, day))
+				}
+			default:
+				for day := firstDay; day <= days; day += 7 {
+					result = append(result, at(fy, fm, day))
+				}
+			}
+		}
+	case FreqYearly:
+		if d <= daysIn(y+offset, m) {
+			result = append(result, at(y+offset, m, d))
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Before(result[j]) })
+	return result
+}
+
+func (rr *RRule) hasWeekday(day time.Weekday) bool {
+	for _, wd := range rr.ByDay {
+		if wd.Day == day {
+			return true
+		}
+	}
+	return false
+}
+
+func daysIn(y int, m time.Month) int {
+	return time.Date(y, m+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
@@ -0,0 +1,124 @@
+package app
+
+import (
+	"testing"
+	"time"
+)
+
+func expand(t *testing.T, rule string, dtstart time.Time, limit int) []time.Time {
+	t.Helper()
+	rr, err := ParseRRule(rule, dtstart.Location())
+	if err != nil {
+		t.Fatalf("ParseRRule(%q): %v", rule, err)
+	}
+	var result []time.Time
+	rr.Each(dtstart, func(o time.Time) bool {
+		result = append(result, o)
+		return len(result) < limit
+	})
+	return result
+}
+
+func TestRRuleExpansion(t *testing.T) {
+	dtstart := time.Date(2025, 1, 1, 9, 30, 0, 0, time.UTC) // среда
+	tests := []struct {
+		rule string
+		want []string
+	}{
+		{"FREQ=DAILY;COUNT=3", []string{"2025-01-01", "2025-01-02", "2025-01-03"}},
+		{"FREQ=DAILY;INTERVAL=2;UNTIL=20250105T235959Z", []string{"2025-01-01", "2025-01-03", "2025-01-05"}},
+		{"FREQ=DAILY;BYDAY=MO,TU,WE,TH,FR;COUNT=4", []string{"2025-01-01", "2025-01-02", "2025-01-03", "2025-01-06"}},
+		{"FREQ=WEEKLY;BYDAY=MO,WE;COUNT=4", []string{"2025-01-01", "2025-01-06", "2025-01-08", "2025-01-13"}},
+		{"FREQ=WEEKLY;INTERVAL=2;COUNT=3", []string{"2025-01-01", "2025-01-15", "2025-01-29"}},
+		{"FREQ=MONTHLY;BYDAY=-1FR;COUNT=3", []string{"2025-01-31", "2025-02-28", "2025-03-28"}},
+		{"FREQ=MONTHLY;BYDAY=1MO;COUNT=2", []string{"2025-01-06", "2025-02-03"}},
+		{"FREQ=YEARLY;COUNT=2", []string{"2025-01-01", "2026-01-01"}},
+	}
+	for _, tt := range tests {
+		got := expand(t, tt.rule, dtstart, 10)
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: expected %d occurrences, got %v", tt.rule, len(tt.want), got)
+			continue
+		}
+		for i, w := range tt.want {
+			if got[i].Format("2006-01-02") != w || got[i].Hour() != 9 || got[i].Minute() != 30 {
+				t.Errorf("%s: occurrence %d = %v, want %s 09:30", tt.rule, i, got[i], w)
+			}
+		}
+	}
+}
+
+func TestRRuleMonthlySkipsShortMonths(t *testing.T) {
+	dtstart := time.Date(2025, 1, 31, 10, 0, 0, 0, time.UTC)
+	got := expand(t, "FREQ=MONTHLY;COUNT=3", dtstart, 10)
+	want := []string{"2025-01-31", "2025-03-31", "2025-05-31"}
+	for i, w := range want {
+		if got[i].Format("2006-01-02") != w {
+			t.Fatalf("occurrence %d = %v, want %s", i, got[i], w)
+		}
+	}
+}
+
+func TestRRuleKeepsWallClockAcrossDST(t *testing.T) {
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+	dtstart := time.Date(2025, 3, 28, 9, 0, 0, 0, berlin)
+	for _, o := range expand(t, "FREQ=DAILY;COUNT=5", dtstart, 10) {
+		if o.Hour() != 9 {
+			t.Fatalf("occurrence moved off 09:00: %v", o)
+		}
+	}
+}
+
+func TestParseRRuleInvalid(t *testing.T) {
+	for _, rule := range []string{
+		"",
+		"INTERVAL=2",
+		"FREQ=HOURLY",
+		"FREQ=DAILY;COUNT=0",
+		"FREQ=DAILY;COUNT=2;UNTIL=20250101",
+		"FREQ=WEEKLY;BYDAY=1MO",
+		"FREQ=WEEKLY;BYDAY=XX",
+		"FREQ=DAILY;BYMONTH=1",
+	} {
+		if _, err := ParseRRule(rule, time.UTC); err == nil {
+			t.Errorf("expected error for %q", rule)
+		}
+	}
+}
+
+func TestEventOccurrencesAndDetach(t *testing.T) {
+	rule := "FREQ=DAILY;COUNT=5"
+	ev, err := NewEvent(&EventRequest{
+		UserID:  1,
+		Start:   "2025-01-01T09:00:00Z",
+		End:     "2025-01-01T09:15:00Z",
+		RRule:   &rule,
+		ExDates: []string{"2025-01-02"},
+	})
+	if err != nil {
+		t.Fatalf("NewEvent failed: %v", err)
+	}
+	from, _ := TimeParser("2025-01-01")
+	occ := ev.Occurrences(from, from.AddDate(0, 0, 7))
+	if len(occ) != 4 {
+		t.Fatalf("expected 4 occurrences (5 minus exdate), got %d", len(occ))
+	}
+	if occ[1].RecurrenceId == nil || occ[1].Start.Day() != 3 || occ[1].End.Sub(occ[1].Start) != 15*time.Minute {
+		t.Fatalf("unexpected occurrence %+v", occ[1])
+	}
+
+	if _, err := ev.OccurrenceAt("2025-01-02"); err == nil {
+		t.Fatal("expected error for excluded occurrence")
+	}
+	at, err := ev.OccurrenceAt("2025-01-03")
+	if err != nil {
+		t.Fatalf("OccurrenceAt failed: %v", err)
+	}
+	detached := ev.Detach(at)
+	if detached.SeriesId == nil || *detached.SeriesId != ev.EventId || detached.RRule != "" {
+		t.Fatalf("unexpected detached event %+v", detached)
+	}
+	if len(ev.Occurrences(from, from.AddDate(0, 0, 7))) != 3 {
+		t.Fatal("detached occurrence still expanded from the series")
+	}
+}
@@ -0,0 +1,79 @@
+package app
+
+import (
+	"time"
+)
+
+// LocationParser загружает IANA-зону, пустая строка означает UTC
+func LocationParser(zone string) (*time.Location, error) {
+	if zone == "" {
+		return time.UTC, nil
+	}
+	return time.LoadLocation(zone)
+}
+
+// DateParser разбирает дату YYYY-MM-DD как полночь в указанной зоне
+func DateParser(date string, loc *time.Location) (time.Time, error) {
+	return time.ParseInLocation("2006-01-02", date, loc)
+}
+
+// timestampParser принимает RFC 3339 или дату без времени
+func timestampParser(ts string, loc *time.Location) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339, ts)
+	if err != nil {
+		return DateParser(ts, loc)
+	}
+	return t.In(loc), nil
+}
+
+// BoundsParser разбирает границы интервала: RFC 3339 или дата YYYY-MM-DD в зоне loc, дата в to входит целиком
+func BoundsParser(from, to string, loc *time.Location) (time.Time, time.Time, error) {
+	start, err := timestampParser(from, loc)
+	if err != nil {
+		return time.Time{}, time.Time{}, InvalidField("from", err)
+	}
+	end, err := time.Parse(time.RFC3339, to)
+	if err != nil {
+		d, derr := DateParser(to, loc)
+		if derr != nil {
+			return time.Time{}, time.Time{}, InvalidField("to", derr)
+		}
+		end = d.AddDate(0, 0, 1)
+	}
+	if !end.After(start) {
+		return time.Time{}, time.Time{}, InvalidField("to", "to must be after from")
+	}
+	return start, end.In(loc), nil
+}
+
+func startOfDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
+
+// floatingDate переносит календарную дату и время t в зону loc без пересчёта
+func floatingDate(t time.Time, loc *time.Location) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), loc)
+}
+
+// DayRange возвращает сутки, содержащие date, в зоне date
+func DayRange(date time.Time) (time.Time, time.Time) {
+	from := startOfDay(date)
+	return from, from.AddDate(0, 0, 1)
+}
+
+// WeekRange возвращает ISO-неделю (с понедельника), содержащую date, в зоне date
+func WeekRange(date time.Time) (time.Time, time.Time) {
+	from := startOfDay(date)
+	offset := (int(from.Weekday()) + 6) % 7
+	from = from.AddDate(0, 0, -offset)
+	return from, from.AddDate(0, 0, 7)
+}
+
+// MonthRange возвращает календарный месяц, содержащий date, в зоне date
+func MonthRange(date time.Time) (time.Time, time.Time) {
+	y, m, _ := date.Date()
+	from := time.Date(y, m, 1, 0, 0, 0, 0, date.Location())
+	return from, from.AddDate(0, 1, 0)
+}
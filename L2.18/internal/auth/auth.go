@@ -0,0 +1,117 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const ScopeAdmin = "admin"
+
+var (
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrForbidden    = errors.New("forbidden")
+)
+
+// Claims — полезная нагрузка токена: sub содержит ID пользователя, scope — области через пробел
+type Claims struct {
+	Scope string `json:"scope,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// Principal — пользователь, от имени которого выполняется запрос
+type Principal struct {
+	UserID int
+	Admin  bool
+}
+
+type principalKey struct{}
+
+// Authenticator проверяет HMAC-подписанные JWT
+type Authenticator struct {
+	secret []byte
+	issuer string
+}
+
+func NewAuthenticator(secret, issuer string) (*Authenticator, error) {
+	if secret == "" {
+		return nil, errors.New("auth: secret is required")
+	}
+	return &Authenticator{secret: []byte(secret), issuer: issuer}, nil
+}
+
+// Issue выпускает токен пользователю; нужен для тестов и утилит
+func (a *Authenticator) Issue(userID int, ttl time.Duration, scopes ...string) (string, error) {
+	now := time.Now()
+	claims := Claims{
+		Scope: strings.Join(scopes, " "),
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   strconv.Itoa(userID),
+			Issuer:    a.issuer,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(a.secret)
+}
+
+// Parse проверяет подпись, срок действия и издателя токена
+func (a *Authenticator) Parse(token string) (Principal, error) {
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg(), jwt.SigningMethodHS384.Alg(), jwt.SigningMethodHS512.Alg()}),
+		jwt.WithExpirationRequired(),
+	}
+	if a.issuer != "" {
+		opts = append(opts, jwt.WithIssuer(a.issuer))
+	}
+	var claims Claims
+	if _, err := jwt.ParseWithClaims(token, &claims, func(*jwt.Token) (any, error) {
+		return a.secret, nil
+	}, opts...); err != nil {
+		return Principal{}, fmt.Errorf("%w: %v", ErrUnauthorized, err)
+	}
+	userID, err := strconv.Atoi(claims.Subject)
+	if err != nil {
+		return Principal{}, fmt.Errorf("%w: invalid sub %q", ErrUnauthorized, claims.Subject)
+	}
+	return Principal{
+		UserID: userID,
+		Admin:  slices.Contains(strings.Fields(claims.Scope), ScopeAdmin),
+	}, nil
+}
+
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+func PrincipalFrom(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(Principal)
+	return p, ok
+}
+
+// Actor — пользователь из токена, который выполняет запрос; без аутентификации 0
+func Actor(ctx context.Context) int {
+	p, _ := PrincipalFrom(ctx)
+	return p.UserID
+}
+
+// ResolveUser возвращает пользователя, с чьими событиями работает запрос: 0 заменяется пользователем
+// из токена, чужой user_id разрешён только администратору. Без аутентификации requested возвращается как есть.
+func ResolveUser(ctx context.Context, requested int) (int, error) {
+	p, ok := PrincipalFrom(ctx)
+	if !ok {
+		return requested, nil
+	}
+	if requested == 0 {
+		return p.UserID, nil
+	}
+	if requested != p.UserID && !p.Admin {
+		return 0, fmt.Errorf("%w: token of user %d cannot act for user %d", ErrForbidden, p.UserID, requested)
+	}
+	return requested, nil
+}
@@ -0,0 +1,81 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"github.com/golang-jwt/jwt/v5"
+	"testing"
+	"time"
+)
+
+func TestIssueAndParse(t *testing.T) {
+	a, _ := NewAuthenticator("secret", "calendar")
+	token, err := a.Issue(42, time.Hour)
+	if err != nil {
+		t.Fatalf("Issue failed: %v", err)
+	}
+	p, err := a.Parse(token)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if p.UserID != 42 || p.Admin {
+		t.Fatalf("unexpected principal %+v", p)
+	}
+
+	admin, _ := a.Issue(1, time.Hour, "read", ScopeAdmin)
+	if p, _ := a.Parse(admin); !p.Admin {
+		t.Fatal("admin scope not recognised")
+	}
+}
+
+func TestParseRejects(t *testing.T) {
+	a, _ := NewAuthenticator("secret", "calendar")
+	other, _ := NewAuthenticator("other", "calendar")
+	foreignIssuer, _ := NewAuthenticator("secret", "someone-else")
+
+	expired, _ := a.Issue(1, -time.Minute)
+	wrongKey, _ := other.Issue(1, time.Hour)
+	wrongIss, _ := foreignIssuer.Issue(1, time.Hour)
+	noExp, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, Claims{RegisteredClaims: jwt.RegisteredClaims{Subject: "1", Issuer: "calendar"}}).SignedString([]byte("secret"))
+	badSub, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, Claims{RegisteredClaims: jwt.RegisteredClaims{Subject: "bob", Issuer: "calendar", ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour))}}).SignedString([]byte("secret"))
+	unsigned, _ := jwt.NewWithClaims(jwt.SigningMethodNone, Claims{RegisteredClaims: jwt.RegisteredClaims{Subject: "1", ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour))}}).SignedString(jwt.UnsafeAllowNoneSignatureType)
+
+	for name, token := range map[string]string{
+		"expired":   expired,
+		"wrong key": wrongKey,
+		"issuer":    wrongIss,
+		"no exp":    noExp,
+		"bad sub":   badSub,
+		"alg none":  unsigned,
+		"garbage":   "a.b.c",
+	} {
+		if _, err := a.Parse(token); !errors.Is(err, ErrUnauthorized) {
+			t.Errorf("%s: expected ErrUnauthorized, got %v", name, err)
+		}
+	}
+}
+
+func TestResolveUser(t *testing.T) {
+	if u, err := ResolveUser(context.Background(), 5); err != nil || u != 5 {
+		t.Fatalf("without auth user must pass through, got %d %v", u, err)
+	}
+
+	user := WithPrincipal(context.Background(), Principal{UserID: 7})
+	if u, _ := ResolveUser(user, 0); u != 7 {
+		t.Fatalf("expected user from token, got %d", u)
+	}
+	if _, err := ResolveUser(user, 8); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("expected ErrForbidden, got %v", err)
+	}
+
+	admin := WithPrincipal(context.Background(), Principal{UserID: 1, Admin: true})
+	if u, err := ResolveUser(admin, 8); err != nil || u != 8 {
+		t.Fatalf("admin must act for anyone, got %d %v", u, err)
+	}
+}
+
+func TestNewAuthenticatorRequiresSecret(t *testing.T) {
+	if _, err := NewAuthenticator("", ""); err == nil {
+		t.Fatal("expected error for empty secret")
+	}
+}
@@ -1,35 +1,153 @@
 package config
 
 import (
+	"errors"
+	"flag"
 	"log"
+	"net/netip"
 	"os"
-
-	"gopkg.in/yaml.v3"
+	"strings"
+	"time"
 )
 
 type Config struct {
-	Env      string `yaml:"env" env-default:"local"`
-	HttpPort int    `yaml:"http_port" env-default:"8080"`
+	Env       string          `yaml:"env" env-default:"local"` // local | dev | prod
+	HttpPort  int             `yaml:"http_port" env-default:"8080"`
+	GrpcPort  int             `yaml:"grpc_port" env-default:"0"` // 0 — gRPC API выключен
+	Log       LogConfig       `yaml:"log"`
+	Server    ServerConfig    `yaml:"server"`
+	Limits    LimitsConfig    `yaml:"limits"`
+	Storage   StorageConfig   `yaml:"storage"`
+	Reminders RemindersConfig `yaml:"reminders"`
+	Stream    StreamConfig    `yaml:"stream"`
+	Webhooks  WebhooksConfig  `yaml:"webhooks"`
+	Auth      AuthConfig      `yaml:"auth"`
+	Telemetry TelemetryConfig `yaml:"telemetry"`
 }
 
-func LoadConfig(path string) (*Config, error) {
-	data, err := os.ReadFile(path)
-	if err != nil {
-		return nil, err
-	}
-	var cfg Config
-	if err = yaml.Unmarshal(data, &cfg); err != nil {
-		return nil, err
+// ServerConfig — таймауты HTTP-сервера; нулевое значение заменяется значением по умолчанию
+type ServerConfig struct {
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout" env-default:"5s"`
+	ReadTimeout       time.Duration `yaml:"read_timeout" env-default:"15s"`
+	WriteTimeout      time.Duration `yaml:"write_timeout" env-default:"30s"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout" env-default:"60s"`
+	ShutdownTimeout   time.Duration `yaml:"shutdown_timeout" env-default:"10s"` // сколько ждать завершения текущих запросов при остановке
+}
+
+// LimitsConfig — размер тела и частота запросов к API по группам маршрутов: чтение, изменение, импорт .ics.
+// Частота ограничивается для каждого клиента (пользователя из токена или IP) отдельно; rps 0 отключает ограничение.
+type LimitsConfig struct {
+	MaxBodyBytes   int     `yaml:"max_body_bytes" env-default:"1048576"`
+	MaxImportBytes int     `yaml:"max_import_bytes" env-default:"10485760"`
+	ReadRPS        float64 `yaml:"read_rps" env-default:"50"`
+	ReadBurst      int     `yaml:"read_burst" env-default:"100"`
+	WriteRPS       float64 `yaml:"write_rps" env-default:"10"`
+	WriteBurst     int     `yaml:"write_burst" env-default:"20"`
+	ImportRPS      float64 `yaml:"import_rps" env-default:"0.2"`
+	ImportBurst    int     `yaml:"import_burst" env-default:"2"`
+	IPRPS          float64 `yaml:"ip_rps" env-default:"100"` // все запросы с одного адреса, считаются до проверки токена
+	IPBurst        int     `yaml:"ip_burst" env-default:"200"`
+}
+
+// LogConfig — уровень, формат и куда писать логи; пустые значения выбираются по env
+type LogConfig struct {
+	Level      string `yaml:"level"`                         // debug | info | warn | error, по умолчанию debug, для prod info; меняется по SIGHUP
+	Format     string `yaml:"format"`                        // console | json, по умолчанию console, для prod json
+	Outputs    string `yaml:"outputs"`                       // через запятую: stdout, stderr или путь к файлу; по умолчанию stderr, для prod logs/app.log
+	MaxSizeMB  int    `yaml:"max_size_mb" env-default:"100"` // размер файла, после которого он ротируется
+	MaxAgeDays int    `yaml:"max_age_days"`                  // сколько хранить ротированные файлы, 0 — не удалять по возрасту
+	MaxBackups int    `yaml:"max_backups"`                   // сколько ротированных файлов хранить, 0 — все
+	Compress   bool   `yaml:"compress"`                      // сжимать ротированные файлы gzip
+}
+
+type StorageConfig struct {
+	Type               string        `yaml:"type" env-default:"memory"` // memory | file
+	Path               string        `yaml:"path" env-default:"data/events.log"`
+	CompactThreshold   int           `yaml:"compact_threshold" env-default:"1000"`
+	RejectConflicts    bool          `yaml:"reject_conflicts" env-default:"false"`   // отклонять события, пересекающиеся у пользователя или на ресурсе
+	TombstoneRetention time.Duration `yaml:"tombstone_retention" env-default:"720h"` // сколько удалённое событие можно восстановить
+}
+
+type RemindersConfig struct {
+	Enabled        bool          `yaml:"enabled" env-default:"false"`
+	Offset         time.Duration `yaml:"offset" env-default:"15m"`   // за сколько до начала события напоминать
+	Interval       time.Duration `yaml:"interval" env-default:"30s"` // как часто проверять хранилище
+	StatePath      string        `yaml:"state_path"`                 // отметки об отправке, чтобы не дублировать после перезапуска
+	Log            bool          `yaml:"log" env-default:"true"`
+	WebhookURL     string        `yaml:"webhook_url"`
+	WebhookTimeout time.Duration `yaml:"webhook_timeout" env-default:"5s"`
+	FilePath       string        `yaml:"file_path"`
+}
+
+// StreamConfig — лента изменений /events/stream
+type StreamConfig struct {
+	Heartbeat time.Duration `yaml:"heartbeat" env-default:"15s"` // пауза, после которой в тихий поток пишется комментарий, чтобы прокси не закрыли его
+	Backlog   int           `yaml:"backlog" env-default:"1000"`  // сколько последних изменений помнить для переподключения с Last-Event-ID
+}
+
+// WebhooksConfig — доставка изменений событий на адреса, которые пользователи регистрируют через /webhooks
+type WebhooksConfig struct {
+	Enabled     bool          `yaml:"enabled" env-default:"false"`
+	StatePath   string        `yaml:"state_path"`                      // подписки и очередь доставок; пусто — только в памяти
+	Workers     int           `yaml:"workers" env-default:"4"`         // сколько доставок отправлять одновременно
+	Timeout     time.Duration `yaml:"timeout" env-default:"10s"`       // ожидание ответа получателя
+	MaxAttempts int           `yaml:"max_attempts" env-default:"8"`    // после стольких неудач доставка попадает в dead letters
+	Backoff     time.Duration `yaml:"backoff" env-default:"10s"`       // пауза перед первым повтором, дальше удваивается
+	MaxBackoff  time.Duration `yaml:"max_backoff" env-default:"1h"`    // предел паузы между повторами
+	History     int           `yaml:"history" env-default:"100"`       // сколько доставленных хранить на подписку
+	DeadLetters int           `yaml:"dead_letters" env-default:"1000"` // сколько недоставленных хранить на подписку
+	// через запятую в CIDR: внутренние сети, куда можно доставлять; loopback, частные и link-local адреса
+	// без этого запрещены
+	AllowedNetworks string `yaml:"allowed_networks"`
+}
+
+// Networks разбирает AllowedNetworks
+func (c WebhooksConfig) Networks() ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+	for _, s := range strings.Split(c.AllowedNetworks, ",") {
+		if s = strings.TrimSpace(s); s == "" {
+			continue
+		}
+		prefix, err := netip.ParsePrefix(s)
+		if err != nil {
+			return nil, err
+		}
+		prefixes = append(prefixes, prefix.Masked())
 	}
-	return &cfg, err
+	return prefixes, nil
+}
+
+// AuthConfig — JWT-аутентификация. Она включена по умолчанию; выключить её можно только при env: local.
+type AuthConfig struct {
+	Enabled bool   `yaml:"enabled" env-default:"true"`
+	Secret  string `yaml:"secret"` // HMAC-ключ для подписи JWT; лучше задавать через CALENDAR_AUTH_SECRET
+	Issuer  string `yaml:"issuer"` // если задан, iss токена должен совпадать
+}
+
+// TelemetryConfig — метрики Prometheus отдаются всегда на /metrics, трассировка включается отдельно
+type TelemetryConfig struct {
+	ServiceName string        `yaml:"service_name" env-default:"calendar"`
+	Tracing     TracingConfig `yaml:"tracing"`
+}
+
+type TracingConfig struct {
+	Enabled     bool    `yaml:"enabled" env-default:"false"`
+	Endpoint    string  `yaml:"endpoint" env-default:"localhost:4318"` // OTLP/HTTP коллектор, host:port
+	Insecure    bool    `yaml:"insecure" env-default:"false"`          // без TLS, для локального коллектора
+	SampleRatio float64 `yaml:"sample_ratio" env-default:"1"`          // доля корневых трасс, 0 означает 1
+}
+
+// LoadConfig читает только YAML-файл поверх значений по умолчанию, без окружения и флагов
+func LoadConfig(path string) (*Config, error) {
+	return Load([]string{"-config", path}, func(string) (string, bool) { return "", false })
 }
 
+// MustLoad загружает конфиг из флагов процесса и окружения, при ошибке завершает процесс
 func MustLoad() *Config {
-	path := os.Getenv("CONFIG_PATH")
-	if path == "" {
-		log.Fatal("CONFIG_PATH env is required")
+	cfg, err := Load(os.Args[1:], os.LookupEnv)
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
 	}
-	cfg, err := LoadConfig(path)
 	if err != nil {
 		log.Fatalf("failed to load config: %v", err)
 	}
@@ -0,0 +1,169 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix — префикс переменных окружения: storage.type задаётся как CALENDAR_STORAGE_TYPE
+const EnvPrefix = "CALENDAR_"
+
+// Load собирает конфиг по слоям: значения env-default из тегов, YAML-файл (если задан), переменные
+// CALENDAR_*, флаги командной строки (-storage.type=file). Путь к файлу берётся из флага -config,
+// затем из CALENDAR_CONFIG или CONFIG_PATH. Все ошибки разбора и проверки возвращаются одной ошибкой.
+func Load(args []string, lookupEnv func(string) (string, bool)) (*Config, error) {
+	var cfg Config
+	fields := collectFields(reflect.ValueOf(&cfg).Elem(), nil)
+
+	var errs []error
+	for _, f := range fields {
+		if f.def == "" {
+			continue
+		}
+		if err := setValue(f.value, f.def); err != nil {
+			errs = append(errs, fmt.Errorf("default for %s: %w", f.key("."), err))
+		}
+	}
+
+	fs := flag.NewFlagSet("calendar", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	path := fs.String("config", "", "path to YAML config")
+	// значения флагов применяются после файла и окружения, поэтому сначала только запоминаются
+	var flagged []func() error
+	for _, f := range fields {
+		apply := func(s string) error {
+			flagged = append(flagged, func() error {
+				if err := setValue(f.value, s); err != nil {
+					return fmt.Errorf("-%s: %w", f.key("."), err)
+				}
+				return nil
+			})
+			return nil
+		}
+		if f.value.Kind() == reflect.Bool {
+			fs.BoolFunc(f.key("."), "override "+f.key("."), apply)
+		} else {
+			fs.Func(f.key("."), "override "+f.key("."), apply)
+		}
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if *path == "" {
+		*path = firstEnv(lookupEnv, EnvPrefix+"CONFIG", "CONFIG_PATH")
+	}
+	if *path != "" {
+		data, err := os.ReadFile(*path)
+		if err != nil {
+			return nil, err
+		}
+		if err := yaml.Unmarshal(data, &cfg); err != nil {
+			return nil, fmt.Errorf("parse %s: %w", *path, err)
+		}
+	}
+
+	for _, f := range fields {
+		name := EnvPrefix + strings.ToUpper(f.key("_"))
+		if s, ok := lookupEnv(name); ok {
+			if err := setValue(f.value, s); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			}
+		}
+	}
+	for _, apply := range flagged {
+		if err := apply(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return &cfg, nil
+}
+
+type field struct {
+	path  []string // имена из yaml-тегов от корня
+	value reflect.Value
+	def   string
+}
+
+func (f field) key(sep string) string {
+	return strings.Join(f.path, sep)
+}
+
+func collectFields(v reflect.Value, prefix []string) []field {
+	var fields []field
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		name, _, _ := strings.Cut(sf.Tag.Get("yaml"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		path := append(append([]string(nil), prefix...), name)
+		if sf.Type.Kind() == reflect.Struct {
+			fields = append(fields, collectFields(v.Field(i), path)...)
+			continue
+		}
+		fields = append(fields, field{path: path, value: v.Field(i), def: sf.Tag.Get("env-default")})
+	}
+	return fields
+}
+
+func setValue(v reflect.Value, s string) error {
+	if v.Type() == reflect.TypeOf(time.Duration(0)) {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q", s)
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Int:
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", s)
+		}
+		v.SetInt(int64(n))
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", s)
+		}
+		v.SetBool(b)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", s)
+		}
+		v.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+func firstEnv(lookupEnv func(string) (string, bool), names ...string) string {
+	for _, name := range names {
+		if s, ok := lookupEnv(name); ok && s != "" {
+			return s
+		}
+	}
+	return ""
+}
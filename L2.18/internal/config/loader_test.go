@@ -0,0 +1,95 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func envOf(m map[string]string) func(string) (string, bool) {
+	return func(k string) (string, bool) {
+		v, ok := m[k]
+		return v, ok
+	}
+}
+
+func TestLoadLayers(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cfg.yaml")
+	yaml := "env: dev\nhttp_port: 9000\nstorage:\n  type: file\n  path: from-file.log\n"
+	if err := os.WriteFile(path, []byte(yaml), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	env := envOf(map[string]string{
+		"CALENDAR_CONFIG":       path,
+		"CALENDAR_HTTP_PORT":    "9100",
+		"CALENDAR_STORAGE_PATH": "from-env.log",
+	})
+	cfg, err := Load([]string{"-http_port=9200", "-auth.enabled", "-auth.secret", "s"}, env)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	if cfg.Env != "dev" || cfg.Storage.Type != "file" {
+		t.Fatalf("file values not applied: %+v", cfg)
+	}
+	if cfg.Storage.Path != "from-env.log" {
+		t.Fatalf("env must override file, got %q", cfg.Storage.Path)
+	}
+	if cfg.HttpPort != 9200 {
+		t.Fatalf("flag must override env, got %d", cfg.HttpPort)
+	}
+	if !cfg.Auth.Enabled || cfg.Auth.Secret != "s" {
+		t.Fatalf("bool/string flags not applied: %+v", cfg.Auth)
+	}
+	if cfg.Server.WriteTimeout != 30*time.Second || cfg.Storage.CompactThreshold != 1000 {
+		t.Fatalf("defaults not applied: %+v", cfg)
+	}
+}
+
+func TestLoadWithoutFile(t *testing.T) {
+	cfg, err := Load(nil, envOf(map[string]string{"CALENDAR_AUTH_SECRET": "s"}))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.Env != "local" || cfg.HttpPort != 8080 || cfg.Storage.Type != "memory" || !cfg.Auth.Enabled {
+		t.Fatalf("unexpected defaults: %+v", cfg)
+	}
+}
+
+func TestLoadReportsAllErrors(t *testing.T) {
+	env := envOf(map[string]string{
+		"CALENDAR_ENV":              "staging",
+		"CALENDAR_REMINDERS_OFFSET": "soon",
+	})
+	_, err := Load([]string{"-http_port=70000", "-storage.type=sql", "-auth.enabled", "-webhooks.enabled", "-webhooks.allowed_networks=10.0.0.0/8,intranet", "-reminders.enabled", "-reminders.offset=0s"}, env)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	for _, want := range []string{"CALENDAR_REMINDERS_OFFSET", "env:", "http_port:", "storage.type:", "auth.secret:", "webhooks.allowed_networks:", "reminders.offset:"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error does not mention %s:\n%v", want, err)
+		}
+	}
+}
+
+func TestLoadAuthRequired(t *testing.T) {
+	for _, tc := range []struct {
+		args []string
+		want string
+	}{
+		{nil, "auth.secret: is required"},
+		{[]string{"-auth.secret=change-me"}, "auth.secret: must be changed"},
+		{[]string{"-env=prod", "-auth.enabled=false"}, "auth.enabled:"},
+	} {
+		if _, err := Load(tc.args, envOf(nil)); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("Load %v: expected %q, got %v", tc.args, tc.want, err)
+		}
+	}
+	// без аутентификации можно работать только локально
+	if cfg, err := Load([]string{"-auth.enabled=false"}, envOf(nil)); err != nil || cfg.Auth.Enabled {
+		t.Fatalf("local run without auth failed: %v", err)
+	}
+}
@@ -0,0 +1,98 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+var (
+	knownEnvs      = []string{"local", "dev", "prod"}
+	knownLogLevels = []string{"", "debug", "info", "warn", "error"}
+	knownFormats   = []string{"", "console", "json"}
+	knownStorages  = []string{"memory", "file"}
+)
+
+// placeholderSecret — секрет из примеров конфига; с ним токены может подписать кто угодно
+const placeholderSecret = "change-me"
+
+// Validate проверяет значения и сообщает обо всех неверных полях сразу
+func (c *Config) Validate() error {
+	var problems []string
+	check := func(ok bool, field, format string, args ...any) {
+		if !ok {
+			problems = append(problems, field+": "+fmt.Sprintf(format, args...))
+		}
+	}
+
+	check(slices.Contains(knownEnvs, c.Env), "env", "must be one of %s, got %q", strings.Join(knownEnvs, ", "), c.Env)
+	check(c.HttpPort >= 1 && c.HttpPort <= 65535, "http_port", "must be between 1 and 65535, got %d", c.HttpPort)
+	check(c.GrpcPort >= 0 && c.GrpcPort <= 65535, "grpc_port", "must be between 0 and 65535, got %d", c.GrpcPort)
+	check(c.GrpcPort == 0 || c.GrpcPort != c.HttpPort, "grpc_port", "must differ from http_port")
+
+	check(slices.Contains(knownLogLevels, c.Log.Level), "log.level", "must be one of debug, info, warn, error, got %q", c.Log.Level)
+	check(slices.Contains(knownFormats, c.Log.Format), "log.format", "must be console or json, got %q", c.Log.Format)
+	blank := func(s string) bool { return strings.TrimSpace(s) == "" }
+	check(c.Log.Outputs == "" || !slices.ContainsFunc(strings.Split(c.Log.Outputs, ","), blank), "log.outputs", "must not contain empty entries")
+	check(c.Log.MaxSizeMB > 0, "log.max_size_mb", "must be positive")
+	check(c.Log.MaxAgeDays >= 0, "log.max_age_days", "must not be negative")
+	check(c.Log.MaxBackups >= 0, "log.max_backups", "must not be negative")
+
+	check(c.Server.ReadHeaderTimeout >= 0, "server.read_header_timeout", "must not be negative")
+	check(c.Server.ReadTimeout >= 0, "server.read_timeout", "must not be negative")
+	check(c.Server.WriteTimeout >= 0, "server.write_timeout", "must not be negative")
+	check(c.Server.IdleTimeout >= 0, "server.idle_timeout", "must not be negative")
+	check(c.Server.ShutdownTimeout >= 0, "server.shutdown_timeout", "must not be negative")
+
+	check(c.Limits.MaxBodyBytes > 0, "limits.max_body_bytes", "must be positive")
+	check(c.Limits.MaxImportBytes > 0, "limits.max_import_bytes", "must be positive")
+	for _, g := range []struct {
+		name  string
+		rps   float64
+		burst int
+	}{{"read", c.Limits.ReadRPS, c.Limits.ReadBurst}, {"write", c.Limits.WriteRPS, c.Limits.WriteBurst}, {"import", c.Limits.ImportRPS, c.Limits.ImportBurst}, {"ip", c.Limits.IPRPS, c.Limits.IPBurst}} {
+		check(g.rps >= 0, "limits."+g.name+"_rps", "must not be negative")
+		check(g.rps == 0 || g.burst >= 1, "limits."+g.name+"_burst", "must be at least 1 when %s_rps is set", g.name)
+	}
+
+	check(slices.Contains(knownStorages, c.Storage.Type), "storage.type", "must be one of %s, got %q", strings.Join(knownStorages, ", "), c.Storage.Type)
+	check(c.Storage.Type != "file" || c.Storage.Path != "", "storage.path", "is required for file storage")
+	check(c.Storage.CompactThreshold >= 0, "storage.compact_threshold", "must not be negative")
+	check(c.Storage.TombstoneRetention > 0, "storage.tombstone_retention", "must be positive")
+
+	if c.Reminders.Enabled {
+		check(c.Reminders.Offset > 0, "reminders.offset", "must be positive")
+		check(c.Reminders.Interval > 0, "reminders.interval", "must be positive")
+		check(c.Reminders.WebhookTimeout >= 0, "reminders.webhook_timeout", "must not be negative")
+	}
+
+	check(c.Stream.Heartbeat > 0, "stream.heartbeat", "must be positive")
+	check(c.Stream.Backlog >= 0, "stream.backlog", "must not be negative")
+
+	if c.Webhooks.Enabled {
+		check(c.Webhooks.Workers > 0, "webhooks.workers", "must be positive")
+		check(c.Webhooks.Timeout > 0, "webhooks.timeout", "must be positive")
+		check(c.Webhooks.MaxAttempts > 0, "webhooks.max_attempts", "must be positive")
+		check(c.Webhooks.Backoff > 0, "webhooks.backoff", "must be positive")
+		check(c.Webhooks.MaxBackoff >= c.Webhooks.Backoff, "webhooks.max_backoff", "must not be less than webhooks.backoff")
+		check(c.Webhooks.History > 0, "webhooks.history", "must be positive")
+		check(c.Webhooks.DeadLetters > 0, "webhooks.dead_letters", "must be positive")
+	}
+	// allowed_networks действует и на вебхук напоминаний, поэтому проверяется всегда
+	_, err := c.Webhooks.Networks()
+	check(err == nil, "webhooks.allowed_networks", "must be comma-separated CIDR prefixes: %v", err)
+
+	check(c.Auth.Enabled || c.Env == "local", "auth.enabled", "auth can be disabled only with env: local")
+	check(!c.Auth.Enabled || c.Auth.Secret != "", "auth.secret", "is required when auth is enabled")
+	check(!c.Auth.Enabled || c.Auth.Secret != placeholderSecret, "auth.secret", "must be changed from the example value %q", placeholderSecret)
+
+	ratio := c.Telemetry.Tracing.SampleRatio
+	check(ratio >= 0 && ratio <= 1, "telemetry.tracing.sample_ratio", "must be between 0 and 1, got %v", ratio)
+	check(!c.Telemetry.Tracing.Enabled || c.Telemetry.Tracing.Endpoint != "", "telemetry.tracing.endpoint", "is required when tracing is enabled")
+
+	if len(problems) == 0 {
+		return nil
+	}
+	return errors.New("invalid config:\n  " + strings.Join(problems, "\n  "))
+}
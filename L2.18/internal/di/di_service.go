@@ -1,40 +1,102 @@
 package di
 
 import (
+	"calendar/internal/auth"
 	"calendar/internal/config"
+	"calendar/internal/feed"
+	"calendar/internal/repository"
+	"calendar/internal/telemetry"
 	"calendar/internal/web"
+	"calendar/internal/webhook"
 	"context"
+	"errors"
 	"fmt"
 	"github.com/go-chi/chi/v5"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/fx"
-	"log"
+	"go.uber.org/zap"
+	"net"
 	"net/http"
+	"time"
 )
 
-func StartHttpServer(lc fx.Lifecycle, calendarHandler *web.CalendarHandler, config *config.Config) {
+func StartHttpServer(lc fx.Lifecycle, shutdowner fx.Shutdowner, calendarHandler *web.CalendarHandler, repo repository.Storage, hub *feed.Hub,
+	webhooks *webhook.Dispatcher, authn *auth.Authenticator, metrics *telemetry.Metrics, tp trace.TracerProvider, logger *zap.Logger, config *config.Config) {
 	router := chi.NewRouter()
+	router.Use(web.TracingMiddleware(tp), web.MetricsMiddleware(metrics))
 
-	web.RegisterRoutes(router, calendarHandler)
+	router.Handle("/metrics", metrics.Handler())
+	health := web.NewHealth(repo, logger)
+	web.RegisterHealthRoutes(router, health)
+	calendarHandler.SetFeed(hub, config.Stream.Heartbeat)
+	calendarHandler.SetWebhooks(webhooks)
+	web.RegisterRoutes(router, calendarHandler, authn, routeLimits(config.Limits))
 	address := fmt.Sprintf(":%d", config.HttpPort)
+	timeouts := config.Server
 	server := &http.Server{
-		Addr:    address,
-		Handler: router,
+		Addr:              address,
+		Handler:           router,
+		ReadHeaderTimeout: orDefault(timeouts.ReadHeaderTimeout, 5*time.Second),
+		ReadTimeout:       orDefault(timeouts.ReadTimeout, 15*time.Second),
+		WriteTimeout:      orDefault(timeouts.WriteTimeout, 30*time.Second),
+		IdleTimeout:       orDefault(timeouts.IdleTimeout, 60*time.Second),
 	}
+	// Shutdown не прерывает активные запросы, поэтому потоки /events/stream закрываются хабом
+	server.RegisterOnShutdown(hub.Close)
 
 	lc.Append(fx.Hook{
 		OnStart: func(ctx context.Context) error {
-			log.Printf("Server started")
+			// порт занимается синхронно, чтобы ошибка привязки остановила запуск приложения
+			ln, err := net.Listen("tcp", address)
+			if err != nil {
+				return err
+			}
+			logger.Info("server started", zap.String("address", ln.Addr().String()))
 			go func() {
-				if err := server.ListenAndServe(); err != nil {
-					log.Printf("ListenAndServe error: %v", err)
+				if err := server.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+					logger.Error("server failed", zap.Error(err))
+					shutdowner.Shutdown(fx.ExitCode(1))
 				}
 			}()
 			return nil
 		},
 		OnStop: func(ctx context.Context) error {
-			log.Printf("Shutting down server...")
-			return server.Close()
+			logger.Info("shutting down server")
+			ctx, cancel := context.WithTimeout(ctx, orDefault(timeouts.ShutdownTimeout, 10*time.Second))
+			defer cancel()
+			if err := server.Shutdown(ctx); err != nil {
+				// не дождались текущих запросов — закрываем соединения принудительно
+				logger.Warn("graceful shutdown failed", zap.Error(err))
+				return errors.Join(err, server.Close())
+			}
+			return nil
 		},
 	})
 
 }
+
+func routeLimits(cfg config.LimitsConfig) web.Limits {
+	return web.Limits{
+		MaxBody:   int64(cfg.MaxBodyBytes),
+		MaxImport: int64(cfg.MaxImportBytes),
+		Read:      web.Rate{RPS: cfg.ReadRPS, Burst: cfg.ReadBurst},
+		Write:     web.Rate{RPS: cfg.WriteRPS, Burst: cfg.WriteBurst},
+		Import:    web.Rate{RPS: cfg.ImportRPS, Burst: cfg.ImportBurst},
+		IP:        web.Rate{RPS: cfg.IPRPS, Burst: cfg.IPBurst},
+	}
+}
+
+// ProvideAuthenticator возвращает nil, если аутентификация выключена в конфиге
+func ProvideAuthenticator(config *config.Config) (*auth.Authenticator, error) {
+	if !config.Auth.Enabled {
+		return nil, nil
+	}
+	return auth.NewAuthenticator(config.Auth.Secret, config.Auth.Issuer)
+}
+
+func orDefault(d, def time.Duration) time.Duration {
+	if d <= 0 {
+		return def
+	}
+	return d
+}
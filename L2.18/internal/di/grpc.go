@@ -0,0 +1,64 @@
+package di
+
+import (
+	"calendar/internal/auth"
+	"calendar/internal/config"
+	"calendar/internal/feed"
+	"calendar/internal/repository"
+	"calendar/internal/rpc"
+	"calendar/internal/rpc/calendarpb"
+	"context"
+	"fmt"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"net"
+	"time"
+)
+
+// StartGrpcServer поднимает gRPC API рядом с HTTP на grpc_port; 0 его отключает
+func StartGrpcServer(lc fx.Lifecycle, shutdowner fx.Shutdowner, repo repository.Storage, hub *feed.Hub,
+	authn *auth.Authenticator, tp trace.TracerProvider, logger *zap.Logger, config *config.Config) {
+	if config.GrpcPort == 0 {
+		logger.Info("gRPC server disabled")
+		return
+	}
+	server := grpc.NewServer(rpc.ServerOptions(authn, tp, logger)...)
+	calendarpb.RegisterCalendarServiceServer(server, rpc.NewServer(repo, hub, logger))
+	address := fmt.Sprintf(":%d", config.GrpcPort)
+
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			ln, err := net.Listen("tcp", address)
+			if err != nil {
+				return err
+			}
+			logger.Info("gRPC server started", zap.String("address", ln.Addr().String()))
+			go func() {
+				if err := server.Serve(ln); err != nil {
+					logger.Error("gRPC server failed", zap.Error(err))
+					shutdowner.Shutdown(fx.ExitCode(1))
+				}
+			}()
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			logger.Info("shutting down gRPC server")
+			// GracefulStop ждёт завершения WatchEvents, поэтому подписки закрываются заранее
+			hub.Close()
+			done := make(chan struct{})
+			go func() {
+				server.GracefulStop()
+				close(done)
+			}()
+			select {
+			case <-done:
+			case <-time.After(orDefault(config.Server.ShutdownTimeout, 10*time.Second)):
+				logger.Warn("graceful gRPC shutdown timed out")
+				server.Stop()
+			}
+			return nil
+		},
+	})
+}
@@ -0,0 +1,59 @@
+package di
+
+import (
+	"calendar/internal/config"
+	"calendar/internal/logger"
+	"context"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+	"os"
+	"os/signal"
+	"reflect"
+	"syscall"
+)
+
+// StartConfigReloader перечитывает конфиг по SIGHUP. На ходу применяется только log.level,
+// об остальных изменениях пишется предупреждение: они вступят в силу после перезапуска.
+// Неверный конфиг не применяется, сервис продолжает работать со старым.
+func StartConfigReloader(lc fx.Lifecycle, cfg *config.Config, level zap.AtomicLevel, logger *zap.Logger) {
+	sig := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			signal.Notify(sig, syscall.SIGHUP)
+			go func() {
+				defer close(done)
+				current := *cfg
+				for range sig {
+					current = reloadConfig(current, level, logger)
+				}
+			}()
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			signal.Stop(sig)
+			close(sig)
+			<-done
+			return nil
+		},
+	})
+}
+
+func reloadConfig(current config.Config, level zap.AtomicLevel, log *zap.Logger) config.Config {
+	next, err := config.Load(os.Args[1:], os.LookupEnv)
+	if err != nil {
+		log.Error("config reload failed, keeping current config", zap.Error(err))
+		return current
+	}
+	if l := logger.Level(next); l != level.Level() {
+		level.SetLevel(l)
+		log.Info("log level changed", zap.Stringer("level", l))
+	}
+	applied := current
+	applied.Log.Level = next.Log.Level
+	if !reflect.DeepEqual(applied, *next) {
+		log.Warn("config changed, restart required to apply everything except log.level")
+	}
+	log.Info("config reloaded")
+	return applied
+}
@@ -0,0 +1,64 @@
+package di
+
+import (
+	"calendar/internal/config"
+	"calendar/internal/reminder"
+	"calendar/internal/repository"
+	"context"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+	"time"
+)
+
+func StartReminderScheduler(lc fx.Lifecycle, repo repository.Storage, logger *zap.Logger, config *config.Config) error {
+	cfg := config.Reminders
+	if !cfg.Enabled {
+		return nil
+	}
+
+	var notifiers []reminder.Notifier
+	if cfg.Log {
+		notifiers = append(notifiers, reminder.NewLogNotifier(logger))
+	}
+	if cfg.WebhookURL != "" {
+		networks, err := config.Webhooks.Networks()
+		if err != nil {
+			return err
+		}
+		notifiers = append(notifiers, reminder.NewWebhookNotifier(cfg.WebhookURL, orDefault(cfg.WebhookTimeout, 5*time.Second), networks))
+	}
+	if cfg.FilePath != "" {
+		n, err := reminder.NewFileNotifier(cfg.FilePath)
+		if err != nil {
+			return err
+		}
+		notifiers = append(notifiers, n)
+	}
+
+	scheduler, err := reminder.NewScheduler(repo, logger, cfg.Offset, cfg.Interval, cfg.StatePath, notifiers...)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			go func() {
+				defer close(done)
+				scheduler.Run(ctx)
+			}()
+			return nil
+		},
+		OnStop: func(stopCtx context.Context) error {
+			cancel()
+			select {
+			case <-done:
+				return nil
+			case <-stopCtx.Done():
+				return stopCtx.Err()
+			}
+		},
+	})
+	return nil
+}
@@ -0,0 +1,58 @@
+package di
+
+import (
+	"calendar/internal/config"
+	"calendar/internal/feed"
+	"calendar/internal/repository"
+	"calendar/internal/telemetry"
+	"calendar/internal/webhook"
+	"context"
+	"fmt"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/fx"
+)
+
+// ProvideFeed создаёт хаб ленты изменений; хранилище публикует в него, /events/stream читает
+func ProvideFeed(config *config.Config) *feed.Hub {
+	return feed.NewHub(config.Stream.Backlog)
+}
+
+// ProvideStorage создаёт хранилище из конфига, публикующее изменения в ленту и вебхуки, и оборачивает его метриками и трассировкой
+func ProvideStorage(lc fx.Lifecycle, config *config.Config, hub *feed.Hub, webhooks *webhook.Dispatcher,
+	metrics *telemetry.Metrics, tp trace.TracerProvider) (repository.Storage, error) {
+	repo, err := newStorage(lc, config, publisher(hub, webhooks))
+	if err != nil {
+		return nil, err
+	}
+	if counter, ok := repo.(repository.EventCounter); ok {
+		metrics.RegisterEventCounter(counter)
+	}
+	return telemetry.InstrumentStorage(repo, metrics, tp), nil
+}
+
+func newStorage(lc fx.Lifecycle, config *config.Config, pub repository.Publisher) (repository.Storage, error) {
+	switch config.Storage.Type {
+	case "", "memory":
+		repo := repository.NewInMemoryRepo()
+		repo.SetRejectConflicts(config.Storage.RejectConflicts)
+		repo.SetTombstoneRetention(config.Storage.TombstoneRetention)
+		repo.SetPublisher(pub)
+		return repo, nil
+	case "file":
+		repo, err := repository.NewFileRepo(config.Storage.Path, config.Storage.CompactThreshold)
+		if err != nil {
+			return nil, err
+		}
+		repo.SetRejectConflicts(config.Storage.RejectConflicts)
+		repo.SetTombstoneRetention(config.Storage.TombstoneRetention)
+		repo.SetPublisher(pub)
+		lc.Append(fx.Hook{
+			OnStop: func(ctx context.Context) error {
+				return repo.Close()
+			},
+		})
+		return repo, nil
+	default:
+		return nil, fmt.Errorf("unknown storage type %q", config.Storage.Type)
+	}
+}
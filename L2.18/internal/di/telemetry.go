@@ -0,0 +1,28 @@
+package di
+
+import (
+	"calendar/internal/config"
+	"calendar/internal/telemetry"
+	"context"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/fx"
+)
+
+func ProvideMetrics() *telemetry.Metrics {
+	return telemetry.NewMetrics()
+}
+
+// ProvideTracerProvider настраивает экспорт трасс по OTLP; при остановке накопленные спаны досылаются
+func ProvideTracerProvider(lc fx.Lifecycle, config *config.Config) (trace.TracerProvider, error) {
+	tp, shutdown, err := telemetry.NewTracerProvider(context.Background(), config.Telemetry)
+	if err != nil {
+		return nil, err
+	}
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(telemetry.Propagator)
+	lc.Append(fx.Hook{
+		OnStop: shutdown,
+	})
+	return tp, nil
+}
@@ -0,0 +1,68 @@
+package di
+
+import (
+	"calendar/internal/config"
+	"calendar/internal/feed"
+	"calendar/internal/repository"
+	"calendar/internal/webhook"
+	"context"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+// ProvideWebhooks создаёт доставку вебхуков и запускает её вместе с приложением; nil, если она выключена в конфиге
+func ProvideWebhooks(lc fx.Lifecycle, config *config.Config, logger *zap.Logger) (*webhook.Dispatcher, error) {
+	cfg := config.Webhooks
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	networks, err := cfg.Networks()
+	if err != nil {
+		return nil, err
+	}
+	dispatcher, err := webhook.NewDispatcher(webhook.Options{
+		StatePath:   cfg.StatePath,
+		Workers:     cfg.Workers,
+		Timeout:     cfg.Timeout,
+		MaxAttempts: cfg.MaxAttempts,
+		Backoff:     cfg.Backoff,
+		MaxBackoff:  cfg.MaxBackoff,
+		History:     cfg.History,
+		DeadLetters: cfg.DeadLetters,
+
+		AllowedNetworks: networks,
+	}, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			go func() {
+				defer close(done)
+				dispatcher.Run(ctx)
+			}()
+			return nil
+		},
+		OnStop: func(stopCtx context.Context) error {
+			cancel()
+			select {
+			case <-done:
+				return nil
+			case <-stopCtx.Done():
+				return stopCtx.Err()
+			}
+		},
+	})
+	return dispatcher, nil
+}
+
+// publisher — куда хранилище отправляет изменения: лента /events/stream и, если включены, вебхуки
+func publisher(hub *feed.Hub, webhooks *webhook.Dispatcher) repository.Publisher {
+	if webhooks == nil {
+		return hub
+	}
+	return repository.Publishers{hub, webhooks}
+}
@@ -0,0 +1,156 @@
+package feed
+
+import (
+	"calendar/internal/app"
+	"calendar/internal/repository"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+)
+
+// ErrClosed — хаб остановлен вместе с сервером, новые подписки не принимаются
+var ErrClosed = errors.New("feed is closed")
+
+// subscriberBuffer — сколько изменений может ждать медленный подписчик, прежде чем его отключат
+const subscriberBuffer = 64
+
+// Message — изменение, разосланное подписчикам. ID растут монотонно; отсчёт начинается
+// с текущего времени, поэтому ID прошлого запуска всегда меньше ID нового.
+type Message struct {
+	ID    uint64
+	Type  string // created | updated | deleted
+	Event app.Event
+}
+
+type entry struct {
+	msg   Message
+	users []int // организатор и участники: кому изменение видно
+}
+
+// Hub раздаёт изменения хранилища подписчикам-пользователям и хранит последние из них,
+// чтобы переподключившийся клиент получил пропущенное
+type Hub struct {
+	mu      sync.Mutex
+	backlog []entry
+	size    int
+	last    uint64 // ID последнего разосланного изменения
+	subs    map[*Subscription]struct{}
+	closed  bool
+}
+
+// Subscription — подписка одного пользователя. Missed — изменения после lastID, пропущенные клиентом;
+// Lost означает, что часть пропущенного уже не восстановить и календарь нужно перечитать целиком.
+type Subscription struct {
+	Missed []Message
+	Lost   bool
+	Head   uint64 // ID последнего изменения на момент подписки
+
+	hub  *Hub
+	user int
+	ch   chan Message
+}
+
+// NewHub создаёт хаб, который помнит backlog последних изменений
+func NewHub(backlog int) *Hub {
+	return &Hub{
+		size: backlog,
+		last: uint64(time.Now().UnixNano()),
+		subs: make(map[*Subscription]struct{}),
+	}
+}
+
+// Publish рассылает изменения; подписчик с переполненным буфером отключается, а не тормозит хранилище
+func (h *Hub) Publish(changes []repository.Change) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, c := range changes {
+		h.last++
+		en := entry{msg: Message{ID: h.last, Type: c.Type, Event: c.Event}, users: recipients(&c.Event)}
+		if h.size > 0 {
+			if len(h.backlog) == h.size {
+				h.backlog = h.backlog[1:]
+			}
+			h.backlog = append(h.backlog, en)
+		}
+		for sub := range h.subs {
+			if !slices.Contains(en.users, sub.user) {
+				continue
+			}
+			select {
+			case sub.ch <- en.msg:
+			default:
+				h.drop(sub)
+			}
+		}
+	}
+}
+
+// Subscribe подписывает user на изменения. Если resume, в Missed попадают изменения после lastID
+func (h *Hub) Subscribe(user int, lastID uint64, resume bool) (*Subscription, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return nil, ErrClosed
+	}
+	sub := &Subscription{Head: h.last, hub: h, user: user, ch: make(chan Message, subscriberBuffer)}
+	if resume && lastID != h.last {
+		sub.Missed, sub.Lost = h.since(user, lastID)
+	}
+	h.subs[sub] = struct{}{}
+	return sub, nil
+}
+
+// since отдаёт изменения user после lastID; lost — lastID из другого запуска или уже вытеснен из буфера
+func (h *Hub) since(user int, lastID uint64) ([]Message, bool) {
+	oldest := h.last + 1
+	if len(h.backlog) > 0 {
+		oldest = h.backlog[0].msg.ID
+	}
+	if lastID+1 < oldest || lastID > h.last {
+		return nil, true
+	}
+	var missed []Message
+	for _, en := range h.backlog {
+		if en.msg.ID > lastID && slices.Contains(en.users, user) {
+			missed = append(missed, en.msg)
+		}
+	}
+	return missed, false
+}
+
+// Close отключает всех подписчиков; вызывается при остановке сервера, чтобы потоки не держали Shutdown
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closed = true
+	for sub := range h.subs {
+		h.drop(sub)
+	}
+}
+
+func (h *Hub) drop(sub *Subscription) {
+	delete(h.subs, sub)
+	close(sub.ch)
+}
+
+// Events закрывается, когда подписчик отстал или хаб остановлен
+func (s *Subscription) Events() <-chan Message {
+	return s.ch
+}
+
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	if _, ok := s.hub.subs[s]; ok {
+		s.hub.drop(s)
+	}
+}
+
+func recipients(e *app.Event) []int {
+	users := []int{e.UserID}
+	for _, a := range e.Attendees {
+		users = append(users, a.UserID)
+	}
+	return users
+}
@@ -0,0 +1,90 @@
+package feed
+
+import (
+	"calendar/internal/app"
+	"calendar/internal/repository"
+	"testing"
+)
+
+func change(typ string, organizer int, attendees ...int) repository.Change {
+	e := app.Event{UserID: organizer, EventText: typ}
+	for _, a := range attendees {
+		e.Attendees = append(e.Attendees, app.Attendee{UserID: a})
+	}
+	return repository.Change{Type: typ, Event: e}
+}
+
+func TestHubDeliversToOrganizerAndAttendees(t *testing.T) {
+	h := NewHub(10)
+	organizer, _ := h.Subscribe(1, 0, false)
+	attendee, _ := h.Subscribe(2, 0, false)
+	stranger, _ := h.Subscribe(3, 0, false)
+
+	h.Publish([]repository.Change{change(repository.ChangeCreated, 1, 2), change(repository.ChangeUpdated, 1)})
+
+	if m := <-organizer.Events(); m.Type != repository.ChangeCreated {
+		t.Fatalf("unexpected first message %+v", m)
+	}
+	if m := <-organizer.Events(); m.Type != repository.ChangeUpdated {
+		t.Fatalf("unexpected second message %+v", m)
+	}
+	if m := <-attendee.Events(); m.Type != repository.ChangeCreated || len(attendee.Events()) != 0 {
+		t.Fatalf("attendee got %+v and %d more", m, len(attendee.Events()))
+	}
+	if len(stranger.Events()) != 0 {
+		t.Fatal("change leaked to an unrelated user")
+	}
+}
+
+func TestHubResume(t *testing.T) {
+	h := NewHub(3)
+	first, _ := h.Subscribe(1, 0, false)
+	h.Publish([]repository.Change{change("a", 1), change("b", 2), change("c", 1)})
+	a := <-first.Events()
+	first.Close()
+
+	sub, _ := h.Subscribe(1, a.ID, true)
+	if sub.Lost || len(sub.Missed) != 1 || sub.Missed[0].Type != "c" {
+		t.Fatalf("expected to resume with c, got %+v lost=%v", sub.Missed, sub.Lost)
+	}
+	if up, _ := h.Subscribe(1, sub.Head, true); up.Lost || len(up.Missed) != 0 {
+		t.Fatalf("up-to-date client got %+v lost=%v", up.Missed, up.Lost)
+	}
+
+	// a вытесняется из буфера: после него уже нельзя восстановить всё пропущенное
+	h.Publish([]repository.Change{change("d", 1)})
+	if old, _ := h.Subscribe(1, a.ID-1, true); !old.Lost {
+		t.Fatal("expected lost for an evicted id")
+	}
+	if stale, _ := h.Subscribe(1, 42, true); !stale.Lost {
+		t.Fatal("expected lost for an id of a previous run")
+	}
+	if future, _ := h.Subscribe(1, sub.Head+100, true); !future.Lost {
+		t.Fatal("expected lost for an unknown id")
+	}
+}
+
+func TestHubDropsSlowSubscriberAndCloses(t *testing.T) {
+	h := NewHub(0)
+	slow, _ := h.Subscribe(1, 0, false)
+	idle, _ := h.Subscribe(2, 0, false)
+	for i := 0; i <= subscriberBuffer; i++ {
+		h.Publish([]repository.Change{change("x", 1)})
+	}
+	n := 0
+	for range slow.Events() {
+		n++
+	}
+	if n != subscriberBuffer {
+		t.Fatalf("expected %d buffered messages before drop, got %d", subscriberBuffer, n)
+	}
+
+	h.Close()
+	if _, ok := <-idle.Events(); ok {
+		t.Fatal("Close must end all subscriptions")
+	}
+	idle.Close()
+	if _, err := h.Subscribe(1, 0, false); err != ErrClosed {
+		t.Fatalf("expected ErrClosed, got %v", err)
+	}
+}
@@ -0,0 +1,370 @@
+package ical
+
+import (
+	"bufio"
+	"calendar/internal/app"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	prodID     = "-//L2.18//calendar//RU"
+	dateLayout = "20060102"
+	timeLayout = "20060102T150405"
+	utcLayout  = "20060102T150405Z"
+	maxLineLen = 75
+)
+
+// VEvent — событие, прочитанное из .ics. Вхождения серий с RECURRENCE-ID ссылаются на серию через UID.
+type VEvent struct {
+	UID          string
+	RecurrenceId string // RFC 3339, пусто для самостоятельных событий и серий
+	Request      app.EventRequest
+	Err          error // событие не удалось разобрать
+}
+
+// Encode пишет события в формате RFC 5545. Выделенные вхождения серий получают UID серии и RECURRENCE-ID.
+func Encode(w io.Writer, events []*app.Event) error {
+	bw := bufio.NewWriter(w)
+	line := func(s string) {
+		writeFolded(bw, s)
+	}
+	stamp := time.Now().UTC().Format(utcLayout)
+
+	line("BEGIN:VCALENDAR")
+	line("VERSION:2.0")
+	line("PRODID:" + prodID)
+	line("CALSCALE:GREGORIAN")
+	for _, e := range events {
+		uid := e.EventId.String()
+		if e.SeriesId != nil {
+			uid = e.SeriesId.String()
+		}
+		// UID, заданный клиентом, выделенные вхождения наследуют от серии
+		if e.UID != "" {
+			uid = e.UID
+		}
+		line("BEGIN:VEVENT")
+		line("UID:" + uid)
+		line("DTSTAMP:" + stamp)
+		line(formatTime("DTSTART", e.Start, e))
+		line(formatTime("DTEND", e.End, e))
+		if e.EventText != "" {
+			line("SUMMARY:" + escapeText(e.EventText))
+		}
+		if e.Description != "" {
+			line("DESCRIPTION:" + escapeText(e.Description))
+		}
+		if e.Location != "" {
+			line("LOCATION:" + escapeText(e.Location))
+		}
+		if len(e.Tags) > 0 {
+			categories := make([]string, len(e.Tags))
+			for i, t := range e.Tags {
+				categories[i] = escapeText(t)
+			}
+			line("CATEGORIES:" + strings.Join(categories, ","))
+		}
+		if e.RRule != "" {
+			line("RRULE:" + e.RRule)
+		}
+		for _, x := range e.ExDates {
+			line(formatTime("EXDATE", x, e))
+		}
+		if e.RecurrenceId != nil {
+			line(formatTime("RECURRENCE-ID", *e.RecurrenceId, e))
+		}
+		line("END:VEVENT")
+	}
+	line("END:VCALENDAR")
+	return bw.Flush()
+}
+
+func formatTime(name string, t time.Time, e *app.Event) string {
+	if e.AllDay {
+		return name + ";VALUE=DATE:" + t.Format(dateLayout)
+	}
+	if e.TimeZone == "" || e.TimeZone == "UTC" {
+		return name + ":" + t.UTC().Format(utcLayout)
+	}
+	if loc, err := time.LoadLocation(e.TimeZone); err == nil {
+		t = t.In(loc)
+	}
+	return name + ";TZID=" + e.TimeZone + ":" + t.Format(timeLayout)
+}
+
+// writeFolded переносит строки длиннее 75 октетов, не разрывая UTF-8 символы
+func writeFolded(w *bufio.Writer, s string) {
+	first := true
+	for len(s) > 0 {
+		limit := maxLineLen
+		if !first {
+			limit--
+			w.WriteByte(' ')
+		}
+		if len(s) <= limit {
+			w.WriteString(s)
+			break
+		}
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		w.WriteString(s[:cut])
+		w.WriteString("\r\n")
+		s = s[cut:]
+		first = false
+	}
+	w.WriteString("\r\n")
+}
+
+func escapeText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(s)
+}
+
+func unescapeText(s string) string {
+	return strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n").Replace(s)
+}
+
+type property struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+// Decode читает все VEVENT из календаря. Ошибка возвращается только для синтаксически битого файла;
+// ошибки отдельных событий попадают в поле Err, чтобы импорт мог отчитаться по каждому событию.
+func Decode(r io.Reader) ([]VEvent, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		events  []VEvent
+		current []property
+		inEvent bool
+		depth   int // вложенные компоненты внутри VEVENT (VALARM)
+		seen    bool
+	)
+	for n, l := range lines {
+		p, err := parseLine(l)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n+1, err)
+		}
+		switch {
+		case p.name == "BEGIN" && strings.EqualFold(p.value, "VCALENDAR"):
+			seen = true
+		case p.name == "BEGIN" && strings.EqualFold(p.value, "VEVENT") && !inEvent:
+			inEvent, current = true, nil
+		case p.name == "BEGIN" && inEvent:
+			depth++
+		case p.name == "END" && inEvent && depth > 0:
+			depth--
+		case p.name == "END" && strings.EqualFold(p.value, "VEVENT") && inEvent:
+			inEvent = false
+			ev, err := toVEvent(current)
+			ev.Err = err
+			events = append(events, ev)
+		case inEvent && depth == 0:
+			current = append(current, p)
+		}
+	}
+	if !seen {
+		return nil, errors.New("not an iCalendar file: BEGIN:VCALENDAR not found")
+	}
+	if inEvent {
+		return nil, errors.New("unterminated VEVENT")
+	}
+	return events, nil
+}
+
+func unfold(r io.Reader) ([]string, error) {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	var lines []string
+	for sc.Scan() {
+		l := strings.TrimRight(sc.Text(), "\r")
+		if (strings.HasPrefix(l, " ") || strings.HasPrefix(l, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += l[1:]
+			continue
+		}
+		if l == "" {
+			continue
+		}
+		lines = append(lines, l)
+	}
+	return lines, sc.Err()
+}
+
+func parseLine(l string) (property, error) {
+	p := property{params: map[string]string{}}
+	inQuotes := false
+	colon := -1
+	for i, c := range l {
+		if c == '"' {
+			inQuotes = !inQuotes
+		}
+		if c == ':' && !inQuotes {
+			colon = i
+			break
+		}
+	}
+	if colon < 0 {
+		return p, fmt.Errorf("malformed content line %q", l)
+	}
+	head := strings.Split(l[:colon], ";")
+	p.name = strings.ToUpper(head[0])
+	p.value = l[colon+1:]
+	for _, param := range head[1:] {
+		k, v, _ := strings.Cut(param, "=")
+		p.params[strings.ToUpper(k)] = strings.Trim(v, `"`)
+	}
+	return p, nil
+}
+
+func toVEvent(props []property) (VEvent, error) {
+	var (
+		ev       VEvent
+		start    time.Time
+		allDay   bool
+		zone     string
+		end      string
+		duration string
+	)
+	for _, p := range props {
+		switch p.name {
+		case "UID":
+			ev.UID = p.value
+		case "SUMMARY":
+			ev.Request.EventText = unescapeText(p.value)
+		case "DESCRIPTION":
+			description := unescapeText(p.value)
+			ev.Request.Description = &description
+		case "LOCATION":
+			location := unescapeText(p.value)
+			ev.Request.Location = &location
+		case "CATEGORIES":
+			// категории могут идти несколькими строками; теги без запятых, поэтому экранированных запятых в них нет
+			for _, c := range strings.Split(p.value, ",") {
+				if c = strings.TrimSpace(unescapeText(c)); c != "" {
+					ev.Request.Tags = append(ev.Request.Tags, c)
+				}
+			}
+		case "DTSTART":
+			var err error
+			if start, allDay, err = parseTime(p); err != nil {
+				return ev, fmt.Errorf("DTSTART: %w", err)
+			}
+			zone = p.params["TZID"]
+		case "DTEND":
+			t, _, err := parseTime(p)
+			if err != nil {
+				return ev, fmt.Errorf("DTEND: %w", err)
+			}
+			end = t.Format(time.RFC3339)
+		case "DURATION":
+			duration = p.value
+		case "RRULE":
+			rule := p.value
+			ev.Request.RRule = &rule
+		case "EXDATE":
+			for _, v := range strings.Split(p.value, ",") {
+				t, _, err := parseTime(property{name: p.name, params: p.params, value: v})
+				if err != nil {
+					return ev, fmt.Errorf("EXDATE: %w", err)
+				}
+				ev.Request.ExDates = append(ev.Request.ExDates, t.Format(time.RFC3339))
+			}
+		case "RECURRENCE-ID":
+			t, _, err := parseTime(p)
+			if err != nil {
+				return ev, fmt.Errorf("RECURRENCE-ID: %w", err)
+			}
+			ev.RecurrenceId = t.Format(time.RFC3339)
+		}
+	}
+	if start.IsZero() {
+		return ev, errors.New("DTSTART is required")
+	}
+	if end == "" && duration != "" {
+		d, err := parseDuration(duration)
+		if err != nil {
+			return ev, fmt.Errorf("DURATION: %w", err)
+		}
+		end = start.Add(d).Format(time.RFC3339)
+	}
+	ev.Request.Start = start.Format(time.RFC3339)
+	ev.Request.End = end
+	ev.Request.AllDay = &allDay
+	ev.Request.TimeZone = zone
+	return ev, nil
+}
+
+func parseTime(p property) (time.Time, bool, error) {
+	if strings.EqualFold(p.params["VALUE"], "DATE") || len(p.value) == len(dateLayout) {
+		t, err := time.Parse(dateLayout, p.value)
+		return t, true, err
+	}
+	if strings.HasSuffix(p.value, "Z") {
+		t, err := time.Parse(utcLayout, p.value)
+		return t, false, err
+	}
+	loc := time.UTC
+	if tzid := p.params["TZID"]; tzid != "" {
+		var err error
+		if loc, err = time.LoadLocation(tzid); err != nil {
+			return time.Time{}, false, fmt.Errorf("unknown TZID %q", tzid)
+		}
+	}
+	t, err := time.ParseInLocation(timeLayout, p.value, loc)
+	return t, false, err
+}
+
+// parseDuration разбирает длительность RFC 5545: P1W, P1DT2H, PT30M
+func parseDuration(s string) (time.Duration, error) {
+	sign := time.Duration(1)
+	switch {
+	case strings.HasPrefix(s, "-"):
+		sign, s = -1, s[1:]
+	case strings.HasPrefix(s, "+"):
+		s = s[1:]
+	}
+	if !strings.HasPrefix(s, "P") || len(s) < 3 {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	var (
+		total  time.Duration
+		num    string
+		inTime bool
+	)
+	units := map[bool]map[byte]time.Duration{
+		false: {'W': 7 * 24 * time.Hour, 'D': 24 * time.Hour},
+		true:  {'H': time.Hour, 'M': time.Minute, 'S': time.Second},
+	}
+	for i := 1; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == 'T':
+			inTime = true
+		case c >= '0' && c <= '9':
+			num += string(c)
+		default:
+			unit, ok := units[inTime][c]
+			if !ok || num == "" {
+				return 0, fmt.Errorf("invalid duration %q", s)
+			}
+			n, _ := strconv.Atoi(num)
+			total += time.Duration(n) * unit
+			num = ""
+		}
+	}
+	if num != "" {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	return sign * total, nil
+}
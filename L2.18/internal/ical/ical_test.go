@@ -0,0 +1,161 @@
+package ical
+
+import (
+	"bytes"
+	"calendar/internal/app"
+	"strings"
+	"testing"
+	"time"
+)
+
+const sample = "BEGIN:VCALENDAR\r\n" +
+	"VERSION:2.0\r\n" +
+	"PRODID:-//Test//EN\r\n" +
+	"BEGIN:VTIMEZONE\r\nTZID:Europe/Moscow\r\nEND:VTIMEZONE\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:standup@example.com\r\n" +
+	"DTSTART;TZID=Europe/Moscow:20250505T100000\r\n" +
+	"DURATION:PT15M\r\n" +
+	"RRULE:FREQ=WEEKLY;BYDAY=MO,WE\r\n" +
+	"EXDATE;TZID=Europe/Moscow:20250507T100000,20250512T100000\r\n" +
+	"SUMMARY:Stand-up\\, daily\r\n" +
+	"BEGIN:VALARM\r\nTRIGGER:-PT5M\r\nSUMMARY:ignored\r\nEND:VALARM\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:standup@example.com\r\n" +
+	"RECURRENCE-ID;TZID=Europe/Moscow:20250514T100000\r\n" +
+	"DTSTART;TZID=Europe/Moscow:20250514T120000\r\n" +
+	"DTEND;TZID=Europe/Moscow:20250514T121500\r\n" +
+	"SUMMARY:Moved stand-up\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:holiday@example.com\r\n" +
+	"DTSTART;VALUE=DATE:20250509\r\n" +
+	"SUMMARY:Holi\r\n day\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:broken@example.com\r\n" +
+	"SUMMARY:no start\r\n" +
+	"END:VEVENT\r\n" +
+	"END:VCALENDAR\r\n"
+
+func TestDecode(t *testing.T) {
+	events, err := Decode(strings.NewReader(sample))
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	if len(events) != 4 {
+		t.Fatalf("expected 4 events, got %d", len(events))
+	}
+
+	series := events[0].Request
+	if series.TimeZone != "Europe/Moscow" || series.Start != "2025-05-05T10:00:00+03:00" || series.End != "2025-05-05T10:15:00+03:00" {
+		t.Fatalf("unexpected series timing: %+v", series)
+	}
+	if series.RRule == nil || *series.RRule != "FREQ=WEEKLY;BYDAY=MO,WE" || len(series.ExDates) != 2 {
+		t.Fatalf("unexpected recurrence: %+v", series)
+	}
+	if series.EventText != "Stand-up, daily" {
+		t.Fatalf("text not unescaped: %q", series.EventText)
+	}
+
+	if events[1].UID != "standup@example.com" || events[1].RecurrenceId != "2025-05-14T10:00:00+03:00" {
+		t.Fatalf("unexpected override: %+v", events[1])
+	}
+
+	holiday := events[2].Request
+	if holiday.AllDay == nil || !*holiday.AllDay || holiday.EventText != "Holiday" {
+		t.Fatalf("unexpected all-day event: %+v", holiday)
+	}
+
+	if events[3].Err == nil {
+		t.Fatal("expected per-event error for VEVENT without DTSTART")
+	}
+}
+
+func TestDecodeNotCalendar(t *testing.T) {
+	if _, err := Decode(strings.NewReader("hello")); err == nil {
+		t.Fatal("expected error for non-calendar input")
+	}
+}
+
+func TestEncodeRoundTrip(t *testing.T) {
+	rule := "FREQ=DAILY;COUNT=3"
+	long := strings.Repeat("Очень длинное описание события; ", 5)
+	location := "Москва, офис"
+	series, err := app.NewEvent(&app.EventRequest{
+		UserID:    1,
+		Start:     "2025-05-05T10:00:00+03:00",
+		End:       "2025-05-05T11:00:00+03:00",
+		TimeZone:  "Europe/Moscow",
+		RRule:     &rule,
+		ExDates:   []string{"2025-05-06"},
+		EventText: long,
+		Location:  &location,
+		Tags:      []string{"работа", "планы; q2"},
+	})
+	if err != nil {
+		t.Fatalf("NewEvent failed: %v", err)
+	}
+	at, _ := series.OccurrenceAt("2025-05-07")
+	detached := series.Detach(at)
+
+	var buf bytes.Buffer
+	if err := Encode(&buf, []*app.Event{series, detached}); err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+	for _, l := range strings.Split(buf.String(), "\r\n") {
+		if len(l) > maxLineLen {
+			t.Fatalf("line not folded: %q", l)
+		}
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"DTSTART;TZID=Europe/Moscow:20250505T100000",
+		"RRULE:FREQ=DAILY;COUNT=3",
+		"RECURRENCE-ID;TZID=Europe/Moscow:20250507T100000",
+		"UID:" + series.EventId.String(),
+		"LOCATION:Москва\\, офис",
+		"CATEGORIES:работа,планы\\; q2",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output does not contain %q", want)
+		}
+	}
+
+	events, err := Decode(&buf)
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	if len(events) != 2 || events[0].Request.EventText != long || len(events[0].Request.ExDates) != 2 {
+		t.Fatalf("round trip mismatch: %+v", events)
+	}
+	if r := events[0].Request; *r.Location != location || len(r.Tags) != 2 || r.Tags[1] != "планы; q2" || r.Description != nil {
+		t.Fatalf("details lost in round trip: %+v", r)
+	}
+	if events[1].UID != series.EventId.String() || events[1].RecurrenceId == "" {
+		t.Fatalf("detached occurrence lost its series: %+v", events[1])
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := map[string]time.Duration{
+		"PT15M":   15 * time.Minute,
+		"P1D":     24 * time.Hour,
+		"P1W":     7 * 24 * time.Hour,
+		"P1DT2H":  26 * time.Hour,
+		"-PT30S":  -30 * time.Second,
+		"PT1H30M": 90 * time.Minute,
+	}
+	for in, want := range tests {
+		got, err := parseDuration(in)
+		if err != nil || got != want {
+			t.Errorf("parseDuration(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"P", "15M", "PT", "P1H", "PT1"} {
+		if _, err := parseDuration(in); err == nil {
+			t.Errorf("expected error for %q", in)
+		}
+	}
+}
@@ -2,45 +2,144 @@ package logger
 
 import (
 	"calendar/internal/config"
+	"context"
+	"errors"
+	"go.uber.org/fx"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
+	"gopkg.in/natefinch/lumberjack.v2"
+	"io"
 	"os"
 	"path/filepath"
+	"strings"
 )
 
-func ProvideLogger(cfg *config.Config) (*zap.Logger, error) {
-	switch cfg.Env {
-	case "prod":
-		// путь до файла логов
-		logDir := "logs"
-		logFile := filepath.Join(logDir, "app.log")
+// defaultProdOutput — куда пишет prod, если log.outputs не задан
+const defaultProdOutput = "logs/app.log"
 
-		if err := os.MkdirAll(logDir, 0755); err != nil {
-			return nil, err
-		}
-
-		file, err := os.OpenFile(logFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-		if err != nil {
-			return nil, err
-		}
+// ProvideLogger возвращает логгер и его уровень; уровень можно менять на ходу (см. di.StartConfigReloader).
+// При остановке приложения буферы сбрасываются, а файлы логов закрываются.
+func ProvideLogger(lc fx.Lifecycle, cfg *config.Config) (*zap.Logger, zap.AtomicLevel, error) {
+	logger, level, closer, err := New(cfg)
+	if err != nil {
+		return nil, level, err
+	}
+	lc.Append(fx.Hook{
+		OnStop: func(ctx context.Context) error {
+			// Sync для консоли может вернуть ошибку на терминале, её не показываем
+			_ = logger.Sync()
+			return closer.Close()
+		},
+	})
+	return logger, level, nil
+}
 
-		writer := zapcore.AddSync(file)
+// New собирает логгер по секции log: формат, уровень и список выходов; файлы ротируются по размеру и возрасту
+func New(cfg *config.Config) (*zap.Logger, zap.AtomicLevel, io.Closer, error) {
+	level := zap.NewAtomicLevelAt(Level(cfg))
+	writer, closer, err := openOutputs(cfg)
+	if err != nil {
+		return nil, level, nil, err
+	}
 
+	var encoder zapcore.Encoder
+	if format(cfg) == "json" {
 		encoderCfg := zap.NewProductionEncoderConfig()
 		encoderCfg.EncodeTime = zapcore.ISO8601TimeEncoder
+		encoder = zapcore.NewJSONEncoder(encoderCfg)
+	} else {
+		encoder = zapcore.NewConsoleEncoder(zap.NewDevelopmentEncoderConfig())
+	}
+	core := zapcore.NewCore(encoder, writer, level)
 
-		core := zapcore.NewCore(
-			zapcore.NewJSONEncoder(encoderCfg),
-			writer,
-			zap.InfoLevel,
-		)
+	if cfg.Env == "prod" {
+		return zap.New(core), level, closer, nil
+	}
+	return zap.New(core, zap.Development(), zap.AddCaller(), zap.AddStacktrace(zap.WarnLevel)), level, closer, nil
+}
+
+// Level — уровень из log.level; если он не задан, для prod info, иначе debug
+func Level(cfg *config.Config) zapcore.Level {
+	if cfg.Log.Level != "" {
+		if l, err := zapcore.ParseLevel(cfg.Log.Level); err == nil {
+			return l
+		}
+	}
+	if cfg.Env == "prod" {
+		return zap.InfoLevel
+	}
+	return zap.DebugLevel
+}
+
+func format(cfg *config.Config) string {
+	if cfg.Log.Format != "" {
+		return cfg.Log.Format
+	}
+	if cfg.Env == "prod" {
+		return "json"
+	}
+	return "console"
+}
+
+func outputs(cfg *config.Config) []string {
+	if cfg.Log.Outputs == "" {
+		if cfg.Env == "prod" {
+			return []string{defaultProdOutput}
+		}
+		return []string{"stderr"}
+	}
+	var result []string
+	for _, out := range strings.Split(cfg.Log.Outputs, ",") {
+		result = append(result, strings.TrimSpace(out))
+	}
+	return result
+}
+
+// openOutputs открывает все выходы; запись идёт во все сразу
+func openOutputs(cfg *config.Config) (zapcore.WriteSyncer, io.Closer, error) {
+	var (
+		syncers []zapcore.WriteSyncer
+		files   closers
+	)
+	for _, out := range outputs(cfg) {
+		switch out {
+		case "stdout":
+			syncers = append(syncers, zapcore.Lock(os.Stdout))
+		case "stderr":
+			syncers = append(syncers, zapcore.Lock(os.Stderr))
+		default:
+			if err := os.MkdirAll(filepath.Dir(out), 0755); err != nil {
+				files.Close()
+				return nil, nil, err
+			}
+			// lumberjack открывает файл лениво, поэтому проверяем доступ сразу, чтобы ошибка была при старте
+			f, err := os.OpenFile(out, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+			if err != nil {
+				files.Close()
+				return nil, nil, err
+			}
+			f.Close()
+			file := &lumberjack.Logger{
+				Filename:   out,
+				MaxSize:    cfg.Log.MaxSizeMB,
+				MaxAge:     cfg.Log.MaxAgeDays,
+				MaxBackups: cfg.Log.MaxBackups,
+				Compress:   cfg.Log.Compress,
+				LocalTime:  true,
+			}
+			files = append(files, file)
+			syncers = append(syncers, zapcore.AddSync(file))
+		}
+	}
+	return zapcore.NewMultiWriteSyncer(syncers...), files, nil
+}
 
-		logger := zap.New(core)
-		return logger, nil
+type closers []io.Closer
 
-	default:
-		zapCfg := zap.NewDevelopmentConfig()
-		zapCfg.Encoding = "console"
-		return zapCfg.Build()
+func (c closers) Close() error {
+	var errs []error
+	for _, cl := range c {
+		errs = append(errs, cl.Close())
 	}
+	return errors.Join(errs...)
 }
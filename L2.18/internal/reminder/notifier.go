@@ -0,0 +1,114 @@
+package reminder
+
+import (
+	"bytes"
+	"calendar/internal/webhook"
+	"context"
+	"encoding/json"
+	"fmt"
+	"go.uber.org/zap"
+	"net/http"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Notifier доставляет напоминание. Ошибка означает, что доставку нужно повторить на следующем шаге планировщика.
+// Name — постоянное имя способа доставки: по нему отмечаются доставленные напоминания, поэтому порядок
+// способов в конфиге можно менять без повторной отправки.
+type Notifier interface {
+	Name() string
+	Notify(ctx context.Context, r Reminder) error
+}
+
+type LogNotifier struct {
+	logger *zap.Logger
+}
+
+func NewLogNotifier(logger *zap.Logger) *LogNotifier {
+	return &LogNotifier{logger: logger}
+}
+
+func (n *LogNotifier) Name() string { return "log" }
+
+func (n *LogNotifier) Notify(ctx context.Context, r Reminder) error {
+	n.logger.Info("event reminder",
+		zap.String("event_id", r.EventId),
+		zap.Int("user_id", r.UserID),
+		zap.Time("start", r.Start),
+		zap.String("event", r.EventText),
+	)
+	return nil
+}
+
+// WebhookNotifier отправляет напоминание POST-запросом с JSON-телом. Внутренние адреса, кроме сетей allowed,
+// запрещены так же, как для вебхуков (webhook.NewClient).
+type WebhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+func NewWebhookNotifier(url string, timeout time.Duration, allowed []netip.Prefix) *WebhookNotifier {
+	return &WebhookNotifier{
+		url:    url,
+		client: webhook.NewClient(timeout, allowed),
+	}
+}
+
+func (n *WebhookNotifier) Name() string { return "webhook:" + n.url }
+
+func (n *WebhookNotifier) Notify(ctx context.Context, r Reminder) error {
+	body, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook %s responded %d", n.url, resp.StatusCode)
+	}
+	return nil
+}
+
+// FileNotifier дописывает напоминания в файл, по одному JSON на строку
+type FileNotifier struct {
+	path string
+	mu   sync.Mutex
+}
+
+func NewFileNotifier(path string) (*FileNotifier, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	return &FileNotifier{path: path}, nil
+}
+
+func (n *FileNotifier) Name() string { return "file:" + n.path }
+
+func (n *FileNotifier) Notify(ctx context.Context, r Reminder) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	file, err := os.OpenFile(n.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
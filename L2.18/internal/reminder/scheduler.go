@@ -0,0 +1,226 @@
+package reminder
+
+import (
+	"calendar/internal/app"
+	"calendar/internal/repository"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	defaultOffset   = 15 * time.Minute
+	defaultInterval = 30 * time.Second
+)
+
+// Reminder — то, что получает Notifier; UserID — получатель напоминания
+type Reminder struct {
+	EventId      string     `json:"event_id"`
+	UserID       int        `json:"user_id"`
+	EventText    string     `json:"event"`
+	Start        time.Time  `json:"start"`
+	End          time.Time  `json:"end"`
+	RecurrenceId *time.Time `json:"recurrence_id,omitempty"`
+	RemindAt     time.Time  `json:"remind_at"`
+}
+
+// Scheduler раз в interval выбирает из хранилища вхождения, начинающиеся в ближайшие offset,
+// и отправляет напоминания каждому получателю ровно один раз. Состояние не нужно восстанавливать:
+// ожидающие напоминания каждый раз вычисляются заново из хранилища, а отметки об отправке
+// (если задан statePath) переживают перезапуск, чтобы не слать повторно.
+type Scheduler struct {
+	repo      repository.Storage
+	notifiers []Notifier
+	logger    *zap.Logger
+	offset    time.Duration
+	interval  time.Duration
+	statePath string
+	now       func() time.Time
+
+	mu        sync.Mutex
+	delivered map[string]time.Time // ключ доставки -> начало вхождения, чтобы чистить прошедшие
+	sending   map[string]bool      // доставки, которые сейчас выполняются, чтобы параллельная проверка их не повторила
+}
+
+func NewScheduler(repo repository.Storage, logger *zap.Logger, offset, interval time.Duration, statePath string, notifiers ...Notifier) (*Scheduler, error) {
+	if offset <= 0 {
+		offset = defaultOffset
+	}
+	if interval <= 0 {
+		interval = defaultInterval
+	}
+	names := make(map[string]bool)
+	for _, n := range notifiers {
+		if names[n.Name()] {
+			return nil, fmt.Errorf("duplicate reminder notifier %q", n.Name())
+		}
+		names[n.Name()] = true
+	}
+	s := &Scheduler{
+		repo:      repo,
+		notifiers: notifiers,
+		logger:    logger,
+		offset:    offset,
+		interval:  interval,
+		statePath: statePath,
+		now:       time.Now,
+		delivered: make(map[string]time.Time),
+		sending:   make(map[string]bool),
+	}
+	if err := s.loadState(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Run проверяет напоминания сразу и затем каждые interval, пока не отменён ctx
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		s.Check(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// delivery — напоминание, которое нужно передать одному Notifier
+type delivery struct {
+	key      string
+	reminder Reminder
+	notifier Notifier
+}
+
+// Check отправляет все наступившие и ещё не доставленные напоминания. Список ожидающих
+// собирается под блокировкой, а доставка идёт вне её, у каждой — свой срок interval,
+// чтобы медленный получатель не задерживал остальных и следующую проверку.
+func (s *Scheduler) Check(ctx context.Context) {
+	now := s.now()
+	// напоминание наступило, если start-offset <= now, то есть start <= now+offset включительно
+	events, err := s.repo.LoadUpcoming(now, now.Add(s.offset+time.Nanosecond))
+	if err != nil {
+		s.logger.Error("load upcoming events failed", zap.Error(err))
+		return
+	}
+
+	s.mu.Lock()
+	var pending []delivery
+	for _, e := range events {
+		for _, r := range newReminders(e, s.offset) {
+			for _, n := range s.notifiers {
+				key := fmt.Sprintf("%s|%s|%d|%s", r.EventId, r.Start.UTC().Format(time.RFC3339), r.UserID, n.Name())
+				if _, ok := s.delivered[key]; ok || s.sending[key] {
+					continue
+				}
+				s.sending[key] = true
+				pending = append(pending, delivery{key: key, reminder: r, notifier: n})
+			}
+		}
+	}
+	s.mu.Unlock()
+
+	sent := make([]bool, len(pending))
+	var wg sync.WaitGroup
+	for i, d := range pending {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			dctx, cancel := context.WithTimeout(ctx, s.interval)
+			defer cancel()
+			if err := d.notifier.Notify(dctx, d.reminder); err != nil {
+				s.logger.Warn("reminder delivery failed, will retry",
+					zap.String("event_id", d.reminder.EventId), zap.Int("user_id", d.reminder.UserID),
+					zap.Time("start", d.reminder.Start), zap.Error(err))
+				return
+			}
+			sent[i] = true
+		}()
+	}
+	wg.Wait()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	changed := false
+	for i, d := range pending {
+		delete(s.sending, d.key)
+		if sent[i] {
+			s.delivered[d.key] = d.reminder.Start
+			changed = true
+		}
+	}
+	for key, start := range s.delivered {
+		if start.Before(now) {
+			delete(s.delivered, key)
+			changed = true
+		}
+	}
+	if changed {
+		if err := s.saveState(); err != nil {
+			s.logger.Error("save reminder state failed", zap.Error(err))
+		}
+	}
+}
+
+// newReminders — напоминания о вхождении e: организатору и участникам, принявшим приглашение
+func newReminders(e *app.Event, offset time.Duration) []Reminder {
+	list := []Reminder{newReminder(e, e.UserID, offset)}
+	for _, a := range e.Attendees {
+		if a.Status == app.RSVPAccepted {
+			list = append(list, newReminder(e, a.UserID, offset))
+		}
+	}
+	return list
+}
+
+func newReminder(e *app.Event, userID int, offset time.Duration) Reminder {
+	return Reminder{
+		EventId:      e.EventId.String(),
+		UserID:       userID,
+		EventText:    e.EventText,
+		Start:        e.Start,
+		End:          e.End,
+		RecurrenceId: e.RecurrenceId,
+		RemindAt:     e.Start.Add(-offset),
+	}
+}
+
+func (s *Scheduler) loadState() error {
+	if s.statePath == "" {
+		return nil
+	}
+	data, err := os.ReadFile(s.statePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, &s.delivered)
+}
+
+func (s *Scheduler) saveState() error {
+	if s.statePath == "" {
+		return nil
+	}
+	data, err := json.Marshal(s.delivered)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.statePath), 0755); err != nil {
+		return err
+	}
+	tmp := s.statePath + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.statePath)
+}
@@ -0,0 +1,272 @@
+package reminder
+
+import (
+	"bufio"
+	"calendar/internal/app"
+	"calendar/internal/repository"
+	"calendar/internal/webhook"
+	"context"
+	"encoding/json"
+	"errors"
+	"go.uber.org/zap"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+type recordingNotifier struct {
+	name string
+	mu   sync.Mutex
+	got  []Reminder
+	fail bool
+}
+
+func (n *recordingNotifier) Name() string { return "recording:" + n.name }
+
+func (n *recordingNotifier) Notify(ctx context.Context, r Reminder) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.fail {
+		return errors.New("unavailable")
+	}
+	n.got = append(n.got, r)
+	return nil
+}
+
+func newTestScheduler(t *testing.T, repo repository.Storage, now *time.Time, statePath string, notifiers ...Notifier) *Scheduler {
+	t.Helper()
+	s, err := NewScheduler(repo, zap.NewNop(), 15*time.Minute, time.Minute, statePath, notifiers...)
+	if err != nil {
+		t.Fatalf("NewScheduler failed: %v", err)
+	}
+	s.now = func() time.Time { return *now }
+	return s
+}
+
+func TestSchedulerFiresOnceAtOffset(t *testing.T) {
+	repo := repository.NewInMemoryRepo()
+	repo.Save(&app.EventRequest{UserID: 1, Start: "2025-05-05T10:00:00Z", End: "2025-05-05T11:00:00Z", EventText: "meeting"})
+	rule := "FREQ=DAILY;COUNT=2"
+	repo.Save(&app.EventRequest{UserID: 2, Start: "2025-05-05T12:00:00Z", End: "2025-05-05T12:15:00Z", RRule: &rule})
+
+	now := time.Date(2025, 5, 5, 9, 40, 0, 0, time.UTC)
+	n := &recordingNotifier{}
+	s := newTestScheduler(t, repo, &now, "", n)
+	ctx := context.Background()
+
+	s.Check(ctx)
+	if len(n.got) != 0 {
+		t.Fatalf("reminder fired too early: %+v", n.got)
+	}
+
+	now = time.Date(2025, 5, 5, 9, 45, 0, 0, time.UTC)
+	s.Check(ctx)
+	s.Check(ctx)
+	if len(n.got) != 1 || n.got[0].EventText != "meeting" || !n.got[0].RemindAt.Equal(now) {
+		t.Fatalf("expected exactly one reminder at 09:45, got %+v", n.got)
+	}
+
+	now = time.Date(2025, 5, 6, 11, 50, 0, 0, time.UTC)
+	s.Check(ctx)
+	if len(n.got) != 2 || n.got[1].UserID != 2 || n.got[1].RecurrenceId == nil {
+		t.Fatalf("expected reminder for the second occurrence, got %+v", n.got)
+	}
+}
+
+func TestSchedulerRetriesFailedNotifierOnly(t *testing.T) {
+	repo := repository.NewInMemoryRepo()
+	repo.Save(&app.EventRequest{UserID: 1, Start: "2025-05-05T10:00:00Z"})
+	now := time.Date(2025, 5, 5, 9, 50, 0, 0, time.UTC)
+	ok, failing := &recordingNotifier{name: "ok"}, &recordingNotifier{name: "failing", fail: true}
+	s := newTestScheduler(t, repo, &now, "", ok, failing)
+
+	s.Check(context.Background())
+	failing.fail = false
+	s.Check(context.Background())
+	if len(ok.got) != 1 || len(failing.got) != 1 {
+		t.Fatalf("expected one delivery per notifier, got %d and %d", len(ok.got), len(failing.got))
+	}
+}
+
+func TestSchedulerRemindsAcceptedAttendees(t *testing.T) {
+	repo := repository.NewInMemoryRepo()
+	e, _ := repo.Save(&app.EventRequest{UserID: 1, Start: "2025-05-05T10:00:00Z",
+		Attendees: []app.Attendee{{UserID: 2}, {UserID: 3}, {UserID: 4}}})
+	id := e.EventId.String()
+	repo.Respond(&app.RSVPRequest{EventId: id, UserID: 2, Status: app.RSVPAccepted})
+	repo.Respond(&app.RSVPRequest{EventId: id, UserID: 3, Status: app.RSVPDeclined})
+
+	now := time.Date(2025, 5, 5, 9, 50, 0, 0, time.UTC)
+	n := &recordingNotifier{}
+	s := newTestScheduler(t, repo, &now, "", n)
+	s.Check(context.Background())
+	s.Check(context.Background())
+	users := map[int]int{}
+	for _, r := range n.got {
+		users[r.UserID]++
+	}
+	if len(n.got) != 2 || users[1] != 1 || users[2] != 1 {
+		t.Fatalf("expected reminders for the organizer and the accepted attendee, got %+v", n.got)
+	}
+}
+
+// blockingNotifier ждёт release, пока не истечёт срок доставки
+type blockingNotifier struct {
+	release chan struct{}
+}
+
+func (n *blockingNotifier) Name() string { return "blocking" }
+
+func (n *blockingNotifier) Notify(ctx context.Context, r Reminder) error {
+	select {
+	case <-n.release:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func TestSchedulerDeliversOutsideLock(t *testing.T) {
+	repo := repository.NewInMemoryRepo()
+	repo.Save(&app.EventRequest{UserID: 1, Start: "2025-05-05T10:00:00Z"})
+	now := time.Date(2025, 5, 5, 9, 50, 0, 0, time.UTC)
+	slow, fast := &blockingNotifier{release: make(chan struct{})}, &recordingNotifier{}
+	s := newTestScheduler(t, repo, &now, "", slow, fast)
+
+	done := make(chan struct{})
+	go func() {
+		s.Check(context.Background())
+		close(done)
+	}()
+	// пока первая проверка ждёт медленного получателя, вторая не блокируется и не повторяет доставку
+	deadline := time.After(5 * time.Second)
+	for {
+		fast.mu.Lock()
+		got := len(fast.got)
+		fast.mu.Unlock()
+		if got == 1 {
+			break
+		}
+		select {
+		case <-deadline:
+			t.Fatal("fast notifier waited for the slow one")
+		case <-time.After(time.Millisecond):
+		}
+	}
+	s.Check(context.Background())
+	close(slow.release)
+	<-done
+	if len(fast.got) != 1 {
+		t.Fatalf("reminder repeated while the first check was running: %d deliveries", len(fast.got))
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.delivered) != 2 || len(s.sending) != 0 {
+		t.Fatalf("unexpected state: delivered %v, sending %v", s.delivered, s.sending)
+	}
+}
+
+func TestSchedulerStateSurvivesRestart(t *testing.T) {
+	repo := repository.NewInMemoryRepo()
+	repo.Save(&app.EventRequest{UserID: 1, Start: "2025-05-05T10:00:00Z"})
+	state := filepath.Join(t.TempDir(), "reminders.json")
+	now := time.Date(2025, 5, 5, 9, 50, 0, 0, time.UTC)
+
+	n := &recordingNotifier{}
+	newTestScheduler(t, repo, &now, state, n).Check(context.Background())
+	newTestScheduler(t, repo, &now, state, n).Check(context.Background())
+	if len(n.got) != 1 {
+		t.Fatalf("reminder repeated after restart: %d deliveries", len(n.got))
+	}
+
+	// после перезапуска пропущенное, но ещё актуальное напоминание вычисляется заново
+	repo.Save(&app.EventRequest{UserID: 1, Start: "2025-05-05T10:05:00Z"})
+	newTestScheduler(t, repo, &now, state, n).Check(context.Background())
+	if len(n.got) != 2 {
+		t.Fatalf("pending reminder not recomputed after restart: %d deliveries", len(n.got))
+	}
+}
+
+func TestSchedulerStateKeyedByNotifierName(t *testing.T) {
+	repo := repository.NewInMemoryRepo()
+	repo.Save(&app.EventRequest{UserID: 1, Start: "2025-05-05T10:00:00Z"})
+	state := filepath.Join(t.TempDir(), "reminders.json")
+	now := time.Date(2025, 5, 5, 9, 50, 0, 0, time.UTC)
+
+	a, b := &recordingNotifier{name: "a"}, &recordingNotifier{name: "b"}
+	newTestScheduler(t, repo, &now, state, a, b).Check(context.Background())
+	// порядок способов доставки поменялся после перезапуска — повторной отправки нет
+	newTestScheduler(t, repo, &now, state, b, a).Check(context.Background())
+	if len(a.got) != 1 || len(b.got) != 1 {
+		t.Fatalf("reminder repeated after reordering notifiers: %d and %d deliveries", len(a.got), len(b.got))
+	}
+	if _, err := NewScheduler(repo, zap.NewNop(), time.Minute, time.Minute, "", a, &recordingNotifier{name: "a"}); err == nil {
+		t.Fatal("expected error for duplicate notifier names")
+	}
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	var got Reminder
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer srv.Close()
+
+	// адрес httptest-сервера внутренний: без разрешённой сети доставка запрещена
+	if err := NewWebhookNotifier(srv.URL, time.Second, nil).Notify(context.Background(), Reminder{}); !errors.Is(err, webhook.ErrAddressNotAllowed) {
+		t.Fatalf("expected internal address to be refused, got %v", err)
+	}
+	loopback := []netip.Prefix{netip.MustParsePrefix("127.0.0.0/8")}
+	n := NewWebhookNotifier(srv.URL, time.Second, loopback)
+	if err := n.Notify(context.Background(), Reminder{EventId: "x", UserID: 3}); err != nil {
+		t.Fatalf("Notify failed: %v", err)
+	}
+	if got.EventId != "x" || got.UserID != 3 {
+		t.Fatalf("unexpected payload %+v", got)
+	}
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+	if err := NewWebhookNotifier(failing.URL, time.Second, loopback).Notify(context.Background(), Reminder{}); err == nil {
+		t.Fatal("expected error for non-2xx response")
+	}
+}
+
+func TestFileNotifier(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out", "reminders.log")
+	n, err := NewFileNotifier(path)
+	if err != nil {
+		t.Fatalf("NewFileNotifier failed: %v", err)
+	}
+	n.Notify(context.Background(), Reminder{EventId: "a"})
+	n.Notify(context.Background(), Reminder{EventId: "b"})
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer f.Close()
+	var ids []string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var r Reminder
+		if err := json.Unmarshal(sc.Bytes(), &r); err != nil {
+			t.Fatalf("bad line %q: %v", sc.Text(), err)
+		}
+		ids = append(ids, r.EventId)
+	}
+	if len(ids) != 2 || ids[0] != "a" || ids[1] != "b" {
+		t.Fatalf("unexpected file contents %v", ids)
+	}
+}
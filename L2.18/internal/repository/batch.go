@@ -0,0 +1,186 @@
+package repository
+
+import (
+	"calendar/internal/app"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+)
+
+// Операции пакета
+const (
+	BatchCreate = "create"
+	BatchUpdate = "update"
+	BatchDelete = "delete"
+	// BatchMatch ничего не меняет, а проверяет ETag ресурса CalDAV с именем Request.UID у Request.UserID:
+	// Request.IfMatch — ожидаемый ETag или "*", пустой — ресурса быть не должно; иначе ErrPreconditionFailed
+	BatchMatch = "match"
+
+	// MaxBatchSize — сколько операций можно передать одним пакетом
+	MaxBatchSize = 1000
+)
+
+// ErrBatchAborted — операция атомарного пакета не применена, потому что не прошла другая
+var ErrBatchAborted = errors.New("batch aborted")
+
+// BatchOp — операция пакета; Request — как у Save, Update и Delete
+type BatchOp struct {
+	Op      string
+	Request *app.EventRequest
+}
+
+// BatchResult — итог операции: копия события после create и update или ошибка.
+// Если атомарный пакет не прошёл, у не прошедшей операции её ошибка, у остальных — ErrBatchAborted.
+type BatchResult struct {
+	Event *app.Event
+	Err   error
+}
+
+// batchUndo — всё, что нужно для отката атомарного пакета. Изменения событий откатываются по записям
+// истории пакета: в них есть состояние до и после каждого изменения.
+type batchUndo struct {
+	entries []app.AuditEntry
+	history map[uuid.UUID][]app.AuditEntry // история событий до пакета; nil — её не было
+	keys    []idempotencyKey
+	changes int // длина r.changes до пакета
+}
+
+// Batch применяет операции по порядку под одной блокировкой, подписчики получают изменения одной публикацией.
+// Без atomic каждая операция применяется независимо и ошибка у каждой своя. С atomic первая же ошибка
+// откатывает изменения пакета: вместе с итогами возвращается ошибка этой операции с её номером.
+func (r *InMemoryRepo) Batch(ops []BatchOp, atomic bool) ([]BatchResult, error) {
+	if len(ops) > MaxBatchSize {
+		return nil, app.InvalidField("operations", fmt.Sprintf("at most %d operations per batch", MaxBatchSize))
+	}
+	r.mu.Lock()
+	defer r.unlock()
+	if atomic {
+		r.undo = &batchUndo{history: make(map[uuid.UUID][]app.AuditEntry), changes: len(r.changes)}
+		defer func() { r.undo = nil }()
+	}
+
+	results := make([]BatchResult, len(ops))
+	for i, op := range ops {
+		e, err := r.applyOp(op)
+		if err != nil && atomic {
+			r.rollback()
+			for j := range results {
+				results[j] = BatchResult{Err: ErrBatchAborted}
+			}
+			results[i].Err = err
+			return results, fmt.Errorf("operation %d: %w", i, err)
+		}
+		// событие ещё могут изменить следующие операции пакета
+		results[i] = BatchResult{Event: e.Snapshot(), Err: err}
+	}
+	return results, nil
+}
+
+func (r *InMemoryRepo) applyOp(op BatchOp) (*app.Event, error) {
+	if op.Request == nil {
+		return nil, app.InvalidField("event", "event is required")
+	}
+	switch op.Op {
+	case BatchCreate:
+		return r.save(op.Request)
+	case BatchUpdate:
+		return r.update(op.Request)
+	case BatchDelete:
+		return nil, r.delete(op.Request)
+	case BatchMatch:
+		return nil, r.matchObject(op.Request)
+	}
+	return nil, app.InvalidField("op", fmt.Sprintf("unknown operation %q", op.Op))
+}
+
+// rollback возвращает события, историю и ключи идемпотентности к состоянию до пакета. Вызывается под mu.
+func (r *InMemoryRepo) rollback() {
+	u := r.undo
+	deleted := 0
+	for i := len(u.entries) - 1; i >= 0; i-- {
+		entry := u.entries[i]
+		switch {
+		case entry.Before == nil:
+			r.unlink(entry.After.UserID, entry.After.EventId)
+		case entry.After == nil:
+			deleted++
+			e := entry.Before.Snapshot()
+			r.Repo[e.UserID] = append(r.Repo[e.UserID], e)
+			r.indexAdd(e)
+		default:
+			if _, e := r.find(entry.Before.UserID, entry.EventId); e != nil {
+				r.indexRemove(e)
+				*e = *entry.Before.Snapshot()
+				r.indexAdd(e)
+			}
+		}
+	}
+	for id, h := range u.history {
+		if h == nil {
+			delete(r.history, id)
+		} else {
+			r.history[id] = h
+		}
+	}
+	// удаления пакета — последние в deletions: purge отрезает только старые с начала
+	r.deletions = r.deletions[:len(r.deletions)-deleted]
+	for _, key := range u.keys {
+		delete(r.idempotency, key)
+	}
+	r.changes = r.changes[:u.changes]
+}
+
+// unlink убирает событие из хранилища и индексов без записи в историю. Вызывается под mu.
+func (r *InMemoryRepo) unlink(userID int, id uuid.UUID) {
+	i, e := r.find(userID, id)
+	if e == nil {
+		return
+	}
+	r.Repo[userID] = append(r.Repo[userID][:i], r.Repo[userID][i+1:]...)
+	r.indexRemove(e)
+}
+
+// matchObject проверяет ETag ресурса CalDAV под mu: пакет применяется к тому состоянию ресурса, которое видел клиент
+func (r *InMemoryRepo) matchObject(er *app.EventRequest) error {
+	events := r.object(er.UserID, er.UID)
+	current := "none"
+	if events != nil {
+		current = app.ObjectETag(events)
+	}
+	switch {
+	case er.IfMatch == "" && events == nil,
+		er.IfMatch == "*" && events != nil,
+		er.IfMatch == current:
+		return nil
+	}
+	return fmt.Errorf("%w: resource is at %s", app.ErrPreconditionFailed, current)
+}
+
+// object — события ресурса CalDAV name: серия первой, затем её выделенные вхождения. Вызывается под mu.
+func (r *InMemoryRepo) object(userID int, name string) []*app.Event {
+	var master *app.Event
+	for _, e := range r.Repo[userID] {
+		if e.SeriesId == nil && e.ObjectName() == name {
+			master = e
+			break
+		}
+	}
+	if master == nil {
+		// вхождение, серии которого нет среди событий пользователя, — отдельный ресурс с именем event_id
+		for _, e := range r.Repo[userID] {
+			if e.SeriesId != nil && e.EventId.String() == name {
+				if _, series := r.find(userID, *e.SeriesId); series == nil {
+					return []*app.Event{e}
+				}
+			}
+		}
+		return nil
+	}
+	events := []*app.Event{master}
+	for _, e := range r.Repo[userID] {
+		if e.SeriesId != nil && *e.SeriesId == master.EventId {
+			events = append(events, e)
+		}
+	}
+	return events
+}
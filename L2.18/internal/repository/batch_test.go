@@ -0,0 +1,158 @@
+package repository
+
+import (
+	"calendar/internal/app"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBatchBestEffort(t *testing.T) {
+	r := NewInMemoryRepo()
+	pub := &recordingPublisher{}
+	r.SetPublisher(pub)
+	existing, _ := r.Save(newReq(1, "2025-05-05", "existing"))
+	pub.batches = nil
+
+	results, err := r.Batch([]BatchOp{
+		{Op: BatchCreate, Request: newReq(1, "2025-05-06", "new")},
+		{Op: BatchUpdate, Request: &app.EventRequest{EventId: existing.EventId.String(), UserID: 1, EventText: "changed"}},
+		{Op: BatchDelete, Request: &app.EventRequest{EventId: "00000000-0000-0000-0000-000000000001", UserID: 1}},
+		{Op: "rename", Request: newReq(1, "2025-05-07", "unknown")},
+	}, false)
+	if err != nil {
+		t.Fatalf("Batch failed: %v", err)
+	}
+	if results[0].Err != nil || results[0].Event.EventText != "new" || results[1].Err != nil || results[1].Event.Version != 2 {
+		t.Fatalf("unexpected successful results %+v", results)
+	}
+	if !errors.Is(results[2].Err, app.ErrNotFound) || !errors.Is(results[3].Err, app.ErrInvalidInput) {
+		t.Fatalf("unexpected failed results %+v", results)
+	}
+	if all, _ := r.LoadAll(1); len(all) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(all))
+	}
+	// подписчики получают изменения пакета одной публикацией
+	if len(pub.batches) != 1 || len(pub.batches[0]) != 2 {
+		t.Fatalf("expected one publication with 2 changes, got %+v", pub.batches)
+	}
+}
+
+func TestBatchAtomicRollback(t *testing.T) {
+	r := NewInMemoryRepo()
+	pub := &recordingPublisher{}
+	r.SetPublisher(pub)
+	rule := "FREQ=DAILY;COUNT=3"
+	series, _ := r.Save(&app.EventRequest{UserID: 1, Start: "2025-05-05T10:00:00Z", End: "2025-05-05T11:00:00Z", RRule: &rule, EventText: "series"})
+	other, _ := r.Save(newReq(1, "2025-05-10", "other"))
+	pub.batches = nil
+	id := series.EventId.String()
+
+	results, err := r.Batch([]BatchOp{
+		{Op: BatchCreate, Request: &app.EventRequest{UserID: 1, Date: "2025-05-11", EventText: "created", IdempotencyKey: "k1"}},
+		{Op: BatchUpdate, Request: &app.EventRequest{EventId: id, UserID: 1, Scope: app.ScopeThis, RecurrenceId: "2025-05-06T10:00:00Z", EventText: "moved"}},
+		{Op: BatchUpdate, Request: &app.EventRequest{EventId: id, UserID: 1, EventText: "renamed"}},
+		{Op: BatchDelete, Request: &app.EventRequest{EventId: other.EventId.String(), UserID: 1}},
+		{Op: BatchUpdate, Request: &app.EventRequest{EventId: id, UserID: 1, EventText: "stale", IfMatch: series.ETag()}},
+	}, true)
+	if !errors.Is(err, app.ErrPreconditionFailed) || len(results) != 5 {
+		t.Fatalf("expected precondition failure with results, got %v %+v", err, results)
+	}
+	if !errors.Is(results[4].Err, app.ErrPreconditionFailed) || !errors.Is(results[0].Err, ErrBatchAborted) || results[0].Event != nil {
+		t.Fatalf("unexpected results %+v", results)
+	}
+
+	all, _ := r.LoadAll(1)
+	if len(all) != 2 {
+		t.Fatalf("expected the original 2 events, got %+v", all)
+	}
+	got, _ := r.LoadEvent(1, id)
+	if got.EventText != "series" || got.Version != 1 || len(got.ExDates) != 0 {
+		t.Fatalf("series not rolled back: %+v", got)
+	}
+	day, _ := time.Parse("2006-01-02", "2025-05-06")
+	if list, _ := r.LoadDay(1, day); len(list) != 1 || list[0].EventText != "series" {
+		t.Fatalf("occurrence index not rolled back: %+v", list)
+	}
+	if h, _ := r.LoadHistory(1, id); len(h) != 1 {
+		t.Fatalf("history not rolled back: %+v", h)
+	}
+	if h, _ := r.LoadHistory(1, other.EventId.String()); len(h) != 1 {
+		t.Fatalf("deletion not rolled back: %+v", h)
+	}
+	if len(pub.batches) != 0 {
+		t.Fatalf("rolled back changes published: %+v", pub.batches)
+	}
+
+	// ключ идемпотентности отменённого создания свободен
+	created, err := r.Save(&app.EventRequest{UserID: 1, Date: "2025-05-11", EventText: "again", IdempotencyKey: "k1"})
+	if err != nil || created.EventText != "again" {
+		t.Fatalf("idempotency key kept after rollback: %+v, %v", created, err)
+	}
+}
+
+func TestBatchMatchObject(t *testing.T) {
+	r := NewInMemoryRepo()
+	match := func(etag string) BatchOp {
+		return BatchOp{Op: BatchMatch, Request: &app.EventRequest{UserID: 1, UID: "standup", IfMatch: etag}}
+	}
+	create := BatchOp{Op: BatchCreate, Request: &app.EventRequest{UserID: 1, UID: "standup", Date: "2025-05-05", EventText: "standup"}}
+
+	// пустой ETag — ресурса ещё нет
+	results, err := r.Batch([]BatchOp{match(""), create}, true)
+	if err != nil {
+		t.Fatalf("create after match failed: %v", err)
+	}
+	etag := app.ObjectETag([]*app.Event{results[1].Event})
+	if _, err := r.Batch([]BatchOp{match(""), create}, true); !errors.Is(err, app.ErrPreconditionFailed) {
+		t.Fatalf("expected precondition failure for an existing object, got %v", err)
+	}
+
+	update := BatchOp{Op: BatchUpdate, Request: &app.EventRequest{UserID: 1, EventId: results[1].Event.EventId.String(), EventText: "retro"}}
+	if _, err := r.Batch([]BatchOp{match(`"stale"`), update}, true); !errors.Is(err, app.ErrPreconditionFailed) {
+		t.Fatalf("expected precondition failure for a stale ETag, got %v", err)
+	}
+	if e, _ := r.LoadEvent(1, results[1].Event.EventId.String()); e.EventText != "standup" {
+		t.Fatalf("rejected batch changed the event: %q", e.EventText)
+	}
+	for _, etag := range []string{etag, "*"} {
+		if _, err := r.Batch([]BatchOp{match(etag), update}, true); err != nil {
+			t.Fatalf("match %s failed: %v", etag, err)
+		}
+	}
+}
+
+func TestFileRepoBatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.log")
+	r, err := NewFileRepo(path, 0)
+	if err != nil {
+		t.Fatalf("NewFileRepo failed: %v", err)
+	}
+	ops := []BatchOp{
+		{Op: BatchCreate, Request: newReq(1, "2025-05-05", "a")},
+		{Op: BatchCreate, Request: newReq(1, "2025-05-06", "b")},
+	}
+	if _, err := r.Batch(ops, true); err != nil {
+		t.Fatalf("Batch failed: %v", err)
+	}
+	failed := []BatchOp{
+		{Op: BatchCreate, Request: newReq(1, "2025-05-07", "c")},
+		{Op: BatchDelete, Request: &app.EventRequest{EventId: "bad", UserID: 1}},
+	}
+	if _, err := r.Batch(failed, true); !errors.Is(err, app.ErrInvalidInput) {
+		t.Fatalf("expected invalid input, got %v", err)
+	}
+	r.Close()
+
+	data, _ := os.ReadFile(path)
+	if lines := strings.Count(string(data), "\n"); lines != 1 {
+		t.Fatalf("expected the atomic batch in one log line, got %d:\n%s", lines, data)
+	}
+	r2 := newFileRepo(t, path, 0)
+	if all, _ := r2.LoadAll(1); len(all) != 2 || all[0].EventText != "a" || all[1].EventText != "b" {
+		t.Fatalf("unexpected events after reopen %+v", all)
+	}
+}
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"calendar/internal/app"
+	"github.com/google/uuid"
+	"time"
+)
+
+const (
+	// conflictHorizon — насколько вперёд проверяются вхождения серии: бесконечную серию целиком не проверить
+	conflictHorizon = 366 * 24 * time.Hour
+	// maxConflicts — сколько пересечений перечислять в ошибке
+	maxConflicts = 10
+)
+
+// SetRejectConflicts включает отказ в Save и Update, если событие пересекается по времени с другим событием
+// того же пользователя или с событием любого пользователя на том же ресурсе
+func (r *InMemoryRepo) SetRejectConflicts(on bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rejectConflicts = on
+}
+
+// checkConflicts вызывается под mu до изменения хранилища; caller — пользователь, от имени которого идёт изменение,
+// id и владельцы невидимых ему событий в ошибку не попадают
+func (r *InMemoryRepo) checkConflicts(c *app.Event, caller int) error {
+	if !r.rejectConflicts {
+		return nil
+	}
+	own := family(c)
+	other := func(e *app.Event) bool { return family(e) != own && e.Blocks() }
+
+	type source struct {
+		ix    *userIndex
+		match func(*app.Event) bool
+	}
+	// в индексе пользователя лежат и приглашения, от которых он отказался, — они время не занимают
+	sources := []source{{r.userIndex(c.UserID), visibleMatcher(c.UserID, other)}}
+	if c.Resource != "" {
+		sources = append(sources, source{r.resourceIndex(c.Resource), other})
+	}
+
+	var conflicts []app.Conflict
+	seen := make(map[position]bool)
+	for _, occ := range c.Occurrences(c.Start, c.Start.Add(conflictHorizon)) {
+		if !occ.Blocks() {
+			continue
+		}
+		for _, src := range sources {
+			for _, e := range src.ix.query(occ.Start, occ.End, nil, src.match, 0) {
+				if seen[positionOf(e)] || !overlapsBusy(e, occ) {
+					continue
+				}
+				seen[positionOf(e)] = true
+				conflict := app.Conflict{Resource: e.Resource, Start: e.Start, End: e.End}
+				if e.VisibleTo(caller) {
+					id := e.EventId
+					conflict.EventId, conflict.UserID = &id, e.UserID
+				}
+				conflicts = append(conflicts, conflict)
+				if len(conflicts) == maxConflicts {
+					return &app.OverlapError{Conflicts: conflicts}
+				}
+			}
+		}
+	}
+	if len(conflicts) > 0 {
+		return &app.OverlapError{Conflicts: conflicts}
+	}
+	return nil
+}
+
+// overlapsBusy — пересечение по времени; события, стыкующиеся концом к началу, не пересекаются
+func overlapsBusy(a, b *app.Event) bool {
+	return a.Start.Before(b.End) && b.Start.Before(a.End)
+}
+
+// family — серия, к которой относится событие: вхождения и выделенные из серии события не конфликтуют друг с другом
+func family(e *app.Event) uuid.UUID {
+	if e.SeriesId != nil {
+		return *e.SeriesId
+	}
+	return e.EventId
+}
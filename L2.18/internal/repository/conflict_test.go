@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"calendar/internal/app"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func timed(uid int, start, end, resource string) *app.EventRequest {
+	return &app.EventRequest{UserID: uid, Start: start, End: end, Resource: &resource, EventText: "meeting"}
+}
+
+func TestInMemoryRepoRejectsConflicts(t *testing.T) {
+	r := NewInMemoryRepo()
+	base, err := r.Save(timed(1, "2025-05-05T10:00:00Z", "2025-05-05T11:00:00Z", "room-1"))
+	if err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if _, err := r.Save(timed(1, "2025-05-05T10:30:00Z", "2025-05-05T11:30:00Z", "")); err != nil {
+		t.Fatalf("overlaps must be allowed by default: %v", err)
+	}
+
+	r = NewInMemoryRepo()
+	r.SetRejectConflicts(true)
+	base, _ = r.Save(timed(1, "2025-05-05T10:00:00Z", "2025-05-05T11:00:00Z", "room-1"))
+
+	_, err = r.Save(timed(1, "2025-05-05T10:30:00Z", "2025-05-05T11:30:00Z", ""))
+	var overlap *app.OverlapError
+	if !errors.Is(err, app.ErrConflict) || !errors.As(err, &overlap) {
+		t.Fatalf("expected overlap error for the same user, got %v", err)
+	}
+	if len(overlap.Conflicts) != 1 || overlap.Conflicts[0].EventId == nil || *overlap.Conflicts[0].EventId != base.EventId {
+		t.Fatalf("unexpected conflicts %+v", overlap.Conflicts)
+	}
+	_, err = r.Save(timed(2, "2025-05-05T10:30:00Z", "2025-05-05T11:30:00Z", "room-1"))
+	if !errors.As(err, &overlap) {
+		t.Fatalf("expected conflict on the same resource, got %v", err)
+	}
+	// чужое событие на ресурсе описывается только ресурсом и интервалом
+	if c := overlap.Conflicts[0]; c.EventId != nil || c.UserID != 0 || c.Resource != "room-1" || !c.Start.Equal(base.Start) {
+		t.Fatalf("conflict leaks another user's event: %+v", c)
+	}
+	if strings.Contains(err.Error(), base.EventId.String()) {
+		t.Fatalf("error leaks another user's event id: %v", err)
+	}
+
+	allowed := []*app.EventRequest{
+		timed(1, "2025-05-05T11:00:00Z", "2025-05-05T12:00:00Z", "room-1"), // стык концом к началу
+		timed(2, "2025-05-05T10:00:00Z", "2025-05-05T11:00:00Z", "room-2"), // другой ресурс
+		{UserID: 1, Date: "2025-05-05", EventText: "holiday"},              // весь день
+	}
+	for _, er := range allowed {
+		if _, err := r.Save(er); err != nil {
+			t.Fatalf("unexpected conflict for %+v: %v", er, err)
+		}
+	}
+
+	// перенос на занятое время отклоняется, событие не меняется
+	moved, _ := r.Save(timed(1, "2025-05-05T14:00:00Z", "2025-05-05T15:00:00Z", ""))
+	_, err = r.Update(&app.EventRequest{EventId: moved.EventId.String(), UserID: 1, Start: "2025-05-05T10:15:00Z"})
+	if !errors.Is(err, app.ErrConflict) {
+		t.Fatalf("expected conflict on update, got %v", err)
+	}
+	if got, _ := r.LoadEvent(1, moved.EventId.String()); got.Start.Hour() != 14 {
+		t.Fatalf("rejected update changed the event: %v", got.Start)
+	}
+	// а изменение без пересечения проходит, в том числе смена ресурса
+	if _, err := r.Update(&app.EventRequest{EventId: base.EventId.String(), UserID: 1, Resource: new(string)}); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if _, err := r.Save(timed(3, "2025-05-05T10:00:00Z", "2025-05-05T11:00:00Z", "room-1")); err != nil {
+		t.Fatalf("released resource is still busy: %v", err)
+	}
+}
+
+func TestInMemoryRepoSeriesConflicts(t *testing.T) {
+	r := NewInMemoryRepo()
+	r.SetRejectConflicts(true)
+	standup := timed(1, "2025-05-05T09:00:00Z", "2025-05-05T09:15:00Z", "")
+	rule := "FREQ=DAILY;COUNT=10"
+	standup.RRule = &rule
+	series, err := r.Save(standup)
+	if err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	if _, err := r.Save(timed(1, "2025-05-09T09:10:00Z", "2025-05-09T09:30:00Z", "")); !errors.Is(err, app.ErrConflict) {
+		t.Fatalf("expected conflict with a later occurrence, got %v", err)
+	}
+	if _, err := r.Save(timed(1, "2025-05-20T09:00:00Z", "2025-05-20T09:30:00Z", "")); err != nil {
+		t.Fatalf("unexpected conflict after the series ended: %v", err)
+	}
+
+	// выделенное вхождение не конфликтует со своей серией
+	_, err = r.Update(&app.EventRequest{
+		EventId: series.EventId.String(), UserID: 1, Scope: app.ScopeThis,
+		RecurrenceId: "2025-05-06T09:00:00Z", Start: "2025-05-06T09:05:00Z",
+	})
+	if err != nil {
+		t.Fatalf("detaching an occurrence failed: %v", err)
+	}
+}
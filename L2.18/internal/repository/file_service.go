@@ -0,0 +1,489 @@
+package repository
+
+import (
+	"bufio"
+	"bytes"
+	"calendar/internal/app"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	opPut     = "put"
+	opDelete  = "delete"
+	opAudit   = "audit"
+	opRestore = "restore" // только для уведомлений: в журнал восстановление пишется как put
+	opBatch   = "batch"
+	opKey     = "key"
+)
+
+// logRecord — одна строка журнала: put хранит событие целиком, delete — только идентификаторы, audit — запись истории,
+// key — Idempotency-Key созданного события, batch — записи атомарного пакета, чтобы недописанный пакет отрезался
+// при проигрывании целиком
+type logRecord struct {
+	Op      string            `json:"op"`
+	Event   *app.Event        `json:"event,omitempty"`
+	UserID  int               `json:"user_id,omitempty"`
+	EventId string            `json:"event_id,omitempty"`
+	Audit   *app.AuditEntry   `json:"audit,omitempty"`
+	Key     *idempotencyEntry `json:"key,omitempty"`
+	Records []logRecord       `json:"records,omitempty"`
+}
+
+// size — сколько записей в строке, для решения о сжатии
+func (rec logRecord) size() int {
+	if rec.Op == opBatch {
+		return len(rec.Records)
+	}
+	return 1
+}
+
+// FileRepo — хранилище поверх InMemoryRepo, каждое изменение дописывается в журнал (JSON lines).
+// При старте журнал проигрывается заново, а когда он разрастается — сжимается до снимка текущего состояния.
+type FileRepo struct {
+	mem              *InMemoryRepo
+	mu               sync.RWMutex // сериализует изменения и запись в журнал; чтения под RLock не видят незаписанное изменение
+	file             *os.File
+	path             string
+	records          int
+	compactThreshold int
+	pending          []logRecord   // изменения текущей операции, ещё не записанные в журнал
+	rejectConflicts  bool          // переносится в mem при каждом открытии журнала
+	retention        time.Duration // тоже
+	publisher        Publisher     // получает изменения только после записи в журнал
+	changes          []Change
+}
+
+func NewFileRepo(path string, compactThreshold int) (*FileRepo, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	r := &FileRepo{
+		path:             path,
+		compactThreshold: compactThreshold,
+	}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	if r.needCompaction() {
+		if err := r.compact(); err != nil {
+			r.file.Close()
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+func (r *FileRepo) Save(er *app.EventRequest) (*app.Event, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	e, err := r.mem.Save(er)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.flush(); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+func (r *FileRepo) Delete(er *app.EventRequest) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.mem.Delete(er); err != nil {
+		return err
+	}
+	return r.flush()
+}
+
+func (r *FileRepo) Update(er *app.EventRequest) (*app.Event, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	e, err := r.mem.Update(er)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.flush(); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+func (r *FileRepo) Restore(UserID int, EventId string) (*app.Event, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	e, err := r.mem.Restore(UserID, EventId)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.flush(); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+func (r *FileRepo) Respond(rsvp *app.RSVPRequest) (*app.Event, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	e, err := r.mem.Respond(rsvp)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.flush(); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// Batch пишет изменения пакета в журнал одной записью; откаченный атомарный пакет в журнал не попадает
+func (r *FileRepo) Batch(ops []BatchOp, atomic bool) ([]BatchResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	results, err := r.mem.Batch(ops, atomic)
+	if err != nil {
+		r.pending, r.changes = nil, nil
+		return results, err
+	}
+	if atomic && len(r.pending) > 1 {
+		r.pending = []logRecord{{Op: opBatch, Records: r.pending}}
+	}
+	if err := r.flush(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func (r *FileRepo) SetRejectConflicts(on bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rejectConflicts = on
+	r.mem.SetRejectConflicts(on)
+}
+
+func (r *FileRepo) SetTombstoneRetention(d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.retention = d
+	r.mem.SetTombstoneRetention(d)
+}
+
+func (r *FileRepo) SetPublisher(p Publisher) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.publisher = p
+}
+
+func (r *FileRepo) LoadDay(UserID int, Date time.Time) ([]*app.Event, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.mem.LoadDay(UserID, Date)
+}
+
+func (r *FileRepo) LoadWeek(UserID int, Date time.Time) ([]*app.Event, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.mem.LoadWeek(UserID, Date)
+}
+
+func (r *FileRepo) LoadMonth(UserID int, Date time.Time) ([]*app.Event, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.mem.LoadMonth(UserID, Date)
+}
+
+func (r *FileRepo) LoadEvent(UserID int, EventId string) (*app.Event, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.mem.LoadEvent(UserID, EventId)
+}
+
+func (r *FileRepo) LoadHistory(UserID int, EventId string) ([]app.AuditEntry, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.mem.LoadHistory(UserID, EventId)
+}
+
+func (r *FileRepo) LoadRange(UserID int, from, to time.Time, opts RangeOptions) (*EventPage, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.mem.LoadRange(UserID, from, to, opts)
+}
+
+func (r *FileRepo) Search(UserID int, q SearchQuery) ([]SearchHit, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.mem.Search(UserID, q)
+}
+
+func (r *FileRepo) LoadAll(UserID int) ([]*app.Event, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.mem.LoadAll(UserID)
+}
+
+func (r *FileRepo) LoadUpcoming(from, to time.Time) ([]*app.Event, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.mem.LoadUpcoming(from, to)
+}
+
+func (r *FileRepo) EventCounts() map[int]int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.mem.EventCounts()
+}
+
+// Ping проверяет, что журнал открыт и не удалён с диска
+func (r *FileRepo) Ping() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, err := r.file.Stat(); err != nil {
+		return fmt.Errorf("event log: %w", err)
+	}
+	if _, err := os.Stat(r.path); err != nil {
+		return fmt.Errorf("event log: %w", err)
+	}
+	return nil
+}
+
+func (r *FileRepo) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.file.Close()
+}
+
+// open проигрывает журнал в новое in-memory хранилище и открывает файл на дозапись.
+// Хранилище собирается отдельно и подменяет r.mem, только если журнал прочитан целиком.
+func (r *FileRepo) open() error {
+	mem := NewInMemoryRepo()
+	records, err := r.replay(mem)
+	if err != nil {
+		return err
+	}
+	file, err := os.OpenFile(r.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	mem.onChange = r.record
+	mem.onAudit = r.recordAudit
+	mem.onKey = r.recordKey
+	mem.rejectConflicts = r.rejectConflicts
+	mem.retention = r.retention
+	r.mem, r.records, r.file = mem, records, file
+	return nil
+}
+
+func (r *FileRepo) record(op string, e *app.Event) {
+	switch op {
+	case opPut, opRestore:
+		cp := *e
+		r.pending = append(r.pending, logRecord{Op: opPut, Event: &cp})
+	case opDelete:
+		r.pending = append(r.pending, logRecord{Op: opDelete, UserID: e.UserID, EventId: e.EventId.String()})
+	}
+	if r.publisher != nil {
+		r.changes = append(r.changes, newChange(op, e))
+	}
+}
+
+func (r *FileRepo) recordAudit(entry app.AuditEntry) {
+	r.pending = append(r.pending, logRecord{Op: opAudit, Audit: &entry})
+}
+
+func (r *FileRepo) recordKey(entry idempotencyEntry) {
+	r.pending = append(r.pending, logRecord{Op: opKey, Key: &entry})
+}
+
+// flush дописывает изменения операции одной записью. Если запись не удалась, состояние
+// в памяти перечитывается из журнала, чтобы не расходиться с диском.
+func (r *FileRepo) flush() error {
+	records, changes := r.pending, r.changes
+	r.pending, r.changes = nil, nil
+	if len(records) == 0 {
+		return nil
+	}
+	if err := r.append(records); err != nil {
+		r.file.Close()
+		if rerr := r.open(); rerr != nil {
+			return errors.Join(err, rerr)
+		}
+		return err
+	}
+	if len(changes) > 0 {
+		r.publisher.Publish(changes)
+	}
+	return nil
+}
+
+func (r *FileRepo) append(records []logRecord) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, rec := range records {
+		if err := enc.Encode(rec); err != nil {
+			return err
+		}
+	}
+	if _, err := r.file.Write(buf.Bytes()); err != nil {
+		return fmt.Errorf("write log: %w", err)
+	}
+	if err := r.file.Sync(); err != nil {
+		return fmt.Errorf("sync log: %w", err)
+	}
+	for _, rec := range records {
+		r.records += rec.size()
+	}
+	if r.needCompaction() {
+		// изменения уже на диске; если сжать не удалось, журнал остаётся прежним и попытка повторится
+		_ = r.compact()
+	}
+	return nil
+}
+
+func (r *FileRepo) needCompaction() bool {
+	if r.compactThreshold <= 0 || r.records < r.compactThreshold {
+		return false
+	}
+	// живые записи — события, их история и ключи идемпотентности; каждое изменение добавляет одну запись истории
+	// и вытесняет один put, поэтому журнал сжимается, когда устаревших записей больше половины живых
+	live := len(r.mem.events()) + r.mem.historySize() + len(r.mem.keys())
+	return r.records > live+live/2
+}
+
+// compact переписывает журнал снимком текущего состояния через временный файл и rename
+func (r *FileRepo) compact() error {
+	tmpPath := r.path + ".tmp"
+	tmp, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("compact log: %w", err)
+	}
+	w := bufio.NewWriter(tmp)
+	enc := json.NewEncoder(w)
+	events := r.mem.events()
+	for _, e := range events {
+		if err := enc.Encode(logRecord{Op: opPut, Event: e}); err != nil {
+			tmp.Close()
+			return fmt.Errorf("compact log: %w", err)
+		}
+	}
+	// история пишется в порядке изменений, чтобы при проигрывании удаления шли по времени
+	entries := r.mem.auditEntries()
+	for i := range entries {
+		if err := enc.Encode(logRecord{Op: opAudit, Audit: &entries[i]}); err != nil {
+			tmp.Close()
+			return fmt.Errorf("compact log: %w", err)
+		}
+	}
+	// ключи, которые ещё не истекли, переживают сжатие вместе с событиями
+	keys := r.mem.keys()
+	for i := range keys {
+		if err := enc.Encode(logRecord{Op: opKey, Key: &keys[i]}); err != nil {
+			tmp.Close()
+			return fmt.Errorf("compact log: %w", err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("compact log: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("compact log: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("compact log: %w", err)
+	}
+	if err := os.Rename(tmpPath, r.path); err != nil {
+		return fmt.Errorf("compact log: %w", err)
+	}
+	file, err := os.OpenFile(r.path, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("compact log: %w", err)
+	}
+	r.file.Close()
+	r.file = file
+	r.records = len(events) + len(entries) + len(keys)
+	return nil
+}
+
+// replay восстанавливает состояние из журнала в mem и возвращает число записей.
+// Недописанная последняя строка (например, после падения) отрезается.
+func (r *FileRepo) replay(mem *InMemoryRepo) (int, error) {
+	file, err := os.OpenFile(r.path, os.O_RDWR, 0644)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	var offset int64
+	records := 0
+	for {
+		line, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			if len(bytes.TrimSpace(line)) > 0 {
+				return records, file.Truncate(offset)
+			}
+			return records, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		offset += int64(len(line))
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		var rec logRecord
+		if err := json.Unmarshal(line, &rec); err != nil {
+			return 0, fmt.Errorf("corrupted log %s at offset %d: %w", r.path, offset-int64(len(line)), err)
+		}
+		if err := apply(mem, rec); err != nil {
+			return 0, fmt.Errorf("corrupted log %s at offset %d: %w", r.path, offset-int64(len(line)), err)
+		}
+		records += rec.size()
+	}
+}
+
+func apply(mem *InMemoryRepo, rec logRecord) error {
+	switch rec.Op {
+	case opPut:
+		if rec.Event == nil {
+			return errors.New("put record without event")
+		}
+		mem.put(rec.Event)
+	case opDelete:
+		uid, err := uuid.Parse(rec.EventId)
+		if err != nil {
+			return err
+		}
+		mem.remove(rec.UserID, uid)
+	case opAudit:
+		if rec.Audit == nil {
+			return errors.New("audit record without entry")
+		}
+		mem.addHistory(*rec.Audit)
+	case opKey:
+		if rec.Key == nil {
+			return errors.New("key record without entry")
+		}
+		mem.addKey(*rec.Key)
+	case opBatch:
+		for _, nested := range rec.Records {
+			if err := apply(mem, nested); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("unknown op %q", rec.Op)
+	}
+	return nil
+}
@@ -0,0 +1,310 @@
+package repository
+
+import (
+	"calendar/internal/app"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newFileRepo(t *testing.T, path string, threshold int) *FileRepo {
+	t.Helper()
+	r, err := NewFileRepo(path, threshold)
+	if err != nil {
+		t.Fatalf("NewFileRepo failed: %v", err)
+	}
+	t.Cleanup(func() { r.Close() })
+	return r
+}
+
+func TestFileRepoSaveDeleteUpdateLoad(t *testing.T) {
+	r := newFileRepo(t, filepath.Join(t.TempDir(), "events.log"), 0)
+
+	ev, err := r.Save(newReq(10, "2025-05-05", "hello"))
+	if err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if ev.UserID != 10 {
+		t.Fatalf("unexpected userid")
+	}
+
+	ue, err := r.Update(&app.EventRequest{EventId: ev.EventId.String(), UserID: ev.UserID, EventText: "newtext"})
+	if err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if ue.EventText != "newtext" {
+		t.Fatalf("Update did not change text")
+	}
+
+	if _, err = r.Update(&app.EventRequest{EventId: ev.EventId.String(), UserID: ev.UserID}); err == nil {
+		t.Fatalf("expected error when nothing to update")
+	}
+
+	dt, _ := time.Parse("2006-01-02", "2025-05-05")
+	for name, load := range map[string]func(int, time.Time) ([]*app.Event, error){
+		"LoadDay":   r.LoadDay,
+		"LoadWeek":  r.LoadWeek,
+		"LoadMonth": r.LoadMonth,
+	} {
+		list, err := load(ev.UserID, dt)
+		if err != nil {
+			t.Fatalf("%s err: %v", name, err)
+		}
+		if len(list) != 1 {
+			t.Fatalf("expected 1 event for %s, got %d", name, len(list))
+		}
+	}
+
+	if err := r.Delete(&app.EventRequest{EventId: ev.EventId.String(), UserID: ev.UserID}); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if err := r.Delete(&app.EventRequest{EventId: ev.EventId.String(), UserID: ev.UserID}); err == nil {
+		t.Fatalf("expected error when deleting non-existent event")
+	}
+}
+
+func TestFileRepoInvalidUUID(t *testing.T) {
+	r := newFileRepo(t, filepath.Join(t.TempDir(), "events.log"), 0)
+	if _, err := r.Update(&app.EventRequest{EventId: "bad-uuid", UserID: 1, Date: "2025-01-01"}); err == nil {
+		t.Fatal("expected error for invalid uuid in Update")
+	}
+	if err := r.Delete(&app.EventRequest{EventId: "not-uuid", UserID: 1}); err == nil {
+		t.Fatal("expected error for invalid uuid in Delete")
+	}
+}
+
+func TestFileRepoSurvivesReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.log")
+	r, err := NewFileRepo(path, 0)
+	if err != nil {
+		t.Fatalf("NewFileRepo failed: %v", err)
+	}
+	kept, _ := r.Save(newReq(1, "2025-05-05", "kept"))
+	deleted, _ := r.Save(newReq(1, "2025-05-05", "deleted"))
+	if _, err := r.Update(&app.EventRequest{EventId: kept.EventId.String(), UserID: 1, Date: "2025-05-06"}); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if err := r.Delete(&app.EventRequest{EventId: deleted.EventId.String(), UserID: 1}); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	r.Close()
+
+	r2 := newFileRepo(t, path, 0)
+	dt, _ := time.Parse("2006-01-02", "2025-05-06")
+	list, err := r2.LoadDay(1, dt)
+	if err != nil {
+		t.Fatalf("LoadDay err: %v", err)
+	}
+	if len(list) != 1 || list[0].EventId != kept.EventId || list[0].EventText != "kept" {
+		t.Fatalf("unexpected events after reopen: %+v", list)
+	}
+	listM, _ := r2.LoadMonth(1, dt)
+	if len(listM) != 1 {
+		t.Fatalf("deleted event restored after reopen: %d events", len(listM))
+	}
+}
+
+func TestFileRepoCompaction(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.log")
+	r := newFileRepo(t, path, 10)
+	ev, _ := r.Save(newReq(1, "2025-05-05", "v0"))
+	for i := 0; i < 50; i++ {
+		if _, err := r.Update(&app.EventRequest{EventId: ev.EventId.String(), UserID: 1, EventText: "v"}); err != nil {
+			t.Fatalf("Update failed: %v", err)
+		}
+	}
+	// после сжатия в журнале остаются событие и его история (51 запись), а не все 101 запись изменений
+	if live := 1 + 51; r.records > live+live/2 {
+		t.Fatalf("log was not compacted: %d records", r.records)
+	}
+	r.Close()
+
+	r2 := newFileRepo(t, path, 10)
+	dt, _ := time.Parse("2006-01-02", "2025-05-05")
+	list, _ := r2.LoadDay(1, dt)
+	if len(list) != 1 || list[0].EventText != "v" {
+		t.Fatalf("unexpected events after compaction: %+v", list)
+	}
+}
+
+func TestFileRepoKeepsIdempotencyKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.log")
+	r, err := NewFileRepo(path, 0)
+	if err != nil {
+		t.Fatalf("NewFileRepo failed: %v", err)
+	}
+	req := func(key, text string) *app.EventRequest {
+		er := newReq(1, "2025-05-05", text)
+		er.IdempotencyKey = key
+		return er
+	}
+	compacted, _ := r.Save(req("k1", "compacted"))
+	if err := r.compact(); err != nil {
+		t.Fatalf("compact failed: %v", err)
+	}
+	appended, _ := r.Save(req("k2", "appended"))
+	r.Close()
+
+	r2 := newFileRepo(t, path, 0)
+	// ключ переживает и сжатие журнала, и проигрывание дописанной записи
+	for key, want := range map[string]*app.Event{"k1": compacted, "k2": appended} {
+		if again, err := r2.Save(req(key, want.EventText)); err != nil || again.EventId != want.EventId {
+			t.Fatalf("retry with %s after reopen: %v, %v", key, again, err)
+		}
+	}
+	if _, err := r2.Save(req("k1", "other")); !errors.Is(err, app.ErrInvalidInput) {
+		t.Fatalf("expected invalid input for a reused key, got %v", err)
+	}
+}
+
+func TestFileRepoTruncatedTail(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.log")
+	r, err := NewFileRepo(path, 0)
+	if err != nil {
+		t.Fatalf("NewFileRepo failed: %v", err)
+	}
+	if _, err := r.Save(newReq(1, "2025-05-05", "ok")); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	r.Close()
+
+	f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	f.WriteString(`{"op":"put","event":{"event_id"`)
+	f.Close()
+
+	r2 := newFileRepo(t, path, 0)
+	dt, _ := time.Parse("2006-01-02", "2025-05-05")
+	list, _ := r2.LoadDay(1, dt)
+	if len(list) != 1 {
+		t.Fatalf("expected 1 event after truncated tail, got %d", len(list))
+	}
+	if _, err := r2.Save(newReq(1, "2025-05-05", "after")); err != nil {
+		t.Fatalf("Save after recovery failed: %v", err)
+	}
+}
+
+func TestFileRepoPersistsDetachedOccurrence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.log")
+	r, err := NewFileRepo(path, 0)
+	if err != nil {
+		t.Fatalf("NewFileRepo failed: %v", err)
+	}
+	rule := "FREQ=DAILY;COUNT=3"
+	series, _ := r.Save(&app.EventRequest{UserID: 1, Start: "2025-05-05T10:00:00Z", End: "2025-05-05T11:00:00Z", RRule: &rule})
+	if _, err := r.Update(&app.EventRequest{EventId: series.EventId.String(), UserID: 1, Scope: app.ScopeThis, RecurrenceId: "2025-05-06", EventText: "moved"}); err != nil {
+		t.Fatalf("Update this occurrence failed: %v", err)
+	}
+	r.Close()
+
+	r2 := newFileRepo(t, path, 0)
+	dt, _ := time.Parse("2006-01-02", "2025-05-05")
+	list, _ := r2.LoadWeek(1, dt)
+	if len(list) != 3 || list[1].EventText != "moved" || list[1].SeriesId == nil {
+		t.Fatalf("unexpected events after reopen: %+v", list)
+	}
+}
+
+func TestFileRepoPersistsInvitations(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.log")
+	r, err := NewFileRepo(path, 0)
+	if err != nil {
+		t.Fatalf("NewFileRepo failed: %v", err)
+	}
+	ev, _ := r.Save(&app.EventRequest{UserID: 1, Date: "2025-05-05", Attendees: []app.Attendee{{UserID: 2}, {UserID: 3}}})
+	if _, err := r.Respond(&app.RSVPRequest{EventId: ev.EventId.String(), UserID: 2, Status: app.RSVPAccepted}); err != nil {
+		t.Fatalf("Respond failed: %v", err)
+	}
+	r.Close()
+
+	r2 := newFileRepo(t, path, 0)
+	dt, _ := time.Parse("2006-01-02", "2025-05-05")
+	list, _ := r2.LoadDay(3, dt)
+	if len(list) != 1 || list[0].Attendee(2).Status != app.RSVPAccepted {
+		t.Fatalf("unexpected invitations after reopen: %+v", list)
+	}
+}
+
+func TestFileRepoPing(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.log")
+	r, err := NewFileRepo(path, 0)
+	if err != nil {
+		t.Fatalf("NewFileRepo failed: %v", err)
+	}
+	if err := r.Ping(); err != nil {
+		t.Fatalf("Ping failed: %v", err)
+	}
+	os.Remove(path)
+	if err := r.Ping(); err == nil {
+		t.Fatal("expected Ping error when the log is removed")
+	}
+	r.Close()
+	if err := r.Ping(); err == nil {
+		t.Fatal("expected Ping error after Close")
+	}
+}
+
+func TestFileRepoPublishesAfterWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.log")
+	r := newFileRepo(t, path, 0)
+	pub := &recordingPublisher{}
+	r.SetPublisher(pub)
+
+	ev, _ := r.Save(newReq(1, "2025-05-05", "a"))
+	if len(pub.batches) != 1 || pub.batches[0][0].Event.EventId != ev.EventId {
+		t.Fatalf("expected created change, got %+v", pub.batches)
+	}
+
+	// запись в журнал не удалась — подписчики не должны узнать о несохранённом изменении
+	r.file.Close()
+	if _, err := r.Update(&app.EventRequest{EventId: ev.EventId.String(), UserID: 1, EventText: "b"}); err == nil {
+		t.Fatal("expected write error")
+	}
+	if len(pub.batches) != 1 {
+		t.Fatalf("failed update was published: %+v", pub.batches[1:])
+	}
+}
+
+func TestFileRepoEventIdUniqueAcrossUsers(t *testing.T) {
+	testEventIdUniqueAcrossUsers(t, newFileRepo(t, filepath.Join(t.TempDir(), "events.log"), 0))
+}
+
+// после неудачной записи хранилище перечитывается из журнала; чтения в это время не должны
+// застать ни замену хранилища, ни несохранённое изменение
+func TestFileRepoReadsDuringFailedWrite(t *testing.T) {
+	r := newFileRepo(t, filepath.Join(t.TempDir(), "events.log"), 0)
+	r.Save(newReq(1, "2025-05-05", "a"))
+	day := time.Date(2025, 5, 5, 0, 0, 0, 0, time.UTC)
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				if events, _ := r.LoadDay(1, day); len(events) != 1 {
+					t.Errorf("reader saw %d events", len(events))
+					return
+				}
+			}
+		}()
+	}
+	for range 20 {
+		r.mu.Lock()
+		r.file.Close()
+		r.mu.Unlock()
+		if _, err := r.Save(newReq(1, "2025-05-05", "lost")); err == nil {
+			t.Fatal("expected write error")
+		}
+	}
+	close(stop)
+	wg.Wait()
+}
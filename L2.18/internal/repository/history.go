@@ -0,0 +1,180 @@
+package repository
+
+import (
+	"calendar/internal/app"
+	"fmt"
+	"github.com/google/uuid"
+	"sort"
+	"time"
+)
+
+const (
+	// maxHistory — сколько последних записей истории хранить на событие
+	maxHistory = 100
+	// defaultTombstoneRetention — сколько хранить удалённое событие, если срок не задан
+	defaultTombstoneRetention = 30 * 24 * time.Hour
+)
+
+// deletion — удалённое событие в порядке удаления, для истечения срока хранения
+type deletion struct {
+	id uuid.UUID
+	at time.Time
+}
+
+// SetTombstoneRetention задаёт, сколько удалённые события можно восстановить; потом они забываются вместе с историей
+func (r *InMemoryRepo) SetTombstoneRetention(d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.retention = d
+}
+
+// LoadHistory отдаёт историю события от старых записей к новым. Её видят организатор и участники,
+// а историю удалённого события — те, кто видел его до удаления.
+func (r *InMemoryRepo) LoadHistory(UserID int, EventId string) ([]app.AuditEntry, error) {
+	uid, err := uuid.Parse(EventId)
+	if err != nil {
+		return nil, app.InvalidField("event_id", err)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.purge(time.Now())
+	visible := false
+	if _, e := r.find(UserID, uid); e != nil || r.findShared(UserID, uid) != nil {
+		visible = true
+	} else if t := r.tombstone(uid); t != nil {
+		visible = t.Before.VisibleTo(UserID)
+	}
+	if !visible {
+		return nil, app.ErrNotFound
+	}
+	return append([]app.AuditEntry(nil), r.history[uid]...), nil
+}
+
+// Restore возвращает удалённое событие организатору с новой версией; выделенные вхождения, удалённые
+// вместе с серией, возвращаются вместе с ней
+func (r *InMemoryRepo) Restore(UserID int, EventId string) (*app.Event, error) {
+	uid, err := uuid.Parse(EventId)
+	if err != nil {
+		return nil, app.InvalidField("event_id", err)
+	}
+	r.mu.Lock()
+	defer r.unlock()
+	now := time.Now()
+	r.purge(now)
+	if _, e := r.find(UserID, uid); e != nil || r.findShared(UserID, uid) != nil {
+		return nil, fmt.Errorf("%w: event is not deleted", app.ErrConflict)
+	}
+	t := r.tombstone(uid)
+	if t == nil || !t.Before.VisibleTo(UserID) {
+		return nil, app.ErrNotFound
+	}
+	if t.Before.UserID != UserID {
+		return nil, app.ErrForbidden
+	}
+	if s := t.Before.SeriesId; s != nil {
+		if _, series := r.find(UserID, *s); series == nil {
+			return nil, fmt.Errorf("%w: restore the series %s first", app.ErrBusinessLogic, s)
+		}
+	}
+
+	restored := []*app.Event{t.Before.Snapshot()}
+	for _, d := range r.deletions {
+		if c := r.tombstone(d.id); c != nil && d.at.Equal(t.At) && c.Before.SeriesId != nil && *c.Before.SeriesId == uid {
+			restored = append(restored, c.Before.Snapshot())
+		}
+	}
+	for _, e := range restored {
+		e.Version++
+		if err := r.checkConflicts(e, UserID); err != nil {
+			return nil, err
+		}
+	}
+	for _, e := range restored {
+		r.Repo[UserID] = append(r.Repo[UserID], e)
+		r.indexAdd(e)
+		r.notify(opRestore, e)
+		r.audit(app.AuditRestored, UserID, now, nil, e)
+	}
+	return restored[0], nil
+}
+
+// audit записывает изменение в историю события. Вызывается под mu.
+func (r *InMemoryRepo) audit(action string, actor int, at time.Time, before, after *app.Event) {
+	entry := app.AuditEntry{Action: action, UserID: actor, At: at, Before: before.Snapshot(), After: after.Snapshot()}
+	if after != nil {
+		entry.EventId = after.EventId
+	} else {
+		entry.EventId = before.EventId
+	}
+	if u := r.undo; u != nil {
+		if _, ok := u.history[entry.EventId]; !ok {
+			u.history[entry.EventId] = r.history[entry.EventId]
+		}
+		u.entries = append(u.entries, entry)
+	}
+	r.addHistory(entry)
+	if r.onAudit != nil {
+		r.onAudit(entry)
+	}
+}
+
+func (r *InMemoryRepo) addHistory(entry app.AuditEntry) {
+	h := append(r.history[entry.EventId], entry)
+	if len(h) > maxHistory {
+		h = h[len(h)-maxHistory:]
+	}
+	r.history[entry.EventId] = h
+	if entry.Action == app.AuditDeleted {
+		r.deletions = append(r.deletions, deletion{id: entry.EventId, at: entry.At})
+	}
+}
+
+// tombstone возвращает запись об удалении, если событие удалено и ещё не забыто
+func (r *InMemoryRepo) tombstone(id uuid.UUID) *app.AuditEntry {
+	h := r.history[id]
+	if len(h) == 0 || h[len(h)-1].Action != app.AuditDeleted {
+		return nil
+	}
+	return &h[len(h)-1]
+}
+
+// purge забывает события, удалённые раньше срока хранения, вместе с их историей.
+// deletions упорядочен по времени удаления, поэтому хватает просмотра с начала.
+func (r *InMemoryRepo) purge(now time.Time) {
+	retention := r.retention
+	if retention <= 0 {
+		retention = defaultTombstoneRetention
+	}
+	i := 0
+	for ; i < len(r.deletions) && now.Sub(r.deletions[i].at) > retention; i++ {
+		d := r.deletions[i]
+		// событие могли восстановить и удалить снова — тогда срок считается от последнего удаления
+		if t := r.tombstone(d.id); t != nil && t.At.Equal(d.at) {
+			delete(r.history, d.id)
+		}
+	}
+	r.deletions = r.deletions[i:]
+}
+
+// auditEntries — все записи истории в порядке изменений, для сжатия журнала
+func (r *InMemoryRepo) auditEntries() []app.AuditEntry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var entries []app.AuditEntry
+	for _, h := range r.history {
+		entries = append(entries, h...)
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].At.Before(entries[j].At) })
+	return entries
+}
+
+// historySize — число записей истории, для решения о сжатии журнала
+func (r *InMemoryRepo) historySize() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := 0
+	for _, h := range r.history {
+		n += len(h)
+	}
+	return n
+}
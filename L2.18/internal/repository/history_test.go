@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"calendar/internal/app"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestInMemoryRepoHistoryAndRestore(t *testing.T) {
+	r := NewInMemoryRepo()
+	ev, _ := r.Save(&app.EventRequest{UserID: 1, Date: "2025-05-05", EventText: "v1", Attendees: []app.Attendee{{UserID: 2}}})
+	if _, err := r.Update(&app.EventRequest{EventId: ev.EventId.String(), UserID: 1, EventText: "v2"}); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if _, err := r.Respond(&app.RSVPRequest{EventId: ev.EventId.String(), UserID: 2, Status: app.RSVPAccepted}); err != nil {
+		t.Fatalf("Respond failed: %v", err)
+	}
+
+	h, err := r.LoadHistory(2, ev.EventId.String())
+	if err != nil {
+		t.Fatalf("LoadHistory failed: %v", err)
+	}
+	if len(h) != 3 || h[0].Action != app.AuditCreated || h[1].Action != app.AuditUpdated || h[2].Action != app.AuditResponded {
+		t.Fatalf("unexpected history %+v", h)
+	}
+	if h[0].Before != nil || h[1].Before.EventText != "v1" || h[1].After.EventText != "v2" || h[2].UserID != 2 {
+		t.Fatalf("unexpected history entries %+v", h)
+	}
+	if _, err := r.LoadHistory(3, ev.EventId.String()); !errors.Is(err, app.ErrNotFound) {
+		t.Fatalf("expected not found for a stranger, got %v", err)
+	}
+	if _, err := r.Restore(1, ev.EventId.String()); !errors.Is(err, app.ErrConflict) {
+		t.Fatalf("expected conflict when restoring a live event, got %v", err)
+	}
+
+	if err := r.Delete(&app.EventRequest{EventId: ev.EventId.String(), UserID: 1}); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	// история удалённого события доступна участнику, восстановить может только организатор
+	if h, _ = r.LoadHistory(2, ev.EventId.String()); len(h) != 4 || h[3].Action != app.AuditDeleted || h[3].After != nil {
+		t.Fatalf("unexpected history after delete %+v", h)
+	}
+	if _, err := r.Restore(2, ev.EventId.String()); !errors.Is(err, app.ErrForbidden) {
+		t.Fatalf("expected forbidden for an attendee, got %v", err)
+	}
+	if _, err := r.Save(&app.EventRequest{EventId: ev.EventId.String(), UserID: 1, Date: "2025-05-05", EventText: "new"}); !errors.Is(err, app.ErrConflict) {
+		t.Fatalf("expected conflict when reusing a deleted id, got %v", err)
+	}
+
+	restored, err := r.Restore(1, ev.EventId.String())
+	if err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	if restored.EventText != "v2" || restored.Version != h[3].Before.Version+1 {
+		t.Fatalf("unexpected restored event %+v", restored)
+	}
+	if got, err := r.LoadEvent(2, ev.EventId.String()); err != nil || got.Attendee(2).Status != app.RSVPAccepted {
+		t.Fatalf("restored event not visible to the attendee: %+v, %v", got, err)
+	}
+	if _, err := r.Restore(1, "00000000-0000-0000-0000-000000000000"); !errors.Is(err, app.ErrNotFound) {
+		t.Fatalf("expected not found for an unknown id, got %v", err)
+	}
+}
+
+func TestInMemoryRepoRestoreSeries(t *testing.T) {
+	r := NewInMemoryRepo()
+	rule := "FREQ=DAILY;COUNT=5"
+	series, _ := r.Save(&app.EventRequest{UserID: 1, Start: "2025-05-05T10:00:00Z", End: "2025-05-05T10:15:00Z", RRule: &rule, EventText: "standup"})
+	moved, err := r.Update(&app.EventRequest{EventId: series.EventId.String(), UserID: 1, Scope: app.ScopeThis, RecurrenceId: "2025-05-07", Start: "2025-05-07T12:00:00Z"})
+	if err != nil {
+		t.Fatalf("Update this occurrence failed: %v", err)
+	}
+	if err := r.Delete(&app.EventRequest{EventId: series.EventId.String(), UserID: 1}); err != nil {
+		t.Fatalf("Delete series failed: %v", err)
+	}
+	if _, err := r.Restore(1, moved.EventId.String()); !errors.Is(err, app.ErrBusinessLogic) {
+		t.Fatalf("expected error restoring an occurrence of a deleted series, got %v", err)
+	}
+	if _, err := r.Restore(1, series.EventId.String()); err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	week, _ := app.TimeParser("2025-05-05")
+	if list, _ := r.LoadWeek(1, week); len(list) != 5 || list[2].EventId != moved.EventId {
+		t.Fatalf("series not restored with its detached occurrence: %+v", list)
+	}
+}
+
+func TestInMemoryRepoTombstoneRetention(t *testing.T) {
+	r := NewInMemoryRepo()
+	r.SetTombstoneRetention(time.Millisecond)
+	ev, _ := r.Save(newReq(1, "2025-05-05", "gone"))
+	if err := r.Delete(&app.EventRequest{EventId: ev.EventId.String(), UserID: 1}); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	time.Sleep(5 * time.Millisecond)
+	if _, err := r.Restore(1, ev.EventId.String()); !errors.Is(err, app.ErrNotFound) {
+		t.Fatalf("expected not found after retention, got %v", err)
+	}
+	if _, err := r.LoadHistory(1, ev.EventId.String()); !errors.Is(err, app.ErrNotFound) {
+		t.Fatalf("expected history forgotten after retention, got %v", err)
+	}
+}
+
+func TestFileRepoPersistsHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.log")
+	r, err := NewFileRepo(path, 0)
+	if err != nil {
+		t.Fatalf("NewFileRepo failed: %v", err)
+	}
+	ev, _ := r.Save(newReq(1, "2025-05-05", "v1"))
+	r.Update(&app.EventRequest{EventId: ev.EventId.String(), UserID: 1, EventText: "v2"})
+	if err := r.Delete(&app.EventRequest{EventId: ev.EventId.String(), UserID: 1}); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	r.Close()
+
+	// история и надгробие переживают и переоткрытие, и сжатие журнала
+	r2 := newFileRepo(t, path, 1)
+	if h, err := r2.LoadHistory(1, ev.EventId.String()); err != nil || len(h) != 3 {
+		t.Fatalf("unexpected history after reopen: %+v, %v", h, err)
+	}
+	if _, err := r2.Restore(1, ev.EventId.String()); err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	r2.Close()
+
+	r3 := newFileRepo(t, path, 0)
+	got, err := r3.LoadEvent(1, ev.EventId.String())
+	if err != nil || got.EventText != "v2" {
+		t.Fatalf("restored event lost after reopen: %+v, %v", got, err)
+	}
+	if h, _ := r3.LoadHistory(1, ev.EventId.String()); len(h) != 4 || h[3].Action != app.AuditRestored {
+		t.Fatalf("unexpected history after restore: %+v", h)
+	}
+}
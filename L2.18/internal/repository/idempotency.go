@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"calendar/internal/app"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/google/uuid"
+	"time"
+)
+
+const (
+	// idempotencyTTL — сколько помнить Idempotency-Key; FileRepo пишет ключи в журнал, InMemoryRepo теряет их при перезапуске
+	idempotencyTTL       = 24 * time.Hour
+	maxIdempotencyKeyLen = 255
+)
+
+type idempotencyKey struct {
+	user int
+	key  string
+}
+
+// idempotencyEntry — ключ, созданное с ним событие и хеш запроса; в таком виде ключ пишется в журнал
+type idempotencyEntry struct {
+	UserID  int       `json:"user_id"`
+	Key     string    `json:"key"`
+	EventId uuid.UUID `json:"event_id"`
+	Hash    string    `json:"hash"`
+	At      time.Time `json:"at"`
+}
+
+func (e idempotencyEntry) key() idempotencyKey {
+	return idempotencyKey{e.UserID, e.Key}
+}
+
+// requestHash — хеш тела запроса; служебные поля вроде If-Match и самого ключа в JSON не попадают
+func requestHash(er *app.EventRequest) string {
+	// EventRequest состоит из строк, чисел и срезов, ошибки сериализации быть не может
+	data, _ := json.Marshal(er)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// created возвращает событие, уже созданное с ключом запроса, если оно ещё существует.
+// Тот же ключ с другим запросом — ошибка валидации. Вызывается под mu.
+func (r *InMemoryRepo) created(er *app.EventRequest) (*app.Event, error) {
+	if er.IdempotencyKey == "" {
+		return nil, nil
+	}
+	if len(er.IdempotencyKey) > maxIdempotencyKeyLen {
+		return nil, app.InvalidField("idempotency_key", "idempotency key is too long")
+	}
+	r.expireKeys(time.Now())
+	entry, ok := r.idempotency[idempotencyKey{er.UserID, er.IdempotencyKey}]
+	if !ok {
+		return nil, nil
+	}
+	if entry.Hash != requestHash(er) {
+		return nil, app.InvalidField("idempotency_key", "idempotency key was already used with a different request")
+	}
+	_, e := r.find(er.UserID, entry.EventId)
+	return e, nil
+}
+
+// rememberKey связывает ключ запроса с созданным событием. Вызывается под mu.
+func (r *InMemoryRepo) rememberKey(er *app.EventRequest, e *app.Event) {
+	if er.IdempotencyKey == "" {
+		return
+	}
+	entry := idempotencyEntry{UserID: er.UserID, Key: er.IdempotencyKey, EventId: e.EventId, Hash: requestHash(er), At: time.Now()}
+	r.addKey(entry)
+	if r.undo != nil {
+		r.undo.keys = append(r.undo.keys, entry.key())
+	}
+	if r.onKey != nil {
+		r.onKey(entry)
+	}
+}
+
+// addKey запоминает ключ; им же FileRepo восстанавливает ключи из журнала
+func (r *InMemoryRepo) addKey(entry idempotencyEntry) {
+	r.idempotency[entry.key()] = entry
+	r.idempotencyLog = append(r.idempotencyLog, entry)
+}
+
+// expireKeys забывает ключи старше idempotencyTTL; журнал упорядочен по времени, поэтому хватает просмотра с начала
+func (r *InMemoryRepo) expireKeys(now time.Time) {
+	i := 0
+	for ; i < len(r.idempotencyLog) && now.Sub(r.idempotencyLog[i].At) > idempotencyTTL; i++ {
+		entry := r.idempotencyLog[i]
+		// ключ мог быть переиспользован после удаления события — тогда запись уже не его
+		if r.idempotency[entry.key()].EventId == entry.EventId {
+			delete(r.idempotency, entry.key())
+		}
+	}
+	r.idempotencyLog = r.idempotencyLog[i:]
+}
+
+// keys — действующие ключи в порядке создания, для сжатия журнала
+func (r *InMemoryRepo) keys() []idempotencyEntry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.expireKeys(time.Now())
+	var entries []idempotencyEntry
+	for _, entry := range r.idempotencyLog {
+		if r.idempotency[entry.key()] == entry {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"calendar/internal/app"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestInMemoryRepoIdempotentSave(t *testing.T) {
+	r := NewInMemoryRepo()
+	req := func(user int, key string) *app.EventRequest {
+		er := newReq(user, "2025-05-05", "retry me")
+		er.IdempotencyKey = key
+		return er
+	}
+
+	first, err := r.Save(req(1, "k1"))
+	if err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	again, err := r.Save(req(1, "k1"))
+	if err != nil || again.EventId != first.EventId {
+		t.Fatalf("retry must return the original event, got %v, %v", again, err)
+	}
+	if all, _ := r.LoadAll(1); len(all) != 1 {
+		t.Fatalf("retry created a duplicate: %d events", len(all))
+	}
+	// тот же ключ с другим телом — ошибка клиента, а не исходное событие
+	changed := req(1, "k1")
+	changed.EventText = "changed"
+	if _, err := r.Save(changed); !errors.Is(err, app.ErrInvalidInput) {
+		t.Fatalf("expected invalid input for a key reused with another body, got %v", err)
+	}
+
+	// ключ действует в пределах пользователя, без ключа каждый вызов создаёт событие
+	if other, _ := r.Save(req(2, "k1")); other.EventId == first.EventId {
+		t.Fatal("keys of different users must not collide")
+	}
+	a, _ := r.Save(req(1, ""))
+	b, _ := r.Save(req(1, ""))
+	if a.EventId == b.EventId {
+		t.Fatal("saves without a key must not be deduplicated")
+	}
+
+	// после удаления исходного события ключ создаёт новое
+	r.Delete(&app.EventRequest{UserID: 1, EventId: first.EventId.String()})
+	if fresh, _ := r.Save(req(1, "k1")); fresh.EventId == first.EventId {
+		t.Fatal("deleted event must not be returned")
+	}
+
+	r.expireKeys(time.Now().Add(idempotencyTTL + time.Minute))
+	if len(r.idempotency) != 0 || len(r.idempotencyLog) != 0 {
+		t.Fatalf("keys must expire: %d left", len(r.idempotency))
+	}
+
+	if _, err := r.Save(req(1, strings.Repeat("k", maxIdempotencyKeyLen+1))); !errors.Is(err, app.ErrInvalidInput) {
+		t.Fatalf("expected invalid input for a long key, got %v", err)
+	}
+}
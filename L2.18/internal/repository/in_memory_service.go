@@ -2,8 +2,11 @@ package repository
 
 import (
 	"calendar/internal/app"
+	"context"
 	"fmt"
 	"github.com/google/uuid"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 )
@@ -12,114 +15,548 @@ type Storage interface {
 	Save(er *app.EventRequest) (*app.Event, error)
 	Delete(er *app.EventRequest) error
 	Update(*app.EventRequest) (*app.Event, error)
+	// Respond записывает ответ участника на приглашение
+	Respond(rsvp *app.RSVPRequest) (*app.Event, error)
+	// Restore возвращает удалённое событие, пока не истёк срок хранения
+	Restore(UserID int, EventId string) (*app.Event, error)
 	LoadDay(UserID int, Date time.Time) ([]*app.Event, error)
 	LoadWeek(UserID int, WeekStart time.Time) ([]*app.Event, error)
 	LoadMonth(UserID int, MonthStart time.Time) ([]*app.Event, error)
+	LoadEvent(UserID int, EventId string) (*app.Event, error)
+	// LoadHistory отдаёт записи аудита события, в том числе удалённого
+	LoadHistory(UserID int, EventId string) ([]app.AuditEntry, error)
+	// LoadRange отдаёт вхождения, пересекающиеся с [from, to), страницами с курсором
+	LoadRange(UserID int, from, to time.Time, opts RangeOptions) (*EventPage, error)
+	// Search ищет события пользователя по словам и тегам, выдача упорядочена по релевантности
+	Search(UserID int, q SearchQuery) ([]SearchHit, error)
+	LoadAll(UserID int) ([]*app.Event, error)              // события и серии без развёртки вхождений
+	LoadUpcoming(from, to time.Time) ([]*app.Event, error) // вхождения всех пользователей, начинающиеся в [from, to)
+	Ping() error                                           // готовность хранилища обслуживать запросы
+	// Batch применяет операции по порядку под одной блокировкой; с atomic — все или ни одной (см. BatchResult)
+	Batch(ops []BatchOp, atomic bool) ([]BatchResult, error)
+}
+
+// ContextStorage — хранилище, которое привязывает вызовы к контексту запроса (трассировка)
+type ContextStorage interface {
+	WithContext(ctx context.Context) Storage
+}
+
+// EventCounter — хранилище, которое умеет посчитать события каждого пользователя (для метрик)
+type EventCounter interface {
+	EventCounts() map[int]int
+}
+
+// WithContext привязывает s к ctx, если хранилище это поддерживает
+func WithContext(ctx context.Context, s Storage) Storage {
+	if cs, ok := s.(ContextStorage); ok {
+		return cs.WithContext(ctx)
+	}
+	return s
 }
 
 type InMemoryRepo struct {
 	Repo map[int][]*app.Event
 	mu   sync.Mutex // Для обеспечения потокобезопасности
+
+	// index — те же события, упорядоченные по началу, чтобы выборки по интервалу не перебирали всё;
+	// событие лежит в индексе организатора и каждого участника
+	index map[int]*userIndex
+	// resources — события всех пользователей, занимающие ресурс, для проверки пересечений
+	resources map[string]*userIndex
+	// search — обратный индекс слов и тегов для Search
+	search *searchIndex
+
+	rejectConflicts bool
+
+	// idempotency — события, созданные с Idempotency-Key; idempotencyLog — те же ключи в порядке создания для истечения
+	idempotency    map[idempotencyKey]idempotencyEntry
+	idempotencyLog []idempotencyEntry
+
+	// history — записи аудита по событиям; удалённое событие хранится как последняя запись deleted,
+	// пока не истечёт retention; deletions — те же удаления по времени для истечения
+	history   map[uuid.UUID][]app.AuditEntry
+	deletions []deletion
+	retention time.Duration
+
+	// onChange вызывается под mu для каждого сохранённого (opPut) или удалённого (opDelete) события
+	onChange func(op string, e *app.Event)
+	// onAudit вызывается под mu для каждой записи истории
+	onAudit func(entry app.AuditEntry)
+	// onKey вызывается под mu для каждого нового Idempotency-Key
+	onKey func(entry idempotencyEntry)
+	// publisher получает changes — изменения текущей операции — при снятии блокировки
+	publisher Publisher
+	changes   []Change
+
+	// undo — откат атомарного пакета, пока он выполняется (см. Batch)
+	undo *batchUndo
 }
 
 func NewInMemoryRepo() *InMemoryRepo {
 	return &InMemoryRepo{
-		Repo: make(map[int][]*app.Event),
+		Repo:      make(map[int][]*app.Event),
+		index:     make(map[int]*userIndex),
+		resources: make(map[string]*userIndex),
+		search:    newSearchIndex(),
+
+		idempotency: make(map[idempotencyKey]idempotencyEntry),
+		history:     make(map[uuid.UUID][]app.AuditEntry),
 	}
 }
 
 func (r *InMemoryRepo) Save(er *app.EventRequest) (*app.Event, error) {
-	e, err := app.NewEvent(er)
+	r.mu.Lock()
+	defer r.unlock()
+	return r.save(er)
+}
 
+// Delete удаляет событие целиком (вместе с выделенными вхождениями серии),
+// а при scope=this — только вхождение recurrence_id
+func (r *InMemoryRepo) Delete(er *app.EventRequest) error {
+	r.mu.Lock()
+	defer r.unlock()
+	return r.delete(er)
+}
+
+// Update меняет событие (или всю серию), а при scope=this выделяет вхождение recurrence_id
+// в отдельное событие и меняет только его. Участник с can_edit может менять всё, кроме списка участников.
+func (r *InMemoryRepo) Update(e *app.EventRequest) (*app.Event, error) {
+	r.mu.Lock()
+	defer r.unlock()
+	return r.update(e)
+}
+
+// save, delete и update выполняют операции под mu, их же применяет Batch
+func (r *InMemoryRepo) save(er *app.EventRequest) (*app.Event, error) {
+	e, err := app.NewEvent(er)
 	if err != nil {
 		return nil, err
 	}
-
-	r.mu.Lock()
+	if original, err := r.created(er); original != nil || err != nil {
+		return original, err
+	}
+	now := time.Now()
+	r.purge(now)
+	// id событий уникален среди всех пользователей: история, удаления и поиск хранятся по нему.
+	// id удалённого события занят, пока его можно восстановить
+	if r.taken(e.EventId) {
+		return nil, app.ErrConflict
+	}
+	if err := r.checkConflicts(e, e.UserID); err != nil {
+		return nil, err
+	}
 	r.Repo[e.UserID] = append(r.Repo[e.UserID], e)
-	r.mu.Unlock()
+	r.indexAdd(e)
+	r.rememberKey(er, e)
+	r.notify(opPut, e)
+	r.audit(app.AuditCreated, er.Actor(), now, nil, e)
 	return e, nil
 }
 
-func (r *InMemoryRepo) Delete(er *app.EventRequest) error {
+func (r *InMemoryRepo) delete(er *app.EventRequest) error {
+	if err := er.CheckScope(); err != nil {
+		return err
+	}
 
 	uid, err := uuid.Parse(er.EventId)
 	if err != nil {
-		return fmt.Errorf("%w: %v", app.ErrInvalidInput, err)
+		return app.InvalidField("event_id", err)
 	}
-	r.mu.Lock()
-	defer r.mu.Unlock()
+	i, event := r.find(er.UserID, uid)
+	if event == nil {
+		// участник может отказаться от приглашения, но удалить событие — только организатор
+		if r.findShared(er.UserID, uid) != nil {
+			return app.ErrForbidden
+		}
+		return app.ErrNotFound
+	}
+	if err := er.CheckPrecondition(event); err != nil {
+		return err
+	}
+	now := time.Now()
+	r.purge(now)
+
+	if er.Scope == app.ScopeThis && event.RRule != "" {
+		t, err := event.OccurrenceAt(er.RecurrenceId)
+		if err != nil {
+			return err
+		}
+		before := event.Snapshot()
+		event.Exclude(t)
+		event.Version++
+		r.notify(opPut, event)
+		r.audit(app.AuditUpdated, er.Actor(), now, before, event)
+		return nil
+	}
+
+	// событие остаётся в истории, откуда его можно восстановить (см. Restore)
 	events := r.Repo[er.UserID]
-	for i, event := range events {
-		if event.EventId == uid {
-			r.Repo[er.UserID] = append(events[:i], events[i+1:]...)
-			return nil
+	r.Repo[er.UserID] = append(events[:i], events[i+1:]...)
+	r.indexRemove(event)
+	r.notify(opDelete, event)
+	r.audit(app.AuditDeleted, er.Actor(), now, event, nil)
+	kept := r.Repo[er.UserID][:0]
+	for _, e := range r.Repo[er.UserID] {
+		if e.SeriesId != nil && *e.SeriesId == uid {
+			r.indexRemove(e)
+			r.notify(opDelete, e)
+			r.audit(app.AuditDeleted, er.Actor(), now, e, nil)
+			continue
 		}
+		kept = append(kept, e)
 	}
-	return fmt.Errorf("%w: %v", app.ErrBusinessLogic, "event not found")
+	r.Repo[er.UserID] = kept
+	return nil
 }
 
-func (r *InMemoryRepo) Update(e *app.EventRequest) (*app.Event, error) {
-	if e.Date == "" && e.EventText == "" {
+func (r *InMemoryRepo) update(e *app.EventRequest) (*app.Event, error) {
+	if !e.HasChanges() {
 		return nil, fmt.Errorf("%w: %v", app.ErrBusinessLogic, "nothing to update")
 	}
+	if err := e.CheckScope(); err != nil {
+		return nil, err
+	}
 
 	uid, err := uuid.Parse(e.EventId)
 	if err != nil {
-		return nil, fmt.Errorf("%w: %v", app.ErrInvalidInput, err)
+		return nil, app.InvalidField("event_id", err)
 	}
-	r.mu.Lock()
-	defer r.mu.Unlock()
-	events := r.Repo[e.UserID]
-	for _, event := range events {
-		if event.EventId == uid {
-			err := event.Update(e.Date, e.EventText)
-			if err != nil {
-				return nil, err
-			}
-			return event, nil
+	_, event := r.find(e.UserID, uid)
+	if event == nil {
+		if event = r.findShared(e.UserID, uid); event == nil {
+			return nil, app.ErrNotFound
+		}
+		if !event.EditableBy(e.UserID) || e.Attendees != nil {
+			return nil, app.ErrForbidden
 		}
 	}
-	return nil, fmt.Errorf("%w: %v", app.ErrBusinessLogic, "event not found")
+	if err := e.CheckPrecondition(event); err != nil {
+		return nil, err
+	}
+	owner := event.UserID
+	now := time.Now()
+	r.purge(now)
+
+	if e.Scope == app.ScopeThis && event.RRule != "" {
+		if e.RRule != nil || e.ExDates != nil {
+			return nil, app.InvalidField("rrule", "recurrence cannot be changed for a single occurrence")
+		}
+		t, err := event.OccurrenceAt(e.RecurrenceId)
+		if err != nil {
+			return nil, err
+		}
+		series := *event
+		series.Version++
+		occ := series.Detach(t)
+		if err := occ.Apply(e); err != nil {
+			return nil, err
+		}
+		if err := r.checkConflicts(occ, e.UserID); err != nil {
+			return nil, err
+		}
+		before := event.Snapshot()
+		*event = series
+		r.Repo[owner] = append(r.Repo[owner], occ)
+		r.indexAdd(occ)
+		r.notify(opPut, event)
+		r.notify(opPut, occ)
+		r.audit(app.AuditUpdated, e.Actor(), now, before, event)
+		r.audit(app.AuditCreated, e.Actor(), now, nil, occ)
+		return occ, nil
+	}
+
+	next := *event
+	if err := next.Apply(e); err != nil {
+		return nil, err
+	}
+	next.Version++
+	if err := r.checkConflicts(&next, e.UserID); err != nil {
+		return nil, err
+	}
+	// начало, правило и ресурс могут поменяться, поэтому событие переставляется в индексах
+	before := event.Snapshot()
+	r.indexRemove(event)
+	*event = next
+	r.indexAdd(event)
+	r.notify(opPut, event)
+	r.audit(app.AuditUpdated, e.Actor(), now, before, event)
+	return event, nil
 }
 
 func (r *InMemoryRepo) LoadDay(UserID int, Date time.Time) ([]*app.Event, error) {
+	from, to := app.DayRange(Date)
+	return r.loadRange(UserID, from, to), nil
+}
+
+func (r *InMemoryRepo) LoadWeek(UserID int, Date time.Time) ([]*app.Event, error) {
+	from, to := app.WeekRange(Date)
+	return r.loadRange(UserID, from, to), nil
+}
+
+func (r *InMemoryRepo) LoadMonth(UserID int, Date time.Time) ([]*app.Event, error) {
+	from, to := app.MonthRange(Date)
+	return r.loadRange(UserID, from, to), nil
+}
+
+func (r *InMemoryRepo) LoadEvent(UserID int, EventId string) (*app.Event, error) {
+	uid, err := uuid.Parse(EventId)
+	if err != nil {
+		return nil, app.InvalidField("event_id", err)
+	}
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	events := r.Repo[UserID]
-	var result []*app.Event
-	for _, event := range events {
-		if event.Date.Equal(Date) {
-			result = append(result, event)
-		}
+	_, event := r.find(UserID, uid)
+	if event == nil {
+		event = r.findShared(UserID, uid)
+	}
+	if event == nil {
+		return nil, app.ErrNotFound
+	}
+	return event, nil
+}
+
+// Respond меняет статус участника в событии или серии целиком; выделенные вхождения — отдельные события
+func (r *InMemoryRepo) Respond(rsvp *app.RSVPRequest) (*app.Event, error) {
+	uid, err := uuid.Parse(rsvp.EventId)
+	if err != nil {
+		return nil, app.InvalidField("event_id", err)
+	}
+	r.mu.Lock()
+	defer r.unlock()
+	event := r.findShared(rsvp.UserID, uid)
+	if event == nil {
+		return nil, app.ErrNotFound
+	}
+	before := event.Snapshot()
+	if err := event.Respond(rsvp.UserID, rsvp.Status); err != nil {
+		return nil, err
+	}
+	event.Version++
+	r.notify(opPut, event)
+	r.audit(app.AuditResponded, rsvp.Actor(), time.Now(), before, event)
+	return event, nil
+}
+
+// LoadRange упорядочивает вхождения по началу, а при равном начале — по EventId
+func (r *InMemoryRepo) LoadRange(UserID int, from, to time.Time, opts RangeOptions) (*EventPage, error) {
+	if !to.After(from) {
+		return nil, app.InvalidField("to", "to must be after from")
 	}
+	if opts.Limit < 0 {
+		return nil, app.InvalidField("limit", "limit must not be negative")
+	}
+	after, err := decodeCursor(opts.Cursor)
+	if err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	events := r.userIndex(UserID).query(from, to, after, visibleMatcher(UserID, textMatcher(opts.Text)), opts.Limit)
+	page := &EventPage{Events: events}
+	if opts.Limit > 0 && len(events) > opts.Limit {
+		page.Events = events[:opts.Limit]
+		page.NextCursor = encodeCursor(page.Events[opts.Limit-1])
+	}
+	return page, nil
+}
+
+func (r *InMemoryRepo) Search(UserID int, q SearchQuery) ([]SearchHit, error) {
+	if strings.TrimSpace(q.Text) == "" && len(q.Tags) == 0 {
+		return nil, app.InvalidField("q", "q or tag is required")
+	}
+	if q.Limit < 0 {
+		return nil, app.InvalidField("limit", "limit must not be negative")
+	}
+	tags, err := app.NormalizeTags(q.Tags)
+	if err != nil {
+		return nil, err
+	}
+	q.Tags = tags
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.search.search(UserID, q), nil
+}
+
+func (r *InMemoryRepo) LoadAll(UserID int) ([]*app.Event, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	result := append([]*app.Event(nil), r.Repo[UserID]...)
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Start.Before(result[j].Start)
+	})
 	return result, nil
 }
 
-func (r *InMemoryRepo) LoadWeek(UserID int, Date time.Time) ([]*app.Event, error) {
+func (r *InMemoryRepo) LoadUpcoming(from, to time.Time) ([]*app.Event, error) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	events := r.Repo[UserID]
-	Dy, Dw := Date.ISOWeek()
 	var result []*app.Event
-	for _, event := range events {
-		Ey, Ew := event.Date.ISOWeek()
-		if Ey == Dy && Ew == Dw {
-			result = append(result, event)
-		}
+	for user, ix := range r.index {
+		// приглашения лежат и в индексах участников, берём каждое событие только у организатора
+		own := func(e *app.Event) bool { return e.UserID == user && !e.Start.Before(from) }
+		result = append(result, ix.query(from, to, nil, own, 0)...)
 	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Start.Before(result[j].Start)
+	})
 	return result, nil
 }
 
-func (r *InMemoryRepo) LoadMonth(UserID int, Date time.Time) ([]*app.Event, error) {
+// EventCounts считает события и серии (без развёртки) каждого пользователя
+func (r *InMemoryRepo) EventCounts() map[int]int {
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	events := r.Repo[UserID]
-	var result []*app.Event
-	for _, event := range events {
-		if event.Date.Month() == Date.Month() && event.Date.Year() == Date.Year() {
-			result = append(result, event)
+	counts := make(map[int]int, len(r.Repo))
+	for user, events := range r.Repo {
+		if len(events) > 0 {
+			counts[user] = len(events)
 		}
 	}
-	return result, nil
+	return counts
+}
+
+// Ping для хранилища в памяти всегда успешен
+func (r *InMemoryRepo) Ping() error {
+	return nil
+}
+
+// loadRange отбирает события и вхождения серий, пересекающиеся с [from, to), в порядке начала
+func (r *InMemoryRepo) loadRange(UserID int, from, to time.Time) []*app.Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.userIndex(UserID).query(from, to, nil, visibleMatcher(UserID, nil), 0)
+}
+
+// put, remove и events используются файловым хранилищем для восстановления состояния из журнала
+func (r *InMemoryRepo) put(e *app.Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	events := r.Repo[e.UserID]
+	for i, event := range events {
+		if event.EventId == e.EventId {
+			r.indexRemove(event)
+			events[i] = e
+			r.indexAdd(e)
+			return
+		}
+	}
+	r.Repo[e.UserID] = append(events, e)
+	r.indexAdd(e)
+}
+
+func (r *InMemoryRepo) remove(userID int, id uuid.UUID) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.unlink(userID, id)
+}
+
+func (r *InMemoryRepo) find(userID int, id uuid.UUID) (int, *app.Event) {
+	for i, event := range r.Repo[userID] {
+		if event.EventId == id {
+			return i, event
+		}
+	}
+	return -1, nil
+}
+
+// taken сообщает, занят ли id событием любого пользователя или удалённым событием, которое ещё можно восстановить
+func (r *InMemoryRepo) taken(id uuid.UUID) bool {
+	for _, events := range r.Repo {
+		for _, event := range events {
+			if event.EventId == id {
+				return true
+			}
+		}
+	}
+	return r.tombstone(id) != nil
+}
+
+// findShared ищет событие, куда пользователь приглашён, в его индексе
+func (r *InMemoryRepo) findShared(userID int, id uuid.UUID) *app.Event {
+	ix := r.userIndex(userID)
+	for _, events := range [][]*app.Event{ix.single, ix.series} {
+		for _, e := range events {
+			if e.EventId == id && e.Attendee(userID) != nil {
+				return e
+			}
+		}
+	}
+	return nil
+}
+
+func (r *InMemoryRepo) userIndex(userID int) *userIndex {
+	ix, ok := r.index[userID]
+	if !ok {
+		ix = &userIndex{}
+		r.index[userID] = ix
+	}
+	return ix
+}
+
+func (r *InMemoryRepo) resourceIndex(resource string) *userIndex {
+	ix, ok := r.resources[resource]
+	if !ok {
+		ix = &userIndex{}
+		r.resources[resource] = ix
+	}
+	return ix
+}
+
+// indexAdd и indexRemove поддерживают индексы пользователя, ресурса и поиска; при remove начало и ресурс должны быть прежними
+func (r *InMemoryRepo) indexAdd(e *app.Event) {
+	r.search.add(e)
+	r.userIndex(e.UserID).add(e)
+	for _, a := range e.Attendees {
+		r.userIndex(a.UserID).add(e)
+	}
+	if e.Resource != "" {
+		r.resourceIndex(e.Resource).add(e)
+	}
+}
+
+func (r *InMemoryRepo) indexRemove(e *app.Event) {
+	r.search.remove(e)
+	r.userIndex(e.UserID).remove(e)
+	for _, a := range e.Attendees {
+		r.userIndex(a.UserID).remove(e)
+	}
+	if e.Resource != "" {
+		r.resourceIndex(e.Resource).remove(e)
+	}
+}
+
+func (r *InMemoryRepo) notify(op string, e *app.Event) {
+	if r.onChange != nil {
+		r.onChange(op, e)
+	}
+	if r.publisher != nil {
+		r.changes = append(r.changes, newChange(op, e))
+	}
+}
+
+// SetPublisher подключает ленту изменений; nil отключает её
+func (r *InMemoryRepo) SetPublisher(p Publisher) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.publisher = p
+}
+
+// unlock публикует изменения завершившейся операции и снимает блокировку;
+// публикация под mu сохраняет порядок изменений между операциями
+func (r *InMemoryRepo) unlock() {
+	if len(r.changes) > 0 {
+		r.publisher.Publish(r.changes)
+		r.changes = nil
+	}
+	r.mu.Unlock()
+}
+
+func (r *InMemoryRepo) events() []*app.Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var result []*app.Event
+	for _, events := range r.Repo {
+		result = append(result, events...)
+	}
+	return result
 }
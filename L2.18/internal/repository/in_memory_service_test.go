@@ -2,6 +2,8 @@ package repository
 
 import (
 	"calendar/internal/app"
+	"errors"
+	"fmt"
 	"testing"
 	"time"
 )
@@ -93,3 +95,350 @@ func TestInMemoryRepoDeleteInvalidUUID(t *testing.T) {
 		t.Fatal("expected error for invalid uuid in Delete")
 	}
 }
+
+// id события, заданный клиентом, не должен пересекаться с событием или удалением другого пользователя:
+// история, удаления и поиск хранятся по id
+func TestInMemoryRepoEventIdUniqueAcrossUsers(t *testing.T) {
+	testEventIdUniqueAcrossUsers(t, NewInMemoryRepo())
+}
+
+func testEventIdUniqueAcrossUsers(t *testing.T, r Storage) {
+	ev, _ := r.Save(&app.EventRequest{UserID: 1, Date: "2025-05-05", EventText: "секретный отчёт"})
+	id := ev.EventId.String()
+
+	if _, err := r.Save(&app.EventRequest{EventId: id, UserID: 2, Date: "2025-05-06", EventText: "чужой"}); !errors.Is(err, app.ErrConflict) {
+		t.Fatalf("expected conflict for another user's id, got %v", err)
+	}
+	if _, err := r.LoadHistory(2, id); !errors.Is(err, app.ErrNotFound) {
+		t.Fatalf("history leaked to another user: %v", err)
+	}
+	if hits, _ := r.Search(1, SearchQuery{Text: "отчёт"}); len(hits) != 1 || hits[0].Event.EventId != ev.EventId {
+		t.Fatalf("owner's event lost from search: %+v", hits)
+	}
+
+	if err := r.Delete(&app.EventRequest{EventId: id, UserID: 1}); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := r.Save(&app.EventRequest{EventId: id, UserID: 2, Date: "2025-05-06"}); !errors.Is(err, app.ErrConflict) {
+		t.Fatalf("expected conflict for another user's deleted id, got %v", err)
+	}
+	if err := r.Delete(&app.EventRequest{EventId: id, UserID: 2}); !errors.Is(err, app.ErrNotFound) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+	h, err := r.LoadHistory(1, id)
+	if err != nil || len(h) != 2 || h[1].Action != app.AuditDeleted || h[1].UserID != 1 {
+		t.Fatalf("unexpected owner's history %+v, %v", h, err)
+	}
+	if _, err := r.LoadHistory(2, id); !errors.Is(err, app.ErrNotFound) {
+		t.Fatalf("deleted event's history leaked to another user: %v", err)
+	}
+}
+
+func TestInMemoryRepoLoadByOverlapInZone(t *testing.T) {
+	r := NewInMemoryRepo()
+	night, err := r.Save(&app.EventRequest{UserID: 1, Start: "2025-05-05T23:30:00+03:00", End: "2025-05-06T00:30:00+03:00", TimeZone: "Europe/Moscow"})
+	if err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	morning, _ := r.Save(&app.EventRequest{UserID: 1, Start: "2025-05-05T09:00:00+03:00", End: "2025-05-05T10:00:00+03:00"})
+
+	moscow, _ := time.LoadLocation("Europe/Moscow")
+	d5, _ := app.DateParser("2025-05-05", moscow)
+	d6, _ := app.DateParser("2025-05-06", moscow)
+
+	list, _ := r.LoadDay(1, d5)
+	if len(list) != 2 || list[0].EventId != morning.EventId || list[1].EventId != night.EventId {
+		t.Fatalf("expected both events ordered by start on 05-05, got %+v", list)
+	}
+	list, _ = r.LoadDay(1, d6)
+	if len(list) != 1 || list[0].EventId != night.EventId {
+		t.Fatalf("expected overnight event on 05-06, got %+v", list)
+	}
+
+	utc, _ := app.TimeParser("2025-05-06")
+	if list, _ = r.LoadDay(1, utc); len(list) != 0 {
+		t.Fatalf("expected no events on 05-06 UTC, got %d", len(list))
+	}
+}
+
+func TestInMemoryRepoRecurringScopes(t *testing.T) {
+	r := NewInMemoryRepo()
+	rule := "FREQ=DAILY;BYDAY=MO,TU,WE,TH,FR"
+	series, err := r.Save(&app.EventRequest{UserID: 1, Start: "2025-05-05T10:00:00Z", End: "2025-05-05T10:15:00Z", RRule: &rule, EventText: "standup"})
+	if err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	week, _ := app.TimeParser("2025-05-05")
+	list, _ := r.LoadWeek(1, week)
+	if len(list) != 5 {
+		t.Fatalf("expected 5 occurrences in the week, got %d", len(list))
+	}
+
+	moved, err := r.Update(&app.EventRequest{
+		EventId:      series.EventId.String(),
+		UserID:       1,
+		Scope:        app.ScopeThis,
+		RecurrenceId: "2025-05-07",
+		Start:        "2025-05-07T12:00:00Z",
+	})
+	if err != nil {
+		t.Fatalf("Update this occurrence failed: %v", err)
+	}
+	if moved.EventId == series.EventId || moved.Start.Hour() != 12 || moved.EventText != "standup" {
+		t.Fatalf("unexpected detached occurrence %+v", moved)
+	}
+
+	if err := r.Delete(&app.EventRequest{EventId: series.EventId.String(), UserID: 1, Scope: app.ScopeThis, RecurrenceId: "2025-05-08T10:00:00Z"}); err != nil {
+		t.Fatalf("Delete this occurrence failed: %v", err)
+	}
+	if err := r.Delete(&app.EventRequest{EventId: series.EventId.String(), UserID: 1, Scope: app.ScopeThis, RecurrenceId: "2025-05-08T10:00:00Z"}); err == nil {
+		t.Fatal("expected error when deleting an already excluded occurrence")
+	}
+
+	list, _ = r.LoadWeek(1, week)
+	if len(list) != 4 {
+		t.Fatalf("expected 4 events after edits, got %d", len(list))
+	}
+	if list[2].EventId != moved.EventId {
+		t.Fatalf("detached occurrence not in place of the original: %+v", list[2])
+	}
+
+	if _, err := r.Update(&app.EventRequest{EventId: series.EventId.String(), UserID: 1, EventText: "daily"}); err != nil {
+		t.Fatalf("Update whole series failed: %v", err)
+	}
+	day, _ := app.TimeParser("2025-05-12")
+	list, _ = r.LoadDay(1, day)
+	if len(list) != 1 || list[0].EventText != "daily" {
+		t.Fatalf("series update not visible in later occurrences: %+v", list)
+	}
+
+	if err := r.Delete(&app.EventRequest{EventId: series.EventId.String(), UserID: 1}); err != nil {
+		t.Fatalf("Delete series failed: %v", err)
+	}
+	if list, _ = r.LoadWeek(1, week); len(list) != 0 {
+		t.Fatalf("expected series and detached occurrences removed, got %d", len(list))
+	}
+}
+
+func TestInMemoryRepoLoadRangePagination(t *testing.T) {
+	r := NewInMemoryRepo()
+	for i := 0; i < 30; i++ {
+		start := time.Date(2025, 5, 1, 9, 0, 0, 0, time.UTC).Add(time.Duration(i) * 12 * time.Hour)
+		text := "task"
+		if i%3 == 0 {
+			text = "Meeting"
+		}
+		if _, err := r.Save(&app.EventRequest{UserID: 1, Start: start.Format(time.RFC3339), End: start.Add(time.Hour).Format(time.RFC3339), EventText: fmt.Sprint(text, i)}); err != nil {
+			t.Fatalf("Save failed: %v", err)
+		}
+	}
+	rule := "FREQ=DAILY;COUNT=5"
+	if _, err := r.Save(&app.EventRequest{UserID: 1, Start: "2025-05-03T08:00:00Z", End: "2025-05-03T08:30:00Z", EventText: "meeting daily", RRule: &rule}); err != nil {
+		t.Fatalf("Save series failed: %v", err)
+	}
+	// длинное событие начинается до интервала, но пересекается с ним
+	if _, err := r.Save(&app.EventRequest{UserID: 1, Start: "2025-04-01T00:00:00Z", End: "2025-05-04T00:00:00Z", EventText: "trip"}); err != nil {
+		t.Fatalf("Save long event failed: %v", err)
+	}
+	if _, err := r.Save(&app.EventRequest{UserID: 2, Date: "2025-05-05", EventText: "other user"}); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	from := time.Date(2025, 5, 3, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 5, 10, 0, 0, 0, 0, time.UTC)
+	all, err := r.LoadRange(1, from, to, RangeOptions{})
+	if err != nil {
+		t.Fatalf("LoadRange failed: %v", err)
+	}
+	// 14 одиночных событий по 12 часов, 5 вхождений серии и длинное событие
+	if len(all.Events) != 20 || all.NextCursor != "" {
+		t.Fatalf("expected 20 events without cursor, got %d, %q", len(all.Events), all.NextCursor)
+	}
+	if all.Events[0].EventText != "trip" {
+		t.Fatalf("long overlapping event missing or misplaced: %+v", all.Events[0])
+	}
+
+	var paged []*app.Event
+	opts := RangeOptions{Limit: 6}
+	for pages := 0; ; pages++ {
+		if pages > 10 {
+			t.Fatal("pagination does not terminate")
+		}
+		page, err := r.LoadRange(1, from, to, opts)
+		if err != nil {
+			t.Fatalf("LoadRange page failed: %v", err)
+		}
+		paged = append(paged, page.Events...)
+		if page.NextCursor == "" {
+			break
+		}
+		opts.Cursor = page.NextCursor
+	}
+	if len(paged) != len(all.Events) {
+		t.Fatalf("pages returned %d events, want %d", len(paged), len(all.Events))
+	}
+	for i := range paged {
+		if paged[i].EventId != all.Events[i].EventId || !paged[i].Start.Equal(all.Events[i].Start) {
+			t.Fatalf("page order differs at %d", i)
+		}
+		if i > 0 && paged[i].Start.Before(paged[i-1].Start) {
+			t.Fatalf("events are not ordered by start at %d", i)
+		}
+	}
+
+	found, _ := r.LoadRange(1, from, to, RangeOptions{Text: "MEETING"})
+	for _, e := range found.Events {
+		if e.EventText[:1] != "M" && e.EventText[:1] != "m" {
+			t.Fatalf("text filter returned %q", e.EventText)
+		}
+	}
+	if len(found.Events) != 4+5 {
+		t.Fatalf("expected 9 meetings, got %d", len(found.Events))
+	}
+
+	if _, err := r.LoadRange(1, from, to, RangeOptions{Cursor: "%%%"}); err == nil {
+		t.Fatal("expected error for invalid cursor")
+	}
+	if _, err := r.LoadRange(1, to, from, RangeOptions{}); err == nil {
+		t.Fatal("expected error for empty interval")
+	}
+}
+
+func TestInMemoryRepoIndexFollowsUpdates(t *testing.T) {
+	r := NewInMemoryRepo()
+	ev, _ := r.Save(newReq(1, "2025-05-05", "moving"))
+	rule := "FREQ=WEEKLY;COUNT=2"
+	if _, err := r.Update(&app.EventRequest{EventId: ev.EventId.String(), UserID: 1, Date: "2025-06-02", RRule: &rule}); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	may, _ := r.LoadRange(1, time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC), RangeOptions{})
+	june, _ := r.LoadRange(1, time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC), RangeOptions{})
+	if len(may.Events) != 0 || len(june.Events) != 2 {
+		t.Fatalf("index not updated: %d in May, %d in June", len(may.Events), len(june.Events))
+	}
+	if err := r.Delete(&app.EventRequest{EventId: ev.EventId.String(), UserID: 1}); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	june, _ = r.LoadRange(1, time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC), RangeOptions{})
+	if len(june.Events) != 0 {
+		t.Fatalf("deleted series still indexed: %d", len(june.Events))
+	}
+}
+
+func TestInMemoryRepoInvitations(t *testing.T) {
+	r := NewInMemoryRepo()
+	ev, err := r.Save(&app.EventRequest{
+		UserID: 1, Start: "2025-05-05T10:00:00Z", End: "2025-05-05T11:00:00Z", EventText: "planning",
+		Attendees: []app.Attendee{{UserID: 2, CanEdit: true}, {UserID: 3}},
+	})
+	if err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	id := ev.EventId.String()
+	day := time.Date(2025, 5, 5, 0, 0, 0, 0, time.UTC)
+
+	for _, user := range []int{1, 2, 3} {
+		if list, _ := r.LoadDay(user, day); len(list) != 1 {
+			t.Fatalf("user %d: expected the event in LoadDay, got %d", user, len(list))
+		}
+	}
+	if _, err := r.LoadEvent(3, id); err != nil {
+		t.Fatalf("attendee cannot load the event: %v", err)
+	}
+
+	if _, err := r.Update(&app.EventRequest{EventId: id, UserID: 3, EventText: "mine"}); !errors.Is(err, app.ErrForbidden) {
+		t.Fatalf("expected ErrForbidden for attendee without can_edit, got %v", err)
+	}
+	if err := r.Delete(&app.EventRequest{EventId: id, UserID: 2}); !errors.Is(err, app.ErrForbidden) {
+		t.Fatalf("expected ErrForbidden on delete by attendee, got %v", err)
+	}
+	if _, err := r.Update(&app.EventRequest{EventId: id, UserID: 2, Attendees: []app.Attendee{}}); !errors.Is(err, app.ErrForbidden) {
+		t.Fatalf("editor must not change attendees, got %v", err)
+	}
+	if _, err := r.Update(&app.EventRequest{EventId: id, UserID: 2, Start: "2025-05-06T10:00:00Z"}); err != nil {
+		t.Fatalf("editor update failed: %v", err)
+	}
+	if list, _ := r.LoadDay(3, day.AddDate(0, 0, 1)); len(list) != 1 {
+		t.Fatal("moved event must follow in the attendee's index")
+	}
+
+	if _, err := r.Respond(&app.RSVPRequest{EventId: id, UserID: 3, Status: app.RSVPDeclined}); err != nil {
+		t.Fatalf("Respond failed: %v", err)
+	}
+	if list, _ := r.LoadDay(3, day.AddDate(0, 0, 1)); len(list) != 0 {
+		t.Fatal("declined event must disappear from the attendee's calendar")
+	}
+	if _, err := r.Respond(&app.RSVPRequest{EventId: id, UserID: 4, Status: app.RSVPAccepted}); !errors.Is(err, app.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound for uninvited user, got %v", err)
+	}
+
+	upcoming, _ := r.LoadUpcoming(day, day.AddDate(0, 0, 7))
+	if len(upcoming) != 1 {
+		t.Fatalf("invitation must be returned once by LoadUpcoming, got %d", len(upcoming))
+	}
+}
+
+func TestInMemoryRepoVersionsAndPreconditions(t *testing.T) {
+	r := NewInMemoryRepo()
+	ev, _ := r.Save(newReq(1, "2025-05-05", "v1"))
+	if ev.Version != 1 {
+		t.Fatalf("new event must have version 1, got %d", ev.Version)
+	}
+	stale := ev.ETag()
+	id := ev.EventId.String()
+
+	updated, err := r.Update(&app.EventRequest{EventId: id, UserID: 1, EventText: "v2", IfMatch: stale})
+	if err != nil || updated.Version != 2 {
+		t.Fatalf("Update with current ETag: %v, version %d", err, updated.Version)
+	}
+	if _, err := r.Update(&app.EventRequest{EventId: id, UserID: 1, EventText: "lost", IfMatch: stale}); !errors.Is(err, app.ErrPreconditionFailed) {
+		t.Fatalf("expected ErrPreconditionFailed, got %v", err)
+	}
+	if got, _ := r.LoadEvent(1, id); got.EventText != "v2" {
+		t.Fatalf("rejected update changed the event: %q", got.EventText)
+	}
+	if err := r.Delete(&app.EventRequest{EventId: id, UserID: 1, IfMatch: stale}); !errors.Is(err, app.ErrPreconditionFailed) {
+		t.Fatalf("expected ErrPreconditionFailed on delete, got %v", err)
+	}
+	if err := r.Delete(&app.EventRequest{EventId: id, UserID: 1, IfMatch: updated.ETag()}); err != nil {
+		t.Fatalf("Delete with current ETag failed: %v", err)
+	}
+}
+
+type recordingPublisher struct {
+	batches [][]Change
+}
+
+func (p *recordingPublisher) Publish(changes []Change) {
+	p.batches = append(p.batches, changes)
+}
+
+func TestInMemoryRepoPublishesChanges(t *testing.T) {
+	r := NewInMemoryRepo()
+	pub := &recordingPublisher{}
+	r.SetPublisher(pub)
+
+	rule := "FREQ=DAILY;COUNT=3"
+	ev, _ := r.Save(&app.EventRequest{UserID: 1, Start: "2025-05-05T10:00:00Z", End: "2025-05-05T11:00:00Z", RRule: &rule})
+	if _, err := r.Update(&app.EventRequest{EventId: ev.EventId.String(), UserID: 1, EventText: "x", IfMatch: `"stale"`}); err == nil {
+		t.Fatal("expected precondition error")
+	}
+	r.Update(&app.EventRequest{EventId: ev.EventId.String(), UserID: 1, Scope: app.ScopeThis, RecurrenceId: "2025-05-06", EventText: "moved"})
+	r.Delete(&app.EventRequest{EventId: ev.EventId.String(), UserID: 1})
+
+	var got [][]string
+	for _, batch := range pub.batches {
+		var types []string
+		for _, c := range batch {
+			types = append(types, c.Type)
+		}
+		got = append(got, types)
+	}
+	want := [][]string{{ChangeCreated}, {ChangeUpdated, ChangeCreated}, {ChangeDeleted, ChangeDeleted}}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("expected batches %v, got %v", want, got)
+	}
+	if moved := pub.batches[1][1].Event; moved.EventText != "moved" || moved.SeriesId == nil {
+		t.Fatalf("unexpected detached occurrence %+v", moved)
+	}
+}
@@ -0,0 +1,44 @@
+package repository
+
+import "calendar/internal/app"
+
+// Типы изменений в ленте
+const (
+	ChangeCreated = "created"
+	ChangeUpdated = "updated"
+	ChangeDeleted = "deleted"
+)
+
+// Change — изменение события после успешной операции хранилища; Event — копия на момент изменения
+type Change struct {
+	Type  string
+	Event app.Event
+}
+
+// Publisher получает изменения каждой успешной операции одним вызовом, в порядке их применения.
+// Publish вызывается под блокировкой хранилища и не должен блокироваться.
+type Publisher interface {
+	Publish(changes []Change)
+}
+
+// newChange переводит запись журнала в изменение: новое событие (и выделенное вхождение) начинается с версии 1,
+// восстановленное подписчики тоже видят как созданное
+func newChange(op string, e *app.Event) Change {
+	switch {
+	case op == opDelete:
+		return Change{Type: ChangeDeleted, Event: *e}
+	case op == opRestore || e.Version == 1:
+		return Change{Type: ChangeCreated, Event: *e}
+	default:
+		return Change{Type: ChangeUpdated, Event: *e}
+	}
+}
+
+// Publishers раздаёт изменения нескольким подписчикам по очереди
+type Publishers []Publisher
+
+func (p Publishers) Publish(changes []Change) {
+	for _, pub := range p {
+		pub.Publish(changes)
+	}
+}
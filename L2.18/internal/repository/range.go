@@ -0,0 +1,181 @@
+package repository
+
+import (
+	"bytes"
+	"calendar/internal/app"
+	"encoding/base64"
+	"github.com/google/uuid"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// floatingPad — запас на события на весь день: они сравниваются в зоне интервала, а хранятся в своей
+const floatingPad = 24 * time.Hour
+
+// RangeOptions — фильтр и страница для LoadRange
+type RangeOptions struct {
+	Text   string // подстрока EventText без учёта регистра
+	Cursor string // NextCursor предыдущей страницы
+	Limit  int    // 0 — без ограничения
+}
+
+// EventPage — страница вхождений в порядке начала
+type EventPage struct {
+	Events     []*app.Event `json:"events"`
+	NextCursor string       `json:"next_cursor,omitempty"` // пусто на последней странице
+}
+
+// position — место вхождения в выдаче: начало, а при равном начале — EventId
+type position struct {
+	start time.Time
+	id    uuid.UUID
+}
+
+func positionOf(e *app.Event) position {
+	return position{start: e.Start, id: e.EventId}
+}
+
+func (p position) less(q position) bool {
+	if !p.start.Equal(q.start) {
+		return p.start.Before(q.start)
+	}
+	return bytes.Compare(p.id[:], q.id[:]) < 0
+}
+
+func eventLess(a, b *app.Event) bool {
+	return positionOf(a).less(positionOf(b))
+}
+
+// encodeCursor и decodeCursor — непрозрачный курсор на последнее выданное вхождение
+func encodeCursor(e *app.Event) string {
+	raw := strconv.FormatInt(e.Start.UnixNano(), 10) + "|" + e.EventId.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeCursor(s string) (*position, error) {
+	if s == "" {
+		return nil, nil
+	}
+	invalid := app.InvalidField("cursor", "invalid cursor")
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, invalid
+	}
+	ns, id, _ := strings.Cut(string(raw), "|")
+	n, err := strconv.ParseInt(ns, 10, 64)
+	if err != nil {
+		return nil, invalid
+	}
+	uid, err := uuid.Parse(id)
+	if err != nil {
+		return nil, invalid
+	}
+	return &position{start: time.Unix(0, n).UTC(), id: uid}, nil
+}
+
+// userIndex — события пользователя, упорядоченные по началу. Серии лежат отдельно:
+// их вхождения всё равно приходится разворачивать на каждом запросе.
+type userIndex struct {
+	single  []*app.Event
+	series  []*app.Event
+	maxSpan time.Duration // самое длинное одиночное событие: насколько раньше from может начаться пересекающееся
+}
+
+func (ix *userIndex) add(e *app.Event) {
+	if e.RRule != "" {
+		ix.series = append(ix.series, e)
+		return
+	}
+	if span := e.End.Sub(e.Start); span > ix.maxSpan {
+		ix.maxSpan = span
+	}
+	p := positionOf(e)
+	i := sort.Search(len(ix.single), func(i int) bool { return p.less(positionOf(ix.single[i])) })
+	ix.single = append(ix.single, nil)
+	copy(ix.single[i+1:], ix.single[i:])
+	ix.single[i] = e
+}
+
+// remove ищет событие по EventId; e должно иметь то же начало, что и при add
+func (ix *userIndex) remove(e *app.Event) {
+	for i, s := range ix.series {
+		if s.EventId == e.EventId {
+			ix.series = append(ix.series[:i], ix.series[i+1:]...)
+			return
+		}
+	}
+	i := sort.Search(len(ix.single), func(i int) bool { return !ix.single[i].Start.Before(e.Start) })
+	for ; i < len(ix.single) && ix.single[i].Start.Equal(e.Start); i++ {
+		if ix.single[i].EventId == e.EventId {
+			ix.single = append(ix.single[:i], ix.single[i+1:]...)
+			return
+		}
+	}
+}
+
+// query возвращает вхождения, пересекающиеся с [from, to), строго после after и подходящие под match.
+// При limit > 0 возвращается не больше limit+1 вхождений — лишнее показывает, что есть следующая страница.
+func (ix *userIndex) query(from, to time.Time, after *position, match func(*app.Event) bool, limit int) []*app.Event {
+	accept := func(e *app.Event) bool {
+		return (after == nil || after.less(positionOf(e))) && (match == nil || match(e))
+	}
+
+	var series []*app.Event
+	for _, s := range ix.series {
+		for _, occ := range s.Occurrences(from, to) {
+			if accept(occ) {
+				series = append(series, occ)
+			}
+		}
+	}
+	sort.Slice(series, func(i, j int) bool { return eventLess(series[i], series[j]) })
+
+	lower, upper := from.Add(-ix.maxSpan-floatingPad), to.Add(floatingPad)
+	if after != nil && after.start.After(lower) {
+		lower = after.start
+	}
+	var single []*app.Event
+	i := sort.Search(len(ix.single), func(i int) bool { return !ix.single[i].Start.Before(lower) })
+	for ; i < len(ix.single) && ix.single[i].Start.Before(upper); i++ {
+		e := ix.single[i]
+		if !e.Overlaps(from, to) || !accept(e) {
+			continue
+		}
+		single = append(single, e)
+		if limit > 0 && len(single) > limit {
+			break
+		}
+	}
+
+	result := make([]*app.Event, 0, len(series)+len(single))
+	for len(series) > 0 || len(single) > 0 {
+		if len(single) == 0 || (len(series) > 0 && eventLess(series[0], single[0])) {
+			result, series = append(result, series[0]), series[1:]
+		} else {
+			result, single = append(result, single[0]), single[1:]
+		}
+		if limit > 0 && len(result) > limit {
+			break
+		}
+	}
+	return result
+}
+
+// visibleMatcher оставляет события, которые видны пользователю (см. app.Event.VisibleTo), и подходящие под match
+func visibleMatcher(userID int, match func(*app.Event) bool) func(*app.Event) bool {
+	return func(e *app.Event) bool {
+		return e.VisibleTo(userID) && (match == nil || match(e))
+	}
+}
+
+func textMatcher(text string) func(*app.Event) bool {
+	if text == "" {
+		return nil
+	}
+	text = strings.ToLower(text)
+	return func(e *app.Event) bool {
+		return strings.Contains(strings.ToLower(e.EventText), text)
+	}
+}
@@ -0,0 +1,204 @@
+package repository
+
+import (
+	"calendar/internal/app"
+	"github.com/google/uuid"
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// SearchQuery — полнотекстовый поиск: каждое слово Text должно встретиться в событии целиком или как начало слова
+type SearchQuery struct {
+	Text     string
+	Tags     []string  // событие помечено всеми тегами
+	From, To time.Time // если заданы, только события с вхождением в [From, To)
+	Limit    int       // 0 — без ограничения
+}
+
+// SearchHit — найденное событие (серия — целиком, без развёртки) и его релевантность
+type SearchHit struct {
+	Event *app.Event `json:"event"`
+	Score float64    `json:"score"`
+}
+
+// searchFields — поля, по которым ищутся слова, и вес совпадения в каждом
+var searchFields = []struct {
+	weight float64
+	text   func(e *app.Event) string
+}{
+	{3, func(e *app.Event) string { return e.Title }},
+	{3, func(e *app.Event) string { return strings.Join(e.Tags, " ") }},
+	{2, func(e *app.Event) string { return e.EventText }},
+	{1.5, func(e *app.Event) string { return e.Location }},
+	{1, func(e *app.Event) string { return e.Description }},
+}
+
+// searchIndex — обратный индекс: слово -> события и вес слова в них. Хранилище обновляет его
+// вместе с индексами по времени (indexAdd и indexRemove), поэтому он всегда соответствует событиям.
+type searchIndex struct {
+	postings map[string]map[uuid.UUID]float64
+	tagged   map[string]map[uuid.UUID]struct{}
+	docs     map[uuid.UUID]indexedEvent
+	terms    []string // слова postings по алфавиту для поиска по началу; nil — пересобрать при запросе
+}
+
+type indexedEvent struct {
+	event *app.Event
+	terms map[string]float64
+	tags  []string
+}
+
+func newSearchIndex() *searchIndex {
+	return &searchIndex{
+		postings: make(map[string]map[uuid.UUID]float64),
+		tagged:   make(map[string]map[uuid.UUID]struct{}),
+		docs:     make(map[uuid.UUID]indexedEvent),
+	}
+}
+
+// tokenize делит текст на слова в нижнем регистре; ё не отличается от е
+func tokenize(s string) []string {
+	s = strings.ReplaceAll(strings.ToLower(s), "ё", "е")
+	return strings.FieldsFunc(s, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) })
+}
+
+func (ix *searchIndex) add(e *app.Event) {
+	ix.remove(e)
+	terms := make(map[string]float64)
+	for _, f := range searchFields {
+		for _, t := range tokenize(f.text(e)) {
+			terms[t] += f.weight
+		}
+	}
+	for t, w := range terms {
+		p, ok := ix.postings[t]
+		if !ok {
+			p = make(map[uuid.UUID]float64)
+			ix.postings[t] = p
+			ix.terms = nil
+		}
+		p[e.EventId] = w
+	}
+	for _, tag := range e.Tags {
+		if ix.tagged[tag] == nil {
+			ix.tagged[tag] = make(map[uuid.UUID]struct{})
+		}
+		ix.tagged[tag][e.EventId] = struct{}{}
+	}
+	ix.docs[e.EventId] = indexedEvent{event: e, terms: terms, tags: e.Tags}
+}
+
+// remove убирает событие по его записи в индексе: к этому моменту само событие уже могло измениться
+func (ix *searchIndex) remove(e *app.Event) {
+	doc, ok := ix.docs[e.EventId]
+	if !ok {
+		return
+	}
+	for t := range doc.terms {
+		delete(ix.postings[t], e.EventId)
+		if len(ix.postings[t]) == 0 {
+			delete(ix.postings, t)
+			ix.terms = nil
+		}
+	}
+	for _, tag := range doc.tags {
+		delete(ix.tagged[tag], e.EventId)
+		if len(ix.tagged[tag]) == 0 {
+			delete(ix.tagged, tag)
+		}
+	}
+	delete(ix.docs, e.EventId)
+}
+
+// matches — события, где встречается слово word целиком или как начало слова, и вклад в их релевантность.
+// Совпадение по началу весит тем меньше, чем длиннее слово в событии; редкие слова весят больше частых.
+func (ix *searchIndex) matches(word string) map[uuid.UUID]float64 {
+	if ix.terms == nil {
+		ix.terms = make([]string, 0, len(ix.postings))
+		for t := range ix.postings {
+			ix.terms = append(ix.terms, t)
+		}
+		sort.Strings(ix.terms)
+	}
+	result := make(map[uuid.UUID]float64)
+	for i := sort.SearchStrings(ix.terms, word); i < len(ix.terms) && strings.HasPrefix(ix.terms[i], word); i++ {
+		t := ix.terms[i]
+		if t != word && len([]rune(word)) < 2 {
+			break // по одной букве ищется только слово целиком
+		}
+		idf := math.Log(1 + float64(len(ix.docs))/float64(len(ix.postings[t])))
+		closeness := math.Sqrt(float64(len(word)) / float64(len(t)))
+		for id, w := range ix.postings[t] {
+			result[id] = math.Max(result[id], w*idf*closeness)
+		}
+	}
+	return result
+}
+
+// search ищет события пользователя: свои и приглашения, от которых он не отказался.
+// Выдача — по убыванию релевантности, при равной — сначала более поздние.
+func (ix *searchIndex) search(userID int, q SearchQuery) []SearchHit {
+	scores := make(map[uuid.UUID]float64)
+	first := true
+	for _, word := range tokenize(q.Text) {
+		found := ix.matches(word)
+		if first {
+			scores, first = found, false
+			continue
+		}
+		for id := range scores {
+			if s, ok := found[id]; ok {
+				scores[id] += s
+			} else {
+				delete(scores, id)
+			}
+		}
+	}
+	if first {
+		// без текста кандидаты — помеченные первым тегом
+		if len(q.Tags) == 0 {
+			return nil
+		}
+		for id := range ix.tagged[q.Tags[0]] {
+			scores[id] = 0
+		}
+	}
+
+	to := q.To
+	if to.IsZero() {
+		to = time.Date(9999, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+	var hits []SearchHit
+	for id, score := range scores {
+		e := ix.docs[id].event
+		if !e.VisibleTo(userID) || !hasTags(e, q.Tags) {
+			continue
+		}
+		if (!q.From.IsZero() || !q.To.IsZero()) && len(e.Occurrences(q.From, to)) == 0 {
+			continue
+		}
+		hits = append(hits, SearchHit{Event: e, Score: math.Round(score*1000) / 1000})
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return eventLess(hits[j].Event, hits[i].Event)
+	})
+	if q.Limit > 0 && len(hits) > q.Limit {
+		hits = hits[:q.Limit]
+	}
+	return hits
+}
+
+func hasTags(e *app.Event, tags []string) bool {
+	for _, tag := range tags {
+		if !e.HasTag(tag) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"calendar/internal/app"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func strPtr(s string) *string { return &s }
+
+func searchTexts(hits []SearchHit) []string {
+	var texts []string
+	for _, h := range hits {
+		texts = append(texts, h.Event.EventText)
+	}
+	return texts
+}
+
+func TestSearchRanking(t *testing.T) {
+	r := NewInMemoryRepo()
+	r.Save(&app.EventRequest{UserID: 1, Date: "2025-05-05", EventText: "встреча", Title: strPtr("Ретроспектива спринта"), Tags: []string{"Работа"}})
+	r.Save(&app.EventRequest{UserID: 1, Date: "2025-05-06", EventText: "обед", Description: strPtr("обсудить ретро и планы")})
+	r.Save(&app.EventRequest{UserID: 1, Date: "2025-05-07", EventText: "спринт", Location: strPtr("Переговорная Ёлка")})
+	r.Save(&app.EventRequest{UserID: 2, Date: "2025-05-05", EventText: "чужая ретроспектива"})
+
+	hits, err := r.Search(1, SearchQuery{Text: "ретро"})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	// точное слово в описании весит меньше, чем начало слова в заголовке
+	if got := searchTexts(hits); len(got) != 2 || got[0] != "встреча" || got[1] != "обед" || hits[0].Score <= hits[1].Score {
+		t.Fatalf("unexpected ranking %v %+v", got, hits)
+	}
+	if hits, _ := r.Search(1, SearchQuery{Text: "ретро спринт"}); len(hits) != 1 || hits[0].Event.EventText != "встреча" {
+		t.Fatalf("all words must match: %v", searchTexts(hits))
+	}
+	if hits, _ := r.Search(1, SearchQuery{Text: "елк"}); len(hits) != 1 || hits[0].Event.EventText != "спринт" {
+		t.Fatalf("ё must match е: %v", searchTexts(hits))
+	}
+	if hits, _ := r.Search(1, SearchQuery{Text: "р"}); len(hits) != 0 {
+		t.Fatalf("one letter must match whole words only: %v", searchTexts(hits))
+	}
+	if hits, _ := r.Search(1, SearchQuery{Tags: []string{" РАБОТА "}}); len(hits) != 1 || hits[0].Event.EventText != "встреча" {
+		t.Fatalf("unexpected tag search: %v", searchTexts(hits))
+	}
+	if hits, _ := r.Search(1, SearchQuery{Text: "спринт", Tags: []string{"работа"}}); len(hits) != 1 {
+		t.Fatalf("tag must filter text matches: %v", searchTexts(hits))
+	}
+	if hits, _ := r.Search(1, SearchQuery{Text: "ретро", Limit: 1}); len(hits) != 1 {
+		t.Fatalf("limit ignored: %v", searchTexts(hits))
+	}
+	from, _ := time.Parse(time.DateOnly, "2025-05-06")
+	if hits, _ := r.Search(1, SearchQuery{Text: "ретро", From: from, To: from.AddDate(0, 0, 1)}); len(hits) != 1 || hits[0].Event.EventText != "обед" {
+		t.Fatalf("interval ignored: %v", searchTexts(hits))
+	}
+
+	if _, err := r.Search(1, SearchQuery{Text: " "}); !errors.Is(err, app.ErrInvalidInput) {
+		t.Fatalf("expected invalid input for an empty query, got %v", err)
+	}
+	if _, err := r.Search(1, SearchQuery{Tags: []string{"a,b"}}); !errors.Is(err, app.ErrInvalidInput) {
+		t.Fatalf("expected invalid input for a bad tag, got %v", err)
+	}
+}
+
+func TestSearchFollowsChanges(t *testing.T) {
+	r := NewInMemoryRepo()
+	e, _ := r.Save(&app.EventRequest{UserID: 1, Date: "2025-05-05", EventText: "планёрка", Tags: []string{"работа"},
+		Attendees: []app.Attendee{{UserID: 2}}})
+	id := e.EventId.String()
+
+	// приглашённый находит событие, пока не отказался
+	if hits, _ := r.Search(2, SearchQuery{Text: "планерка"}); len(hits) != 1 {
+		t.Fatalf("invitation not found: %v", searchTexts(hits))
+	}
+	r.Respond(&app.RSVPRequest{EventId: id, UserID: 2, Status: app.RSVPDeclined})
+	if hits, _ := r.Search(2, SearchQuery{Text: "планерка"}); len(hits) != 0 {
+		t.Fatalf("declined invitation found: %v", searchTexts(hits))
+	}
+
+	if _, err := r.Update(&app.EventRequest{EventId: id, UserID: 1, EventText: "созвон", Tags: []string{}}); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if hits, _ := r.Search(1, SearchQuery{Text: "планерка"}); len(hits) != 0 {
+		t.Fatalf("old text still indexed: %v", searchTexts(hits))
+	}
+	if hits, _ := r.Search(1, SearchQuery{Tags: []string{"работа"}}); len(hits) != 0 {
+		t.Fatalf("old tag still indexed: %v", searchTexts(hits))
+	}
+	if hits, _ := r.Search(1, SearchQuery{Text: "созв"}); len(hits) != 1 {
+		t.Fatalf("new text not indexed: %v", searchTexts(hits))
+	}
+
+	r.Delete(&app.EventRequest{EventId: id, UserID: 1})
+	if hits, _ := r.Search(1, SearchQuery{Text: "созвон"}); len(hits) != 0 {
+		t.Fatalf("deleted event found: %v", searchTexts(hits))
+	}
+	if _, err := r.Restore(1, id); err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	if hits, _ := r.Search(1, SearchQuery{Text: "созвон"}); len(hits) != 1 {
+		t.Fatalf("restored event not found: %v", searchTexts(hits))
+	}
+}
+
+func TestFileRepoSearchAfterReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.log")
+	r := newFileRepo(t, path, 0)
+	r.Save(&app.EventRequest{UserID: 1, Date: "2025-05-05", EventText: "отпуск", Location: strPtr("Сочи"), Tags: []string{"личное"}})
+	r.Close()
+
+	r2 := newFileRepo(t, path, 0)
+	if hits, _ := r2.Search(1, SearchQuery{Text: "соч", Tags: []string{"личное"}}); len(hits) != 1 || hits[0].Event.Location != "Сочи" {
+		t.Fatalf("index not rebuilt from the log: %+v", hits)
+	}
+}
@@ -0,0 +1,95 @@
+package rpc
+
+import (
+	"calendar/internal/app"
+	"calendar/internal/rpc/calendarpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"time"
+)
+
+// eventRequest переводит EventInput в запрос хранилища; проверяет его, как и для HTTP, app.NewEvent/Apply
+func eventRequest(in *calendarpb.EventInput) *app.EventRequest {
+	if in == nil {
+		return &app.EventRequest{}
+	}
+	er := &app.EventRequest{
+		EventId:   in.GetEventId(),
+		UserID:    int(in.GetUserId()),
+		Date:      in.GetDate(),
+		Start:     in.GetStart(),
+		End:       in.GetEnd(),
+		AllDay:    in.AllDay,
+		TimeZone:  in.GetTimeZone(),
+		EventText: in.GetText(),
+		Resource:  in.Resource,
+		RRule:     in.Rrule,
+
+		Title:       in.Title,
+		Description: in.Description,
+		Location:    in.Location,
+	}
+	if in.Attendees != nil {
+		er.Attendees = make([]app.Attendee, 0, len(in.Attendees.Items))
+		for _, a := range in.Attendees.Items {
+			er.Attendees = append(er.Attendees, app.Attendee{UserID: int(a.GetUserId()), CanEdit: a.GetCanEdit()})
+		}
+	}
+	if in.Exdates != nil {
+		er.ExDates = append([]string{}, in.Exdates.Items...)
+	}
+	if in.Tags != nil {
+		er.Tags = append([]string{}, in.Tags.Items...)
+	}
+	return er
+}
+
+func eventMessage(e *app.Event) *calendarpb.Event {
+	m := &calendarpb.Event{
+		EventId:  e.EventId.String(),
+		Version:  int64(e.Version),
+		UserId:   int64(e.UserID),
+		Date:     timestamp(e.Date),
+		Start:    timestamp(e.Start),
+		End:      timestamp(e.End),
+		AllDay:   e.AllDay,
+		TimeZone: e.TimeZone,
+		Text:     e.EventText,
+		Resource: e.Resource,
+		Rrule:    e.RRule,
+		Etag:     e.ETag(),
+
+		Title:       e.Title,
+		Description: e.Description,
+		Location:    e.Location,
+		Tags:        e.Tags,
+	}
+	for _, a := range e.Attendees {
+		m.Attendees = append(m.Attendees, &calendarpb.Attendee{UserId: int64(a.UserID), Status: a.Status, CanEdit: a.CanEdit})
+	}
+	for _, d := range e.ExDates {
+		m.Exdates = append(m.Exdates, timestamp(d))
+	}
+	if e.SeriesId != nil {
+		m.SeriesId = e.SeriesId.String()
+	}
+	if e.RecurrenceId != nil {
+		m.RecurrenceId = timestamp(*e.RecurrenceId)
+	}
+	return m
+}
+
+func eventMessages(events []*app.Event) []*calendarpb.Event {
+	out := make([]*calendarpb.Event, len(events))
+	for i, e := range events {
+		out[i] = eventMessage(e)
+	}
+	return out
+}
+
+// timestamp оставляет нулевое время незаданным
+func timestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
@@ -0,0 +1,81 @@
+package rpc
+
+import (
+	"calendar/internal/app"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+	"time"
+)
+
+// errorDomain — домен в ErrorInfo: Reason в нём — тот же код, что и в поле code ответа HTTP
+const errorDomain = "calendar"
+
+// codeStatus — gRPC-код для кода ошибки хранилища, как codeStatus в web для HTTP-статусов
+var codeStatus = map[string]codes.Code{
+	app.CodeNotFound:     codes.NotFound,
+	app.CodeConflict:     codes.AlreadyExists,
+	app.CodeForbidden:    codes.PermissionDenied,
+	app.CodePrecondition: codes.FailedPrecondition,
+	app.CodeValidation:   codes.InvalidArgument,
+	app.CodeBusinessRule: codes.FailedPrecondition,
+}
+
+// statusError переводит ошибку хранилища в статус gRPC. Код ошибки передаётся в ErrorInfo,
+// поля с ошибками — в BadRequest, пересечения — в PreconditionFailure.
+func statusError(logger *zap.Logger, err error, msg string) error {
+	code := app.ErrorCode(err)
+	if code == app.CodeInternal {
+		// подробности внутренних ошибок остаются только в логе
+		logger.Error(msg, zap.Error(err))
+		return withDetails(status.New(codes.Internal, msg), &errdetails.ErrorInfo{Reason: code, Domain: errorDomain})
+	}
+	logger.Debug(msg, zap.Error(err))
+
+	info := &errdetails.ErrorInfo{Reason: code, Domain: errorDomain}
+	st := status.New(codeStatus[code], msg+": "+err.Error())
+	var verr *app.ValidationError
+	if errors.As(err, &verr) {
+		br := &errdetails.BadRequest{}
+		for _, f := range verr.Fields {
+			br.FieldViolations = append(br.FieldViolations, &errdetails.BadRequest_FieldViolation{Field: f.Field, Description: f.Message})
+		}
+		return withDetails(st, info, br)
+	}
+	var overlap *app.OverlapError
+	if errors.As(err, &overlap) {
+		pf := &errdetails.PreconditionFailure{}
+		for _, c := range overlap.Conflicts {
+			v := &errdetails.PreconditionFailure_Violation{
+				Type:        "OVERLAP",
+				Subject:     "resource:" + c.Resource,
+				Description: fmt.Sprintf("resource %q: %s–%s", c.Resource, c.Start.Format(time.RFC3339), c.End.Format(time.RFC3339)),
+			}
+			// событие, которое вызывающий не видит, описывается только ресурсом и интервалом
+			if c.EventId != nil {
+				v.Subject = c.EventId.String()
+				v.Description = fmt.Sprintf("user %d, %s", c.UserID, v.Description)
+			}
+			pf.Violations = append(pf.Violations, v)
+		}
+		return withDetails(st, info, pf)
+	}
+	return withDetails(st, info)
+}
+
+// invalidArgument — ошибка разбора запроса до обращения к хранилищу, как 400 в HTTP
+func invalidArgument(logger *zap.Logger, err error, msg string) error {
+	logger.Warn(msg, zap.Error(err))
+	return status.Error(codes.InvalidArgument, msg+": "+err.Error())
+}
+
+func withDetails(st *status.Status, details ...protoadapt.MessageV1) error {
+	if detailed, err := st.WithDetails(details...); err == nil {
+		st = detailed
+	}
+	return st.Err()
+}
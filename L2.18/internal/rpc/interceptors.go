@@ -0,0 +1,151 @@
+package rpc
+
+import (
+	"calendar/internal/auth"
+	"calendar/internal/logger"
+	"calendar/internal/telemetry"
+	"context"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"strings"
+	"time"
+)
+
+// RequestIDKey — ключ метаданных с идентификатором запроса, как заголовок X-Request-ID в HTTP
+const RequestIDKey = "x-request-id"
+
+const maxRequestIDLen = 128
+
+// ServerOptions собирает перехватчики: трассировка, лог с request_id и, если authn задан, проверка bearer-токена
+func ServerOptions(authn *auth.Authenticator, tp trace.TracerProvider, base *zap.Logger) []grpc.ServerOption {
+	before := func(ctx context.Context, method string) (context.Context, func(err error)) {
+		ctx, finishSpan := startSpan(ctx, tp, method)
+		ctx, finishLog := startLog(ctx, base, method)
+		return ctx, func(err error) {
+			finishLog(err)
+			finishSpan(err)
+		}
+	}
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+			ctx, finish := before(ctx, info.FullMethod)
+			ctx, err := authenticate(ctx, authn)
+			var resp any
+			if err == nil {
+				resp, err = handler(ctx, req)
+			}
+			finish(err)
+			return resp, err
+		}),
+		grpc.ChainStreamInterceptor(func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			ctx, finish := before(ss.Context(), info.FullMethod)
+			ctx, err := authenticate(ctx, authn)
+			if err == nil {
+				err = handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+			}
+			finish(err)
+			return err
+		}),
+	}
+}
+
+// authenticate кладёт пользователя из токена в контекст; user_id запроса с ним сверяют методы через auth.ResolveUser
+func authenticate(ctx context.Context, authn *auth.Authenticator) (context.Context, error) {
+	if authn == nil {
+		return ctx, nil
+	}
+	token, ok := strings.CutPrefix(firstValue(ctx, "authorization"), "Bearer ")
+	if !ok || token == "" {
+		return ctx, status.Error(codes.Unauthenticated, "missing bearer token")
+	}
+	p, err := authn.Parse(token)
+	if err != nil {
+		logger.FromContext(ctx, zap.NewNop()).Warn("invalid token", zap.Error(err))
+		return ctx, status.Error(codes.Unauthenticated, "invalid token")
+	}
+	return auth.WithPrincipal(ctx, p), nil
+}
+
+// startLog привязывает к контексту логгер с request_id клиента (или новым) и возвращает его клиенту в заголовке
+func startLog(ctx context.Context, base *zap.Logger, method string) (context.Context, func(err error)) {
+	start := time.Now()
+	id := firstValue(ctx, RequestIDKey)
+	if id == "" || len(id) > maxRequestIDLen {
+		id = uuid.NewString()
+	}
+	grpc.SetHeader(ctx, metadata.Pairs(RequestIDKey, id))
+	reqLogger := base.With(zap.String("request_id", id))
+	return logger.WithContext(ctx, reqLogger), func(err error) {
+		reqLogger.Info("gRPC request",
+			zap.String("method", method),
+			zap.String("code", status.Code(err).String()),
+			zap.Duration("duration", time.Since(start)),
+		)
+	}
+}
+
+// startSpan продолжает трассу из traceparent в метаданных или начинает новую, как TracingMiddleware в web
+func startSpan(ctx context.Context, tp trace.TracerProvider, method string) (context.Context, func(err error)) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	ctx = telemetry.Propagator.Extract(ctx, metadataCarrier(md))
+	ctx, span := tp.Tracer(telemetry.InstrumentationName).Start(ctx, strings.TrimPrefix(method, "/"),
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			attribute.String("rpc.system", "grpc"),
+			attribute.String("rpc.method", method),
+		))
+	return ctx, func(err error) {
+		code := status.Code(err)
+		span.SetAttributes(attribute.Int("rpc.grpc.status_code", int(code)))
+		if code == codes.Internal || code == codes.Unknown {
+			span.SetStatus(otelcodes.Error, code.String())
+		}
+		span.End()
+	}
+}
+
+func firstValue(ctx context.Context, key string) string {
+	if v := metadata.ValueFromIncomingContext(ctx, key); len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
+
+// contextStream подменяет контекст потока, чтобы обработчик видел пользователя и логгер запроса
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
+
+// metadataCarrier позволяет пропагатору OpenTelemetry читать метаданные gRPC
+type metadataCarrier metadata.MD
+
+func (c metadataCarrier) Get(key string) string {
+	if v := metadata.MD(c).Get(key); len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
+
+func (c metadataCarrier) Set(key, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	return keys
+}
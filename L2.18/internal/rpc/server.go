@@ -0,0 +1,216 @@
+package rpc
+
+import (
+	"calendar/internal/app"
+	"calendar/internal/auth"
+	"calendar/internal/feed"
+	"calendar/internal/logger"
+	"calendar/internal/repository"
+	"calendar/internal/rpc/calendarpb"
+	"context"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+const (
+	defaultPageSize = 100
+	maxPageSize     = 1000
+)
+
+// Server — gRPC-сервис календаря поверх того же хранилища, что и HTTP API
+type Server struct {
+	calendarpb.UnimplementedCalendarServiceServer
+
+	repo   repository.Storage
+	feed   *feed.Hub // nil — WatchEvents недоступен
+	logger *zap.Logger
+}
+
+func NewServer(repo repository.Storage, hub *feed.Hub, logger *zap.Logger) *Server {
+	return &Server{
+		repo:   repo,
+		feed:   hub,
+		logger: logger,
+	}
+}
+
+func (s *Server) CreateEvent(ctx context.Context, req *calendarpb.CreateEventRequest) (*calendarpb.Event, error) {
+	er := eventRequest(req.GetEvent())
+	if err := resolveUser(ctx, &er.UserID); err != nil {
+		return nil, err
+	}
+	er.IdempotencyKey, er.ActorID = req.GetIdempotencyKey(), auth.Actor(ctx)
+	e, err := s.store(ctx).Save(er)
+	if err != nil {
+		return nil, statusError(s.log(ctx), err, "save failed")
+	}
+	s.log(ctx).Info("event created", zap.String("event_id", e.EventId.String()))
+	return eventMessage(e), nil
+}
+
+func (s *Server) GetEvent(ctx context.Context, req *calendarpb.GetEventRequest) (*calendarpb.Event, error) {
+	user := int(req.GetUserId())
+	if err := resolveUser(ctx, &user); err != nil {
+		return nil, err
+	}
+	e, err := s.store(ctx).LoadEvent(user, req.GetEventId())
+	if err != nil {
+		return nil, statusError(s.log(ctx), err, "load event failed")
+	}
+	return eventMessage(e), nil
+}
+
+func (s *Server) UpdateEvent(ctx context.Context, req *calendarpb.UpdateEventRequest) (*calendarpb.Event, error) {
+	er := eventRequest(req.GetEvent())
+	if err := resolveUser(ctx, &er.UserID); err != nil {
+		return nil, err
+	}
+	er.Scope, er.RecurrenceId, er.IfMatch = req.GetScope(), req.GetRecurrenceId(), req.GetIfMatch()
+	er.ActorID = auth.Actor(ctx)
+	e, err := s.store(ctx).Update(er)
+	if err != nil {
+		return nil, statusError(s.log(ctx), err, "update failed")
+	}
+	s.log(ctx).Info("event updated", zap.String("event_id", e.EventId.String()))
+	return eventMessage(e), nil
+}
+
+func (s *Server) DeleteEvent(ctx context.Context, req *calendarpb.DeleteEventRequest) (*emptypb.Empty, error) {
+	er := &app.EventRequest{
+		EventId:      req.GetEventId(),
+		UserID:       int(req.GetUserId()),
+		Scope:        req.GetScope(),
+		RecurrenceId: req.GetRecurrenceId(),
+		IfMatch:      req.GetIfMatch(),
+		ActorID:      auth.Actor(ctx),
+	}
+	if err := resolveUser(ctx, &er.UserID); err != nil {
+		return nil, err
+	}
+	if err := s.store(ctx).Delete(er); err != nil {
+		return nil, statusError(s.log(ctx), err, "delete failed")
+	}
+	s.log(ctx).Info("event deleted", zap.String("event_id", er.EventId))
+	return &emptypb.Empty{}, nil
+}
+
+func (s *Server) RespondEvent(ctx context.Context, req *calendarpb.RespondEventRequest) (*calendarpb.Event, error) {
+	rsvp := &app.RSVPRequest{EventId: req.GetEventId(), UserID: int(req.GetUserId()), Status: req.GetStatus(), ActorID: auth.Actor(ctx)}
+	if err := resolveUser(ctx, &rsvp.UserID); err != nil {
+		return nil, err
+	}
+	e, err := s.store(ctx).Respond(rsvp)
+	if err != nil {
+		return nil, statusError(s.log(ctx), err, "respond failed")
+	}
+	s.log(ctx).Info("invitation answered", zap.String("event_id", rsvp.EventId), zap.String("status", rsvp.Status))
+	return eventMessage(e), nil
+}
+
+func (s *Server) ListEvents(ctx context.Context, req *calendarpb.ListEventsRequest) (*calendarpb.ListEventsResponse, error) {
+	user := int(req.GetUserId())
+	if err := resolveUser(ctx, &user); err != nil {
+		return nil, err
+	}
+	loc, err := app.LocationParser(req.GetTimeZone())
+	if err != nil {
+		return nil, invalidArgument(s.log(ctx), err, "invalid time_zone")
+	}
+	from, to, err := app.BoundsParser(req.GetFrom(), req.GetTo(), loc)
+	if err != nil {
+		return nil, invalidArgument(s.log(ctx), err, "invalid interval")
+	}
+	limit := int(req.GetLimit())
+	if limit == 0 {
+		limit = defaultPageSize
+	}
+	if limit < 1 || limit > maxPageSize {
+		return nil, status.Errorf(codes.InvalidArgument, "limit must be between 1 and %d", maxPageSize)
+	}
+
+	page, err := s.store(ctx).LoadRange(user, from, to, repository.RangeOptions{
+		Text:   req.GetQuery(),
+		Cursor: req.GetCursor(),
+		Limit:  limit,
+	})
+	if err != nil {
+		return nil, statusError(s.log(ctx), err, "events for range load failed")
+	}
+	s.log(ctx).Info("events fetched", zap.String("Period", "Range"), zap.Int("user_id", user))
+	return &calendarpb.ListEventsResponse{Events: eventMessages(page.Events), NextCursor: page.NextCursor}, nil
+}
+
+// WatchEvents отдаёт изменения, как /events/stream: с last_id сначала пропущенное, а если его уже
+// не восстановить — изменение reset. Поток завершается с Unavailable, когда клиент отстал или сервер
+// останавливается; клиент переподключается с id последнего полученного изменения.
+func (s *Server) WatchEvents(req *calendarpb.WatchEventsRequest, stream calendarpb.CalendarService_WatchEventsServer) error {
+	ctx := stream.Context()
+	if s.feed == nil {
+		return status.Error(codes.Unimplemented, "change feed is disabled")
+	}
+	user := int(req.GetUserId())
+	if err := resolveUser(ctx, &user); err != nil {
+		return err
+	}
+	sub, err := s.feed.Subscribe(user, req.GetLastId(), req.LastId != nil)
+	if errors.Is(err, feed.ErrClosed) {
+		return status.Error(codes.Unavailable, err.Error())
+	}
+	if err != nil {
+		return statusError(s.log(ctx), err, "subscribe failed")
+	}
+	defer sub.Close()
+	s.log(ctx).Info("stream opened", zap.Int("user_id", user), zap.Int("missed", len(sub.Missed)), zap.Bool("lost", sub.Lost))
+
+	if sub.Lost {
+		if err := stream.Send(&calendarpb.EventChange{Id: sub.Head, Type: "reset"}); err != nil {
+			return err
+		}
+	}
+	for _, m := range sub.Missed {
+		if err := stream.Send(changeMessage(m)); err != nil {
+			return err
+		}
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case m, ok := <-sub.Events():
+			if !ok {
+				s.log(ctx).Info("stream closed by server", zap.Int("user_id", user))
+				return status.Error(codes.Unavailable, "stream closed by server, reconnect with last_id")
+			}
+			if err := stream.Send(changeMessage(m)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func changeMessage(m feed.Message) *calendarpb.EventChange {
+	return &calendarpb.EventChange{Id: m.ID, Type: m.Type, Event: eventMessage(&m.Event)}
+}
+
+// store привязывает хранилище к контексту вызова, чтобы спаны хранилища попали в трассу
+func (s *Server) store(ctx context.Context) repository.Storage {
+	return repository.WithContext(ctx, s.repo)
+}
+
+func (s *Server) log(ctx context.Context) *zap.Logger {
+	return logger.FromContext(ctx, s.logger)
+}
+
+// resolveUser подставляет пользователя из токена и запрещает работу с чужими событиями
+func resolveUser(ctx context.Context, user *int) error {
+	resolved, err := auth.ResolveUser(ctx, *user)
+	if err != nil {
+		return status.Error(codes.PermissionDenied, fmt.Sprintf("user_id does not match token: %v", err))
+	}
+	*user = resolved
+	return nil
+}
@@ -0,0 +1,234 @@
+package rpc
+
+import (
+	"calendar/internal/app"
+	"calendar/internal/auth"
+	"calendar/internal/feed"
+	"calendar/internal/repository"
+	"calendar/internal/rpc/calendarpb"
+	"context"
+	"go.opentelemetry.io/otel/trace/noop"
+	"go.uber.org/zap"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newClient(t *testing.T, repo *repository.InMemoryRepo, hub *feed.Hub, authn *auth.Authenticator) calendarpb.CalendarServiceClient {
+	t.Helper()
+	ln := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer(ServerOptions(authn, noop.NewTracerProvider(), zap.NewNop())...)
+	calendarpb.RegisterCalendarServiceServer(srv, NewServer(repo, hub, zap.NewNop()))
+	go srv.Serve(ln)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return ln.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() {
+		conn.Close()
+		if hub != nil {
+			hub.Close()
+		}
+		srv.Stop()
+	})
+	return calendarpb.NewCalendarServiceClient(conn)
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func TestEventLifecycle(t *testing.T) {
+	c := newClient(t, repository.NewInMemoryRepo(), nil, nil)
+	ctx := context.Background()
+
+	created, err := c.CreateEvent(ctx, &calendarpb.CreateEventRequest{Event: &calendarpb.EventInput{
+		UserId:    5,
+		Start:     "2025-05-05T14:00:00Z",
+		End:       "2025-05-05T15:00:00Z",
+		Text:      "review",
+		Attendees: &calendarpb.AttendeeList{Items: []*calendarpb.Attendee{{UserId: 6}}},
+		Title:     ptr("Q3"),
+		Location:  ptr("Room 1"),
+		Tags:      &calendarpb.StringList{Items: []string{"Work", "work", "team"}},
+	}})
+	if err != nil {
+		t.Fatalf("CreateEvent failed: %v", err)
+	}
+	if created.UserId != 5 || created.Version != 1 || created.Start.AsTime().Hour() != 14 || len(created.Attendees) != 1 ||
+		created.Title != "Q3" || created.Location != "Room 1" || strings.Join(created.Tags, ",") != "work,team" {
+		t.Fatalf("unexpected created event %+v", created)
+	}
+
+	got, err := c.GetEvent(ctx, &calendarpb.GetEventRequest{UserId: 6, EventId: created.EventId})
+	if err != nil || got.Text != "review" {
+		t.Fatalf("attendee cannot get the event: %+v, %v", got, err)
+	}
+
+	updated, err := c.UpdateEvent(ctx, &calendarpb.UpdateEventRequest{
+		Event: &calendarpb.EventInput{EventId: created.EventId, UserId: 5, Text: "retro", Resource: ptr("room-1"),
+			Description: ptr("agenda"), Location: ptr(""), Tags: &calendarpb.StringList{}},
+		IfMatch: created.Etag,
+	})
+	if err != nil || updated.Text != "retro" || updated.Resource != "room-1" || updated.Version != 2 ||
+		updated.Title != "Q3" || updated.Description != "agenda" || updated.Location != "" || len(updated.Tags) != 0 {
+		t.Fatalf("unexpected update result %+v, %v", updated, err)
+	}
+	_, err = c.UpdateEvent(ctx, &calendarpb.UpdateEventRequest{Event: &calendarpb.EventInput{EventId: created.EventId, UserId: 5, Text: "stale"}, IfMatch: created.Etag})
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("expected FailedPrecondition for a stale etag, got %v", err)
+	}
+
+	if _, err := c.RespondEvent(ctx, &calendarpb.RespondEventRequest{UserId: 6, EventId: created.EventId, Status: app.RSVPAccepted}); err != nil {
+		t.Fatalf("RespondEvent failed: %v", err)
+	}
+
+	page, err := c.ListEvents(ctx, &calendarpb.ListEventsRequest{UserId: 6, From: "2025-05-05", To: "2025-05-05"})
+	if err != nil || len(page.Events) != 1 || page.Events[0].Attendees[0].Status != app.RSVPAccepted {
+		t.Fatalf("unexpected ListEvents result %+v, %v", page, err)
+	}
+
+	if _, err := c.DeleteEvent(ctx, &calendarpb.DeleteEventRequest{UserId: 5, EventId: created.EventId}); err != nil {
+		t.Fatalf("DeleteEvent failed: %v", err)
+	}
+	if _, err := c.GetEvent(ctx, &calendarpb.GetEventRequest{UserId: 5, EventId: created.EventId}); status.Code(err) != codes.NotFound {
+		t.Fatalf("expected NotFound after delete, got %v", err)
+	}
+}
+
+func TestErrorsMatchHTTP(t *testing.T) {
+	repo := repository.NewInMemoryRepo()
+	repo.SetRejectConflicts(true)
+	c := newClient(t, repo, nil, nil)
+	ctx := context.Background()
+
+	_, err := c.CreateEvent(ctx, &calendarpb.CreateEventRequest{Event: &calendarpb.EventInput{UserId: 1, Text: "no date"}})
+	st := status.Convert(err)
+	if st.Code() != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument, got %v", err)
+	}
+	var reason string
+	var fields []string
+	for _, d := range st.Details() {
+		switch d := d.(type) {
+		case *errdetails.ErrorInfo:
+			reason = d.Reason
+		case *errdetails.BadRequest:
+			for _, f := range d.FieldViolations {
+				fields = append(fields, f.Field)
+			}
+		}
+	}
+	if reason != app.CodeValidation || len(fields) == 0 {
+		t.Fatalf("expected validation details, got reason %q fields %v", reason, fields)
+	}
+
+	in := &calendarpb.EventInput{UserId: 1, Start: "2025-05-05T10:00:00Z", End: "2025-05-05T11:00:00Z", Text: "busy"}
+	if _, err := c.CreateEvent(ctx, &calendarpb.CreateEventRequest{Event: in}); err != nil {
+		t.Fatalf("CreateEvent failed: %v", err)
+	}
+	_, err = c.CreateEvent(ctx, &calendarpb.CreateEventRequest{Event: in})
+	st = status.Convert(err)
+	if st.Code() != codes.AlreadyExists || len(st.Details()) != 2 {
+		t.Fatalf("expected AlreadyExists with the overlap, got %v %v", err, st.Details())
+	}
+
+	if _, err := c.ListEvents(ctx, &calendarpb.ListEventsRequest{UserId: 1, From: "bad", To: "2025-05-05"}); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument for a bad interval, got %v", err)
+	}
+	if _, err := c.GetEvent(ctx, &calendarpb.GetEventRequest{UserId: 1, EventId: "not-a-uuid"}); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument for a bad event_id, got %v", err)
+	}
+}
+
+func TestAuthentication(t *testing.T) {
+	authn, _ := auth.NewAuthenticator("secret", "")
+	c := newClient(t, repository.NewInMemoryRepo(), nil, authn)
+	token, _ := authn.Issue(1, time.Hour)
+
+	in := &calendarpb.EventInput{Date: "2025-05-05", Text: "mine"}
+	if _, err := c.CreateEvent(context.Background(), &calendarpb.CreateEventRequest{Event: in}); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("expected Unauthenticated without a token, got %v", err)
+	}
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+token)
+	var header metadata.MD
+	e, err := c.CreateEvent(ctx, &calendarpb.CreateEventRequest{Event: in}, grpc.Header(&header))
+	if err != nil || e.UserId != 1 {
+		t.Fatalf("user from token not used: %+v, %v", e, err)
+	}
+	if len(header.Get(RequestIDKey)) != 1 {
+		t.Fatalf("expected %s in response header, got %v", RequestIDKey, header)
+	}
+	if _, err := c.GetEvent(ctx, &calendarpb.GetEventRequest{UserId: 2, EventId: e.EventId}); status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("expected PermissionDenied for another user, got %v", err)
+	}
+}
+
+func TestWatchEvents(t *testing.T) {
+	hub := feed.NewHub(100)
+	repo := repository.NewInMemoryRepo()
+	repo.SetPublisher(hub)
+	c := newClient(t, repo, hub, nil)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	first, _ := repo.Save(&app.EventRequest{UserID: 1, Date: "2025-05-05", EventText: "before"})
+	stream, err := c.WatchEvents(ctx, &calendarpb.WatchEventsRequest{UserId: 1})
+	if err != nil {
+		t.Fatalf("WatchEvents failed: %v", err)
+	}
+	// подписка регистрируется асинхронно, поэтому изменения публикуются, пока одно из них не дойдёт
+	var change *calendarpb.EventChange
+	received := make(chan *calendarpb.EventChange)
+	go func() {
+		for {
+			m, err := stream.Recv()
+			if err != nil {
+				close(received)
+				return
+			}
+			received <- m
+		}
+	}()
+	for change == nil {
+		repo.Update(&app.EventRequest{EventId: first.EventId.String(), UserID: 1, EventText: "after"})
+		select {
+		case change = <-received:
+		case <-time.After(50 * time.Millisecond):
+		case <-ctx.Done():
+			t.Fatal("no change received")
+		}
+	}
+	if change.Type != "updated" || change.Event.EventId != first.EventId.String() || change.Id == 0 {
+		t.Fatalf("unexpected change %+v", change)
+	}
+
+	// переподключение с last_id отдаёт пропущенное
+	repo.Delete(&app.EventRequest{EventId: first.EventId.String(), UserID: 1})
+	resumed, err := c.WatchEvents(ctx, &calendarpb.WatchEventsRequest{UserId: 1, LastId: &change.Id})
+	if err != nil {
+		t.Fatalf("WatchEvents resume failed: %v", err)
+	}
+	var last *calendarpb.EventChange
+	for last == nil || last.Type != "deleted" {
+		if last, err = resumed.Recv(); err != nil {
+			t.Fatalf("missed changes not received: %v", err)
+		}
+	}
+
+	hub.Close()
+	if _, err := resumed.Recv(); status.Code(err) != codes.Unavailable {
+		t.Fatalf("expected Unavailable after the feed is closed, got %v", err)
+	}
+}
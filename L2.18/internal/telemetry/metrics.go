@@ -0,0 +1,106 @@
+package telemetry
+
+import (
+	"calendar/internal/repository"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const namespace = "calendar"
+
+// границы гистограммы числа событий на пользователя
+var eventsPerUserBuckets = []float64{1, 5, 10, 50, 100, 500, 1000, 5000, 10000}
+
+// Metrics — метрики сервиса в собственном реестре, чтобы тесты не делили глобальное состояние
+type Metrics struct {
+	registry        *prometheus.Registry
+	httpRequests    *prometheus.CounterVec
+	httpDuration    *prometheus.HistogramVec
+	storageDuration *prometheus.HistogramVec
+}
+
+func NewMetrics() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "HTTP requests by route, method and status code.",
+		}, []string{"route", "method", "status"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP request latency by route, method and status code.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"route", "method", "status"}),
+		storageDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "storage_operation_duration_seconds",
+			Help:      "Storage operation latency by operation and result (ok, rejected or error).",
+			Buckets:   []float64{.0001, .0005, .001, .005, .01, .05, .1, .5, 1},
+		}, []string{"operation", "result"}),
+	}
+	m.registry.MustRegister(
+		m.httpRequests,
+		m.httpDuration,
+		m.storageDuration,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return m
+}
+
+// Handler отдаёт метрики в формате Prometheus
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// ObserveRequest учитывает завершённый HTTP-запрос; route — шаблон маршрута, а не путь, чтобы не плодить серии
+func (m *Metrics) ObserveRequest(route, method string, status int, d time.Duration) {
+	code := strconv.Itoa(status)
+	m.httpRequests.WithLabelValues(route, method, code).Inc()
+	m.httpDuration.WithLabelValues(route, method, code).Observe(d.Seconds())
+}
+
+func (m *Metrics) observeStorage(op, result string, d time.Duration) {
+	m.storageDuration.WithLabelValues(op, result).Observe(d.Seconds())
+}
+
+// RegisterEventCounter публикует распределение числа событий по пользователям; считается при каждом опросе
+func (m *Metrics) RegisterEventCounter(counter repository.EventCounter) {
+	m.registry.MustRegister(&eventsCollector{counter: counter})
+}
+
+type eventsCollector struct {
+	counter repository.EventCounter
+}
+
+var eventsPerUserDesc = prometheus.NewDesc(
+	prometheus.BuildFQName(namespace, "", "events_per_user"),
+	"Stored events and series per user: _count is the number of users, _sum the number of events.",
+	nil, nil,
+)
+
+func (c *eventsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- eventsPerUserDesc
+}
+
+func (c *eventsCollector) Collect(ch chan<- prometheus.Metric) {
+	buckets := make(map[float64]uint64, len(eventsPerUserBuckets))
+	var users uint64
+	var sum float64
+	for _, n := range c.counter.EventCounts() {
+		users++
+		sum += float64(n)
+		for _, b := range eventsPerUserBuckets {
+			if float64(n) <= b {
+				buckets[b]++
+			}
+		}
+	}
+	ch <- prometheus.MustNewConstHistogram(eventsPerUserDesc, users, sum, buckets)
+}
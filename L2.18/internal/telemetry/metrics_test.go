@@ -0,0 +1,76 @@
+package telemetry
+
+import (
+	"calendar/internal/app"
+	"calendar/internal/repository"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// sampleCount возвращает число наблюдений гистограммы или значение счётчика с заданными метками
+func sampleCount(t *testing.T, m *Metrics, name string, labels map[string]string) uint64 {
+	t.Helper()
+	families, err := m.registry.Gather()
+	if err != nil {
+		t.Fatalf("gather: %v", err)
+	}
+	for _, f := range families {
+		if f.GetName() != name {
+			continue
+		}
+	next:
+		for _, metric := range f.GetMetric() {
+			for _, l := range metric.GetLabel() {
+				if v, ok := labels[l.GetName()]; ok && v != l.GetValue() {
+					continue next
+				}
+			}
+			if h := metric.GetHistogram(); h != nil {
+				return h.GetSampleCount()
+			}
+			return uint64(metric.GetCounter().GetValue())
+		}
+	}
+	return 0
+}
+
+func TestMetricsRequestsAndEventsPerUser(t *testing.T) {
+	m := NewMetrics()
+	m.ObserveRequest("/v2/users/{user_id}/events", "GET", 200, 3*time.Millisecond)
+	m.ObserveRequest("/v2/users/{user_id}/events", "GET", 200, 5*time.Millisecond)
+	m.ObserveRequest("/v2/users/{user_id}/events", "POST", 422, time.Millisecond)
+
+	labels := map[string]string{"route": "/v2/users/{user_id}/events", "method": "GET", "status": "200"}
+	if n := sampleCount(t, m, "calendar_http_requests_total", labels); n != 2 {
+		t.Fatalf("expected 2 requests, got %d", n)
+	}
+	if n := sampleCount(t, m, "calendar_http_request_duration_seconds", labels); n != 2 {
+		t.Fatalf("expected 2 latency observations, got %d", n)
+	}
+
+	repo := repository.NewInMemoryRepo()
+	for i := 0; i < 12; i++ {
+		repo.Save(&app.EventRequest{UserID: 1, Date: "2025-05-05"})
+	}
+	repo.Save(&app.EventRequest{UserID: 2, Date: "2025-05-05"})
+	m.RegisterEventCounter(repo)
+
+	w := httptest.NewRecorder()
+	m.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	body, _ := io.ReadAll(w.Body)
+	for _, want := range []string{
+		`calendar_events_per_user_bucket{le="1"} 1`,
+		`calendar_events_per_user_bucket{le="10"} 1`,
+		`calendar_events_per_user_bucket{le="50"} 2`,
+		`calendar_events_per_user_sum 13`,
+		`calendar_events_per_user_count 2`,
+		`calendar_http_requests_total{method="POST",route="/v2/users/{user_id}/events",status="422"} 1`,
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Fatalf("metrics output does not contain %q", want)
+		}
+	}
+}
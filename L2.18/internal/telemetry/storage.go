@@ -0,0 +1,148 @@
+package telemetry
+
+import (
+	"calendar/internal/app"
+	"calendar/internal/repository"
+	"context"
+	"errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"time"
+)
+
+// Storage оборачивает хранилище: каждая операция получает спан и попадает в гистограмму длительности.
+// Спан становится дочерним для контекста, привязанного через WithContext.
+type Storage struct {
+	inner   repository.Storage
+	metrics *Metrics
+	tracer  trace.Tracer
+	ctx     context.Context
+}
+
+func InstrumentStorage(inner repository.Storage, metrics *Metrics, tp trace.TracerProvider) *Storage {
+	return &Storage{
+		inner:   inner,
+		metrics: metrics,
+		tracer:  tp.Tracer(InstrumentationName),
+		ctx:     context.Background(),
+	}
+}
+
+func (s *Storage) WithContext(ctx context.Context) repository.Storage {
+	cp := *s
+	cp.ctx = ctx
+	return &cp
+}
+
+// EventCounts пробрасывается, чтобы обёртку можно было отдать в RegisterEventCounter
+func (s *Storage) EventCounts() map[int]int {
+	if c, ok := s.inner.(repository.EventCounter); ok {
+		return c.EventCounts()
+	}
+	return nil
+}
+
+func (s *Storage) observe(op string, userID int, fn func() error) {
+	_, span := s.tracer.Start(s.ctx, "storage."+op, trace.WithSpanKind(trace.SpanKindInternal))
+	if userID != 0 {
+		span.SetAttributes(attribute.Int("calendar.user_id", userID))
+	}
+	start := time.Now()
+	err := fn()
+	// отказ по бизнес-правилам (нет события, неверный запрос) — штатный ответ, а не сбой хранилища
+	result := "ok"
+	switch {
+	case err == nil:
+	case errors.Is(err, app.ErrInvalidInput) || errors.Is(err, app.ErrBusinessLogic):
+		result = "rejected"
+		span.SetAttributes(attribute.String("calendar.rejected", err.Error()))
+	default:
+		result = "error"
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	s.metrics.observeStorage(op, result, time.Since(start))
+	span.End()
+}
+
+func (s *Storage) Save(er *app.EventRequest) (e *app.Event, err error) {
+	s.observe("save", er.UserID, func() error { e, err = s.inner.Save(er); return err })
+	return
+}
+
+func (s *Storage) Delete(er *app.EventRequest) (err error) {
+	s.observe("delete", er.UserID, func() error { err = s.inner.Delete(er); return err })
+	return
+}
+
+func (s *Storage) Update(er *app.EventRequest) (e *app.Event, err error) {
+	s.observe("update", er.UserID, func() error { e, err = s.inner.Update(er); return err })
+	return
+}
+
+func (s *Storage) Respond(rsvp *app.RSVPRequest) (e *app.Event, err error) {
+	s.observe("respond", rsvp.UserID, func() error { e, err = s.inner.Respond(rsvp); return err })
+	return
+}
+
+func (s *Storage) Restore(UserID int, EventId string) (e *app.Event, err error) {
+	s.observe("restore", UserID, func() error { e, err = s.inner.Restore(UserID, EventId); return err })
+	return
+}
+
+func (s *Storage) LoadDay(UserID int, Date time.Time) (events []*app.Event, err error) {
+	s.observe("load_day", UserID, func() error { events, err = s.inner.LoadDay(UserID, Date); return err })
+	return
+}
+
+func (s *Storage) LoadWeek(UserID int, Date time.Time) (events []*app.Event, err error) {
+	s.observe("load_week", UserID, func() error { events, err = s.inner.LoadWeek(UserID, Date); return err })
+	return
+}
+
+func (s *Storage) LoadMonth(UserID int, Date time.Time) (events []*app.Event, err error) {
+	s.observe("load_month", UserID, func() error { events, err = s.inner.LoadMonth(UserID, Date); return err })
+	return
+}
+
+func (s *Storage) LoadEvent(UserID int, EventId string) (e *app.Event, err error) {
+	s.observe("load_event", UserID, func() error { e, err = s.inner.LoadEvent(UserID, EventId); return err })
+	return
+}
+
+func (s *Storage) LoadHistory(UserID int, EventId string) (entries []app.AuditEntry, err error) {
+	s.observe("load_history", UserID, func() error { entries, err = s.inner.LoadHistory(UserID, EventId); return err })
+	return
+}
+
+func (s *Storage) LoadRange(UserID int, from, to time.Time, opts repository.RangeOptions) (page *repository.EventPage, err error) {
+	s.observe("load_range", UserID, func() error { page, err = s.inner.LoadRange(UserID, from, to, opts); return err })
+	return
+}
+
+func (s *Storage) Search(UserID int, q repository.SearchQuery) (hits []repository.SearchHit, err error) {
+	s.observe("search", UserID, func() error { hits, err = s.inner.Search(UserID, q); return err })
+	return
+}
+
+func (s *Storage) LoadAll(UserID int) (events []*app.Event, err error) {
+	s.observe("load_all", UserID, func() error { events, err = s.inner.LoadAll(UserID); return err })
+	return
+}
+
+func (s *Storage) LoadUpcoming(from, to time.Time) (events []*app.Event, err error) {
+	s.observe("load_upcoming", 0, func() error { events, err = s.inner.LoadUpcoming(from, to); return err })
+	return
+}
+
+// Batch — один спан на пакет: операции могут относиться к разным пользователям
+func (s *Storage) Batch(ops []repository.BatchOp, atomic bool) (results []repository.BatchResult, err error) {
+	s.observe("batch", 0, func() error { results, err = s.inner.Batch(ops, atomic); return err })
+	return
+}
+
+func (s *Storage) Ping() (err error) {
+	s.observe("ping", 0, func() error { err = s.inner.Ping(); return err })
+	return
+}
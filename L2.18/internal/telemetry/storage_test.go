@@ -0,0 +1,61 @@
+package telemetry
+
+import (
+	"calendar/internal/app"
+	"calendar/internal/config"
+	"calendar/internal/repository"
+	"context"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"testing"
+)
+
+func TestInstrumentedStorage(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := NewSDKTracerProvider(config.TelemetryConfig{}, sdktrace.WithSpanProcessor(recorder))
+	metrics := NewMetrics()
+	s := InstrumentStorage(repository.NewInMemoryRepo(), metrics, tp)
+
+	ctx, request := tp.Tracer("test").Start(context.Background(), "request")
+	repo := repository.WithContext(ctx, s)
+	if _, err := repo.Save(&app.EventRequest{UserID: 3, Date: "2025-05-05", EventText: "x"}); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if _, err := repo.LoadEvent(3, "00000000-0000-0000-0000-000000000000"); err == nil {
+		t.Fatal("expected not found")
+	}
+	request.End()
+	if _, err := s.LoadAll(3); err != nil {
+		t.Fatalf("LoadAll failed: %v", err)
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 4 {
+		t.Fatalf("expected 4 spans, got %d", len(spans))
+	}
+	save, load, root, all := spans[0], spans[1], spans[2], spans[3]
+	for _, span := range []sdktrace.ReadOnlySpan{save, load} {
+		if span.Parent().SpanID() != root.SpanContext().SpanID() {
+			t.Fatalf("%s is not a child of the request span", span.Name())
+		}
+	}
+	if save.Name() != "storage.save" || load.Name() != "storage.load_event" {
+		t.Fatalf("unexpected span names %q, %q", save.Name(), load.Name())
+	}
+	// ненайденное событие — штатный отказ, а не ошибка хранилища
+	if load.Status().Code == codes.Error {
+		t.Fatalf("not found must not mark the span as failed: %v", load.Status())
+	}
+	if all.Parent().IsValid() {
+		t.Fatal("span without a bound context must be a root span")
+	}
+
+	for _, tt := range []struct {
+		op, result string
+	}{{"save", "ok"}, {"load_event", "rejected"}, {"load_all", "ok"}} {
+		if n := sampleCount(t, metrics, "calendar_storage_operation_duration_seconds", map[string]string{"operation": tt.op, "result": tt.result}); n != 1 {
+			t.Fatalf("%s/%s: expected 1 observation, got %d", tt.op, tt.result, n)
+		}
+	}
+}
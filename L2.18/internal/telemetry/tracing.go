@@ -0,0 +1,54 @@
+package telemetry
+
+import (
+	"calendar/internal/config"
+	"context"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// InstrumentationName — имя, под которым сервис создаёт свои трассировщики
+const InstrumentationName = "calendar"
+
+// Propagator читает и пишет заголовки W3C traceparent/tracestate и baggage
+var Propagator = propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+
+// NewTracerProvider создаёт провайдер с экспортом по OTLP/HTTP; при выключенной трассировке возвращает noop.
+// Возвращаемая функция дожидается отправки накопленных спанов.
+func NewTracerProvider(ctx context.Context, cfg config.TelemetryConfig) (trace.TracerProvider, func(context.Context) error, error) {
+	if !cfg.Tracing.Enabled {
+		return noop.NewTracerProvider(), func(context.Context) error { return nil }, nil
+	}
+	opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(cfg.Tracing.Endpoint)}
+	if cfg.Tracing.Insecure {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+	exporter, err := otlptracehttp.New(ctx, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+	tp := NewSDKTracerProvider(cfg, sdktrace.WithBatcher(exporter))
+	return tp, tp.Shutdown, nil
+}
+
+// NewSDKTracerProvider собирает провайдер с ресурсом и сэмплером из конфига; тесты передают сюда in-memory экспортёр
+func NewSDKTracerProvider(cfg config.TelemetryConfig, opts ...sdktrace.TracerProviderOption) *sdktrace.TracerProvider {
+	name := cfg.ServiceName
+	if name == "" {
+		name = "calendar"
+	}
+	ratio := cfg.Tracing.SampleRatio
+	if ratio <= 0 {
+		ratio = 1
+	}
+	opts = append([]sdktrace.TracerProviderOption{
+		sdktrace.WithResource(resource.NewSchemaless(semconv.ServiceName(name))),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))),
+	}, opts...)
+	return sdktrace.NewTracerProvider(opts...)
+}
@@ -0,0 +1,149 @@
+package web
+
+import (
+	"calendar/internal/app"
+	"calendar/internal/auth"
+	"calendar/internal/repository"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"net/http"
+)
+
+// BatchRequest — операции пакета; с atomic они применяются все или ни одной
+type BatchRequest struct {
+	Atomic     bool             `json:"atomic"`
+	Operations []BatchOperation `json:"operations"`
+}
+
+// BatchOperation — одна операция: event как в теле /create_event, /update_event или /delete_event,
+// а if_match и idempotency_key вместо одноимённых заголовков
+type BatchOperation struct {
+	Op             string            `json:"op"` // create | update | delete
+	Event          *app.EventRequest `json:"event"`
+	IfMatch        string            `json:"if_match,omitempty"`
+	IdempotencyKey string            `json:"idempotency_key,omitempty"` // только для create
+}
+
+// BatchOperationResult — итог операции: HTTP-статус, который вернул бы отдельный запрос, и событие или ошибка
+type BatchOperationResult struct {
+	Index  int            `json:"index"`
+	Status int            `json:"status"`
+	Event  *app.Event     `json:"event,omitempty"`
+	ETag   string         `json:"etag,omitempty"`
+	Error  *ErrorResponse `json:"error,omitempty"`
+}
+
+type BatchResponse struct {
+	Atomic  bool                   `json:"atomic"`
+	Applied int                    `json:"applied"`
+	Failed  int                    `json:"failed"`
+	Results []BatchOperationResult `json:"results"`
+}
+
+// BatchEvents godoc
+// @Summary      Batch create, update and delete
+// @Description  Applies up to 1000 create/update/delete operations under one storage lock. With atomic=true either all operations
+// @Description  are applied or none: the failed operation carries its error and the others are reported as "aborted" (424).
+// @Description  Without atomic every operation is applied on its own. The response is 200 in both modes; check failed and each status.
+// @Tags         events
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        batch  body      BatchRequest   true  "Operations to apply"
+// @Success      200    {object}  BatchResponse  "result for each operation" // note: response wrapped as {"result": <BatchResponse>}
+// @Failure 	 400  {object} ErrorResponse "invalid body, no operations or too many of them"
+// @Failure 	 401  {object} ErrorResponse "missing or invalid token"
+// @Failure 	 413  {object} ErrorResponse "request body is too large"
+// @Failure 	 429  {object} ErrorResponse "rate limit exceeded, see Retry-After"
+// @Failure 	 500  {object} ErrorResponse "internal server error"
+// @Router       /events/batch [post]
+func (h *CalendarHandler) BatchEvents(w http.ResponseWriter, r *http.Request) {
+	var req BatchRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeBodyError(w, h.log(r), err, "bad batch request")
+		return
+	}
+	if len(req.Operations) == 0 {
+		writeError(w, "operations are required", http.StatusBadRequest)
+		return
+	}
+	if len(req.Operations) > repository.MaxBatchSize {
+		writeError(w, fmt.Sprintf("at most %d operations per batch", repository.MaxBatchSize), http.StatusBadRequest)
+		return
+	}
+
+	resp := BatchResponse{Atomic: req.Atomic, Results: make([]BatchOperationResult, len(req.Operations))}
+	var (
+		ops   []repository.BatchOp
+		index []int // номер операции в запросе для каждой операции пакета
+	)
+	for i, op := range req.Operations {
+		resp.Results[i].Index = i
+		if op.Op == repository.BatchMatch {
+			// проверка ресурса CalDAV — служебная операция хранилища, в API пакетов её нет
+			resp.Results[i] = h.batchResult(r, i, op.Op, repository.BatchResult{Err: app.InvalidField("op", fmt.Sprintf("unknown operation %q", op.Op))})
+			continue
+		}
+		if op.Event != nil {
+			user, err := auth.ResolveUser(r.Context(), op.Event.UserID)
+			if err != nil {
+				h.log(r).Warn("access denied", zap.Int("operation", i), zap.Error(err))
+				resp.Results[i].Status = http.StatusForbidden
+				resp.Results[i].Error = &ErrorResponse{Error: "user_id does not match token", Code: CodeForbidden}
+				continue
+			}
+			op.Event.UserID, op.Event.ActorID = user, auth.Actor(r.Context())
+			op.Event.IfMatch, op.Event.IdempotencyKey = op.IfMatch, op.IdempotencyKey
+		}
+		ops = append(ops, repository.BatchOp{Op: op.Op, Request: op.Event})
+		index = append(index, i)
+	}
+
+	var results []repository.BatchResult
+	if req.Atomic && len(ops) < len(req.Operations) {
+		// чужие события и служебные операции отклонены до хранилища, атомарный пакет в него уже не передаётся
+		results = make([]repository.BatchResult, len(ops))
+		for j := range results {
+			results[j].Err = repository.ErrBatchAborted
+		}
+	} else {
+		var err error
+		results, err = h.store(r).Batch(ops, req.Atomic)
+		if results == nil {
+			errParser(w, h.log(r), err, "batch failed")
+			return
+		}
+	}
+	for j, res := range results {
+		i := index[j]
+		resp.Results[i] = h.batchResult(r, i, req.Operations[i].Op, res)
+	}
+	for _, res := range resp.Results {
+		if res.Error == nil {
+			resp.Applied++
+		} else {
+			resp.Failed++
+		}
+	}
+
+	h.log(r).Info("batch applied", zap.Bool("atomic", req.Atomic), zap.Int("applied", resp.Applied), zap.Int("failed", resp.Failed))
+	writeJson(w, resp)
+}
+
+func (h *CalendarHandler) batchResult(r *http.Request, i int, op string, res repository.BatchResult) BatchOperationResult {
+	out := BatchOperationResult{Index: i, Status: http.StatusOK}
+	switch {
+	case res.Err == nil:
+		if res.Event != nil {
+			out.Event, out.ETag = res.Event, res.Event.ETag()
+		}
+	case errors.Is(res.Err, repository.ErrBatchAborted):
+		out.Status = http.StatusFailedDependency
+		out.Error = &ErrorResponse{Error: "not applied: another operation in the batch failed", Code: CodeAborted}
+	default:
+		status, body := errorResponse(h.log(r).With(zap.Int("operation", i)), res.Err, op+" failed")
+		out.Status, out.Error = status, &body
+	}
+	return out
+}
@@ -0,0 +1,114 @@
+package web
+
+import (
+	"calendar/internal/auth"
+	"calendar/internal/repository"
+	"encoding/json"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func doBatch(t *testing.T, h http.Handler, token, body string) (int, BatchResponse) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/events/batch", strings.NewReader(body))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	var out struct {
+		Result BatchResponse `json:"result"`
+	}
+	if w.Code == http.StatusOK {
+		if err := json.NewDecoder(w.Body).Decode(&out); err != nil {
+			t.Fatalf("decode response: %v", err)
+		}
+	}
+	return w.Code, out.Result
+}
+
+func statuses(resp BatchResponse) []int {
+	var s []int
+	for _, r := range resp.Results {
+		s = append(s, r.Status)
+	}
+	return s
+}
+
+func TestBatchEvents(t *testing.T) {
+	h := newV2Router()
+
+	code, resp := doBatch(t, h, "", `{"operations":[
+		{"op":"create","event":{"user_id":1,"date":"2025-05-05","event":"a"},"idempotency_key":"a-1"},
+		{"op":"create","event":{"user_id":1,"date":"2025-05-06","event":"b"}},
+		{"op":"delete","event":{"user_id":1,"event_id":"00000000-0000-0000-0000-000000000001"}},
+		{"op":"match","event":{"user_id":1}}]}`)
+	if code != http.StatusOK || resp.Applied != 2 || resp.Failed != 2 {
+		t.Fatalf("unexpected best-effort response %d %+v", code, resp)
+	}
+	if got := statuses(resp); got[0] != 200 || got[1] != 200 || got[2] != 404 || resp.Results[2].Error.Code != CodeNotFound || got[3] != 422 {
+		t.Fatalf("unexpected statuses %v %+v", got, resp.Results[2].Error)
+	}
+	first := resp.Results[0]
+	if first.Event == nil || first.ETag != first.Event.ETag() {
+		t.Fatalf("created event without etag: %+v", first)
+	}
+
+	// атомарный пакет с устаревшим if_match не применяется целиком
+	id := first.Event.EventId.String()
+	code, resp = doBatch(t, h, "", `{"atomic":true,"operations":[
+		{"op":"update","event":{"user_id":1,"event_id":"`+id+`","event":"a2"},"if_match":`+jsonString(first.ETag)+`},
+		{"op":"create","event":{"user_id":1,"date":"2025-05-07","event":"c"}},
+		{"op":"update","event":{"user_id":1,"event_id":"`+id+`","event":"a3"},"if_match":`+jsonString(first.ETag)+`}]}`)
+	if code != http.StatusOK || resp.Applied != 0 || resp.Failed != 3 {
+		t.Fatalf("unexpected atomic response %d %+v", code, resp)
+	}
+	if got := statuses(resp); got[0] != 424 || got[1] != 424 || got[2] != 412 || resp.Results[0].Error.Code != CodeAborted {
+		t.Fatalf("unexpected atomic statuses %v", got)
+	}
+	w := doV2(t, h, http.MethodGet, "/v2/users/1/events/"+id, "")
+	if e := decodeEvent(t, w); e.EventText != "a" || e.Version != 1 {
+		t.Fatalf("atomic batch partially applied: %+v", e)
+	}
+
+	code, resp = doBatch(t, h, "", `{"operations":[
+		{"op":"update","event":{"user_id":1,"event_id":"`+id+`","event":"a2"},"if_match":`+jsonString(first.ETag)+`},
+		{"op":"delete","event":{"user_id":1,"event_id":"bad"}}]}`)
+	if code != http.StatusOK || resp.Applied != 1 || resp.Results[0].Event.Version != 2 || resp.Results[1].Error.Fields[0].Field != "event_id" {
+		t.Fatalf("unexpected response for an invalid event_id %d %+v", code, resp)
+	}
+
+	for _, body := range []string{`{"operations":[]}`, `{"operations":[{"op":"create"}],"extra":1}`, `[]`} {
+		if code, _ := doBatch(t, h, "", body); code != http.StatusBadRequest {
+			t.Fatalf("expected 400 for %s, got %d", body, code)
+		}
+	}
+}
+
+func TestBatchEventsForeignUser(t *testing.T) {
+	authn, _ := auth.NewAuthenticator("secret", "")
+	token, _ := authn.Issue(1, time.Hour)
+	r := chi.NewRouter()
+	RegisterRoutes(r, NewCalendarHandler(repository.NewInMemoryRepo(), zap.NewNop()), authn, Limits{})
+	ops := `[{"op":"create","event":{"date":"2025-05-05","event":"mine"}},
+		{"op":"create","event":{"user_id":2,"date":"2025-05-05","event":"theirs"}}]`
+
+	_, resp := doBatch(t, r, token, `{"atomic":true,"operations":`+ops+`}`)
+	if got := statuses(resp); got[0] != 424 || got[1] != 403 {
+		t.Fatalf("unexpected atomic statuses %v", got)
+	}
+	_, resp = doBatch(t, r, token, `{"operations":`+ops+`}`)
+	if got := statuses(resp); got[0] != 200 || got[1] != 403 || resp.Results[0].Event.UserID != 1 {
+		t.Fatalf("unexpected best-effort statuses %v %+v", got, resp.Results[0])
+	}
+}
+
+func jsonString(s string) string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
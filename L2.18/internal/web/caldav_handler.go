@@ -0,0 +1,606 @@
+package web
+
+import (
+	"bytes"
+	"calendar/internal/app"
+	"calendar/internal/auth"
+	"calendar/internal/ical"
+	"calendar/internal/repository"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"net/http"
+	"net/url"
+	"path"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// caldavRoot — корень CalDAV: /caldav/{user_id}/ — принципал и домашний набор, /caldav/{user_id}/calendar/ — календарь
+const caldavRoot = "/caldav/"
+
+func init() {
+	chi.RegisterMethod("PROPFIND")
+	chi.RegisterMethod("REPORT")
+}
+
+// davObject — ресурс .ics календаря: событие или серия вместе с выделенными из неё вхождениями, у них общий UID
+type davObject struct {
+	name   string
+	events []*app.Event // серия первой
+}
+
+func (o *davObject) master() *app.Event {
+	return o.events[0]
+}
+
+// etag меняется при изменении серии и любого её вхождения; хранилище проверяет его в пакете BatchMatch
+func (o *davObject) etag() string {
+	return app.ObjectETag(o.events)
+}
+
+func (o *davObject) calendarData() string {
+	var b bytes.Buffer
+	ical.Encode(&b, o.events)
+	return b.String()
+}
+
+// loadObjects собирает собственные события пользователя в ресурсы календаря
+func loadObjects(repo repository.Storage, user int) ([]*davObject, error) {
+	events, err := repo.LoadAll(user)
+	if err != nil {
+		return nil, err
+	}
+	byId := make(map[string]*davObject)
+	var objects []*davObject
+	for _, e := range events {
+		if e.SeriesId == nil {
+			o := &davObject{name: e.ObjectName(), events: []*app.Event{e}}
+			byId[e.EventId.String()] = o
+			objects = append(objects, o)
+		}
+	}
+	for _, e := range events {
+		if e.SeriesId == nil {
+			continue
+		}
+		if o, ok := byId[e.SeriesId.String()]; ok {
+			o.events = append(o.events, e)
+			continue
+		}
+		// серии нет среди событий пользователя — вхождение остаётся отдельным ресурсом
+		objects = append(objects, &davObject{name: e.EventId.String(), events: []*app.Event{e}})
+	}
+	return objects, nil
+}
+
+func findObject(objects []*davObject, name string) *davObject {
+	for _, o := range objects {
+		if o.name == name {
+			return o
+		}
+	}
+	return nil
+}
+
+// ctag меняется при любом изменении календаря, по нему клиенты решают, нужно ли синхронизироваться
+func ctag(objects []*davObject) string {
+	tags := make([]string, len(objects))
+	for i, o := range objects {
+		tags[i] = o.name + o.etag()
+	}
+	sort.Strings(tags)
+	sum := sha1.Sum([]byte(strings.Join(tags, ";")))
+	return hex.EncodeToString(sum[:])
+}
+
+func principalHref(user int) string {
+	return caldavRoot + strconv.Itoa(user) + "/"
+}
+
+func calendarHref(user int) string {
+	return principalHref(user) + "calendar/"
+}
+
+func objectHref(user int, name string) string {
+	return calendarHref(user) + url.PathEscape(name) + ".ics"
+}
+
+// WellKnownCalDAV отправляет клиента, настроенного по адресу сервера, в корень CalDAV (RFC 6764)
+func (h *CalendarHandler) WellKnownCalDAV(w http.ResponseWriter, r *http.Request) {
+	http.Redirect(w, r, caldavRoot, http.StatusMovedPermanently)
+}
+
+// CalDAVOptions сообщает клиенту, что сервер умеет CalDAV
+func (h *CalendarHandler) CalDAVOptions(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("DAV", "1, 3, calendar-access")
+	w.Header().Set("Allow", "OPTIONS, GET, HEAD, PUT, DELETE, PROPFIND, REPORT")
+	w.WriteHeader(http.StatusOK)
+}
+
+// CalDAVRoot — PROPFIND корня: клиент узнаёт из него своего принципала
+func (h *CalendarHandler) CalDAVRoot(w http.ResponseWriter, r *http.Request) {
+	var req propfindRequest
+	if err := decodeDAV(r, &req); err != nil {
+		writeBodyError(w, h.log(r), err, "invalid propfind")
+		return
+	}
+	props := davProps{
+		{Space: nsDAV, Local: "resourcetype"}: "<D:collection/>",
+	}
+	if p, ok := auth.PrincipalFrom(r.Context()); ok {
+		props[xml.Name{Space: nsDAV, Local: "current-user-principal"}] = hrefXML(principalHref(p.UserID))
+	} else {
+		props[xml.Name{Space: nsDAV, Local: "current-user-principal"}] = "<D:unauthenticated/>"
+	}
+	writeMultistatus(w, []davResponse{props.response(caldavRoot, req.Prop)})
+}
+
+// CalDAVPrincipal — PROPFIND принципала и домашнего набора календарей; с Depth: 1 — вместе с календарём
+func (h *CalendarHandler) CalDAVPrincipal(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.pathUser(w, r)
+	if !ok {
+		return
+	}
+	var req propfindRequest
+	if err := decodeDAV(r, &req); err != nil {
+		writeBodyError(w, h.log(r), err, "invalid propfind")
+		return
+	}
+	responses := []davResponse{h.principalProps(user).response(principalHref(user), req.Prop)}
+	if r.Header.Get("Depth") != "0" {
+		objects, err := loadObjects(h.store(r), user)
+		if err != nil {
+			errParser(w, h.log(r), err, "propfind failed")
+			return
+		}
+		responses = append(responses, h.calendarProps(user, objects).response(calendarHref(user), req.Prop))
+	}
+	writeMultistatus(w, responses)
+}
+
+// CalDAVCalendar — PROPFIND календаря; с Depth: 1 — вместе с ресурсами событий
+func (h *CalendarHandler) CalDAVCalendar(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.pathUser(w, r)
+	if !ok {
+		return
+	}
+	var req propfindRequest
+	if err := decodeDAV(r, &req); err != nil {
+		writeBodyError(w, h.log(r), err, "invalid propfind")
+		return
+	}
+	objects, err := loadObjects(h.store(r), user)
+	if err != nil {
+		errParser(w, h.log(r), err, "propfind failed")
+		return
+	}
+	responses := []davResponse{h.calendarProps(user, objects).response(calendarHref(user), req.Prop)}
+	if r.Header.Get("Depth") != "0" {
+		for _, o := range objects {
+			responses = append(responses, objectProps(o, false).response(objectHref(user, o.name), req.Prop))
+		}
+	}
+	h.log(r).Info("caldav propfind", zap.Int("user_id", user), zap.Int("objects", len(objects)))
+	writeMultistatus(w, responses)
+}
+
+// CalDAVObjectProps — PROPFIND одного ресурса события
+func (h *CalendarHandler) CalDAVObjectProps(w http.ResponseWriter, r *http.Request) {
+	user, o, ok := h.davObject(w, r)
+	if !ok {
+		return
+	}
+	var req propfindRequest
+	if err := decodeDAV(r, &req); err != nil {
+		writeBodyError(w, h.log(r), err, "invalid propfind")
+		return
+	}
+	writeMultistatus(w, []davResponse{objectProps(o, false).response(objectHref(user, o.name), req.Prop)})
+}
+
+// CalDAVReport — calendar-query (события, пересекающие интервал) и calendar-multiget (события по href)
+func (h *CalendarHandler) CalDAVReport(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.pathUser(w, r)
+	if !ok {
+		return
+	}
+	var req reportRequest
+	if err := decodeDAV(r, &req); err != nil {
+		writeBodyError(w, h.log(r), err, "invalid report")
+		return
+	}
+	objects, err := loadObjects(h.store(r), user)
+	if err != nil {
+		errParser(w, h.log(r), err, "report failed")
+		return
+	}
+
+	var responses []davResponse
+	switch req.XMLName {
+	case xml.Name{Space: nsCalDAV, Local: "calendar-query"}:
+		match, err := queryFilter(req.Filter)
+		if err != nil {
+			h.log(r).Warn("invalid calendar-query filter", zap.Error(err))
+			writeError(w, "invalid filter: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		for _, o := range objects {
+			if match(o) {
+				responses = append(responses, objectProps(o, true).response(objectHref(user, o.name), req.Prop))
+			}
+		}
+	case xml.Name{Space: nsCalDAV, Local: "calendar-multiget"}:
+		for _, href := range req.Hrefs {
+			o := findObject(objects, hrefName(href, user))
+			if o == nil {
+				responses = append(responses, davResponse{Href: href, Status: davStatus(http.StatusNotFound)})
+				continue
+			}
+			responses = append(responses, objectProps(o, true).response(href, req.Prop))
+		}
+	default:
+		writeError(w, "unsupported report "+req.XMLName.Local, http.StatusForbidden)
+		return
+	}
+	h.log(r).Info("caldav report", zap.String("report", req.XMLName.Local), zap.Int("user_id", user), zap.Int("objects", len(responses)))
+	writeMultistatus(w, responses)
+}
+
+// CalDAVGet отдаёт ресурс события в формате iCalendar
+func (h *CalendarHandler) CalDAVGet(w http.ResponseWriter, r *http.Request) {
+	_, o, ok := h.davObject(w, r)
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("ETag", o.etag())
+	if r.Method == http.MethodHead {
+		return
+	}
+	if err := ical.Encode(w, o.events); err != nil {
+		h.log(r).Error("ics encode failed", zap.Error(err))
+	}
+}
+
+// CalDAVPut создаёт или заменяет ресурс события. Имя ресурса должно совпадать с UID в календаре.
+// If-Match и If-None-Match: * защищают от перезаписи чужих изменений.
+func (h *CalendarHandler) CalDAVPut(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.pathUser(w, r)
+	if !ok {
+		return
+	}
+	name := resourceName(r)
+	vevents, err := ical.Decode(r.Body)
+	if err != nil {
+		writeBodyError(w, h.log(r), err, "invalid ics")
+		return
+	}
+	master, overrides, err := splitObject(vevents, name)
+	if err != nil {
+		h.log(r).Warn("invalid calendar object", zap.Error(err))
+		writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	repo, actor := h.store(r), auth.Actor(r.Context())
+	status := http.StatusNoContent
+	err = applyObject(r, repo, user, name, func(existing *davObject) []repository.BatchOp {
+		if existing == nil {
+			status = http.StatusCreated
+			return createOps(user, actor, name, master, overrides)
+		}
+		status = http.StatusNoContent
+		return replaceOps(user, actor, existing, master, overrides)
+	})
+	if err != nil {
+		errParser(w, h.log(r), err, "put failed")
+		return
+	}
+	if objects, err := loadObjects(repo, user); err == nil {
+		if o := findObject(objects, name); o != nil {
+			w.Header().Set("ETag", o.etag())
+		}
+	}
+	h.log(r).Info("caldav object saved", zap.String("name", name), zap.Int("status", status))
+	w.WriteHeader(status)
+}
+
+// CalDAVDelete удаляет ресурс: серию вместе с выделенными вхождениями
+func (h *CalendarHandler) CalDAVDelete(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.pathUser(w, r)
+	if !ok {
+		return
+	}
+	name, actor := resourceName(r), auth.Actor(r.Context())
+	err := applyObject(r, h.store(r), user, name, func(o *davObject) []repository.BatchOp {
+		if o == nil {
+			return nil
+		}
+		return []repository.BatchOp{{Op: repository.BatchDelete, Request: &app.EventRequest{
+			EventId: o.master().EventId.String(), UserID: user, ActorID: actor,
+		}}}
+	})
+	if err != nil {
+		errParser(w, h.log(r), err, "delete failed")
+		return
+	}
+	h.log(r).Info("caldav object deleted", zap.String("name", name))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// davAttempts — сколько раз собирать пакет заново, если ресурс менялся между чтением и записью
+const davAttempts = 3
+
+// applyObject собирает операции по текущему состоянию ресурса и применяет их одним атомарным пакетом.
+// Пакет начинается с проверок BatchMatch: условия клиента (If-Match, If-None-Match: *) и того, что ресурс
+// не изменился после чтения. Не выполненное условие клиента — 412 от хранилища; изменившийся ресурс —
+// повод перечитать его и собрать пакет заново. build без операций означает, что ресурса нет.
+func applyObject(r *http.Request, repo repository.Storage, user int, name string, build func(*davObject) []repository.BatchOp) error {
+	for attempt := 1; ; attempt++ {
+		objects, err := loadObjects(repo, user)
+		if err != nil {
+			return err
+		}
+		o := findObject(objects, name)
+		ops := build(o)
+		if len(ops) == 0 {
+			return app.ErrNotFound
+		}
+		conditions := davConditions(r, user, name, o)
+		results, err := repo.Batch(append(conditions, ops...), true)
+		if err == nil {
+			return nil
+		}
+		seen := len(conditions) - 1
+		stale := len(results) > seen && results[seen].Err != nil && !errors.Is(results[seen].Err, repository.ErrBatchAborted)
+		if !stale || attempt == davAttempts {
+			return err
+		}
+	}
+}
+
+// davConditions — проверки пакета: If-None-Match: * (ресурса ещё нет) и If-Match от клиента, последняя —
+// ETag ресурса, каким его прочитал обработчик
+func davConditions(r *http.Request, user int, name string, o *davObject) []repository.BatchOp {
+	match := func(etag string) repository.BatchOp {
+		return repository.BatchOp{Op: repository.BatchMatch, Request: &app.EventRequest{UserID: user, UID: name, IfMatch: etag}}
+	}
+	var ops []repository.BatchOp
+	if r.Header.Get("If-None-Match") == "*" {
+		ops = append(ops, match(""))
+	}
+	if etag := r.Header.Get("If-Match"); etag != "" {
+		ops = append(ops, match(etag))
+	}
+	seen := ""
+	if o != nil {
+		seen = o.etag()
+	}
+	return append(ops, match(seen))
+}
+
+// davObject находит ресурс из пути запроса; если его нет, отвечает 404
+func (h *CalendarHandler) davObject(w http.ResponseWriter, r *http.Request) (int, *davObject, bool) {
+	user, ok := h.pathUser(w, r)
+	if !ok {
+		return 0, nil, false
+	}
+	objects, err := loadObjects(h.store(r), user)
+	if err != nil {
+		errParser(w, h.log(r), err, "load failed")
+		return 0, nil, false
+	}
+	o := findObject(objects, resourceName(r))
+	if o == nil {
+		writeError(w, "calendar object not found", http.StatusNotFound)
+		return 0, nil, false
+	}
+	return user, o, true
+}
+
+func (h *CalendarHandler) principalProps(user int) davProps {
+	return davProps{
+		{Space: nsDAV, Local: "resourcetype"}:           "<D:collection/><D:principal/>",
+		{Space: nsDAV, Local: "displayname"}:            "user " + strconv.Itoa(user),
+		{Space: nsDAV, Local: "current-user-principal"}: hrefXML(principalHref(user)),
+		{Space: nsDAV, Local: "principal-URL"}:          hrefXML(principalHref(user)),
+		{Space: nsCalDAV, Local: "calendar-home-set"}:   hrefXML(principalHref(user)),
+	}
+}
+
+func (h *CalendarHandler) calendarProps(user int, objects []*davObject) davProps {
+	return davProps{
+		{Space: nsDAV, Local: "resourcetype"}:                        "<D:collection/><C:calendar/>",
+		{Space: nsDAV, Local: "displayname"}:                         "Calendar",
+		{Space: nsDAV, Local: "current-user-principal"}:              hrefXML(principalHref(user)),
+		{Space: nsDAV, Local: "current-user-privilege-set"}:          "<D:privilege><D:read/></D:privilege><D:privilege><D:write/></D:privilege>",
+		{Space: nsCalDAV, Local: "supported-calendar-component-set"}: `<C:comp name="VEVENT"/>`,
+		{Space: nsCS, Local: "getctag"}:                              ctag(objects),
+	}
+}
+
+// objectProps — свойства ресурса события; calendar-data отдаётся только в REPORT
+func objectProps(o *davObject, withData bool) davProps {
+	props := davProps{
+		{Space: nsDAV, Local: "resourcetype"}:   "",
+		{Space: nsDAV, Local: "getetag"}:        escapeXML(o.etag()),
+		{Space: nsDAV, Local: "getcontenttype"}: "text/calendar; charset=utf-8; component=vevent",
+	}
+	if withData {
+		props[xml.Name{Space: nsCalDAV, Local: "calendar-data"}] = escapeXML(o.calendarData())
+	}
+	return props
+}
+
+// queryFilter разбирает фильтр calendar-query: VCALENDAR > VEVENT с необязательным time-range.
+// Ресурс подходит, если хотя бы одно его вхождение пересекает интервал.
+func queryFilter(f *compFilter) (func(*davObject) bool, error) {
+	all := func(*davObject) bool { return true }
+	if f == nil {
+		return all, nil
+	}
+	if f.Name != "VCALENDAR" {
+		return nil, fmt.Errorf("unsupported component %q", f.Name)
+	}
+	if len(f.Comps) == 0 {
+		return all, nil
+	}
+	vevent := f.Comps[0]
+	if vevent.Name != "VEVENT" {
+		// в календаре только события, задачи и прочие компоненты не находятся
+		return func(*davObject) bool { return false }, nil
+	}
+	if vevent.TimeRange == nil {
+		return all, nil
+	}
+	from, to, err := vevent.TimeRange.bounds()
+	if err != nil {
+		return nil, err
+	}
+	return func(o *davObject) bool {
+		return slices.ContainsFunc(o.events, func(e *app.Event) bool { return len(e.Occurrences(from, to)) > 0 })
+	}, nil
+}
+
+// resourceName — имя ресурса из пути без .ics
+func resourceName(r *http.Request) string {
+	return strings.TrimSuffix(chi.URLParam(r, "name"), ".ics")
+}
+
+// hrefName — имя ресурса из href calendar-multiget; href вне календаря пользователя не находит ничего
+func hrefName(href string, user int) string {
+	if u, err := url.Parse(href); err == nil {
+		href = u.Path
+	}
+	if path.Dir(href)+"/" != calendarHref(user) {
+		return ""
+	}
+	name, err := url.PathUnescape(path.Base(href))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSuffix(name, ".ics")
+}
+
+// splitObject делит ресурс на серию и изменённые вхождения; у всех VEVENT должен быть UID, равный имени ресурса
+func splitObject(vevents []ical.VEvent, name string) (*ical.VEvent, []ical.VEvent, error) {
+	var (
+		master    *ical.VEvent
+		overrides []ical.VEvent
+	)
+	for i, ve := range vevents {
+		if ve.Err != nil {
+			return nil, nil, ve.Err
+		}
+		if ve.UID != name {
+			return nil, nil, fmt.Errorf("UID %q does not match the resource name %q", ve.UID, name)
+		}
+		if ve.RecurrenceId != "" {
+			overrides = append(overrides, ve)
+			continue
+		}
+		if master != nil {
+			return nil, nil, errors.New("more than one VEVENT without RECURRENCE-ID")
+		}
+		master = &vevents[i]
+	}
+	if master == nil {
+		return nil, nil, errors.New("VEVENT without RECURRENCE-ID is required")
+	}
+	return master, overrides, nil
+}
+
+// createOps — операции нового ресурса: серия и выделенные из неё изменённые вхождения
+func createOps(user, actor int, name string, master *ical.VEvent, overrides []ical.VEvent) []repository.BatchOp {
+	er := master.Request
+	// UID клиента остаётся только в UID: event_id всегда выдаёт сервер, даже если UID похож на uuid.
+	// Он нужен заранее, чтобы вхождения в том же пакете ссылались на серию
+	er.UserID, er.ActorID, er.UID, er.EventId = user, actor, name, uuid.NewString()
+	ops := []repository.BatchOp{{Op: repository.BatchCreate, Request: &er}}
+	for _, ve := range overrides {
+		ops = append(ops, detachOp(user, actor, er.EventId, ve))
+	}
+	return ops
+}
+
+// replaceOps приводит серию и её вхождения к присланному ресурсу: вхождения, которых нет в ресурсе,
+// удаляются и возвращаются в серию, новые выделяются из неё
+func replaceOps(user, actor int, o *davObject, master *ical.VEvent, overrides []ical.VEvent) []repository.BatchOp {
+	seriesId := o.master().EventId.String()
+	children := make(map[string]*app.Event)
+	for _, e := range o.events[1:] {
+		children[e.RecurrenceId.UTC().Format(time.RFC3339)] = e
+	}
+	kept := make(map[string]bool)
+	for _, ve := range overrides {
+		if t, err := time.Parse(time.RFC3339, ve.RecurrenceId); err == nil {
+			if _, ok := children[t.UTC().Format(time.RFC3339)]; ok {
+				kept[t.UTC().Format(time.RFC3339)] = true
+			}
+		}
+	}
+
+	er := master.Request
+	er.EventId, er.UserID, er.ActorID = seriesId, user, actor
+	if er.RRule == nil {
+		er.RRule = new(string)
+	}
+	replaceDetails(&er)
+	// выделенные вхождения исключены из серии, пока они существуют
+	er.ExDates = append([]string{}, er.ExDates...)
+	for recurrence := range kept {
+		er.ExDates = append(er.ExDates, recurrence)
+	}
+	ops := []repository.BatchOp{{Op: repository.BatchUpdate, Request: &er}}
+
+	for recurrence, child := range children {
+		if !kept[recurrence] {
+			ops = append(ops, repository.BatchOp{Op: repository.BatchDelete, Request: &app.EventRequest{
+				EventId: child.EventId.String(), UserID: user, ActorID: actor,
+			}})
+		}
+	}
+	for _, ve := range overrides {
+		t, _ := time.Parse(time.RFC3339, ve.RecurrenceId)
+		if child, ok := children[t.UTC().Format(time.RFC3339)]; ok {
+			er := ve.Request
+			er.EventId, er.UserID, er.ActorID = child.EventId.String(), user, actor
+			replaceDetails(&er)
+			ops = append(ops, repository.BatchOp{Op: repository.BatchUpdate, Request: &er})
+			continue
+		}
+		ops = append(ops, detachOp(user, actor, seriesId, ve))
+	}
+	return ops
+}
+
+// replaceDetails очищает описание, место и теги, которых нет в присланном ресурсе: PUT заменяет его целиком.
+// Заголовка в iCalendar нет, он остаётся прежним.
+func replaceDetails(er *app.EventRequest) {
+	if er.Description == nil {
+		er.Description = new(string)
+	}
+	if er.Location == nil {
+		er.Location = new(string)
+	}
+	if er.Tags == nil {
+		er.Tags = []string{}
+	}
+}
+
+// detachOp выделяет изменённое вхождение из серии, как импорт .ics
+func detachOp(user, actor int, seriesId string, ve ical.VEvent) repository.BatchOp {
+	er := ve.Request
+	er.EventId, er.UserID, er.ActorID, er.Scope, er.RecurrenceId = seriesId, user, actor, app.ScopeThis, ve.RecurrenceId
+	er.RRule, er.ExDates = nil, nil
+	return repository.BatchOp{Op: repository.BatchUpdate, Request: &er}
+}
@@ -0,0 +1,281 @@
+package web
+
+import (
+	"calendar/internal/app"
+	"calendar/internal/auth"
+	"calendar/internal/repository"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	davSeries = "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nPRODID:-//test//EN\r\n" +
+		"BEGIN:VEVENT\r\nUID:Team-Sync\r\nDTSTART:20250505T100000Z\r\nDTEND:20250505T110000Z\r\nRRULE:FREQ=DAILY;COUNT=3\r\nSUMMARY:sync\r\nEND:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\nUID:Team-Sync\r\nRECURRENCE-ID:20250506T100000Z\r\nDTSTART:20250506T150000Z\r\nDTEND:20250506T160000Z\r\nSUMMARY:moved sync\r\nEND:VEVENT\r\n" +
+		"END:VCALENDAR\r\n"
+	davSingle = "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n" +
+		"BEGIN:VEVENT\r\nUID:Team-Sync\r\nDTSTART:20250505T100000Z\r\nDTEND:20250505T120000Z\r\nSUMMARY:long sync\r\nEND:VEVENT\r\n" +
+		"END:VCALENDAR\r\n"
+)
+
+// davClient — минимальный клиент WebDAV: Basic с токеном в пароле, как у настольных клиентов календаря
+type davClient struct {
+	t     *testing.T
+	base  string
+	token string
+}
+
+func (c *davClient) do(method, path, body string, headers ...string) (*http.Response, string) {
+	c.t.Helper()
+	req, _ := http.NewRequest(method, c.base+path, strings.NewReader(body))
+	if c.token != "" {
+		req.SetBasicAuth("calendar", c.token)
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.Do(req)
+	if err != nil {
+		c.t.Fatalf("%s %s: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	return resp, string(data)
+}
+
+func (c *davClient) expect(status int, method, path, body string, headers ...string) (*http.Response, string) {
+	c.t.Helper()
+	resp, data := c.do(method, path, body, headers...)
+	if resp.StatusCode != status {
+		c.t.Fatalf("%s %s: expected %d, got %d: %s", method, path, status, resp.StatusCode, data)
+	}
+	return resp, data
+}
+
+// davValue вырезает текст первого элемента с префиксом tag, например "D:getetag"
+func davValue(body, tag string) string {
+	_, rest, ok := strings.Cut(body, "<"+tag+">")
+	if !ok {
+		return ""
+	}
+	value, _, _ := strings.Cut(rest, "</"+tag+">")
+	return value
+}
+
+func newDAVClient(t *testing.T) *davClient {
+	return newDAVClientFor(t, repository.NewInMemoryRepo())
+}
+
+func newDAVClientFor(t *testing.T, repo repository.Storage) *davClient {
+	t.Helper()
+	authn, _ := auth.NewAuthenticator("secret", "")
+	token, _ := authn.Issue(7, time.Hour)
+	r := chi.NewRouter()
+	RegisterRoutes(r, NewCalendarHandler(repo, zap.NewNop()), authn, Limits{})
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+	return &davClient{t: t, base: srv.URL, token: token}
+}
+
+func TestCalDAVDiscovery(t *testing.T) {
+	c := newDAVClient(t)
+
+	resp, _ := c.expect(http.StatusMovedPermanently, http.MethodGet, "/.well-known/caldav", "")
+	if resp.Header.Get("Location") != "/caldav/" {
+		t.Fatalf("unexpected redirect %q", resp.Header.Get("Location"))
+	}
+	resp, _ = c.expect(http.StatusOK, http.MethodOptions, "/caldav/7/calendar/", "")
+	if !strings.Contains(resp.Header.Get("DAV"), "calendar-access") {
+		t.Fatalf("calendar-access not advertised: %q", resp.Header.Get("DAV"))
+	}
+
+	anonymous := &davClient{t: t, base: c.base}
+	resp, _ = anonymous.expect(http.StatusUnauthorized, "PROPFIND", "/caldav/", "")
+	if !strings.Contains(strings.Join(resp.Header.Values("WWW-Authenticate"), ","), "Basic") {
+		t.Fatalf("Basic challenge expected, got %v", resp.Header.Values("WWW-Authenticate"))
+	}
+
+	propfind := `<?xml version="1.0"?><D:propfind xmlns:D="DAV:"><D:prop><D:current-user-principal/></D:prop></D:propfind>`
+	_, body := c.expect(http.StatusMultiStatus, "PROPFIND", "/caldav/", propfind, "Depth", "0")
+	if davValue(body, "D:href") != "/caldav/" || !strings.Contains(body, "<D:href>/caldav/7/</D:href>") {
+		t.Fatalf("principal not found:\n%s", body)
+	}
+
+	home := `<D:propfind xmlns:D="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav"><D:prop><C:calendar-home-set/><D:owner/></D:prop></D:propfind>`
+	_, body = c.expect(http.StatusMultiStatus, "PROPFIND", "/caldav/7/", home, "Depth", "1")
+	if !strings.Contains(body, "<C:calendar-home-set><D:href>/caldav/7/</D:href></C:calendar-home-set>") ||
+		!strings.Contains(body, "<D:href>/caldav/7/calendar/</D:href>") || !strings.Contains(body, "404 Not Found") {
+		t.Fatalf("unexpected principal props:\n%s", body)
+	}
+
+	c.expect(http.StatusForbidden, "PROPFIND", "/caldav/8/calendar/", "", "Depth", "0")
+}
+
+func TestCalDAVSync(t *testing.T) {
+	c := newDAVClient(t)
+	const object = "/caldav/7/calendar/Team-Sync.ics"
+	ctagProp := `<D:propfind xmlns:D="DAV:" xmlns:CS="http://calendarserver.org/ns/"><D:prop><CS:getctag/><D:getetag/></D:prop></D:propfind>`
+
+	_, body := c.expect(http.StatusMultiStatus, "PROPFIND", "/caldav/7/calendar/", ctagProp, "Depth", "0")
+	emptyTag := davValue(body, "CS:getctag")
+
+	// If-None-Match: * — клиент создаёт ресурс, только если его ещё нет
+	resp, _ := c.expect(http.StatusCreated, http.MethodPut, object, davSeries, "Content-Type", "text/calendar", "If-None-Match", "*")
+	etag := resp.Header.Get("ETag")
+	if etag == "" {
+		t.Fatal("ETag missing after PUT")
+	}
+	c.expect(http.StatusPreconditionFailed, http.MethodPut, object, davSeries, "If-None-Match", "*")
+
+	_, body = c.expect(http.StatusMultiStatus, "PROPFIND", "/caldav/7/calendar/", ctagProp, "Depth", "1")
+	if davValue(body, "CS:getctag") == emptyTag || !strings.Contains(body, "<D:href>"+object+"</D:href>") ||
+		!strings.Contains(body, "<D:getetag>"+strings.ReplaceAll(etag, `"`, "&#34;")+"</D:getetag>") {
+		t.Fatalf("calendar listing does not reflect the new object (etag %s):\n%s", etag, body)
+	}
+
+	resp, body = c.expect(http.StatusOK, http.MethodGet, object, "")
+	if resp.Header.Get("ETag") != etag || strings.Count(body, "UID:Team-Sync") != 2 ||
+		!strings.Contains(body, "RECURRENCE-ID:20250506T100000Z") {
+		t.Fatalf("unexpected object %s:\n%s", resp.Header.Get("ETag"), body)
+	}
+
+	query := func(start, end string) string {
+		return `<C:calendar-query xmlns:D="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav"><D:prop><D:getetag/><C:calendar-data/></D:prop>` +
+			`<C:filter><C:comp-filter name="VCALENDAR"><C:comp-filter name="VEVENT">` +
+			`<C:time-range start="` + start + `" end="` + end + `"/></C:comp-filter></C:comp-filter></C:filter></C:calendar-query>`
+	}
+	_, body = c.expect(http.StatusMultiStatus, "REPORT", "/caldav/7/calendar/", query("20250506T140000Z", "20250506T170000Z"), "Depth", "1")
+	if !strings.Contains(body, "<D:href>"+object+"</D:href>") || !strings.Contains(body, "SUMMARY:moved sync") {
+		t.Fatalf("calendar-query missed the moved occurrence:\n%s", body)
+	}
+	_, body = c.expect(http.StatusMultiStatus, "REPORT", "/caldav/7/calendar/", query("20260101T000000Z", "20260102T000000Z"), "Depth", "1")
+	if strings.Contains(body, "<D:response>") {
+		t.Fatalf("calendar-query outside the series matched:\n%s", body)
+	}
+
+	multiget := `<C:calendar-multiget xmlns:D="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav"><D:prop><D:getetag/><C:calendar-data/></D:prop>` +
+		`<D:href>` + object + `</D:href><D:href>/caldav/7/calendar/missing.ics</D:href></C:calendar-multiget>`
+	_, body = c.expect(http.StatusMultiStatus, "REPORT", "/caldav/7/calendar/", multiget, "Depth", "1")
+	if strings.Count(body, "<D:response>") != 2 || !strings.Contains(body, "BEGIN:VCALENDAR") ||
+		!strings.Contains(body, "<D:status>HTTP/1.1 404 Not Found</D:status>") {
+		t.Fatalf("unexpected multiget:\n%s", body)
+	}
+
+	// замена с устаревшим ETag отклоняется, с текущим — применяется целиком
+	c.expect(http.StatusPreconditionFailed, http.MethodPut, object, davSingle, "If-Match", `"stale"`)
+	resp, _ = c.expect(http.StatusNoContent, http.MethodPut, object, davSingle, "If-Match", etag)
+	if resp.Header.Get("ETag") == etag {
+		t.Fatal("ETag not changed after replace")
+	}
+	_, body = c.expect(http.StatusOK, http.MethodGet, object, "")
+	if strings.Count(body, "BEGIN:VEVENT") != 1 || !strings.Contains(body, "SUMMARY:long sync") || strings.Contains(body, "RRULE") {
+		t.Fatalf("object not replaced:\n%s", body)
+	}
+
+	c.expect(http.StatusBadRequest, http.MethodPut, "/caldav/7/calendar/other.ics", davSingle)
+	c.expect(http.StatusPreconditionFailed, http.MethodDelete, object, "", "If-Match", etag)
+	c.expect(http.StatusNoContent, http.MethodDelete, object, "")
+	c.expect(http.StatusNotFound, http.MethodGet, object, "")
+}
+
+// UID в виде uuid не становится event_id: иначе клиент мог бы занять id чужого события
+func TestCalDAVUUIDNameIsNotEventId(t *testing.T) {
+	repo := repository.NewInMemoryRepo()
+	other, _ := repo.Save(&app.EventRequest{UserID: 1, Date: "2025-05-05", EventText: "other"})
+	c := newDAVClientFor(t, repo)
+	uid := other.EventId.String()
+	object := "/caldav/7/calendar/" + uid + ".ics"
+
+	c.expect(http.StatusCreated, http.MethodPut, object, strings.ReplaceAll(davSingle, "Team-Sync", uid), "If-None-Match", "*")
+	events, _ := repo.LoadAll(7)
+	if len(events) != 1 || events[0].UID != uid || events[0].EventId == other.EventId {
+		t.Fatalf("unexpected events %+v", events)
+	}
+	if _, body := c.expect(http.StatusOK, http.MethodGet, object, ""); !strings.Contains(body, "UID:"+uid) {
+		t.Fatalf("object not found by its UID:\n%s", body)
+	}
+}
+
+// racingRepo выполняет race перед пакетом, как параллельный клиент между чтением ресурса и записью
+type racingRepo struct {
+	repository.Storage
+	mu   sync.Mutex
+	race func()
+}
+
+func (r *racingRepo) Batch(ops []repository.BatchOp, atomic bool) ([]repository.BatchResult, error) {
+	r.mu.Lock()
+	race := r.race
+	r.race = nil
+	r.mu.Unlock()
+	if race != nil {
+		race()
+	}
+	return r.Storage.Batch(ops, atomic)
+}
+
+func (r *racingRepo) before(race func()) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.race = race
+}
+
+func TestCalDAVPutChecksETagInStorage(t *testing.T) {
+	mem := repository.NewInMemoryRepo()
+	repo := &racingRepo{Storage: mem}
+	c := newDAVClientFor(t, repo)
+	const object = "/caldav/7/calendar/Team-Sync.ics"
+
+	resp, _ := c.expect(http.StatusCreated, http.MethodPut, object, davSeries, "If-None-Match", "*")
+	etag := resp.Header.Get("ETag")
+	events, _ := mem.LoadAll(7)
+	rename := func(text string) func() {
+		return func() {
+			mem.Update(&app.EventRequest{EventId: events[0].EventId.String(), UserID: 7, EventText: text})
+		}
+	}
+
+	// ресурс изменился после того, как обработчик его прочитал: If-Match клиента проверяет хранилище
+	repo.before(rename("changed elsewhere"))
+	c.expect(http.StatusPreconditionFailed, http.MethodPut, object, davSingle, "If-Match", etag)
+	if _, body := c.expect(http.StatusOK, http.MethodGet, object, ""); !strings.Contains(body, "SUMMARY:changed elsewhere") ||
+		!strings.Contains(body, "RECURRENCE-ID:20250506T100000Z") {
+		t.Fatalf("rejected PUT changed the object:\n%s", body)
+	}
+
+	// без условий клиента ресурс перечитывается и заменяется целиком
+	repo.before(rename("changed again"))
+	c.expect(http.StatusNoContent, http.MethodPut, object, davSingle)
+	if _, body := c.expect(http.StatusOK, http.MethodGet, object, ""); strings.Count(body, "BEGIN:VEVENT") != 1 || !strings.Contains(body, "SUMMARY:long sync") {
+		t.Fatalf("object not replaced:\n%s", body)
+	}
+}
+
+func TestCalDAVPutIsAtomic(t *testing.T) {
+	repo := repository.NewInMemoryRepo()
+	c := newDAVClientFor(t, repo)
+	const object = "/caldav/7/calendar/Team-Sync.ics"
+
+	// вхождения 12:00 в серии нет: ресурс не создаётся вовсе, а не остаётся без вхождения
+	broken := strings.ReplaceAll(davSeries, "RECURRENCE-ID:20250506T100000Z", "RECURRENCE-ID:20250506T120000Z")
+	c.expect(http.StatusNotFound, http.MethodPut, object, broken, "If-None-Match", "*")
+	if events, _ := repo.LoadAll(7); len(events) != 0 {
+		t.Fatalf("failed PUT left events behind: %+v", events)
+	}
+	c.expect(http.StatusNotFound, http.MethodGet, object, "")
+
+	// неудачная замена оставляет серию и вхождение прежними
+	resp, _ := c.expect(http.StatusCreated, http.MethodPut, object, davSeries)
+	c.expect(http.StatusNotFound, http.MethodPut, object, strings.ReplaceAll(broken, "SUMMARY:sync", "SUMMARY:renamed"))
+	if after, _ := c.expect(http.StatusOK, http.MethodGet, object, ""); after.Header.Get("ETag") != resp.Header.Get("ETag") {
+		t.Fatal("failed replace changed the object")
+	}
+}
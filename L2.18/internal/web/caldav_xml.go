@@ -0,0 +1,179 @@
+package web
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	nsDAV    = "DAV:"
+	nsCalDAV = "urn:ietf:params:xml:ns:caldav"
+	nsCS     = "http://calendarserver.org/ns/"
+
+	davTimeLayout = "20060102T150405Z"
+)
+
+// davPrefixes — префиксы известных пространств имён в ответах
+var davPrefixes = map[string]string{nsDAV: "D", nsCalDAV: "C", nsCS: "CS"}
+
+// propNames — имена свойств из <D:prop> запроса
+type propNames []xml.Name
+
+func (p *propNames) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			*p = append(*p, t.Name)
+			if err := d.Skip(); err != nil {
+				return err
+			}
+		case xml.EndElement:
+			return nil
+		}
+	}
+}
+
+type propfindRequest struct {
+	XMLName xml.Name  `xml:"DAV: propfind"`
+	Prop    propNames `xml:"DAV: prop"`
+	AllProp *struct{} `xml:"DAV: allprop"`
+}
+
+// reportRequest — calendar-query или calendar-multiget, различаются по XMLName
+type reportRequest struct {
+	XMLName xml.Name
+	Prop    propNames   `xml:"DAV: prop"`
+	Hrefs   []string    `xml:"DAV: href"`
+	Filter  *compFilter `xml:"urn:ietf:params:xml:ns:caldav filter>comp-filter"`
+}
+
+// compFilter — фильтр calendar-query; поддерживаются VCALENDAR > VEVENT с time-range
+type compFilter struct {
+	Name      string       `xml:"name,attr"`
+	TimeRange *timeRange   `xml:"urn:ietf:params:xml:ns:caldav time-range"`
+	Comps     []compFilter `xml:"urn:ietf:params:xml:ns:caldav comp-filter"`
+}
+
+type timeRange struct {
+	Start string `xml:"start,attr"`
+	End   string `xml:"end,attr"`
+}
+
+// bounds возвращает интервал фильтра; отсутствующая граница не ограничивает
+func (t *timeRange) bounds() (time.Time, time.Time, error) {
+	from, to := time.Time{}, time.Date(9999, 1, 1, 0, 0, 0, 0, time.UTC)
+	var err error
+	if t.Start != "" {
+		if from, err = time.Parse(davTimeLayout, t.Start); err != nil {
+			return from, to, err
+		}
+	}
+	if t.End != "" {
+		if to, err = time.Parse(davTimeLayout, t.End); err != nil {
+			return from, to, err
+		}
+	}
+	return from, to, nil
+}
+
+// decodeDAV разбирает тело запроса WebDAV; пустое тело оставляет v нулевым
+func decodeDAV(r *http.Request, v any) error {
+	err := xml.NewDecoder(r.Body).Decode(v)
+	if err == io.EOF {
+		return nil
+	}
+	return err
+}
+
+type multistatus struct {
+	XMLName   xml.Name      `xml:"D:multistatus"`
+	XmlnsD    string        `xml:"xmlns:D,attr"`
+	XmlnsC    string        `xml:"xmlns:C,attr"`
+	XmlnsCS   string        `xml:"xmlns:CS,attr"`
+	Responses []davResponse `xml:"D:response"`
+}
+
+type davResponse struct {
+	Href      string     `xml:"D:href"`
+	Propstats []propstat `xml:"D:propstat,omitempty"`
+	Status    string     `xml:"D:status,omitempty"`
+}
+
+type propstat struct {
+	Prop   davPropList `xml:"D:prop"`
+	Status string      `xml:"D:status"`
+}
+
+type davPropList struct {
+	Props []davProp
+}
+
+// davProp — свойство с готовым XML внутри; имя уже с префиксом, если пространство известно
+type davProp struct {
+	XMLName xml.Name
+	Inner   string `xml:",innerxml"`
+}
+
+// davProps — значения свойств ресурса: внутренний XML по имени свойства
+type davProps map[xml.Name]string
+
+// response отбирает запрошенные свойства: найденные в 200, остальные в 404. Без списка отдаются все.
+func (p davProps) response(href string, names propNames) davResponse {
+	if len(names) == 0 {
+		for name := range p {
+			names = append(names, name)
+		}
+	}
+	var found, missing []davProp
+	for _, name := range names {
+		if inner, ok := p[name]; ok {
+			found = append(found, davProp{XMLName: prefixed(name), Inner: inner})
+		} else {
+			missing = append(missing, davProp{XMLName: prefixed(name)})
+		}
+	}
+	resp := davResponse{Href: href}
+	if len(found) > 0 {
+		resp.Propstats = append(resp.Propstats, propstat{Prop: davPropList{found}, Status: davStatus(http.StatusOK)})
+	}
+	if len(missing) > 0 {
+		resp.Propstats = append(resp.Propstats, propstat{Prop: davPropList{missing}, Status: davStatus(http.StatusNotFound)})
+	}
+	return resp
+}
+
+func prefixed(name xml.Name) xml.Name {
+	if prefix, ok := davPrefixes[name.Space]; ok {
+		return xml.Name{Local: prefix + ":" + name.Local}
+	}
+	return name
+}
+
+func davStatus(code int) string {
+	return fmt.Sprintf("HTTP/1.1 %d %s", code, http.StatusText(code))
+}
+
+func hrefXML(href string) string {
+	return "<D:href>" + escapeXML(href) + "</D:href>"
+}
+
+func escapeXML(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+func writeMultistatus(w http.ResponseWriter, responses []davResponse) error {
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.WriteHeader(http.StatusMultiStatus)
+	io.WriteString(w, xml.Header)
+	return xml.NewEncoder(w).Encode(multistatus{XmlnsD: nsDAV, XmlnsC: nsCalDAV, XmlnsCS: nsCS, Responses: responses})
+}
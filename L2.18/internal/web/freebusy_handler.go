@@ -0,0 +1,146 @@
+package web
+
+import (
+	"calendar/internal/app"
+	"calendar/internal/repository"
+	"fmt"
+	"go.uber.org/zap"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultSlotDuration = 30 * time.Minute
+	defaultSlotLimit    = 10
+	maxSlotLimit        = 100
+	maxFreeBusyUsers    = 50
+	maxFreeBusyRange    = 62 * 24 * time.Hour
+)
+
+// FreeBusy — занятость нескольких пользователей за интервал
+type FreeBusy struct {
+	From  time.Time      `json:"from"`
+	To    time.Time      `json:"to"`
+	Busy  []app.Interval `json:"busy"`  // время, занятое хотя бы у одного пользователя
+	Free  []app.Interval `json:"free"`  // промежутки, где свободны все, не короче duration
+	Slots []app.Interval `json:"slots"` // предложенные слоты длины duration
+}
+
+// FreeBusy godoc
+// @Summary      Free/busy
+// @Description  Merged busy intervals of several users and suggested free slots of the requested length.
+// @Description  All-day and zero-length events do not occupy time. Only times are returned, not event details,
+// @Description  so any authenticated user may query any user.
+// @Tags         events
+// @Security     BearerAuth
+// @Produce      json
+// @Param        user_ids  query  string  true   "Comma-separated user IDs, up to 50"
+// @Param        from      query  string  true   "Interval start: RFC 3339 or YYYY-MM-DD"
+// @Param        to        query  string  true   "Interval end: RFC 3339 (exclusive) or YYYY-MM-DD (inclusive), at most 62 days after from"
+// @Param        tz        query  string  false  "IANA time zone for dates, e.g. Europe/Moscow (default UTC)"
+// @Param        duration  query  string  false  "Slot length as Go duration, e.g. 45m (default 30m)"
+// @Param        limit     query  int     false  "Max number of slots, 1..100 (default 10)"
+// @Success      200  {object}  FreeBusy
+// @Failure 	 400  {object} ErrorResponse "invalid user_ids, interval, duration or limit"
+// @Failure 	 401  {object} ErrorResponse "missing or invalid token"
+// @Failure 	 429  {object} ErrorResponse "rate limit exceeded, see Retry-After"
+// @Failure 	 500  {object} ErrorResponse "internal server error"
+// @Router       /freebusy [get]
+func (h *CalendarHandler) FreeBusy(w http.ResponseWriter, r *http.Request) {
+	rq := r.URL.Query()
+
+	users, err := parseUserIDs(rq.Get("user_ids"))
+	if err != nil {
+		h.log(r).Warn("invalid user ids", zap.Error(err))
+		writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	loc, err := app.LocationParser(rq.Get("tz"))
+	if err != nil {
+		h.log(r).Warn("invalid time zone", zap.Error(err))
+		writeError(w, "invalid tz", http.StatusBadRequest)
+		return
+	}
+	from, to, err := app.BoundsParser(rq.Get("from"), rq.Get("to"), loc)
+	if err != nil {
+		h.log(r).Warn("invalid interval", zap.Error(err))
+		writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if to.Sub(from) > maxFreeBusyRange {
+		writeError(w, "interval must not exceed 62 days", http.StatusBadRequest)
+		return
+	}
+
+	duration := defaultSlotDuration
+	if raw := rq.Get("duration"); raw != "" {
+		if duration, err = time.ParseDuration(raw); err != nil || duration <= 0 {
+			writeError(w, "duration must be a positive Go duration, e.g. 30m", http.StatusBadRequest)
+			return
+		}
+	}
+	limit := defaultSlotLimit
+	if raw := rq.Get("limit"); raw != "" {
+		if limit, err = strconv.Atoi(raw); err != nil || limit < 1 || limit > maxSlotLimit {
+			writeError(w, fmt.Sprintf("limit must be between 1 and %d", maxSlotLimit), http.StatusBadRequest)
+			return
+		}
+	}
+
+	store := h.store(r)
+	var events []*app.Event
+	for _, user := range users {
+		page, err := store.LoadRange(user, from, to, repository.RangeOptions{})
+		if err != nil {
+			errParser(w, h.log(r), err, "free/busy load failed")
+			return
+		}
+		events = append(events, page.Events...)
+	}
+
+	busy := app.Busy(events, from, to)
+	free := app.Free(busy, from, to, duration)
+	result := FreeBusy{
+		From:  from,
+		To:    to,
+		Busy:  nonNil(busy),
+		Free:  nonNil(free),
+		Slots: nonNil(app.Slots(free, duration, limit)),
+	}
+	h.log(r).Info("free/busy fetched", zap.Ints("user_ids", users), zap.Int("busy", len(busy)))
+	writeJson(w, result)
+}
+
+// parseUserIDs разбирает список через запятую, повторы отбрасываются
+func parseUserIDs(raw string) ([]int, error) {
+	if raw == "" {
+		return nil, fmt.Errorf("user_ids is required")
+	}
+	var users []int
+	seen := make(map[int]bool)
+	for _, s := range strings.Split(raw, ",") {
+		id, err := strconv.Atoi(strings.TrimSpace(s))
+		if err != nil {
+			return nil, fmt.Errorf("invalid user id %q", s)
+		}
+		if !seen[id] {
+			seen[id] = true
+			users = append(users, id)
+		}
+	}
+	if len(users) > maxFreeBusyUsers {
+		return nil, fmt.Errorf("at most %d user_ids are allowed", maxFreeBusyUsers)
+	}
+	return users, nil
+}
+
+// nonNil — чтобы пустой список отдавался как [], а не null
+func nonNil(intervals []app.Interval) []app.Interval {
+	if intervals == nil {
+		return []app.Interval{}
+	}
+	return intervals
+}
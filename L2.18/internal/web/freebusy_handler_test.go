@@ -0,0 +1,71 @@
+package web
+
+import (
+	"calendar/internal/repository"
+	"encoding/json"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+	"net/http"
+	"testing"
+)
+
+func TestFreeBusy(t *testing.T) {
+	repo := repository.NewInMemoryRepo()
+	repo.SetRejectConflicts(true)
+	r := chi.NewRouter()
+	RegisterRoutes(r, NewCalendarHandler(repo, zap.NewNop()), nil, Limits{})
+
+	for _, body := range []string{
+		`{"user_id": 1, "start": "2025-05-05T10:00:00Z", "end": "2025-05-05T11:00:00Z", "resource": "room-1", "event": "a"}`,
+		`{"user_id": 2, "start": "2025-05-05T10:30:00Z", "end": "2025-05-05T12:00:00Z", "event": "b"}`,
+		`{"user_id": 3, "start": "2025-05-05T09:00:00Z", "end": "2025-05-05T18:00:00Z", "event": "c"}`,
+	} {
+		if w := doV2(t, r, http.MethodPost, "/create_event", body); w.Code != http.StatusOK {
+			t.Fatalf("create: %d %s", w.Code, w.Body)
+		}
+	}
+
+	w := doV2(t, r, http.MethodPost, "/create_event",
+		`{"user_id": 4, "start": "2025-05-05T10:15:00Z", "end": "2025-05-05T10:45:00Z", "resource": "room-1", "event": "d"}`)
+	var errBody ErrorResponse
+	json.NewDecoder(w.Body).Decode(&errBody)
+	if w.Code != http.StatusConflict || errBody.Code != CodeConflict || len(errBody.Conflicts) != 1 || errBody.Conflicts[0].UserID != 0 || errBody.Conflicts[0].Resource != "room-1" {
+		t.Fatalf("expected 409 with conflicts, got %d %+v", w.Code, errBody)
+	}
+
+	w = doV2(t, r, http.MethodGet, "/freebusy?user_ids=1,2&from=2025-05-05T09:00:00Z&to=2025-05-05T13:00:00Z&duration=45m", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("freebusy: %d %s", w.Code, w.Body)
+	}
+	var out struct {
+		Result FreeBusy `json:"result"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&out); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	fb := out.Result
+	if len(fb.Busy) != 1 || fb.Busy[0].Start.Hour() != 10 || fb.Busy[0].End.Hour() != 12 {
+		t.Fatalf("unexpected busy %+v", fb.Busy)
+	}
+	if len(fb.Free) != 2 || len(fb.Slots) != 2 || fb.Slots[1].Start.Hour() != 12 {
+		t.Fatalf("unexpected free %+v / slots %+v", fb.Free, fb.Slots)
+	}
+
+	w = doV2(t, r, http.MethodGet, "/freebusy?user_ids=1,3&from=2025-05-05&to=2025-05-05", "")
+	json.NewDecoder(w.Body).Decode(&out)
+	if w.Code != http.StatusOK || len(out.Result.Slots) != 10 || out.Result.Slots[0].Start.Hour() != 0 {
+		t.Fatalf("expected 10 default slots from midnight, got %d %+v", w.Code, out.Result.Slots)
+	}
+
+	for _, url := range []string{
+		"/freebusy?from=2025-05-05&to=2025-05-06",
+		"/freebusy?user_ids=1,x&from=2025-05-05&to=2025-05-06",
+		"/freebusy?user_ids=1&from=2025-05-05&to=2025-09-01",
+		"/freebusy?user_ids=1&from=2025-05-05&to=2025-05-06&duration=-5m",
+		"/freebusy?user_ids=1&from=2025-05-05&to=2025-05-06&limit=0",
+	} {
+		if w := doV2(t, r, http.MethodGet, url, ""); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", url, w.Code)
+		}
+	}
+}
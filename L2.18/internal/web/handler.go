@@ -2,19 +2,39 @@ package web
 
 import (
 	"calendar/internal/app"
+	"calendar/internal/auth"
+	"calendar/internal/feed"
+	"calendar/internal/logger"
 	"calendar/internal/repository"
+	"calendar/internal/webhook"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"go.uber.org/zap"
+	"io"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 )
 
+const (
+	defaultPageSize = 100
+	maxPageSize     = 1000
+
+	IdempotencyKeyHeader = "Idempotency-Key"
+)
+
 type CalendarHandler struct {
 	repo   repository.Storage
 	logger *zap.Logger
+
+	// feed — лента изменений для /events/stream, nil если не подключена
+	feed      *feed.Hub
+	heartbeat time.Duration
+
+	// webhooks — подписки на изменения для /webhooks, nil если вебхуки выключены
+	webhooks *webhook.Dispatcher
 }
 
 func NewCalendarHandler(repo repository.Storage, logger *zap.Logger) *CalendarHandler {
@@ -26,200 +46,499 @@ func NewCalendarHandler(repo repository.Storage, logger *zap.Logger) *CalendarHa
 
 // CreateEvent godoc
 // @Summary Create event
-// @Description Create new calendar event
+// @Description Create new calendar event, optionally recurring by an iCalendar RRULE
 // @Tags events
+// @Security     BearerAuth
 // @Accept json
 // @Produce json
 // @Param event body app.EventRequest true "Event to create"
+// @Param        Idempotency-Key  header  string  false  "Repeating a create with the same key returns the original event (keys are kept for 24h)"
 // @Success 	 200 {object} app.Event "created event" // note: response wrapped as {"result": <app.Event>}
+// @Header       200  {string}  ETag  "Current version of the event"
 // @Failure 	 400  {object} ErrorResponse "invalid user_id or date"
-// @Failure	 	 503  {object} ErrorResponse "service unavailable"
+// @Failure 	 401  {object} ErrorResponse "missing or invalid token"
+// @Failure 	 403  {object} ErrorResponse "user_id does not match token"
+// @Failure 	 409  {object} ErrorResponse "event_id already exists or the time slot is busy (see conflicts)"
+// @Failure 	 413  {object} ErrorResponse "request body is too large"
+// @Failure 	 422  {object} ErrorResponse "validation failed, see fields"
+// @Failure 	 429  {object} ErrorResponse "rate limit exceeded, see Retry-After"
 // @Failure 	 500  {object} ErrorResponse "internal server error"
 // @Router /create_event [post]
 func (h *CalendarHandler) CreateEvent(w http.ResponseWriter, r *http.Request) {
 	var er app.EventRequest
-	if err := json.NewDecoder(r.Body).Decode(&er); err != nil {
-		h.logger.Warn("invalid request body", zap.Error(err))
-		writeError(w, "bad calendar request", 400)
+	if err := decodeJSON(r, &er); err != nil {
+		writeBodyError(w, h.log(r), err, "bad calendar request")
+		return
+	}
+	if !h.resolveBodyUser(w, r, &er) {
 		return
 	}
-	e, err := h.repo.Save(&er)
+	e, err := h.store(r).Save(&er)
 	if err != nil {
-		errParser(w, h.logger, err, "save failed")
+		errParser(w, h.log(r), err, "save failed")
 		return
 	}
-	h.logger.Info("event created", zap.String("event_id", e.EventId.String()))
-	writeJson(w, e)
+	h.log(r).Info("event created", zap.String("event_id", e.EventId.String()))
+	writeEvent(w, http.StatusOK, e)
 }
 
 // UpdateEvent godoc
 // @Summary Update event
-// @Description Update existing calendar event (by event_id)
+// @Description Update existing calendar event (by event_id). For a recurring event scope=all (default) changes the whole series,
+// @Description scope=this with recurrence_id changes a single occurrence, which becomes a separate event.
 // @Tags events
+// @Security     BearerAuth
 // @Accept json
 // @Produce json
 // @Param event body app.EventRequest true "Event update request"
+// @Param        If-Match  header  string  false  "ETag of the event; the change is applied only if it still matches"
 // @Success 	 200 {object} app.Event "updated event" // note: response wrapped as {"result": <app.Event>}
+// @Header       200  {string}  ETag  "Current version of the event"
 // @Failure 	 400  {object} ErrorResponse "invalid user_id or date"
-// @Failure	 	 503  {object} ErrorResponse "service unavailable"
+// @Failure 	 401  {object} ErrorResponse "missing or invalid token"
+// @Failure 	 403  {object} ErrorResponse "user_id does not match token"
+// @Failure 	 404  {object} ErrorResponse "event or occurrence not found"
+// @Failure 	 409  {object} ErrorResponse "time slot is busy (see conflicts)"
+// @Failure 	 412  {object} ErrorResponse "If-Match does not match the current ETag"
+// @Failure 	 413  {object} ErrorResponse "request body is too large"
+// @Failure 	 422  {object} ErrorResponse "validation failed or nothing to update"
+// @Failure 	 429  {object} ErrorResponse "rate limit exceeded, see Retry-After"
 // @Failure 	 500  {object} ErrorResponse "internal server error"
 // @Router /update_event [post]
 func (h *CalendarHandler) UpdateEvent(w http.ResponseWriter, r *http.Request) {
 	var er app.EventRequest
-	if err := json.NewDecoder(r.Body).Decode(&er); err != nil {
-		h.logger.Warn("invalid request body", zap.Error(err))
-		writeError(w, "bad calendar update request", 400)
+	if err := decodeJSON(r, &er); err != nil {
+		writeBodyError(w, h.log(r), err, "bad calendar update request")
 		return
 	}
-	e, err := h.repo.Update(&er)
+	if !h.resolveBodyUser(w, r, &er) {
+		return
+	}
+	e, err := h.store(r).Update(&er)
 	if err != nil {
-		errParser(w, h.logger, err, "update failed")
+		errParser(w, h.log(r), err, "update failed")
 		return
 	}
-	h.logger.Info("event updated", zap.String("event_id", e.EventId.String()))
-	writeJson(w, e)
+	h.log(r).Info("event updated", zap.String("event_id", e.EventId.String()))
+	writeEvent(w, http.StatusOK, e)
 }
 
 // DeleteEvent godoc
 // @Summary Delete event
-// @Description Delete event by event_id for given user
+// @Description Delete event by event_id for given user. For a recurring event scope=all (default) deletes the whole series,
+// @Description scope=this with recurrence_id deletes a single occurrence.
 // @Tags events
+// @Security     BearerAuth
 // @Accept json
 // @Produce json
 // @Param event body app.EventRequest true "Event delete request (needs event_id and user_id)"
+// @Param        If-Match  header  string  false  "ETag of the event; the change is applied only if it still matches"
 // @Success      200  {array}  app.EventRequest
 // @Failure 	 400  {object} ErrorResponse "invalid user_id or date"
-// @Failure	 	 503  {object} ErrorResponse "service unavailable"
+// @Failure 	 401  {object} ErrorResponse "missing or invalid token"
+// @Failure 	 403  {object} ErrorResponse "user_id does not match token"
+// @Failure 	 404  {object} ErrorResponse "event or occurrence not found"
+// @Failure 	 412  {object} ErrorResponse "If-Match does not match the current ETag"
+// @Failure 	 413  {object} ErrorResponse "request body is too large"
+// @Failure 	 422  {object} ErrorResponse "validation failed"
+// @Failure 	 429  {object} ErrorResponse "rate limit exceeded, see Retry-After"
 // @Failure 	 500  {object} ErrorResponse "internal server error"
 // @Router /delete_event [post]
 func (h *CalendarHandler) DeleteEvent(w http.ResponseWriter, r *http.Request) {
 	var er app.EventRequest
-	if err := json.NewDecoder(r.Body).Decode(&er); err != nil {
-		h.logger.Warn("invalid request body", zap.Error(err))
-		writeError(w, "bad calendar delete request", http.StatusBadRequest)
+	if err := decodeJSON(r, &er); err != nil {
+		writeBodyError(w, h.log(r), err, "bad calendar delete request")
+		return
+	}
+	if !h.resolveBodyUser(w, r, &er) {
 		return
 	}
-	err := h.repo.Delete(&er)
+	err := h.store(r).Delete(&er)
 	if err != nil {
-		errParser(w, h.logger, err, "delete failed")
+		errParser(w, h.log(r), err, "delete failed")
 		return
 	}
-	h.logger.Info("event updated", zap.String("event_id", er.EventId))
+	h.log(r).Info("event updated", zap.String("event_id", er.EventId))
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	writeJson(w, er)
 }
 
+// RespondEvent godoc
+// @Summary      Respond to invitation
+// @Description  Set the attendee's RSVP status (accepted, declined, tentative) for an event or a whole series
+// @Tags         events
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        rsvp  body  app.RSVPRequest  true  "Attendee answer"
+// @Success 	 200 {object} app.Event "event with the new status" // note: response wrapped as {"result": <app.Event>}
+// @Failure 	 400  {object} ErrorResponse "malformed body"
+// @Failure 	 401  {object} ErrorResponse "missing or invalid token"
+// @Failure 	 403  {object} ErrorResponse "user_id does not match token"
+// @Failure 	 404  {object} ErrorResponse "event not found or user is not invited"
+// @Failure 	 413  {object} ErrorResponse "request body is too large"
+// @Failure 	 422  {object} ErrorResponse "invalid status"
+// @Failure 	 429  {object} ErrorResponse "rate limit exceeded, see Retry-After"
+// @Failure 	 500  {object} ErrorResponse "internal server error"
+// @Router       /respond_event [post]
+func (h *CalendarHandler) RespondEvent(w http.ResponseWriter, r *http.Request) {
+	var rsvp app.RSVPRequest
+	if err := decodeJSON(r, &rsvp); err != nil {
+		writeBodyError(w, h.log(r), err, "bad rsvp request")
+		return
+	}
+	user, err := auth.ResolveUser(r.Context(), rsvp.UserID)
+	if err != nil {
+		h.log(r).Warn("access denied", zap.Error(err))
+		writeError(w, "user_id does not match token", http.StatusForbidden)
+		return
+	}
+	rsvp.UserID, rsvp.ActorID = user, auth.Actor(r.Context())
+	e, err := h.store(r).Respond(&rsvp)
+	if err != nil {
+		errParser(w, h.log(r), err, "respond failed")
+		return
+	}
+	h.log(r).Info("invitation answered", zap.String("event_id", rsvp.EventId), zap.String("status", rsvp.Status))
+	writeEvent(w, http.StatusOK, e)
+}
+
 // EventsForDay godoc
 // @Summary      Events for day
-// @Description  Get events for a specific day for a user
+// @Description  Get events overlapping a specific day (in the user's time zone) for a user
 // @Tags         events
+// @Security     BearerAuth
 // @Accept       json
 // @Produce      json
 // @Param        user_id  query  int     true  "User ID"
 // @Param        date     query  string  true  "Date in format YYYY-MM-DD"
+// @Param        tz       query  string  false "IANA time zone of the user, e.g. Europe/Moscow (default UTC)"
 // @Success      200  {array}   app.Event  "list of events"
 // @Failure 	 400  {object} ErrorResponse "invalid user_id or date"
-// @Failure	 	 503  {object} ErrorResponse "service unavailable"
+// @Failure 	 401  {object} ErrorResponse "missing or invalid token"
+// @Failure 	 403  {object} ErrorResponse "user_id does not match token"
+// @Failure 	 422  {object} ErrorResponse "validation failed"
+// @Failure 	 429  {object} ErrorResponse "rate limit exceeded, see Retry-After"
 // @Failure 	 500  {object} ErrorResponse "internal server error"
 // @Router       /events_for_day [get]
 func (h *CalendarHandler) EventsForDay(w http.ResponseWriter, r *http.Request) {
-	h.eventsHandler(h.repo.LoadDay, "Day")(w, r)
+	h.eventsHandler(h.store(r).LoadDay, "Day")(w, r)
 }
 
 // EventsForWeek godoc
 // @Summary      Events for week
-// @Description  Get events for the ISO week that contains the given date
+// @Description  Get events overlapping the ISO week that contains the given date
 // @Tags         events
+// @Security     BearerAuth
 // @Accept       json
 // @Produce      json
 // @Param        user_id  query  int     true  "User ID"
 // @Param        date     query  string  true  "Date in format YYYY-MM-DD (any day of the week)"
+// @Param        tz       query  string  false "IANA time zone of the user, e.g. Europe/Moscow (default UTC)"
 // @Success      200  {array}  app.Event
 // @Failure 	 400  {object} ErrorResponse "invalid user_id or date"
-// @Failure	 	 503  {object} ErrorResponse "service unavailable"
+// @Failure 	 401  {object} ErrorResponse "missing or invalid token"
+// @Failure 	 403  {object} ErrorResponse "user_id does not match token"
+// @Failure 	 422  {object} ErrorResponse "validation failed"
+// @Failure 	 429  {object} ErrorResponse "rate limit exceeded, see Retry-After"
 // @Failure 	 500  {object} ErrorResponse "internal server error"
 // @Router       /events_for_week [get]
 func (h *CalendarHandler) EventsForWeek(w http.ResponseWriter, r *http.Request) {
-	h.eventsHandler(h.repo.LoadWeek, "Week")(w, r)
+	h.eventsHandler(h.store(r).LoadWeek, "Week")(w, r)
 }
 
 // EventsForMonth godoc
 // @Summary      Events for month
-// @Description  Get events for the month that contains the given date
+// @Description  Get events overlapping the month that contains the given date
 // @Tags         events
+// @Security     BearerAuth
 // @Accept       json
 // @Produce      json
 // @Param        user_id  query  int     true  "User ID"
 // @Param        date     query  string  true  "Date in format YYYY-MM-DD (any day of the month)"
+// @Param        tz       query  string  false "IANA time zone of the user, e.g. Europe/Moscow (default UTC)"
 // @Success      200  {array}   app.Event
 // @Failure 	 400  {object} ErrorResponse "invalid user_id or date"
-// @Failure	 	 503  {object} ErrorResponse "service unavailable"
+// @Failure 	 401  {object} ErrorResponse "missing or invalid token"
+// @Failure 	 403  {object} ErrorResponse "user_id does not match token"
+// @Failure 	 422  {object} ErrorResponse "validation failed"
+// @Failure 	 429  {object} ErrorResponse "rate limit exceeded, see Retry-After"
 // @Failure 	 500  {object} ErrorResponse "internal server error"
 // @Router       /events_for_month [get]
 func (h *CalendarHandler) EventsForMonth(w http.ResponseWriter, r *http.Request) {
-	h.eventsHandler(h.repo.LoadMonth, "Month")(w, r)
+	h.eventsHandler(h.store(r).LoadMonth, "Month")(w, r)
+}
+
+// EventsForRange godoc
+// @Summary      Events for range
+// @Description  Get occurrences overlapping an arbitrary interval, ordered by start, page by page.
+// @Description  Pass next_cursor from the previous page as cursor to get the next one.
+// @Tags         events
+// @Security     BearerAuth
+// @Produce      json
+// @Param        user_id  query  int     true   "User ID"
+// @Param        from     query  string  true   "Interval start: RFC 3339 or YYYY-MM-DD"
+// @Param        to       query  string  true   "Interval end: RFC 3339 (exclusive) or YYYY-MM-DD (inclusive)"
+// @Param        tz       query  string  false  "IANA time zone for dates, e.g. Europe/Moscow (default UTC)"
+// @Param        q        query  string  false  "Case-insensitive substring of the event text"
+// @Param        cursor   query  string  false  "next_cursor of the previous page"
+// @Param        limit    query  int     false  "Page size, 1..1000 (default 100)"
+// @Success      200  {object}  repository.EventPage
+// @Failure 	 400  {object} ErrorResponse "invalid user_id, interval, cursor or limit"
+// @Failure 	 401  {object} ErrorResponse "missing or invalid token"
+// @Failure 	 403  {object} ErrorResponse "user_id does not match token"
+// @Failure 	 422  {object} ErrorResponse "validation failed"
+// @Failure 	 429  {object} ErrorResponse "rate limit exceeded, see Retry-After"
+// @Failure 	 500  {object} ErrorResponse "internal server error"
+// @Router       /events_for_range [get]
+func (h *CalendarHandler) EventsForRange(w http.ResponseWriter, r *http.Request) {
+	rq := r.URL.Query()
+
+	user, ok := h.queryUser(w, r)
+	if !ok {
+		return
+	}
+
+	loc, err := app.LocationParser(rq.Get("tz"))
+	if err != nil {
+		h.log(r).Warn("invalid time zone", zap.Error(err))
+		writeError(w, "invalid tz", http.StatusBadRequest)
+		return
+	}
+
+	from, to, err := app.BoundsParser(rq.Get("from"), rq.Get("to"), loc)
+	if err != nil {
+		h.log(r).Warn("invalid interval", zap.Error(err))
+		writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	limit := defaultPageSize
+	if raw := rq.Get("limit"); raw != "" {
+		if limit, err = strconv.Atoi(raw); err != nil || limit < 1 || limit > maxPageSize {
+			writeError(w, fmt.Sprintf("limit must be between 1 and %d", maxPageSize), http.StatusBadRequest)
+			return
+		}
+	}
+
+	page, err := h.store(r).LoadRange(user, from, to, repository.RangeOptions{
+		Text:   rq.Get("q"),
+		Cursor: rq.Get("cursor"),
+		Limit:  limit,
+	})
+	if err != nil {
+		errParser(w, h.log(r), err, "events for range load failed")
+		return
+	}
+
+	h.log(r).Info("events fetched", zap.String("Period", "Range"), zap.Int("user_id", user))
+	writeJson(w, page)
 }
 
 func (h *CalendarHandler) eventsHandler(loadFunc func(user int, date time.Time) ([]*app.Event, error), period string) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		rq := r.URL.Query()
 
-		user, err := strconv.Atoi(rq.Get("user_id"))
+		user, ok := h.queryUser(w, r)
+		if !ok {
+			return
+		}
+
+		loc, err := app.LocationParser(rq.Get("tz"))
 		if err != nil {
-			h.logger.Warn("invalid user id", zap.Error(err))
-			writeError(w, "invalid user_id", http.StatusBadRequest)
+			h.log(r).Warn("invalid time zone", zap.Error(err))
+			writeError(w, "invalid tz", http.StatusBadRequest)
 			return
 		}
 
 		date := rq.Get("date")
-		d, err := app.TimeParser(date)
+		d, err := app.DateParser(date, loc)
 		if err != nil {
-			h.logger.Warn("invalid date", zap.Error(err))
+			h.log(r).Warn("invalid date", zap.Error(err))
 			writeError(w, "invalid date", http.StatusBadRequest)
 			return
 		}
 
 		events, err := loadFunc(user, d)
 		if err != nil {
-			errParser(w, h.logger, err, fmt.Sprintf("events for %s load failed ", period))
+			errParser(w, h.log(r), err, fmt.Sprintf("events for %s load failed ", period))
 			return
 		}
 
-		h.logger.Info("events fetched", zap.String("Period", period), zap.Int("user_id", user))
+		h.log(r).Info("events fetched", zap.String("Period", period), zap.Int("user_id", user))
 		writeJson(w, events)
 	}
 }
 
+// store привязывает хранилище к контексту запроса, чтобы спаны хранилища попали в трассу запроса
+func (h *CalendarHandler) store(r *http.Request) repository.Storage {
+	return repository.WithContext(r.Context(), h.repo)
+}
+
+// log — логгер запроса: в каждой строке есть request_id
+func (h *CalendarHandler) log(r *http.Request) *zap.Logger {
+	return logger.FromContext(r.Context(), h.logger)
+}
+
+// queryUser читает user_id из строки запроса; с токеном параметр можно опустить
+func (h *CalendarHandler) queryUser(w http.ResponseWriter, r *http.Request) (int, bool) {
+	raw := r.URL.Query().Get("user_id")
+	requested := 0
+	if _, authenticated := auth.PrincipalFrom(r.Context()); raw != "" || !authenticated {
+		var err error
+		if requested, err = strconv.Atoi(raw); err != nil {
+			h.log(r).Warn("invalid user id", zap.Error(err))
+			writeError(w, "invalid user_id", http.StatusBadRequest)
+			return 0, false
+		}
+	}
+	user, err := auth.ResolveUser(r.Context(), requested)
+	if err != nil {
+		h.log(r).Warn("access denied", zap.Error(err))
+		writeError(w, "user_id does not match token", http.StatusForbidden)
+		return 0, false
+	}
+	return user, true
+}
+
+// resolveBodyUser подставляет пользователя из токена и запрещает работу с чужими событиями
+func (h *CalendarHandler) resolveBodyUser(w http.ResponseWriter, r *http.Request, er *app.EventRequest) bool {
+	user, err := auth.ResolveUser(r.Context(), er.UserID)
+	if err != nil {
+		h.log(r).Warn("access denied", zap.Error(err))
+		writeError(w, "user_id does not match token", http.StatusForbidden)
+		return false
+	}
+	er.UserID = user
+	readConditions(r, er)
+	return true
+}
+
+// readConditions переносит в запрос If-Match и Idempotency-Key, которые хранилище проверяет под своей блокировкой,
+// и пользователя из токена для истории изменений
+func readConditions(r *http.Request, er *app.EventRequest) {
+	er.IfMatch = r.Header.Get("If-Match")
+	er.IdempotencyKey = r.Header.Get(IdempotencyKeyHeader)
+	er.ActorID = auth.Actor(r.Context())
+}
+
+// errParser переводит ошибку хранилища в HTTP-ответ: клиент должен отличать отсутствующее событие от сбоя сервера
 func errParser(w http.ResponseWriter, logger *zap.Logger, err error, msg string) {
-	logger.Debug("get events for month failed", zap.Error(err))
-	if errors.Is(err, app.ErrInvalidInput) {
-		writeError(w, msg, http.StatusBadRequest)
-	} else if errors.Is(err, app.ErrBusinessLogic) {
-		writeError(w, msg, http.StatusServiceUnavailable)
-	} else {
-		writeError(w, msg, http.StatusInternalServerError)
+	status, body := errorResponse(logger, err, msg)
+	writeErrorResponse(w, status, body)
+}
+
+// errorResponse — статус и тело ответа для ошибки хранилища; им же отвечают на каждую операцию пакета
+func errorResponse(logger *zap.Logger, err error, msg string) (int, ErrorResponse) {
+	code := app.ErrorCode(err)
+	if code == app.CodeInternal {
+		// подробности внутренних ошибок остаются только в логе
+		logger.Error(msg, zap.Error(err))
+		return http.StatusInternalServerError, ErrorResponse{Error: msg, Code: CodeInternal}
+	}
+	body := ErrorResponse{Error: msg + ": " + err.Error(), Code: code}
+	var verr *app.ValidationError
+	if errors.As(err, &verr) {
+		body.Fields = verr.Fields
 	}
+	var overlap *app.OverlapError
+	if errors.As(err, &overlap) {
+		body.Conflicts = overlap.Conflicts
+	}
+	logger.Debug(msg, zap.Error(err))
+	return codeStatus[code], body
+}
+
+// writeEvent отдаёт событие с его ETag, чтобы клиент мог прислать его в If-Match
+func writeEvent(w http.ResponseWriter, status int, e *app.Event) {
+	w.Header().Set("ETag", e.ETag())
+	writeJsonStatus(w, status, e)
+}
+
+// decodeJSON разбирает тело строго: неизвестные поля и данные после объекта — ошибка
+func decodeJSON(r *http.Request, v any) error {
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return err
+		}
+		return errors.New("unexpected data after JSON object")
+	}
+	return nil
+}
+
+// writeBodyError отвечает 413 на слишком большое тело и 400 на неразборчивое
+func writeBodyError(w http.ResponseWriter, logger *zap.Logger, err error, msg string) {
+	logger.Warn("invalid request body", zap.Error(err))
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		writeError(w, fmt.Sprintf("request body exceeds %d bytes", tooLarge.Limit), http.StatusRequestEntityTooLarge)
+		return
+	}
+	writeError(w, msg+": "+err.Error(), http.StatusBadRequest)
 }
 
 func writeJson(w http.ResponseWriter, payload any) {
+	writeJsonStatus(w, http.StatusOK, payload)
+}
+
+func writeJsonStatus(w http.ResponseWriter, code int, payload any) {
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
+	w.WriteHeader(code)
 	if err := json.NewEncoder(w).Encode(map[string]any{"result": payload}); err != nil {
 		writeError(w, "failed to encode response", http.StatusInternalServerError)
 	}
 }
 
+// Коды ошибок в теле ответа; для прочих статусов код выводится из текста статуса (bad_request, forbidden, ...)
+const (
+	CodeNotFound     = app.CodeNotFound
+	CodeConflict     = app.CodeConflict
+	CodeForbidden    = app.CodeForbidden
+	CodePrecondition = app.CodePrecondition
+	CodeValidation   = app.CodeValidation
+	CodeBusinessRule = app.CodeBusinessRule
+	CodeInternal     = app.CodeInternal
+	CodeAborted      = "aborted" // операция атомарного пакета не применена, потому что не прошла другая
+)
+
+// codeStatus — HTTP-статус для кода ошибки хранилища
+var codeStatus = map[string]int{
+	CodeNotFound:     http.StatusNotFound,
+	CodeConflict:     http.StatusConflict,
+	CodeForbidden:    http.StatusForbidden,
+	CodePrecondition: http.StatusPreconditionFailed,
+	CodeValidation:   http.StatusUnprocessableEntity,
+	CodeBusinessRule: http.StatusUnprocessableEntity,
+}
+
 type ErrorResponse struct {
-	Error string `json:"error"`
+	Error     string           `json:"error"`
+	Code      string           `json:"code"`
+	RequestID string           `json:"request_id,omitempty"`
+	Fields    []app.FieldError `json:"fields,omitempty"`    // поля запроса, не прошедшие проверку
+	Conflicts []app.Conflict   `json:"conflicts,omitempty"` // события, с которыми пересекается сохраняемое
 }
 
 func writeError(w http.ResponseWriter, msg string, code int) {
-	var er ErrorResponse
-	er.Error = msg
+	writeErrorResponse(w, code, ErrorResponse{Error: msg})
+}
+
+func writeErrorResponse(w http.ResponseWriter, status int, body ErrorResponse) {
+	if body.Code == "" {
+		body.Code = strings.ReplaceAll(strings.ToLower(http.StatusText(status)), " ", "_")
+	}
+	// RequestIDMiddleware выставляет заголовок до вызова обработчика
+	body.RequestID = w.Header().Get(RequestIDHeader)
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(code)
-	if err := json.NewEncoder(w).Encode(er.Error); err != nil {
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 	}
 }
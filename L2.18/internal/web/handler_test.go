@@ -3,9 +3,16 @@ package web
 import (
 	"bytes"
 	"calendar/internal/app"
+	"calendar/internal/auth"
+	"calendar/internal/config"
+	"calendar/internal/repository"
+	"calendar/internal/telemetry"
 	"encoding/json"
+	"errors"
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zaptest/observer"
 	"net/http"
@@ -19,10 +26,20 @@ import (
 type mockRepo struct {
 	SaveFn     func(er *app.EventRequest) (*app.Event, error)
 	UpdateFn   func(er *app.EventRequest) (*app.Event, error)
+	RespondFn  func(rsvp *app.RSVPRequest) (*app.Event, error)
+	RestoreFn  func(UserID int, EventId string) (*app.Event, error)
 	DeleteFn   func(er *app.EventRequest) error
 	LoadDayFn  func(UserID int, Date time.Time) ([]*app.Event, error)
 	LoadWeekFn func(UserID int, Date time.Time) ([]*app.Event, error)
 	LoadMonFn  func(UserID int, Date time.Time) ([]*app.Event, error)
+	LoadAllFn  func(UserID int) ([]*app.Event, error)
+	LoadEvFn   func(UserID int, EventId string) (*app.Event, error)
+	HistoryFn  func(UserID int, EventId string) ([]app.AuditEntry, error)
+	RangeFn    func(UserID int, from, to time.Time, opts repository.RangeOptions) (*repository.EventPage, error)
+	UpcomingFn func(from, to time.Time) ([]*app.Event, error)
+	BatchFn    func(ops []repository.BatchOp, atomic bool) ([]repository.BatchResult, error)
+	SearchFn   func(UserID int, q repository.SearchQuery) ([]repository.SearchHit, error)
+	PingFn     func() error
 }
 
 func (m *mockRepo) Save(er *app.EventRequest) (*app.Event, error) {
@@ -34,6 +51,12 @@ func (m *mockRepo) Delete(er *app.EventRequest) error {
 func (m *mockRepo) Update(er *app.EventRequest) (*app.Event, error) {
 	return m.UpdateFn(er)
 }
+func (m *mockRepo) Respond(rsvp *app.RSVPRequest) (*app.Event, error) {
+	return m.RespondFn(rsvp)
+}
+func (m *mockRepo) Restore(UserID int, EventId string) (*app.Event, error) {
+	return m.RestoreFn(UserID, EventId)
+}
 func (m *mockRepo) LoadDay(UserID int, Date time.Time) ([]*app.Event, error) {
 	return m.LoadDayFn(UserID, Date)
 }
@@ -43,6 +66,34 @@ func (m *mockRepo) LoadWeek(UserID int, Date time.Time) ([]*app.Event, error) {
 func (m *mockRepo) LoadMonth(UserID int, Date time.Time) ([]*app.Event, error) {
 	return m.LoadMonFn(UserID, Date)
 }
+func (m *mockRepo) LoadEvent(UserID int, EventId string) (*app.Event, error) {
+	return m.LoadEvFn(UserID, EventId)
+}
+func (m *mockRepo) LoadHistory(UserID int, EventId string) ([]app.AuditEntry, error) {
+	return m.HistoryFn(UserID, EventId)
+}
+func (m *mockRepo) LoadAll(UserID int) ([]*app.Event, error) {
+	return m.LoadAllFn(UserID)
+}
+func (m *mockRepo) LoadRange(UserID int, from, to time.Time, opts repository.RangeOptions) (*repository.EventPage, error) {
+	return m.RangeFn(UserID, from, to, opts)
+}
+
+func (m *mockRepo) Ping() error {
+	return m.PingFn()
+}
+
+func (m *mockRepo) LoadUpcoming(from, to time.Time) ([]*app.Event, error) {
+	return m.UpcomingFn(from, to)
+}
+
+func (m *mockRepo) Search(UserID int, q repository.SearchQuery) ([]repository.SearchHit, error) {
+	return m.SearchFn(UserID, q)
+}
+
+func (m *mockRepo) Batch(ops []repository.BatchOp, atomic bool) ([]repository.BatchResult, error) {
+	return m.BatchFn(ops, atomic)
+}
 
 func TestCreateEventOK(t *testing.T) {
 	logger := zap.NewNop()
@@ -104,10 +155,10 @@ func TestDeleteEventBehavior(t *testing.T) {
 		t.Fatalf("expected 200 for delete success, got %d", w.Result().StatusCode)
 	}
 
-	// -> 503
+	// -> 404
 	mock2 := &mockRepo{
 		DeleteFn: func(er *app.EventRequest) error {
-			return app.ErrBusinessLogic
+			return app.ErrNotFound
 		},
 	}
 	h2 := NewCalendarHandler(mock2, logger)
@@ -115,8 +166,8 @@ func TestDeleteEventBehavior(t *testing.T) {
 	req2 := httptest.NewRequest(http.MethodPost, "/delete", bytes.NewReader(body2))
 	w2 := httptest.NewRecorder()
 	h2.DeleteEvent(w2, req2)
-	if w2.Result().StatusCode != http.StatusServiceUnavailable {
-		t.Fatalf("expected 503 when repo returns ErrBusinessLogic")
+	if w2.Result().StatusCode != http.StatusNotFound {
+		t.Fatalf("expected 404 when repo returns ErrNotFound, got %d", w2.Result().StatusCode)
 	}
 
 	// -> 400
@@ -181,13 +232,31 @@ func TestUpdateEventErrors(t *testing.T) {
 			name:       "business logic error",
 			body:       `{"event_id":"some-id","user_id":1}`,
 			mockError:  app.ErrBusinessLogic,
-			wantStatus: http.StatusServiceUnavailable,
+			wantStatus: http.StatusUnprocessableEntity,
 		},
 		{
 			name:       "invalid input error",
 			body:       `{"event_id":"some-id","user_id":1}`,
 			mockError:  app.ErrInvalidInput,
-			wantStatus: http.StatusBadRequest,
+			wantStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:       "not found",
+			body:       `{"event_id":"some-id","user_id":1}`,
+			mockError:  app.ErrNotFound,
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "conflict",
+			body:       `{"event_id":"some-id","user_id":1}`,
+			mockError:  app.ErrConflict,
+			wantStatus: http.StatusConflict,
+		},
+		{
+			name:       "storage failure",
+			body:       `{"event_id":"some-id","user_id":1}`,
+			mockError:  errors.New("disk is full"),
+			wantStatus: http.StatusInternalServerError,
 		},
 	}
 
@@ -264,7 +333,7 @@ func TestEventsSuccessAndRepoErrors(t *testing.T) {
 	if w2.Result().StatusCode != http.StatusBadRequest {
 		t.Fatalf("expected 400 when repo returns ErrInvalidInput")
 	}
-	// -> 503
+	// -> 422
 	mock3 := &mockRepo{
 		LoadDayFn: func(UserID int, Date time.Time) ([]*app.Event, error) {
 			return nil, app.ErrBusinessLogic
@@ -275,8 +344,28 @@ func TestEventsSuccessAndRepoErrors(t *testing.T) {
 	w3 := httptest.NewRecorder()
 	f = h.eventsHandler(h3.repo.LoadDay, "Day")
 	f.ServeHTTP(w3, req3)
-	if w3.Result().StatusCode != http.StatusServiceUnavailable {
-		t.Fatalf("expected 503 when repo returns business error")
+	if w3.Result().StatusCode != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422 when repo returns business error")
+	}
+}
+
+func TestHandlerLogsCarryRequestID(t *testing.T) {
+	core, logs := observer.New(zap.DebugLevel)
+	r := chi.NewRouter()
+	RegisterRoutes(r, NewCalendarHandler(&mockRepo{}, zap.New(core)), nil, Limits{})
+
+	req := httptest.NewRequest("POST", "/create_event", strings.NewReader("{"))
+	req.Header.Set(RequestIDHeader, "req-42")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	entries := logs.All()
+	if len(entries) < 2 {
+		t.Fatalf("expected handler and access log entries, got %d", len(entries))
+	}
+	for _, e := range entries {
+		if got := e.ContextMap()["request_id"]; got != "req-42" {
+			t.Errorf("%q: request_id = %v", e.Message, got)
+		}
 	}
 }
 
@@ -331,3 +420,218 @@ func TestLoggerMiddleware(t *testing.T) {
 		t.Errorf("Duration too short: %v", duration)
 	}
 }
+
+func TestLoggerMiddlewareStatus(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+
+	r := chi.NewRouter()
+	r.Use(LoggerMiddleware(zap.New(core)))
+	r.Get("/test", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	})
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/test", nil))
+
+	entries := logs.All()
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 log entry, got %d", len(entries))
+	}
+	fields := entries[0].ContextMap()
+	if status, _ := fields["status"].(int64); status != http.StatusAccepted {
+		t.Errorf("Expected status to be %d, got %v", http.StatusAccepted, fields["status"])
+	}
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	authn, _ := auth.NewAuthenticator("secret", "")
+	userToken, _ := authn.Issue(1, time.Hour)
+	adminToken, _ := authn.Issue(99, time.Hour, auth.ScopeAdmin)
+
+	var loadedFor int
+	mock := &mockRepo{
+		LoadDayFn: func(UserID int, Date time.Time) ([]*app.Event, error) {
+			loadedFor = UserID
+			return nil, nil
+		},
+		DeleteFn: func(er *app.EventRequest) error {
+			loadedFor = er.UserID
+			return nil
+		},
+	}
+	h := NewCalendarHandler(mock, zap.NewNop())
+	r := chi.NewRouter()
+	RegisterRoutes(r, h, authn, Limits{})
+
+	tests := []struct {
+		name       string
+		method     string
+		url        string
+		body       string
+		token      string
+		wantStatus int
+		wantUser   int
+	}{
+		{"no token", http.MethodGet, "/events_for_day?user_id=1&date=2025-01-01", "", "", http.StatusUnauthorized, 0},
+		{"bad token", http.MethodGet, "/events_for_day?user_id=1&date=2025-01-01", "", "nope", http.StatusUnauthorized, 0},
+		{"own events", http.MethodGet, "/events_for_day?user_id=1&date=2025-01-01", "", userToken, http.StatusOK, 1},
+		{"user from token", http.MethodGet, "/events_for_day?date=2025-01-01", "", userToken, http.StatusOK, 1},
+		{"foreign events", http.MethodGet, "/events_for_day?user_id=2&date=2025-01-01", "", userToken, http.StatusForbidden, 0},
+		{"foreign delete", http.MethodPost, "/delete_event", `{"event_id":"x","user_id":2}`, userToken, http.StatusForbidden, 0},
+		{"admin delete", http.MethodPost, "/delete_event", `{"event_id":"x","user_id":2}`, adminToken, http.StatusOK, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loadedFor = 0
+			req := httptest.NewRequest(tt.method, tt.url, strings.NewReader(tt.body))
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("expected %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if loadedFor != tt.wantUser {
+				t.Fatalf("expected repo call for user %d, got %d", tt.wantUser, loadedFor)
+			}
+		})
+	}
+}
+
+func TestEventsForRange(t *testing.T) {
+	var got repository.RangeOptions
+	var gotFrom, gotTo time.Time
+	m := &mockRepo{
+		RangeFn: func(UserID int, from, to time.Time, opts repository.RangeOptions) (*repository.EventPage, error) {
+			got, gotFrom, gotTo = opts, from, to
+			return &repository.EventPage{Events: []*app.Event{{UserID: UserID}}, NextCursor: "next"}, nil
+		},
+	}
+	h := NewCalendarHandler(m, zap.NewNop())
+
+	req := httptest.NewRequest(http.MethodGet, "/events_for_range?user_id=1&from=2025-05-01&to=2025-05-31&tz=Europe/Moscow&q=Sync&cursor=abc&limit=5", nil)
+	w := httptest.NewRecorder()
+	h.EventsForRange(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if got.Text != "Sync" || got.Cursor != "abc" || got.Limit != 5 {
+		t.Fatalf("unexpected options %+v", got)
+	}
+	if !gotFrom.Equal(time.Date(2025, 4, 30, 21, 0, 0, 0, time.UTC)) || !gotTo.Equal(time.Date(2025, 5, 31, 21, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected interval %v - %v", gotFrom, gotTo)
+	}
+	if !strings.Contains(w.Body.String(), `"next_cursor":"next"`) {
+		t.Fatalf("next_cursor missing: %s", w.Body.String())
+	}
+
+	for _, q := range []string{
+		"user_id=1&from=2025-05-01",
+		"user_id=1&from=2025-05-02&to=2025-05-01",
+		"user_id=1&from=2025-05-01&to=2025-05-02&limit=0",
+		"user_id=1&from=2025-05-01&to=2025-05-02&limit=5000",
+		"user_id=1&from=2025-05-01&to=2025-05-02&tz=Nowhere/City",
+	} {
+		w := httptest.NewRecorder()
+		h.EventsForRange(w, httptest.NewRequest(http.MethodGet, "/events_for_range?"+q, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected 400, got %d", q, w.Code)
+		}
+	}
+}
+
+func TestErrorResponseBody(t *testing.T) {
+	mock := &mockRepo{
+		SaveFn: func(er *app.EventRequest) (*app.Event, error) {
+			return nil, app.InvalidField("time_zone", "unknown time zone Mars/Base")
+		},
+		UpdateFn: func(er *app.EventRequest) (*app.Event, error) {
+			return nil, errors.New("disk is full")
+		},
+	}
+	r := chi.NewRouter()
+	RegisterRoutes(r, NewCalendarHandler(mock, zap.NewNop()), nil, Limits{})
+
+	req := httptest.NewRequest(http.MethodPost, "/create_event", strings.NewReader(`{"user_id":1,"date":"2025-05-05","time_zone":"Mars/Base"}`))
+	req.Header.Set(RequestIDHeader, "req-42")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusUnprocessableEntity || w.Header().Get(RequestIDHeader) != "req-42" {
+		t.Fatalf("expected 422 with echoed request id, got %d %q", w.Code, w.Header().Get(RequestIDHeader))
+	}
+	var body ErrorResponse
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+		t.Fatalf("error body is not an object: %v", err)
+	}
+	if body.Code != CodeValidation || body.RequestID != "req-42" || len(body.Fields) != 1 || body.Fields[0].Field != "time_zone" {
+		t.Fatalf("unexpected error body %+v", body)
+	}
+
+	// без заголовка идентификатор генерируется, детали внутренней ошибки не уходят клиенту
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/update_event", strings.NewReader(`{"user_id":1,"event_id":"x","event":"y"}`)))
+	body = ErrorResponse{}
+	json.NewDecoder(w.Body).Decode(&body)
+	if w.Code != http.StatusInternalServerError || body.Code != CodeInternal || body.RequestID == "" || body.RequestID != w.Header().Get(RequestIDHeader) {
+		t.Fatalf("unexpected internal error response %d %+v", w.Code, body)
+	}
+	if strings.Contains(body.Error, "disk") {
+		t.Fatalf("internal error details leaked: %q", body.Error)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/events_for_day?user_id=abc", nil))
+	body = ErrorResponse{}
+	json.NewDecoder(w.Body).Decode(&body)
+	if w.Code != http.StatusBadRequest || body.Code != "bad_request" || body.Error != "invalid user_id" {
+		t.Fatalf("unexpected bad request response %d %+v", w.Code, body)
+	}
+}
+
+func TestTelemetryMiddleware(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := telemetry.NewSDKTracerProvider(config.TelemetryConfig{}, sdktrace.WithSpanProcessor(recorder))
+	metrics := telemetry.NewMetrics()
+
+	r := chi.NewRouter()
+	r.Use(TracingMiddleware(tp), MetricsMiddleware(metrics))
+	repo := telemetry.InstrumentStorage(repository.NewInMemoryRepo(), metrics, tp)
+	RegisterRoutes(r, NewCalendarHandler(repo, zap.NewNop()), nil, Limits{})
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	req := httptest.NewRequest(http.MethodPost, "/v2/users/7/events", strings.NewReader(`{"date":"2025-05-05"}`))
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", w.Code)
+	}
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v2/users/7/events/"+uuid.NewString(), nil))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", w.Code)
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 4 {
+		t.Fatalf("expected 4 spans, got %d", len(spans))
+	}
+	save, server := spans[0], spans[1]
+	if server.Name() != "POST /v2/users/{user_id}/events" || server.SpanContext().TraceID().String() != traceID {
+		t.Fatalf("unexpected server span %q in trace %s", server.Name(), server.SpanContext().TraceID())
+	}
+	if save.Name() != "storage.save" || save.Parent().SpanID() != server.SpanContext().SpanID() {
+		t.Fatalf("storage span %q is not a child of the server span", save.Name())
+	}
+
+	mw := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(mw, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	for _, want := range []string{
+		`calendar_http_requests_total{method="POST",route="/v2/users/{user_id}/events",status="201"} 1`,
+		`calendar_http_requests_total{method="GET",route="/v2/users/{user_id}/events/{event_id}",status="404"} 1`,
+		`calendar_storage_operation_duration_seconds_count{operation="load_event",result="rejected"} 1`,
+	} {
+		if !strings.Contains(mw.Body.String(), want) {
+			t.Fatalf("metrics output does not contain %q", want)
+		}
+	}
+}
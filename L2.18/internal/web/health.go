@@ -0,0 +1,67 @@
+package web
+
+import (
+	"calendar/internal/repository"
+	"encoding/json"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+	"net/http"
+)
+
+// Health отвечает на пробы оркестратора: liveness не зависит от хранилища, readiness — зависит
+type Health struct {
+	repo   repository.Storage
+	logger *zap.Logger
+}
+
+// HealthStatus — тело ответа проб; ответ не оборачивается в result, чтобы пробы было проще проверять
+type HealthStatus struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+func NewHealth(repo repository.Storage, logger *zap.Logger) *Health {
+	return &Health{repo: repo, logger: logger}
+}
+
+// RegisterHealthRoutes регистрирует пробы вне аутентификации
+func RegisterHealthRoutes(r chi.Router, hl *Health) {
+	r.Get("/healthz", hl.Live)
+	r.Get("/readyz", hl.Ready)
+}
+
+// Live godoc
+// @Summary      Liveness probe
+// @Tags         health
+// @Produce      json
+// @Success      200  {object}  HealthStatus
+// @Router       /healthz [get]
+func (hl *Health) Live(w http.ResponseWriter, r *http.Request) {
+	writeHealth(w, http.StatusOK, HealthStatus{Status: "ok"})
+}
+
+// Ready godoc
+// @Summary      Readiness probe
+// @Description  Checks the storage backend
+// @Tags         health
+// @Produce      json
+// @Success      200  {object}  HealthStatus
+// @Failure      503  {object}  HealthStatus
+// @Router       /readyz [get]
+func (hl *Health) Ready(w http.ResponseWriter, r *http.Request) {
+	status := HealthStatus{Status: "ok", Checks: map[string]string{"storage": "ok"}}
+	code := http.StatusOK
+	if err := hl.repo.Ping(); err != nil {
+		hl.logger.Warn("storage is not ready", zap.Error(err))
+		status.Checks["storage"] = err.Error()
+		status.Status, code = "unavailable", http.StatusServiceUnavailable
+	}
+	writeHealth(w, code, status)
+}
+
+func writeHealth(w http.ResponseWriter, code int, status HealthStatus) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(status)
+}
@@ -0,0 +1,43 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHealthProbes(t *testing.T) {
+	var pingErr error
+	r := chi.NewRouter()
+	RegisterHealthRoutes(r, NewHealth(&mockRepo{PingFn: func() error { return pingErr }}, zap.NewNop()))
+
+	probe := func(path string) (int, HealthStatus) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		var status HealthStatus
+		if err := json.NewDecoder(w.Body).Decode(&status); err != nil {
+			t.Fatalf("%s: decode: %v", path, err)
+		}
+		return w.Code, status
+	}
+
+	if code, st := probe("/healthz"); code != http.StatusOK || st.Status != "ok" {
+		t.Fatalf("healthz: %d %+v", code, st)
+	}
+	if code, st := probe("/readyz"); code != http.StatusOK || st.Checks["storage"] != "ok" {
+		t.Fatalf("readyz: %d %+v", code, st)
+	}
+
+	pingErr = errors.New("event log: file already closed")
+	if code, st := probe("/readyz"); code != http.StatusServiceUnavailable || st.Status != "unavailable" || st.Checks["storage"] != pingErr.Error() {
+		t.Fatalf("readyz with broken storage: %d %+v", code, st)
+	}
+	// liveness не зависит от хранилища, иначе оркестратор перезапускал бы процесс из-за диска
+	if code, _ := probe("/healthz"); code != http.StatusOK {
+		t.Fatalf("healthz with broken storage: %d", code)
+	}
+}
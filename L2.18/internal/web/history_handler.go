@@ -0,0 +1,126 @@
+package web
+
+import (
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+	"net/http"
+)
+
+// EventHistory godoc
+// @Summary      Event history
+// @Description  Audit entries of an event (oldest first): who changed it, when, and the event before and after the change.
+// @Description  The history of a deleted event is available until the tombstone retention expires.
+// @Tags         events
+// @Security     BearerAuth
+// @Produce      json
+// @Param        event_id  path   string  true  "Event ID"
+// @Param        user_id   query  int     true  "User ID"
+// @Success      200  {array}   app.AuditEntry
+// @Failure 	 400  {object} ErrorResponse "invalid user_id"
+// @Failure 	 401  {object} ErrorResponse "missing or invalid token"
+// @Failure 	 403  {object} ErrorResponse "user_id does not match token"
+// @Failure 	 404  {object} ErrorResponse "event not found"
+// @Failure 	 422  {object} ErrorResponse "invalid event_id"
+// @Failure 	 429  {object} ErrorResponse "rate limit exceeded, see Retry-After"
+// @Failure 	 500  {object} ErrorResponse "internal server error"
+// @Router       /events/{event_id}/history [get]
+func (h *CalendarHandler) EventHistory(w http.ResponseWriter, r *http.Request) {
+	if user, ok := h.queryUser(w, r); ok {
+		h.history(w, r, user)
+	}
+}
+
+// RestoreEvent godoc
+// @Summary      Restore deleted event
+// @Description  Bring back a deleted event (with the occurrences detached from it) while the tombstone is kept.
+// @Description  Only the organizer can restore; the event gets a new version.
+// @Tags         events
+// @Security     BearerAuth
+// @Produce      json
+// @Param        event_id  path   string  true  "Event ID"
+// @Param        user_id   query  int     true  "User ID"
+// @Success      200  {object}  app.Event "restored event" // note: response wrapped as {"result": <app.Event>}
+// @Header       200  {string}  ETag  "Current version of the event"
+// @Failure 	 400  {object} ErrorResponse "invalid user_id"
+// @Failure 	 401  {object} ErrorResponse "missing or invalid token"
+// @Failure 	 403  {object} ErrorResponse "user_id does not match token or user is not the organizer"
+// @Failure 	 404  {object} ErrorResponse "no deleted event with this id"
+// @Failure 	 409  {object} ErrorResponse "event is not deleted or the time slot is busy (see conflicts)"
+// @Failure 	 422  {object} ErrorResponse "invalid event_id or its series is deleted"
+// @Failure 	 429  {object} ErrorResponse "rate limit exceeded, see Retry-After"
+// @Failure 	 500  {object} ErrorResponse "internal server error"
+// @Router       /events/{event_id}/restore [post]
+func (h *CalendarHandler) RestoreEvent(w http.ResponseWriter, r *http.Request) {
+	if user, ok := h.queryUser(w, r); ok {
+		h.restore(w, r, user)
+	}
+}
+
+// EventHistoryV2 godoc
+// @Summary      Event history
+// @Description  Same as GET /events/{event_id}/history
+// @Tags         events v2
+// @Security     BearerAuth
+// @Produce      json
+// @Param        user_id   path  int     true  "User ID"
+// @Param        event_id  path  string  true  "Event ID"
+// @Success      200  {array}   app.AuditEntry
+// @Failure 	 400  {object} ErrorResponse "invalid user_id"
+// @Failure 	 401  {object} ErrorResponse "missing or invalid token"
+// @Failure 	 403  {object} ErrorResponse "user_id does not match token"
+// @Failure 	 404  {object} ErrorResponse "event not found"
+// @Failure 	 422  {object} ErrorResponse "invalid event_id"
+// @Failure 	 429  {object} ErrorResponse "rate limit exceeded, see Retry-After"
+// @Failure 	 500  {object} ErrorResponse "internal server error"
+// @Router       /v2/users/{user_id}/events/{event_id}/history [get]
+func (h *CalendarHandler) EventHistoryV2(w http.ResponseWriter, r *http.Request) {
+	if user, ok := h.pathUser(w, r); ok {
+		h.history(w, r, user)
+	}
+}
+
+// RestoreEventV2 godoc
+// @Summary      Restore deleted event
+// @Description  Same as POST /events/{event_id}/restore
+// @Tags         events v2
+// @Security     BearerAuth
+// @Produce      json
+// @Param        user_id   path  int     true  "User ID"
+// @Param        event_id  path  string  true  "Event ID"
+// @Success      200  {object}  app.Event
+// @Header       200  {string}  ETag  "Current version of the event"
+// @Failure 	 400  {object} ErrorResponse "invalid user_id"
+// @Failure 	 401  {object} ErrorResponse "missing or invalid token"
+// @Failure 	 403  {object} ErrorResponse "user_id does not match token or user is not the organizer"
+// @Failure 	 404  {object} ErrorResponse "no deleted event with this id"
+// @Failure 	 409  {object} ErrorResponse "event is not deleted or the time slot is busy (see conflicts)"
+// @Failure 	 422  {object} ErrorResponse "invalid event_id or its series is deleted"
+// @Failure 	 429  {object} ErrorResponse "rate limit exceeded, see Retry-After"
+// @Failure 	 500  {object} ErrorResponse "internal server error"
+// @Router       /v2/users/{user_id}/events/{event_id}/restore [post]
+func (h *CalendarHandler) RestoreEventV2(w http.ResponseWriter, r *http.Request) {
+	if user, ok := h.pathUser(w, r); ok {
+		h.restore(w, r, user)
+	}
+}
+
+func (h *CalendarHandler) history(w http.ResponseWriter, r *http.Request, user int) {
+	id := chi.URLParam(r, "event_id")
+	entries, err := h.store(r).LoadHistory(user, id)
+	if err != nil {
+		errParser(w, h.log(r), err, "load history failed")
+		return
+	}
+	h.log(r).Info("history fetched", zap.String("event_id", id), zap.Int("entries", len(entries)))
+	writeJson(w, entries)
+}
+
+func (h *CalendarHandler) restore(w http.ResponseWriter, r *http.Request, user int) {
+	e, err := h.store(r).Restore(user, chi.URLParam(r, "event_id"))
+	if err != nil {
+		errParser(w, h.log(r), err, "restore failed")
+		return
+	}
+	h.log(r).Info("event restored", zap.String("event_id", e.EventId.String()))
+	writeEvent(w, http.StatusOK, e)
+}
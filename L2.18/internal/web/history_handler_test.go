@@ -0,0 +1,99 @@
+package web
+
+import (
+	"calendar/internal/app"
+	"calendar/internal/auth"
+	"calendar/internal/repository"
+	"encoding/json"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEventHistoryAndRestore(t *testing.T) {
+	h := newV2Router()
+
+	w := doV2(t, h, http.MethodPost, "/v2/users/5/events", `{"date":"2025-05-05","event":"review"}`)
+	created := decodeEvent(t, w)
+	location := w.Header().Get("Location")
+	doV2(t, h, http.MethodPatch, location, `{"event":"retro"}`)
+
+	if w := doV2(t, h, http.MethodPost, location+"/restore", ""); w.Code != http.StatusConflict {
+		t.Fatalf("restore of a live event: expected 409, got %d", w.Code)
+	}
+	if w := doV2(t, h, http.MethodDelete, location, ""); w.Code != http.StatusNoContent {
+		t.Fatalf("DELETE expected 204, got %d", w.Code)
+	}
+
+	w = doV2(t, h, http.MethodGet, "/events/"+created.EventId.String()+"/history?user_id=5", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("history expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var out struct {
+		Result []app.AuditEntry `json:"result"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&out); err != nil {
+		t.Fatalf("decode history: %v", err)
+	}
+	history := out.Result
+	if len(history) != 3 || history[2].Action != app.AuditDeleted || history[2].Before.EventText != "retro" {
+		t.Fatalf("unexpected history %+v", history)
+	}
+	if w := doV2(t, h, http.MethodGet, location+"/history", ""); w.Code != http.StatusOK {
+		t.Fatalf("v2 history expected 200, got %d", w.Code)
+	}
+	if w := doV2(t, h, http.MethodGet, "/v2/users/6/events/"+created.EventId.String()+"/history", ""); w.Code != http.StatusNotFound {
+		t.Fatalf("history of another user: expected 404, got %d", w.Code)
+	}
+
+	w = doV2(t, h, http.MethodPost, "/events/"+created.EventId.String()+"/restore?user_id=5", "")
+	if w.Code != http.StatusOK || w.Header().Get("ETag") == "" {
+		t.Fatalf("restore expected 200 with ETag, got %d: %s", w.Code, w.Body.String())
+	}
+	if restored := decodeEvent(t, w); restored.EventText != "retro" {
+		t.Fatalf("unexpected restored event %+v", restored)
+	}
+	if w := doV2(t, h, http.MethodGet, location, ""); w.Code != http.StatusOK {
+		t.Fatalf("restored event not found: %d", w.Code)
+	}
+	if w := doV2(t, h, http.MethodPost, "/v2/users/5/events/not-a-uuid/restore", ""); w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("invalid event_id: expected 422, got %d", w.Code)
+	}
+}
+
+func TestEventHistoryRecordsActor(t *testing.T) {
+	authn, _ := auth.NewAuthenticator("secret", "")
+	userToken, _ := authn.Issue(5, time.Hour)
+	adminToken, _ := authn.Issue(99, time.Hour, auth.ScopeAdmin)
+	r := chi.NewRouter()
+	RegisterRoutes(r, NewCalendarHandler(repository.NewInMemoryRepo(), zap.NewNop()), authn, Limits{})
+	send := func(method, url, body, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, url, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := send(http.MethodPost, "/v2/users/5/events", `{"date":"2025-05-05","event":"review"}`, userToken)
+	location := w.Header().Get("Location")
+	// администратор меняет чужое событие: в истории он, а не владелец
+	if w := send(http.MethodPatch, location, `{"event":"retro"}`, adminToken); w.Code != http.StatusOK {
+		t.Fatalf("admin PATCH: %d %s", w.Code, w.Body)
+	}
+
+	w = send(http.MethodGet, location+"/history", "", userToken)
+	var out struct {
+		Result []app.AuditEntry `json:"result"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&out); err != nil {
+		t.Fatalf("decode history: %v", err)
+	}
+	if h := out.Result; len(h) != 2 || h[0].UserID != 5 || h[1].Action != app.AuditUpdated || h[1].UserID != 99 {
+		t.Fatalf("history does not show who changed the event: %+v", h)
+	}
+}
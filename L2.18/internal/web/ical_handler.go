@@ -0,0 +1,157 @@
+package web
+
+import (
+	"calendar/internal/app"
+	"calendar/internal/auth"
+	"calendar/internal/ical"
+	"calendar/internal/repository"
+	"errors"
+	"go.uber.org/zap"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// ImportResult — итог импорта одного VEVENT
+type ImportResult struct {
+	UID          string `json:"uid"`
+	RecurrenceId string `json:"recurrence_id,omitempty"`
+	EventId      string `json:"event_id,omitempty"`
+	Status       string `json:"status"` // created | updated | failed
+	Error        string `json:"error,omitempty"`
+}
+
+// ExportICS godoc
+// @Summary      Export calendar
+// @Description  Export all user's events (series with RRULE/EXDATE, detached occurrences with RECURRENCE-ID) as iCalendar
+// @Tags         ical
+// @Security     BearerAuth
+// @Produce      text/calendar
+// @Param        user_id  query  int  true  "User ID"
+// @Success      200  {string}  string  "RFC 5545 calendar"
+// @Failure 	 400  {object} ErrorResponse "invalid user_id"
+// @Failure 	 401  {object} ErrorResponse "missing or invalid token"
+// @Failure 	 403  {object} ErrorResponse "user_id does not match token"
+// @Failure 	 429  {object} ErrorResponse "rate limit exceeded, see Retry-After"
+// @Failure 	 500  {object} ErrorResponse "internal server error"
+// @Router       /calendar.ics [get]
+func (h *CalendarHandler) ExportICS(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.queryUser(w, r)
+	if !ok {
+		return
+	}
+	events, err := h.store(r).LoadAll(user)
+	if err != nil {
+		errParser(w, h.log(r), err, "export failed")
+		return
+	}
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", `inline; filename="calendar.ics"`)
+	if err := ical.Encode(w, events); err != nil {
+		h.log(r).Error("ics encode failed", zap.Error(err))
+		return
+	}
+	h.log(r).Info("calendar exported", zap.Int("user_id", user), zap.Int("events", len(events)))
+}
+
+// ImportICS godoc
+// @Summary      Import calendar
+// @Description  Import VEVENTs from an .ics file (multipart field "file" or raw text/calendar body). Occurrences with RECURRENCE-ID are applied to their series.
+// @Tags         ical
+// @Security     BearerAuth
+// @Accept       text/calendar
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        user_id  query     int   true   "User ID"
+// @Param        file     formData  file  false  ".ics file"
+// @Success      200  {array}   ImportResult  "result for each event"
+// @Failure 	 400  {object} ErrorResponse "invalid user_id or file"
+// @Failure 	 401  {object} ErrorResponse "missing or invalid token"
+// @Failure 	 403  {object} ErrorResponse "user_id does not match token"
+// @Failure 	 413  {object} ErrorResponse "request body is too large"
+// @Failure 	 429  {object} ErrorResponse "rate limit exceeded, see Retry-After"
+// @Failure 	 500  {object} ErrorResponse "internal server error"
+// @Router       /import_ics [post]
+func (h *CalendarHandler) ImportICS(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.queryUser(w, r)
+	if !ok {
+		return
+	}
+
+	// размер тела ограничивает BodyLimitMiddleware группы импорта
+	var body io.Reader = r.Body
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		file, _, err := r.FormFile("file")
+		if errors.As(err, new(*http.MaxBytesError)) {
+			writeBodyError(w, h.log(r), err, "invalid upload")
+			return
+		}
+		if err != nil {
+			h.log(r).Warn("invalid upload", zap.Error(err))
+			writeError(w, "file is required", http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+		body = file
+	}
+
+	vevents, err := ical.Decode(body)
+	if err != nil {
+		writeBodyError(w, h.log(r), err, "invalid ics")
+		return
+	}
+	repo, actor := h.store(r), auth.Actor(r.Context())
+
+	results := make([]ImportResult, len(vevents))
+	ids := make(map[string]string) // UID из файла -> event_id серии
+	// сначала события и серии, затем изменённые вхождения, которым нужна серия
+	for _, overrides := range []bool{false, true} {
+		for i, ve := range vevents {
+			if (ve.RecurrenceId != "") != overrides {
+				continue
+			}
+			results[i] = h.importOne(repo, user, actor, ve, ids)
+		}
+	}
+
+	h.log(r).Info("calendar imported", zap.Int("user_id", user), zap.Int("events", len(vevents)))
+	writeJson(w, results)
+}
+
+func (h *CalendarHandler) importOne(repo repository.Storage, user, actor int, ve ical.VEvent, ids map[string]string) ImportResult {
+	res := ImportResult{UID: ve.UID, RecurrenceId: ve.RecurrenceId, Status: "failed"}
+	if ve.Err != nil {
+		res.Error = ve.Err.Error()
+		return res
+	}
+	er := ve.Request
+	er.UserID, er.ActorID = user, actor
+
+	if ve.RecurrenceId == "" {
+		e, err := repo.Save(&er)
+		if err != nil {
+			res.Error = err.Error()
+			return res
+		}
+		if ve.UID != "" {
+			ids[ve.UID] = e.EventId.String()
+		}
+		res.EventId, res.Status = e.EventId.String(), "created"
+		return res
+	}
+
+	seriesId, ok := ids[ve.UID]
+	if !ok {
+		res.Error = "series not found in the file"
+		return res
+	}
+	er.EventId, er.Scope, er.RecurrenceId = seriesId, app.ScopeThis, ve.RecurrenceId
+	er.RRule, er.ExDates = nil, nil
+	e, err := repo.Update(&er)
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+	res.EventId, res.Status = e.EventId.String(), "updated"
+	return res
+}
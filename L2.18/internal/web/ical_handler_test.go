@@ -0,0 +1,91 @@
+package web
+
+import (
+	"bytes"
+	"calendar/internal/app"
+	"calendar/internal/repository"
+	"encoding/json"
+	"go.uber.org/zap"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const importSample = "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n" +
+	"BEGIN:VEVENT\r\nUID:a\r\nDTSTART:20250505T100000Z\r\nDTEND:20250505T110000Z\r\nRRULE:FREQ=DAILY;COUNT=3\r\nSUMMARY:series\r\nEND:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\nUID:a\r\nRECURRENCE-ID:20250506T100000Z\r\nDTSTART:20250506T150000Z\r\nDTEND:20250506T160000Z\r\nSUMMARY:moved\r\nEND:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\nUID:b\r\nRECURRENCE-ID:20250506T100000Z\r\nDTSTART:20250506T150000Z\r\nEND:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\nUID:c\r\nSUMMARY:no start\r\nEND:VEVENT\r\n" +
+	"END:VCALENDAR\r\n"
+
+func TestImportAndExportICS(t *testing.T) {
+	repo := repository.NewInMemoryRepo()
+	h := NewCalendarHandler(repo, zap.NewNop())
+
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	fw, _ := mw.CreateFormFile("file", "team.ics")
+	fw.Write([]byte(importSample))
+	mw.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/import_ics?user_id=7", &buf)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	w := httptest.NewRecorder()
+	h.ImportICS(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200 got %d: %s", w.Code, w.Body.String())
+	}
+	var out struct {
+		Result []ImportResult `json:"result"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&out); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	statuses := []string{"created", "updated", "failed", "failed"}
+	if len(out.Result) != len(statuses) {
+		t.Fatalf("expected %d results, got %+v", len(statuses), out.Result)
+	}
+	for i, s := range statuses {
+		if out.Result[i].Status != s {
+			t.Errorf("result %d: expected %s, got %+v", i, s, out.Result[i])
+		}
+	}
+
+	day, _ := app.TimeParser("2025-05-06")
+	list, _ := repo.LoadDay(7, day)
+	if len(list) != 1 || list[0].EventText != "moved" || list[0].Start.Hour() != 15 {
+		t.Fatalf("override not applied: %+v", list)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/calendar.ics?user_id=7", nil)
+	w = httptest.NewRecorder()
+	h.ExportICS(w, req)
+	if w.Code != http.StatusOK || !strings.HasPrefix(w.Header().Get("Content-Type"), "text/calendar") {
+		t.Fatalf("unexpected export response %d %s", w.Code, w.Header().Get("Content-Type"))
+	}
+	body := w.Body.String()
+	if strings.Count(body, "BEGIN:VEVENT") != 2 || !strings.Contains(body, "RECURRENCE-ID:20250506T100000Z") {
+		t.Fatalf("unexpected export:\n%s", body)
+	}
+}
+
+func TestImportICSBadInput(t *testing.T) {
+	h := NewCalendarHandler(repository.NewInMemoryRepo(), zap.NewNop())
+
+	req := httptest.NewRequest(http.MethodPost, "/import_ics?user_id=bad", strings.NewReader(importSample))
+	w := httptest.NewRecorder()
+	h.ImportICS(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for bad user_id, got %d", w.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/import_ics?user_id=1", strings.NewReader("not a calendar"))
+	req.Header.Set("Content-Type", "text/calendar")
+	w = httptest.NewRecorder()
+	h.ImportICS(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for bad ics, got %d", w.Code)
+	}
+}
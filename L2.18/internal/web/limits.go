@@ -0,0 +1,142 @@
+package web
+
+import (
+	"calendar/internal/auth"
+	"calendar/internal/logger"
+	"go.uber.org/zap"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	defaultMaxBody   = 1 << 20
+	defaultMaxImport = 10 << 20
+)
+
+// Limits — ограничения групп маршрутов: чтение, изменение и импорт, а IP — общий лимит адреса, который
+// проверяется до аутентификации. Нулевой Rate отключает ограничение частоты, нулевой размер тела
+// заменяется значением по умолчанию.
+type Limits struct {
+	MaxBody   int64
+	MaxImport int64
+	Read      Rate
+	Write     Rate
+	Import    Rate
+	IP        Rate
+}
+
+// Rate — token bucket: RPS запросов в секунду в среднем и до Burst подряд
+type Rate struct {
+	RPS   float64
+	Burst int
+}
+
+// RateLimiter раздаёт токены каждому клиенту отдельно; ключ — пользователь из токена или IP
+type RateLimiter struct {
+	rate  float64
+	burst float64
+	now   func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+type bucket struct {
+	tokens float64
+	at     time.Time
+}
+
+// NewRateLimiter возвращает nil, если ограничение выключено (RPS <= 0)
+func NewRateLimiter(rate Rate) *RateLimiter {
+	if rate.RPS <= 0 {
+		return nil
+	}
+	burst := max(rate.Burst, 1)
+	return &RateLimiter{
+		rate:    rate.RPS,
+		burst:   float64(burst),
+		now:     time.Now,
+		buckets: make(map[string]*bucket),
+	}
+}
+
+// Allow забирает токен клиента key; если токенов нет, возвращает, через сколько появится следующий
+func (l *RateLimiter) Allow(key string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	l.sweep(now)
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: l.burst, at: now}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.at).Seconds()*l.rate)
+	b.at = now
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	return false, time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+}
+
+// sweep раз в минуту забывает клиентов, чьи корзины уже наполнились: для них новая корзина ничем не отличается
+func (l *RateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < time.Minute {
+		return
+	}
+	l.lastSweep = now
+	full := time.Duration(l.burst / l.rate * float64(time.Second))
+	for key, b := range l.buckets {
+		if now.Sub(b.at) > full {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// RateLimitMiddleware отвечает 429 с Retry-After, когда клиент исчерпал свои токены; nil-лимитер пропускает всё
+func RateLimitMiddleware(l *RateLimiter, base *zap.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if l == nil {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := clientKey(r)
+			if ok, wait := l.Allow(key); !ok {
+				retry := int(math.Ceil(wait.Seconds()))
+				logger.FromContext(r.Context(), base).Warn("rate limit exceeded", zap.String("client", key), zap.Int("retry_after", retry))
+				w.Header().Set("Retry-After", strconv.Itoa(retry))
+				writeError(w, "rate limit exceeded, retry in "+strconv.Itoa(retry)+"s", http.StatusTooManyRequests)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// clientKey — пользователь из проверенного токена, а без аутентификации — адрес клиента
+func clientKey(r *http.Request) string {
+	if p, ok := auth.PrincipalFrom(r.Context()); ok {
+		return "user:" + strconv.Itoa(p.UserID)
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+// BodyLimitMiddleware ограничивает тело запроса; чтение сверх n завершается *http.MaxBytesError
+func BodyLimitMiddleware(n int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r.Body = http.MaxBytesReader(w, r.Body, n)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,156 @@
+package web
+
+import (
+	"calendar/internal/auth"
+	"calendar/internal/repository"
+	"context"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterRefills(t *testing.T) {
+	now := time.Date(2025, 5, 5, 10, 0, 0, 0, time.UTC)
+	l := NewRateLimiter(Rate{RPS: 2, Burst: 3})
+	l.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		if ok, _ := l.Allow("a"); !ok {
+			t.Fatalf("request %d within burst was rejected", i)
+		}
+	}
+	ok, wait := l.Allow("a")
+	if ok || wait != 500*time.Millisecond {
+		t.Fatalf("expected rejection with 500ms wait, got %v %v", ok, wait)
+	}
+	if ok, _ := l.Allow("b"); !ok {
+		t.Fatal("clients must have separate buckets")
+	}
+
+	now = now.Add(time.Second)
+	for i := 0; i < 2; i++ {
+		if ok, _ := l.Allow("a"); !ok {
+			t.Fatalf("refilled token %d was rejected", i)
+		}
+	}
+	if ok, _ := l.Allow("a"); ok {
+		t.Fatal("bucket refilled faster than the rate")
+	}
+
+	now = now.Add(2 * time.Minute)
+	l.Allow("c")
+	if _, kept := l.buckets["a"]; kept {
+		t.Fatal("idle full bucket was not swept")
+	}
+	if NewRateLimiter(Rate{}) != nil {
+		t.Fatal("zero rate must disable the limiter")
+	}
+}
+
+func newLimitedRouter(limits Limits) http.Handler {
+	r := chi.NewRouter()
+	RegisterRoutes(r, NewCalendarHandler(repository.NewInMemoryRepo(), zap.NewNop()), nil, limits)
+	return r
+}
+
+func TestRateLimitByRouteGroup(t *testing.T) {
+	h := newLimitedRouter(Limits{Write: Rate{RPS: 0.5, Burst: 1}})
+	create := func(remote string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/create_event", strings.NewReader(`{"user_id":1,"date":"2025-05-05"}`))
+		req.RemoteAddr = remote
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := create("10.0.0.1:1000"); w.Code != http.StatusOK {
+		t.Fatalf("first create: %d %s", w.Code, w.Body)
+	}
+	w := create("10.0.0.1:2000")
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "2" || !strings.Contains(w.Body.String(), `"too_many_requests"`) {
+		t.Fatalf("expected 429 with Retry-After 2, got %d %q %s", w.Code, w.Header().Get("Retry-After"), w.Body)
+	}
+	if w := create("10.0.0.2:1000"); w.Code != http.StatusOK {
+		t.Fatalf("other client limited: %d", w.Code)
+	}
+	// чтение в своей группе без ограничения
+	for i := 0; i < 5; i++ {
+		if w := doV2(t, h, http.MethodGet, "/events_for_day?user_id=1&date=2025-05-05", ""); w.Code != http.StatusOK {
+			t.Fatalf("read limited by write group: %d", w.Code)
+		}
+	}
+}
+
+func TestRateLimitByIPBeforeAuth(t *testing.T) {
+	authn, _ := auth.NewAuthenticator("secret", "calendar")
+	r := chi.NewRouter()
+	RegisterRoutes(r, NewCalendarHandler(repository.NewInMemoryRepo(), zap.NewNop()), authn, Limits{IP: Rate{RPS: 0.5, Burst: 2}})
+	get := func(remote, token string) int {
+		req := httptest.NewRequest(http.MethodGet, "/events_for_day?user_id=1&date=2025-05-05", nil)
+		req.RemoteAddr = remote
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// перебор токенов с одного адреса упирается в лимит, хотя каждый запрос получает 401
+	if get("10.0.0.1:1000", "bad") != http.StatusUnauthorized || get("10.0.0.1:1001", "bad") != http.StatusUnauthorized {
+		t.Fatal("expected 401 for an invalid token")
+	}
+	if code := get("10.0.0.1:1002", "bad"); code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429 after the IP burst, got %d", code)
+	}
+	token, _ := authn.Issue(1, time.Hour)
+	if code := get("10.0.0.2:1000", token); code != http.StatusOK {
+		t.Fatalf("other address limited: %d", code)
+	}
+}
+
+func TestRateLimitKeyedByToken(t *testing.T) {
+	l := NewRateLimiter(Rate{RPS: 1, Burst: 1})
+	h := RateLimitMiddleware(l, zap.NewNop())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	call := func(user int) int {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req = req.WithContext(auth.WithPrincipal(context.Background(), auth.Principal{UserID: user}))
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w.Code
+	}
+	if call(1) != http.StatusOK || call(1) != http.StatusTooManyRequests || call(2) != http.StatusOK {
+		t.Fatal("users behind one address must be limited separately")
+	}
+}
+
+func TestBodyLimitsAndStrictJSON(t *testing.T) {
+	h := newLimitedRouter(Limits{MaxBody: 64, MaxImport: 128})
+	big := `{"user_id":1,"date":"2025-05-05","event":"` + strings.Repeat("x", 100) + `"}`
+	ics := "BEGIN:VCALENDAR\r\n" + strings.Repeat("X-PAD:"+strings.Repeat("x", 60)+"\r\n", 4) + "END:VCALENDAR\r\n"
+
+	tests := []struct {
+		name, method, url, body string
+		want                    int
+	}{
+		{"too large", http.MethodPost, "/create_event", big, http.StatusRequestEntityTooLarge},
+		{"too large v2", http.MethodPatch, "/v2/users/1/events/0b7f3a3e-6a6f-4d5e-9a4b-1c2d3e4f5a6b", big, http.StatusRequestEntityTooLarge},
+		{"unknown field", http.MethodPost, "/create_event", `{"user_id":1,"date":"2025-05-05","colour":"red"}`, http.StatusBadRequest},
+		{"trailing data", http.MethodPost, "/v2/users/1/events", `{"date":"2025-05-05"} {}`, http.StatusBadRequest},
+		{"unknown rsvp field", http.MethodPost, "/respond_event", `{"user_id":1,"answer":"yes"}`, http.StatusBadRequest},
+		{"import too large", http.MethodPost, "/import_ics?user_id=1", ics, http.StatusRequestEntityTooLarge},
+		{"fits", http.MethodPost, "/create_event", `{"user_id":1,"date":"2025-05-05"}`, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := doV2(t, h, tt.method, tt.url, tt.body); w.Code != tt.want {
+				t.Fatalf("expected %d, got %d: %s", tt.want, w.Code, w.Body.String())
+			}
+		})
+	}
+	if w := doV2(t, h, http.MethodPost, "/create_event", `{"user_id":1,"date":"2025-05-05","colour":"red"}`); !strings.Contains(w.Body.String(), `unknown field \"colour\"`) {
+		t.Fatalf("error must name the unknown field: %s", w.Body)
+	}
+}
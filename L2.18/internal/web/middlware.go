@@ -1,27 +1,174 @@
 package web
 
 import (
+	"calendar/internal/auth"
+	"calendar/internal/logger"
+	"calendar/internal/telemetry"
+	"context"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 	"net/http"
+	"strings"
 	"time"
 )
 
-func LoggerMiddleware(logger *zap.Logger) func(http.Handler) http.Handler {
+const (
+	RequestIDHeader = "X-Request-ID"
+	maxRequestIDLen = 128
+)
+
+type requestIDKey struct{}
+
+// RequestIDMiddleware берёт X-Request-ID клиента или создаёт новый, возвращает его в ответе и кладёт в контекст
+func RequestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if !validRequestID(id) {
+			id = uuid.NewString()
+		}
+		w.Header().Set(RequestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestIDKey{}, id)))
+	})
+}
+
+// RequestIDFrom возвращает идентификатор запроса из контекста или пустую строку
+func RequestIDFrom(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// validRequestID не пускает в логи и заголовки слишком длинные и непечатаемые значения
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLen {
+		return false
+	}
+	for _, c := range id {
+		if c < 0x21 || c > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// LoggerMiddleware кладёт в контекст логгер с request_id (см. logger.FromContext) и пишет строку о каждом запросе
+func LoggerMiddleware(base *zap.Logger) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 
 			start := time.Now()
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			reqLogger := base.With(zap.String("request_id", RequestIDFrom(r.Context())))
 
 			// Вызов следующего обработчика
-			next.ServeHTTP(w, r)
+			next.ServeHTTP(ww, r.WithContext(logger.WithContext(r.Context(), reqLogger)))
 
 			// После обработки запроса — логируем
 			duration := time.Since(start)
-			logger.Info("HTTP request",
+			reqLogger.Info("HTTP request",
 				zap.String("method", r.Method),
 				zap.String("url", r.URL.String()),
+				zap.Int("status", responseStatus(ww)),
 				zap.Duration("duration", duration),
 			)
 		})
 	}
 }
+
+// MetricsMiddleware считает запросы и их длительность по шаблону маршрута chi
+func MetricsMiddleware(metrics *telemetry.Metrics) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			next.ServeHTTP(ww, r)
+			metrics.ObserveRequest(routePattern(r), r.Method, responseStatus(ww), time.Since(start))
+		})
+	}
+}
+
+// TracingMiddleware продолжает трассу из traceparent клиента или начинает новую; обработчики
+// передают контекст запроса в хранилище, поэтому спаны хранилища становятся дочерними
+func TracingMiddleware(tp trace.TracerProvider) func(http.Handler) http.Handler {
+	tracer := tp.Tracer(telemetry.InstrumentationName)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := telemetry.Propagator.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+			ctx, span := tracer.Start(ctx, r.Method, trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(
+					attribute.String("http.request.method", r.Method),
+					attribute.String("url.path", r.URL.Path),
+				))
+			defer span.End()
+
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			next.ServeHTTP(ww, r.WithContext(ctx))
+
+			// шаблон маршрута известен только после того, как chi нашёл обработчик
+			route, status := routePattern(r), responseStatus(ww)
+			span.SetName(r.Method + " " + route)
+			span.SetAttributes(
+				attribute.String("http.route", route),
+				attribute.Int("http.response.status_code", status),
+			)
+			// идентификатор выставляет RequestIDMiddleware внутри группы маршрутов, поэтому берём его из ответа
+			if id := ww.Header().Get(RequestIDHeader); id != "" {
+				span.SetAttributes(attribute.String("http.request_id", id))
+			}
+			if status >= http.StatusInternalServerError {
+				span.SetStatus(codes.Error, http.StatusText(status))
+			}
+		})
+	}
+}
+
+// routePattern возвращает шаблон маршрута (/v2/users/{user_id}/events) вместо пути с идентификаторами
+func routePattern(r *http.Request) string {
+	if rctx := chi.RouteContext(r.Context()); rctx != nil {
+		if pattern := rctx.RoutePattern(); pattern != "" {
+			return pattern
+		}
+	}
+	return "unmatched"
+}
+
+// responseStatus — код ответа; обработчик, который ничего не записал, отвечает 200
+func responseStatus(ww middleware.WrapResponseWriter) int {
+	if ww.Status() == 0 {
+		return http.StatusOK
+	}
+	return ww.Status()
+}
+
+// AuthMiddleware проверяет bearer-токен и кладёт пользователя из него в контекст запроса.
+// Соответствие user_id запроса пользователю токена проверяют обработчики через auth.ResolveUser.
+// CalDAV-клиенты не умеют Bearer, поэтому токен принимается и паролем Basic (имя пользователя не проверяется).
+func AuthMiddleware(authn *auth.Authenticator, base *zap.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok {
+				_, token, ok = r.BasicAuth()
+			}
+			if !ok || token == "" {
+				w.Header().Add("WWW-Authenticate", `Bearer realm="calendar"`)
+				w.Header().Add("WWW-Authenticate", `Basic realm="calendar"`)
+				writeError(w, "missing bearer token", http.StatusUnauthorized)
+				return
+			}
+			p, err := authn.Parse(token)
+			if err != nil {
+				logger.FromContext(r.Context(), base).Warn("invalid token", zap.Error(err))
+				w.Header().Set("WWW-Authenticate", `Bearer realm="calendar", error="invalid_token"`)
+				writeError(w, "invalid token", http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(auth.WithPrincipal(r.Context(), p)))
+		})
+	}
+}
@@ -2,19 +2,96 @@ package web
 
 import (
 	_ "calendar/docs"
+	"calendar/internal/auth"
 	"github.com/go-chi/chi/v5"
 	httpSwagger "github.com/swaggo/http-swagger"
+	"net/http"
 )
 
-func RegisterRoutes(r chi.Router, h *CalendarHandler) {
+// RegisterRoutes регистрирует API; если authn == nil, аутентификация отключена.
+// Маршруты разбиты на группы чтения, изменения и импорта (с пакетными операциями), у каждой свой лимит частоты и размера тела.
+func RegisterRoutes(r chi.Router, h *CalendarHandler, authn *auth.Authenticator, limits Limits) {
+	if limits.MaxBody <= 0 {
+		limits.MaxBody = defaultMaxBody
+	}
+	if limits.MaxImport <= 0 {
+		limits.MaxImport = defaultMaxImport
+	}
+
 	r.Group(func(r chi.Router) {
+		r.Use(RequestIDMiddleware)
 		r.Use(LoggerMiddleware(h.logger))
-		r.Post("/create_event", h.CreateEvent)
-		r.Post("/update_event", h.UpdateEvent)
-		r.Post("/delete_event", h.DeleteEvent)
-		r.Get("/events_for_day", h.EventsForDay)
-		r.Get("/events_for_week", h.EventsForWeek)
-		r.Get("/events_for_month", h.EventsForMonth)
+		// до проверки токена пользователь неизвестен, поэтому запросы считаются по IP:
+		// перебор токенов и запросы с невалидными токенами тоже упираются в лимит
+		r.Use(RateLimitMiddleware(NewRateLimiter(limits.IP), h.logger))
+		if authn != nil {
+			r.Use(AuthMiddleware(authn, h.logger))
+		}
+
+		r.Group(func(r chi.Router) {
+			r.Use(RateLimitMiddleware(NewRateLimiter(limits.Read), h.logger))
+			r.Get("/events_for_day", h.EventsForDay)
+			r.Get("/events_for_week", h.EventsForWeek)
+			r.Get("/events_for_month", h.EventsForMonth)
+			r.Get("/events_for_range", h.EventsForRange)
+			r.Get("/events/search", h.SearchEvents)
+			r.Get("/freebusy", h.FreeBusy)
+			r.Get("/calendar.ics", h.ExportICS)
+			if h.feed != nil {
+				r.Get("/events/stream", h.StreamEvents)
+			}
+			if h.webhooks != nil {
+				r.Get("/webhooks", h.ListWebhooks)
+				r.Get("/webhooks/dead_letters", h.WebhookDeadLetters)
+				r.Get("/webhooks/{webhook_id}/deliveries", h.WebhookDeliveries)
+			}
+			r.Get("/v2/users/{user_id}/events", h.ListEventsV2)
+			r.Get("/events/{event_id}/history", h.EventHistory)
+			r.Get("/v2/users/{user_id}/events/{event_id}", h.GetEventV2)
+			r.Get("/v2/users/{user_id}/events/{event_id}/history", h.EventHistoryV2)
+
+			// тела PROPFIND и REPORT — небольшие XML-запросы
+			dav := r.With(BodyLimitMiddleware(limits.MaxBody))
+			dav.Method("PROPFIND", "/caldav", http.HandlerFunc(h.CalDAVRoot))
+			dav.Method("PROPFIND", "/caldav/", http.HandlerFunc(h.CalDAVRoot))
+			dav.Method("PROPFIND", "/caldav/{user_id}/", http.HandlerFunc(h.CalDAVPrincipal))
+			dav.Method("PROPFIND", "/caldav/{user_id}/calendar/", http.HandlerFunc(h.CalDAVCalendar))
+			dav.Method("PROPFIND", "/caldav/{user_id}/calendar/{name}", http.HandlerFunc(h.CalDAVObjectProps))
+			dav.Method("REPORT", "/caldav/{user_id}/calendar/", http.HandlerFunc(h.CalDAVReport))
+			r.Get("/caldav/{user_id}/calendar/{name}", h.CalDAVGet)
+			r.Head("/caldav/{user_id}/calendar/{name}", h.CalDAVGet)
+		})
+
+		r.Group(func(r chi.Router) {
+			r.Use(BodyLimitMiddleware(limits.MaxBody), RateLimitMiddleware(NewRateLimiter(limits.Write), h.logger))
+			r.Post("/create_event", h.CreateEvent)
+			r.Post("/update_event", h.UpdateEvent)
+			r.Post("/delete_event", h.DeleteEvent)
+			r.Post("/respond_event", h.RespondEvent)
+			r.Post("/events/{event_id}/restore", h.RestoreEvent)
+			r.Post("/v2/users/{user_id}/events", h.CreateEventV2)
+			r.Put("/v2/users/{user_id}/events/{event_id}", h.ReplaceEventV2)
+			r.Patch("/v2/users/{user_id}/events/{event_id}", h.PatchEventV2)
+			r.Delete("/v2/users/{user_id}/events/{event_id}", h.DeleteEventV2)
+			r.Post("/v2/users/{user_id}/events/{event_id}/rsvp", h.RespondEventV2)
+			r.Post("/v2/users/{user_id}/events/{event_id}/restore", h.RestoreEventV2)
+			r.Put("/caldav/{user_id}/calendar/{name}", h.CalDAVPut)
+			r.Delete("/caldav/{user_id}/calendar/{name}", h.CalDAVDelete)
+			if h.webhooks != nil {
+				r.Post("/webhooks", h.CreateWebhook)
+				r.Delete("/webhooks/{webhook_id}", h.DeleteWebhook)
+				r.Post("/webhooks/{webhook_id}/deliveries/{delivery_id}/retry", h.RetryWebhookDelivery)
+			}
+		})
+
+		r.Group(func(r chi.Router) {
+			r.Use(BodyLimitMiddleware(limits.MaxImport), RateLimitMiddleware(NewRateLimiter(limits.Import), h.logger))
+			r.Post("/import_ics", h.ImportICS)
+			r.Post("/events/batch", h.BatchEvents)
+		})
 	})
 	r.Get("/swagger/*", httpSwagger.WrapHandler)
+	// обнаружение CalDAV и OPTIONS клиенты делают до аутентификации
+	r.HandleFunc("/.well-known/caldav", h.WellKnownCalDAV)
+	r.Options("/caldav*", h.CalDAVOptions)
 }
@@ -0,0 +1,77 @@
+package web
+
+import (
+	"calendar/internal/app"
+	"calendar/internal/repository"
+	"fmt"
+	"go.uber.org/zap"
+	"net/http"
+	"strconv"
+)
+
+// SearchEvents godoc
+// @Summary      Search events
+// @Description  Full-text search over title, tags, event text, location and description of the user's events and invitations.
+// @Description  Every word of q must match a whole word or its beginning; results are ranked by relevance, ties — later events first.
+// @Description  Series are returned as a whole, without expanding occurrences. At least one of q and tag is required.
+// @Tags         events
+// @Security     BearerAuth
+// @Produce      json
+// @Param        user_id  query  int       true   "User ID"
+// @Param        q        query  string    false  "Words to search for"
+// @Param        tag      query  []string  false  "Only events with all these tags" collectionFormat(multi)
+// @Param        from     query  string    false  "Only events with an occurrence after: RFC 3339 or YYYY-MM-DD, together with to"
+// @Param        to       query  string    false  "Only events with an occurrence before: RFC 3339 (exclusive) or YYYY-MM-DD (inclusive)"
+// @Param        tz       query  string    false  "IANA time zone for dates, e.g. Europe/Moscow (default UTC)"
+// @Param        limit    query  int       false  "Max number of results, 1..1000 (default 100)"
+// @Success      200  {array}   repository.SearchHit  "found events" // note: response wrapped as {"result": [...]}
+// @Failure 	 400  {object} ErrorResponse "invalid user_id, interval or limit"
+// @Failure 	 401  {object} ErrorResponse "missing or invalid token"
+// @Failure 	 403  {object} ErrorResponse "user_id does not match token"
+// @Failure 	 422  {object} ErrorResponse "neither q nor tag given, or invalid tag"
+// @Failure 	 429  {object} ErrorResponse "rate limit exceeded, see Retry-After"
+// @Failure 	 500  {object} ErrorResponse "internal server error"
+// @Router       /events/search [get]
+func (h *CalendarHandler) SearchEvents(w http.ResponseWriter, r *http.Request) {
+	rq := r.URL.Query()
+
+	user, ok := h.queryUser(w, r)
+	if !ok {
+		return
+	}
+
+	q := repository.SearchQuery{Text: rq.Get("q"), Tags: rq["tag"], Limit: defaultPageSize}
+	if rq.Get("from") != "" || rq.Get("to") != "" {
+		loc, err := app.LocationParser(rq.Get("tz"))
+		if err != nil {
+			h.log(r).Warn("invalid time zone", zap.Error(err))
+			writeError(w, "invalid tz", http.StatusBadRequest)
+			return
+		}
+		if q.From, q.To, err = app.BoundsParser(rq.Get("from"), rq.Get("to"), loc); err != nil {
+			h.log(r).Warn("invalid interval", zap.Error(err))
+			writeError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	if raw := rq.Get("limit"); raw != "" {
+		var err error
+		if q.Limit, err = strconv.Atoi(raw); err != nil || q.Limit < 1 || q.Limit > maxPageSize {
+			writeError(w, fmt.Sprintf("limit must be between 1 and %d", maxPageSize), http.StatusBadRequest)
+			return
+		}
+	}
+
+	hits, err := h.store(r).Search(user, q)
+	if err != nil {
+		errParser(w, h.log(r), err, "search failed")
+		return
+	}
+	if hits == nil {
+		hits = []repository.SearchHit{}
+	}
+
+	h.log(r).Info("events searched", zap.Int("user_id", user), zap.Int("found", len(hits)))
+	writeJson(w, hits)
+}
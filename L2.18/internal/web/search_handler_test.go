@@ -0,0 +1,56 @@
+package web
+
+import (
+	"calendar/internal/repository"
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestSearchEvents(t *testing.T) {
+	h := newV2Router()
+	for _, body := range []string{
+		`{"user_id":1,"date":"2025-05-05","event":"встреча","title":"Ретро спринта","tags":["Работа"]}`,
+		`{"user_id":1,"date":"2025-06-05","event":"ретроспектива","location":"офис"}`,
+		`{"user_id":2,"date":"2025-05-05","event":"ретро"}`,
+	} {
+		if w := doV2(t, h, http.MethodPost, "/create_event", body); w.Code != http.StatusOK {
+			t.Fatalf("create failed: %d %s", w.Code, w.Body)
+		}
+	}
+
+	search := func(query string) (int, []repository.SearchHit) {
+		w := doV2(t, h, http.MethodGet, "/events/search?user_id=1&"+query, "")
+		var out struct {
+			Result []repository.SearchHit `json:"result"`
+		}
+		if w.Code == http.StatusOK {
+			if err := json.NewDecoder(w.Body).Decode(&out); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+		}
+		return w.Code, out.Result
+	}
+
+	if code, hits := search("q=%D1%80%D0%B5%D1%82%D1%80%D0%BE"); code != http.StatusOK || len(hits) != 2 || hits[0].Event.Title != "Ретро спринта" {
+		t.Fatalf("unexpected search result %d %+v", code, hits)
+	}
+	if code, hits := search("tag=%D1%80%D0%B0%D0%B1%D0%BE%D1%82%D0%B0&tag=work"); code != http.StatusOK || hits == nil || len(hits) != 0 {
+		t.Fatalf("expected an empty list for two tags, got %d %+v", code, hits)
+	}
+	if code, hits := search("q=%D1%80%D0%B5%D1%82%D1%80%D0%BE&from=2025-06-01&to=2025-06-30"); code != http.StatusOK || len(hits) != 1 || hits[0].Event.Location != "офис" {
+		t.Fatalf("unexpected search in interval %d %+v", code, hits)
+	}
+
+	for query, want := range map[string]int{
+		"":                    http.StatusUnprocessableEntity,
+		"tag=a,b":             http.StatusUnprocessableEntity,
+		"q=x&limit=0":         http.StatusBadRequest,
+		"q=x&from=2025-06-01": http.StatusBadRequest,
+		"q=x&from=bad&to=bad": http.StatusBadRequest,
+	} {
+		if code, _ := search(query); code != want {
+			t.Errorf("%q: expected %d, got %d", query, want, code)
+		}
+	}
+}
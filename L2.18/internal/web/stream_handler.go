@@ -0,0 +1,118 @@
+package web
+
+import (
+	"calendar/internal/app"
+	"calendar/internal/feed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const defaultHeartbeat = 15 * time.Second
+
+// StreamMessage — данные одного события SSE; тип продублирован в поле event потока
+type StreamMessage struct {
+	Type  string     `json:"type"`
+	Event *app.Event `json:"event,omitempty"`
+}
+
+// SetFeed включает ленту изменений /events/stream; heartbeat — как часто слать комментарий в тихий поток
+func (h *CalendarHandler) SetFeed(hub *feed.Hub, heartbeat time.Duration) {
+	if heartbeat <= 0 {
+		heartbeat = defaultHeartbeat
+	}
+	h.feed, h.heartbeat = hub, heartbeat
+}
+
+// StreamEvents godoc
+// @Summary      Change feed
+// @Description  Server-Sent Events with events of the user (as organizer or attendee) that are created, updated or deleted.
+// @Description  Every message has an id; a client reconnecting with Last-Event-ID first receives the changes it missed.
+// @Description  If they are no longer available, a "reset" message is sent and the calendar should be reloaded.
+// @Description  Comments (": ping") are sent as heartbeats while nothing changes.
+// @Tags         events
+// @Security     BearerAuth
+// @Produce      text/event-stream
+// @Param        user_id        query   int     true   "User ID"
+// @Param        Last-Event-ID  header  string  false  "id of the last received message"
+// @Success      200  {object}  StreamMessage  "stream of messages: id, event (created, updated, deleted, reset) and data"
+// @Failure 	 400  {object} ErrorResponse "invalid user_id or Last-Event-ID"
+// @Failure 	 401  {object} ErrorResponse "missing or invalid token"
+// @Failure 	 403  {object} ErrorResponse "user_id does not match token"
+// @Failure 	 429  {object} ErrorResponse "rate limit exceeded, see Retry-After"
+// @Failure 	 503  {object} ErrorResponse "server is shutting down"
+// @Router       /events/stream [get]
+func (h *CalendarHandler) StreamEvents(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.queryUser(w, r)
+	if !ok {
+		return
+	}
+	var lastID uint64
+	raw := r.Header.Get("Last-Event-ID")
+	if raw != "" {
+		var err error
+		if lastID, err = strconv.ParseUint(raw, 10, 64); err != nil {
+			h.log(r).Warn("invalid Last-Event-ID", zap.Error(err))
+			writeError(w, "invalid Last-Event-ID", http.StatusBadRequest)
+			return
+		}
+	}
+	sub, err := h.feed.Subscribe(user, lastID, raw != "")
+	if err != nil {
+		writeError(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	defer sub.Close()
+
+	// поток живёт дольше WriteTimeout сервера, обрыв соединения замечается по ошибке Flush
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		h.log(r).Warn("cannot extend write deadline", zap.Error(err))
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	h.log(r).Info("stream opened", zap.Int("user_id", user), zap.Int("missed", len(sub.Missed)), zap.Bool("lost", sub.Lost))
+
+	if sub.Lost {
+		writeStream(w, sub.Head, StreamMessage{Type: "reset"})
+	}
+	for _, m := range sub.Missed {
+		writeStream(w, m.ID, StreamMessage{Type: m.Type, Event: &m.Event})
+	}
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	ticker := time.NewTicker(h.heartbeat)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case m, ok := <-sub.Events():
+			if !ok {
+				// клиент отстал или сервер останавливается: при переподключении он продолжит с последнего id
+				h.log(r).Info("stream closed by server", zap.Int("user_id", user))
+				return
+			}
+			writeStream(w, m.ID, StreamMessage{Type: m.Type, Event: &m.Event})
+		case <-ticker.C:
+			io.WriteString(w, ": ping\n\n")
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func writeStream(w io.Writer, id uint64, m StreamMessage) {
+	data, _ := json.Marshal(m)
+	fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", id, m.Type, data)
+}
@@ -0,0 +1,164 @@
+package web
+
+import (
+	"bufio"
+	"calendar/internal/app"
+	"calendar/internal/feed"
+	"calendar/internal/repository"
+	"context"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// sseEvent — одно сообщение потока: поля id, event и data
+type sseEvent struct {
+	id, event, data string
+}
+
+func newStreamServer(t *testing.T, heartbeat time.Duration) (*httptest.Server, *feed.Hub) {
+	t.Helper()
+	hub := feed.NewHub(100)
+	repo := repository.NewInMemoryRepo()
+	repo.SetPublisher(hub)
+	h := NewCalendarHandler(repo, zap.NewNop())
+	h.SetFeed(hub, heartbeat)
+	r := chi.NewRouter()
+	RegisterRoutes(r, h, nil, Limits{})
+	srv := httptest.NewServer(r)
+	t.Cleanup(func() {
+		hub.Close()
+		srv.Close()
+	})
+	return srv, hub
+}
+
+func openStream(t *testing.T, url, lastID string) (*bufio.Reader, func()) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if lastID != "" {
+		req.Header.Set("Last-Event-ID", lastID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("open stream: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("unexpected stream response %d %q", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	return bufio.NewReader(resp.Body), func() {
+		cancel()
+		resp.Body.Close()
+	}
+}
+
+// next читает следующее сообщение, пропуская комментарии-heartbeat; comments — сколько их встретилось
+func next(t *testing.T, r *bufio.Reader) (ev sseEvent, comments int) {
+	t.Helper()
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("read stream: %v", err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case line == "" && ev.event != "":
+			return ev, comments
+		case strings.HasPrefix(line, ":"):
+			comments++
+		case strings.HasPrefix(line, "id: "):
+			ev.id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			ev.event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			ev.data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+}
+
+func TestStreamEvents(t *testing.T) {
+	srv, _ := newStreamServer(t, time.Hour)
+	stream, closeStream := openStream(t, srv.URL+"/events/stream?user_id=2", "")
+
+	post := func(url, body string) {
+		resp, err := http.Post(srv.URL+url, "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("POST %s: %v", url, err)
+		}
+		resp.Body.Close()
+	}
+	post("/v2/users/1/events", `{"date":"2025-05-05","event":"private"}`)
+	post("/v2/users/1/events", `{"date":"2025-05-05","event":"shared","attendees":[{"user_id":2}]}`)
+
+	created, _ := next(t, stream)
+	if created.event != repository.ChangeCreated || !strings.Contains(created.data, `"shared"`) {
+		t.Fatalf("expected only the shared event, got %+v", created)
+	}
+	closeStream()
+
+	// пока клиент переподключается, событие удаляют; после переподключения он получает пропущенное
+	id := created.data[strings.Index(created.data, `"event_id":"`)+12:][:36]
+	req, _ := http.NewRequest(http.MethodDelete, srv.URL+"/v2/users/1/events/"+id, nil)
+	resp, _ := http.DefaultClient.Do(req)
+	resp.Body.Close()
+
+	stream, closeStream = openStream(t, srv.URL+"/events/stream?user_id=2", created.id)
+	defer closeStream()
+	if deleted, _ := next(t, stream); deleted.event != repository.ChangeDeleted || !strings.Contains(deleted.data, id) {
+		t.Fatalf("expected missed delete, got %+v", deleted)
+	}
+}
+
+func TestStreamEventsResetAndHeartbeat(t *testing.T) {
+	srv, hub := newStreamServer(t, 10*time.Millisecond)
+	stream, closeStream := openStream(t, srv.URL+"/events/stream?user_id=1", "1")
+	defer closeStream()
+
+	reset, _ := next(t, stream)
+	if reset.event != "reset" {
+		t.Fatalf("expected reset for an unknown id, got %+v", reset)
+	}
+	time.Sleep(50 * time.Millisecond)
+	hub.Publish([]repository.Change{{Type: repository.ChangeUpdated, Event: app.Event{UserID: 1}}})
+	if ev, comments := next(t, stream); ev.event != repository.ChangeUpdated || comments == 0 {
+		t.Fatalf("expected heartbeats before the update, got %d and %+v", comments, ev)
+	}
+
+	// остановка хаба завершает поток, чтобы Shutdown сервера не ждал его
+	hub.Close()
+	if _, err := stream.ReadString('\n'); err == nil {
+		if _, err := stream.ReadString('\n'); err == nil {
+			t.Fatal("stream must end after the hub is closed")
+		}
+	}
+}
+
+func TestStreamEventsErrors(t *testing.T) {
+	srv, hub := newStreamServer(t, time.Hour)
+	for url, want := range map[string]int{
+		"/events/stream?user_id=x": http.StatusBadRequest,
+		"/events/stream?user_id=1": http.StatusBadRequest, // Last-Event-ID не число
+	} {
+		req, _ := http.NewRequest(http.MethodGet, srv.URL+url, nil)
+		req.Header.Set("Last-Event-ID", "abc")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != want {
+			t.Fatalf("%s: expected %d, got %d", url, want, resp.StatusCode)
+		}
+	}
+	hub.Close()
+	resp, _ := http.Get(srv.URL + "/events/stream?user_id=1")
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("closed feed: expected 503, got %d", resp.StatusCode)
+	}
+}
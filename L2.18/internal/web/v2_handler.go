@@ -0,0 +1,351 @@
+package web
+
+import (
+	"calendar/internal/app"
+	"calendar/internal/auth"
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ListEventsV2 godoc
+// @Summary      List user's events
+// @Description  Without date returns all events and series (not expanded). With date returns occurrences overlapping the day, ISO week or month containing it.
+// @Tags         events v2
+// @Security     BearerAuth
+// @Produce      json
+// @Param        user_id  path   int     true   "User ID"
+// @Param        date     query  string  false  "Date in format YYYY-MM-DD"
+// @Param        period   query  string  false  "day (default), week or month"
+// @Param        tz       query  string  false  "IANA time zone of the user, e.g. Europe/Moscow (default UTC)"
+// @Success      200  {array}   app.Event
+// @Failure 	 400  {object} ErrorResponse "invalid user_id, date or period"
+// @Failure 	 401  {object} ErrorResponse "missing or invalid token"
+// @Failure 	 403  {object} ErrorResponse "user_id does not match token"
+// @Failure 	 429  {object} ErrorResponse "rate limit exceeded, see Retry-After"
+// @Failure 	 500  {object} ErrorResponse "internal server error"
+// @Router       /v2/users/{user_id}/events [get]
+func (h *CalendarHandler) ListEventsV2(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.pathUser(w, r)
+	if !ok {
+		return
+	}
+	rq := r.URL.Query()
+	if rq.Get("date") == "" {
+		events, err := h.store(r).LoadAll(user)
+		if err != nil {
+			errParser(w, h.log(r), err, "list events failed")
+			return
+		}
+		writeJson(w, events)
+		return
+	}
+
+	loc, err := app.LocationParser(rq.Get("tz"))
+	if err != nil {
+		writeError(w, "invalid tz", http.StatusBadRequest)
+		return
+	}
+	date, err := app.DateParser(rq.Get("date"), loc)
+	if err != nil {
+		writeError(w, "invalid date", http.StatusBadRequest)
+		return
+	}
+	var load func(int, time.Time) ([]*app.Event, error)
+	switch rq.Get("period") {
+	case "", "day":
+		load = h.store(r).LoadDay
+	case "week":
+		load = h.store(r).LoadWeek
+	case "month":
+		load = h.store(r).LoadMonth
+	default:
+		writeError(w, "period must be day, week or month", http.StatusBadRequest)
+		return
+	}
+	events, err := load(user, date)
+	if err != nil {
+		errParser(w, h.log(r), err, "list events failed")
+		return
+	}
+	writeJson(w, events)
+}
+
+// GetEventV2 godoc
+// @Summary      Get event
+// @Tags         events v2
+// @Security     BearerAuth
+// @Produce      json
+// @Param        user_id   path  int     true  "User ID"
+// @Param        event_id  path  string  true  "Event ID"
+// @Param        If-None-Match  header  string  false  "ETag from a previous response; 304 if the event has not changed"
+// @Success      200  {object}  app.Event
+// @Success      304  "not modified"
+// @Header       200  {string}  ETag  "Current version of the event"
+// @Failure 	 400  {object} ErrorResponse "invalid user_id"
+// @Failure 	 401  {object} ErrorResponse "missing or invalid token"
+// @Failure 	 403  {object} ErrorResponse "user_id does not match token"
+// @Failure 	 404  {object} ErrorResponse "event not found"
+// @Failure 	 422  {object} ErrorResponse "invalid event_id"
+// @Failure 	 429  {object} ErrorResponse "rate limit exceeded, see Retry-After"
+// @Failure 	 500  {object} ErrorResponse "internal server error"
+// @Router       /v2/users/{user_id}/events/{event_id} [get]
+func (h *CalendarHandler) GetEventV2(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.pathUser(w, r)
+	if !ok {
+		return
+	}
+	e, err := h.store(r).LoadEvent(user, chi.URLParam(r, "event_id"))
+	if err != nil {
+		errParser(w, h.log(r), err, "get event failed")
+		return
+	}
+	if inm := r.Header.Get("If-None-Match"); inm != "" && e.MatchesETag(inm) {
+		w.Header().Set("ETag", e.ETag())
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	writeEvent(w, http.StatusOK, e)
+}
+
+// CreateEventV2 godoc
+// @Summary      Create event
+// @Description  Create event; event_id may be set by the client, an existing id gives 409
+// @Tags         events v2
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        user_id  path  int               true  "User ID"
+// @Param        event    body  app.EventRequest  true  "Event to create"
+// @Param        Idempotency-Key  header  string  false  "Repeating a create with the same key and body returns the original event (keys are kept for 24h)"
+// @Success      201  {object}  app.Event  "created event, Location header points to it"
+// @Header       201  {string}  ETag  "Current version of the event"
+// @Failure 	 400  {object} ErrorResponse "malformed body or invalid user_id"
+// @Failure 	 401  {object} ErrorResponse "missing or invalid token"
+// @Failure 	 403  {object} ErrorResponse "user_id does not match token"
+// @Failure 	 409  {object} ErrorResponse "event_id already exists or the time slot is busy (see conflicts)"
+// @Failure 	 413  {object} ErrorResponse "request body is too large"
+// @Failure 	 422  {object} ErrorResponse "validation failed or Idempotency-Key reused with another body, see fields"
+// @Failure 	 429  {object} ErrorResponse "rate limit exceeded, see Retry-After"
+// @Failure 	 500  {object} ErrorResponse "internal server error"
+// @Router       /v2/users/{user_id}/events [post]
+func (h *CalendarHandler) CreateEventV2(w http.ResponseWriter, r *http.Request) {
+	er, ok := h.decodeV2(w, r)
+	if !ok {
+		return
+	}
+	e, err := h.store(r).Save(er)
+	if err != nil {
+		errParser(w, h.log(r), err, "create event failed")
+		return
+	}
+	h.log(r).Info("event created", zap.String("event_id", e.EventId.String()))
+	w.Header().Set("Location", fmt.Sprintf("/v2/users/%d/events/%s", e.UserID, e.EventId))
+	writeEvent(w, http.StatusCreated, e)
+}
+
+// ReplaceEventV2 godoc
+// @Summary      Replace event
+// @Description  Replace event (or whole series) with the given representation; date or start is required, omitted fields get their defaults: event text, title, description, location, tags, resource, rrule and exdates are cleared, time_zone becomes UTC, end equals start (next day for all-day events), attendees are cleared for the organizer
+// @Tags         events v2
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        user_id   path  int               true  "User ID"
+// @Param        event_id  path  string            true  "Event ID"
+// @Param        event     body  app.EventRequest  true  "New event representation"
+// @Param        If-Match  header  string  false  "ETag of the event; the change is applied only if it still matches"
+// @Success      200  {object}  app.Event
+// @Header       200  {string}  ETag  "Current version of the event"
+// @Failure 	 400  {object} ErrorResponse "malformed body or invalid user_id"
+// @Failure 	 401  {object} ErrorResponse "missing or invalid token"
+// @Failure 	 403  {object} ErrorResponse "user_id does not match token"
+// @Failure 	 404  {object} ErrorResponse "event not found"
+// @Failure 	 409  {object} ErrorResponse "time slot is busy (see conflicts)"
+// @Failure 	 412  {object} ErrorResponse "If-Match does not match the current ETag"
+// @Failure 	 413  {object} ErrorResponse "request body is too large"
+// @Failure 	 422  {object} ErrorResponse "validation failed, see fields"
+// @Failure 	 429  {object} ErrorResponse "rate limit exceeded, see Retry-After"
+// @Failure 	 500  {object} ErrorResponse "internal server error"
+// @Router       /v2/users/{user_id}/events/{event_id} [put]
+func (h *CalendarHandler) ReplaceEventV2(w http.ResponseWriter, r *http.Request) {
+	er, ok := h.decodeV2(w, r)
+	if !ok {
+		return
+	}
+	if er.Date == "" && er.Start == "" {
+		errParser(w, h.log(r), &app.ValidationError{Fields: []app.FieldError{
+			{Field: "date", Message: "date or start is required"},
+			{Field: "start", Message: "date or start is required"},
+		}}, "replace event failed")
+		return
+	}
+	er.EventId = chi.URLParam(r, "event_id")
+	// PUT заменяет событие целиком: не переданные поля сбрасываются в хранилище под той же блокировкой
+	er.Replace = true
+	if er.Scope == "" {
+		er.Scope = app.ScopeAll
+	}
+	h.updateV2(w, r, er)
+}
+
+// PatchEventV2 godoc
+// @Summary      Patch event
+// @Description  Change only the given fields; scope=this with recurrence_id changes a single occurrence of a series
+// @Tags         events v2
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        user_id   path  int               true  "User ID"
+// @Param        event_id  path  string            true  "Event ID"
+// @Param        event     body  app.EventRequest  true  "Fields to change"
+// @Param        If-Match  header  string  false  "ETag of the event; the change is applied only if it still matches"
+// @Success      200  {object}  app.Event
+// @Header       200  {string}  ETag  "Current version of the event"
+// @Failure 	 400  {object} ErrorResponse "malformed body or invalid user_id"
+// @Failure 	 401  {object} ErrorResponse "missing or invalid token"
+// @Failure 	 403  {object} ErrorResponse "user_id does not match token"
+// @Failure 	 404  {object} ErrorResponse "event not found"
+// @Failure 	 409  {object} ErrorResponse "time slot is busy (see conflicts)"
+// @Failure 	 412  {object} ErrorResponse "If-Match does not match the current ETag"
+// @Failure 	 413  {object} ErrorResponse "request body is too large"
+// @Failure 	 422  {object} ErrorResponse "validation failed or nothing to update"
+// @Failure 	 429  {object} ErrorResponse "rate limit exceeded, see Retry-After"
+// @Failure 	 500  {object} ErrorResponse "internal server error"
+// @Router       /v2/users/{user_id}/events/{event_id} [patch]
+func (h *CalendarHandler) PatchEventV2(w http.ResponseWriter, r *http.Request) {
+	er, ok := h.decodeV2(w, r)
+	if !ok {
+		return
+	}
+	er.EventId = chi.URLParam(r, "event_id")
+	h.updateV2(w, r, er)
+}
+
+// DeleteEventV2 godoc
+// @Summary      Delete event
+// @Description  Delete event or whole series; scope=this with recurrence_id deletes a single occurrence
+// @Tags         events v2
+// @Security     BearerAuth
+// @Param        user_id        path   int     true   "User ID"
+// @Param        event_id       path   string  true   "Event ID"
+// @Param        scope          query  string  false  "all (default) or this"
+// @Param        recurrence_id  query  string  false  "Occurrence of a series for scope=this"
+// @Param        If-Match  header  string  false  "ETag of the event; the change is applied only if it still matches"
+// @Success      204  "deleted"
+// @Failure 	 400  {object} ErrorResponse "invalid user_id"
+// @Failure 	 401  {object} ErrorResponse "missing or invalid token"
+// @Failure 	 403  {object} ErrorResponse "user_id does not match token"
+// @Failure 	 404  {object} ErrorResponse "event not found"
+// @Failure 	 412  {object} ErrorResponse "If-Match does not match the current ETag"
+// @Failure 	 422  {object} ErrorResponse "invalid event_id, scope or recurrence_id"
+// @Failure 	 429  {object} ErrorResponse "rate limit exceeded, see Retry-After"
+// @Failure 	 500  {object} ErrorResponse "internal server error"
+// @Router       /v2/users/{user_id}/events/{event_id} [delete]
+func (h *CalendarHandler) DeleteEventV2(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.pathUser(w, r)
+	if !ok {
+		return
+	}
+	er := &app.EventRequest{
+		EventId:      chi.URLParam(r, "event_id"),
+		UserID:       user,
+		Scope:        r.URL.Query().Get("scope"),
+		RecurrenceId: r.URL.Query().Get("recurrence_id"),
+	}
+	readConditions(r, er)
+	if err := h.store(r).Delete(er); err != nil {
+		errParser(w, h.log(r), err, "delete event failed")
+		return
+	}
+	h.log(r).Info("event deleted", zap.String("event_id", er.EventId))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RespondEventV2 godoc
+// @Summary      Respond to invitation
+// @Description  Set the attendee's RSVP status for an event or a whole series. Declined events disappear from the attendee's listings.
+// @Tags         events v2
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        user_id   path  int              true  "Attendee user ID"
+// @Param        event_id  path  string           true  "Event ID"
+// @Param        rsvp      body  app.RSVPRequest  true  "Only status is used"
+// @Success      200  {object}  app.Event
+// @Failure 	 400  {object} ErrorResponse "malformed body or invalid user_id"
+// @Failure 	 401  {object} ErrorResponse "missing or invalid token"
+// @Failure 	 403  {object} ErrorResponse "user_id does not match token"
+// @Failure 	 404  {object} ErrorResponse "event not found or user is not invited"
+// @Failure 	 413  {object} ErrorResponse "request body is too large"
+// @Failure 	 422  {object} ErrorResponse "invalid status"
+// @Failure 	 429  {object} ErrorResponse "rate limit exceeded, see Retry-After"
+// @Failure 	 500  {object} ErrorResponse "internal server error"
+// @Router       /v2/users/{user_id}/events/{event_id}/rsvp [post]
+func (h *CalendarHandler) RespondEventV2(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.pathUser(w, r)
+	if !ok {
+		return
+	}
+	var rsvp app.RSVPRequest
+	if err := decodeJSON(r, &rsvp); err != nil {
+		writeBodyError(w, h.log(r), err, "bad rsvp request")
+		return
+	}
+	rsvp.UserID, rsvp.EventId, rsvp.ActorID = user, chi.URLParam(r, "event_id"), auth.Actor(r.Context())
+	e, err := h.store(r).Respond(&rsvp)
+	if err != nil {
+		errParser(w, h.log(r), err, "respond failed")
+		return
+	}
+	h.log(r).Info("invitation answered", zap.String("event_id", rsvp.EventId), zap.String("status", rsvp.Status))
+	writeEvent(w, http.StatusOK, e)
+}
+
+func (h *CalendarHandler) updateV2(w http.ResponseWriter, r *http.Request, er *app.EventRequest) {
+	e, err := h.store(r).Update(er)
+	if err != nil {
+		errParser(w, h.log(r), err, "update event failed")
+		return
+	}
+	h.log(r).Info("event updated", zap.String("event_id", e.EventId.String()))
+	writeEvent(w, http.StatusOK, e)
+}
+
+// pathUser читает user_id из пути и проверяет, что токен даёт к нему доступ
+func (h *CalendarHandler) pathUser(w http.ResponseWriter, r *http.Request) (int, bool) {
+	requested, err := strconv.Atoi(chi.URLParam(r, "user_id"))
+	if err != nil || requested <= 0 {
+		writeError(w, "invalid user_id", http.StatusBadRequest)
+		return 0, false
+	}
+	user, err := auth.ResolveUser(r.Context(), requested)
+	if err != nil {
+		h.log(r).Warn("access denied", zap.Error(err))
+		writeError(w, "user_id does not match token", http.StatusForbidden)
+		return 0, false
+	}
+	return user, true
+}
+
+func (h *CalendarHandler) decodeV2(w http.ResponseWriter, r *http.Request) (*app.EventRequest, bool) {
+	user, ok := h.pathUser(w, r)
+	if !ok {
+		return nil, false
+	}
+	var er app.EventRequest
+	if err := decodeJSON(r, &er); err != nil {
+		writeBodyError(w, h.log(r), err, "bad calendar request")
+		return nil, false
+	}
+	if er.UserID != 0 && er.UserID != user {
+		writeError(w, "user_id in body does not match path", http.StatusBadRequest)
+		return nil, false
+	}
+	er.UserID = user
+	readConditions(r, &er)
+	return &er, true
+}
@@ -0,0 +1,264 @@
+package web
+
+import (
+	"calendar/internal/app"
+	"calendar/internal/repository"
+	"encoding/json"
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newV2Router() http.Handler {
+	r := chi.NewRouter()
+	RegisterRoutes(r, NewCalendarHandler(repository.NewInMemoryRepo(), zap.NewNop()), nil, Limits{})
+	return r
+}
+
+func doV2(t *testing.T, h http.Handler, method, url, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, url, strings.NewReader(body))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w
+}
+
+func decodeEvent(t *testing.T, w *httptest.ResponseRecorder) app.Event {
+	t.Helper()
+	var out struct {
+		Result app.Event `json:"result"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&out); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	return out.Result
+}
+
+func TestV2EventLifecycle(t *testing.T) {
+	h := newV2Router()
+
+	w := doV2(t, h, http.MethodPost, "/v2/users/5/events", `{"start":"2025-05-05T14:00:00Z","end":"2025-05-05T15:00:00Z","event":"review"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	created := decodeEvent(t, w)
+	location := w.Header().Get("Location")
+	if created.UserID != 5 || location != "/v2/users/5/events/"+created.EventId.String() {
+		t.Fatalf("unexpected create result %+v, Location %q", created, location)
+	}
+
+	if w := doV2(t, h, http.MethodGet, location, ""); w.Code != http.StatusOK || decodeEvent(t, w).EventText != "review" {
+		t.Fatalf("GET created event failed: %d", w.Code)
+	}
+
+	w = doV2(t, h, http.MethodPatch, location, `{"event":"retro"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("PATCH expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if patched := decodeEvent(t, w); patched.EventText != "retro" || patched.Start.Hour() != 14 {
+		t.Fatalf("PATCH changed more than requested: %+v", patched)
+	}
+
+	w = doV2(t, h, http.MethodPut, location, `{"date":"2025-05-06","event":"holiday"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("PUT expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if replaced := decodeEvent(t, w); !replaced.AllDay || replaced.EventText != "holiday" {
+		t.Fatalf("PUT did not replace event: %+v", replaced)
+	}
+
+	if w := doV2(t, h, http.MethodGet, "/v2/users/5/events?date=2025-05-06&period=week", ""); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "holiday") {
+		t.Fatalf("list by week failed: %d %s", w.Code, w.Body.String())
+	}
+
+	if w := doV2(t, h, http.MethodDelete, location, ""); w.Code != http.StatusNoContent || w.Body.Len() != 0 {
+		t.Fatalf("DELETE expected empty 204, got %d", w.Code)
+	}
+	if w := doV2(t, h, http.MethodDelete, location, ""); w.Code != http.StatusNotFound {
+		t.Fatalf("second DELETE expected 404, got %d", w.Code)
+	}
+	if w := doV2(t, h, http.MethodGet, location, ""); w.Code != http.StatusNotFound {
+		t.Fatalf("GET deleted event expected 404, got %d", w.Code)
+	}
+}
+
+// PUT после PATCH не оставляет поля, которых нет в новом представлении
+func TestV2ReplaceClearsOmittedFields(t *testing.T) {
+	h := newV2Router()
+	w := doV2(t, h, http.MethodPost, "/v2/users/1/events", `{"date":"2025-05-05","event":"offsite","attendees":[{"user_id":2,"can_edit":true}]}`)
+	location := w.Header().Get("Location")
+	w = doV2(t, h, http.MethodPatch, location, `{"title":"Q3","description":"agenda","location":"Room 1","tags":["work"]}`)
+	if patched := decodeEvent(t, w); w.Code != http.StatusOK || patched.Title != "Q3" || len(patched.Tags) != 1 {
+		t.Fatalf("PATCH failed: %d %+v", w.Code, patched)
+	}
+
+	// участник с can_edit заменяет событие, но список участников не теряется
+	editor := strings.Replace(location, "/users/1/", "/users/2/", 1)
+	w = doV2(t, h, http.MethodPut, editor, `{"date":"2025-05-05","event":"offsite","title":"Q4"}`)
+	if replaced := decodeEvent(t, w); w.Code != http.StatusOK || replaced.Title != "Q4" || replaced.Location != "" || len(replaced.Attendees) != 1 {
+		t.Fatalf("editor PUT: %d %+v", w.Code, replaced)
+	}
+
+	w = doV2(t, h, http.MethodPut, location, `{"date":"2025-05-06","event":"holiday"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("PUT expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	replaced := decodeEvent(t, w)
+	if replaced.Title != "" || replaced.Description != "" || replaced.Location != "" || len(replaced.Tags) != 0 || len(replaced.Attendees) != 0 {
+		t.Fatalf("PUT kept omitted fields: %+v", replaced)
+	}
+	if w := doV2(t, h, http.MethodGet, "/events/search?user_id=1&q=agenda", ""); w.Code != http.StatusOK || strings.Contains(w.Body.String(), "holiday") {
+		t.Fatalf("search still finds the cleared description: %s", w.Body)
+	}
+}
+
+func TestV2ReplaceMinimalBody(t *testing.T) {
+	h := newV2Router()
+	w := doV2(t, h, http.MethodPost, "/v2/users/1/events", `{"start":"2025-05-05T10:00:00+03:00","end":"2025-05-05T12:00:00+03:00","time_zone":"Europe/Moscow","event":"review","resource":"room-1"}`)
+	location := w.Header().Get("Location")
+
+	w = doV2(t, h, http.MethodPut, location, `{"start":"2025-05-06T09:00:00Z"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("PUT expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	replaced := decodeEvent(t, w)
+	if replaced.EventText != "" || replaced.TimeZone != "UTC" || replaced.Resource != "" || !replaced.End.Equal(replaced.Start) {
+		t.Fatalf("PUT kept previous text, zone, resource or duration: %+v", replaced)
+	}
+}
+
+func TestV2Errors(t *testing.T) {
+	h := newV2Router()
+	id := "0b7f3a3e-6a6f-4d5e-9a4b-1c2d3e4f5a6b"
+	body := `{"event_id":"` + id + `","date":"2025-05-05"}`
+
+	if w := doV2(t, h, http.MethodPost, "/v2/users/1/events", body); w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", w.Code)
+	}
+
+	tests := []struct {
+		name, method, url, body string
+		want                    int
+	}{
+		{"duplicate id", http.MethodPost, "/v2/users/1/events", body, http.StatusConflict},
+		{"bad user", http.MethodGet, "/v2/users/abc/events", "", http.StatusBadRequest},
+		{"body user mismatch", http.MethodPost, "/v2/users/1/events", `{"user_id":2,"date":"2025-05-05"}`, http.StatusBadRequest},
+		{"bad period", http.MethodGet, "/v2/users/1/events?date=2025-05-05&period=year", "", http.StatusBadRequest},
+		{"put without date", http.MethodPut, "/v2/users/1/events/" + id, `{"event":"x"}`, http.StatusUnprocessableEntity},
+		{"patch nothing", http.MethodPatch, "/v2/users/1/events/" + id, `{}`, http.StatusUnprocessableEntity},
+		{"patch missing", http.MethodPatch, "/v2/users/1/events/" + "1b7f3a3e-6a6f-4d5e-9a4b-1c2d3e4f5a6b", `{"event":"x"}`, http.StatusNotFound},
+		{"other user's event", http.MethodGet, "/v2/users/2/events/" + id, "", http.StatusNotFound},
+		{"bad event id", http.MethodGet, "/v2/users/1/events/nope", "", http.StatusUnprocessableEntity},
+		{"method not allowed", http.MethodPost, "/v2/users/1/events/" + id, body, http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := doV2(t, h, tt.method, tt.url, tt.body); w.Code != tt.want {
+				t.Fatalf("expected %d, got %d: %s", tt.want, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	// старые маршруты продолжают работать рядом с v2
+	if w := doV2(t, h, http.MethodGet, "/events_for_day?user_id=1&date=2025-05-05", ""); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), id) {
+		t.Fatalf("legacy route broken: %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestV2Invitations(t *testing.T) {
+	h := newV2Router()
+	w := doV2(t, h, http.MethodPost, "/v2/users/1/events",
+		`{"date":"2025-05-05","event":"offsite","attendees":[{"user_id":2,"can_edit":true},{"user_id":3}]}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create: %d %s", w.Code, w.Body)
+	}
+	id := decodeEvent(t, w).EventId.String()
+	url := "/v2/users/%d/events/" + id
+
+	tests := []struct {
+		name, method, url, body string
+		want                    int
+	}{
+		{"attendee reads", http.MethodGet, fmt.Sprintf(url, 3), "", http.StatusOK},
+		{"attendee without rights edits", http.MethodPatch, fmt.Sprintf(url, 3), `{"event":"x"}`, http.StatusForbidden},
+		{"editor edits", http.MethodPatch, fmt.Sprintf(url, 2), `{"event":"offsite 2"}`, http.StatusOK},
+		{"editor deletes", http.MethodDelete, fmt.Sprintf(url, 2), "", http.StatusForbidden},
+		{"bad status", http.MethodPost, fmt.Sprintf(url, 3) + "/rsvp", `{"status":"maybe"}`, http.StatusUnprocessableEntity},
+		{"not invited", http.MethodPost, fmt.Sprintf(url, 4) + "/rsvp", `{"status":"accepted"}`, http.StatusNotFound},
+		{"decline", http.MethodPost, fmt.Sprintf(url, 3) + "/rsvp", `{"status":"declined"}`, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := doV2(t, h, tt.method, tt.url, tt.body); w.Code != tt.want {
+				t.Fatalf("expected %d, got %d: %s", tt.want, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	if w := doV2(t, h, http.MethodGet, "/events_for_day?user_id=2&date=2025-05-05", ""); !strings.Contains(w.Body.String(), "offsite 2") {
+		t.Fatalf("invitation missing from attendee's day: %s", w.Body)
+	}
+	if w := doV2(t, h, http.MethodGet, "/events_for_day?user_id=3&date=2025-05-05", ""); strings.Contains(w.Body.String(), "offsite") {
+		t.Fatalf("declined invitation still listed: %s", w.Body)
+	}
+	w = doV2(t, h, http.MethodPost, "/respond_event", `{"user_id":3,"event_id":"`+id+`","status":"tentative"}`)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"tentative"`) {
+		t.Fatalf("legacy rsvp: %d %s", w.Code, w.Body)
+	}
+}
+
+func TestV2Preconditions(t *testing.T) {
+	h := newV2Router()
+	send := func(method, url, body string, header map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, url, strings.NewReader(body))
+		for k, v := range header {
+			req.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	create := `{"date":"2025-05-05","event":"planning"}`
+	w := send(http.MethodPost, "/v2/users/1/events", create, map[string]string{IdempotencyKeyHeader: "abc"})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create: %d %s", w.Code, w.Body)
+	}
+	etag := w.Header().Get("ETag")
+	created := decodeEvent(t, w)
+	if etag != created.ETag() {
+		t.Fatalf("ETag header %q does not match event %q", etag, created.ETag())
+	}
+	if retry := send(http.MethodPost, "/v2/users/1/events", create, map[string]string{IdempotencyKeyHeader: "abc"}); decodeEvent(t, retry).EventId != created.EventId {
+		t.Fatal("retried create returned a different event")
+	}
+	if w := send(http.MethodPost, "/v2/users/1/events", `{"date":"2025-05-06","event":"planning"}`, map[string]string{IdempotencyKeyHeader: "abc"}); w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("key reused with another body: expected 422, got %d", w.Code)
+	}
+
+	url := "/v2/users/1/events/" + created.EventId.String()
+	if w := send(http.MethodGet, url, "", map[string]string{"If-None-Match": etag}); w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+		t.Fatalf("expected empty 304, got %d", w.Code)
+	}
+
+	w = send(http.MethodPatch, url, `{"event":"planning 2"}`, map[string]string{"If-Match": etag})
+	if w.Code != http.StatusOK || w.Header().Get("ETag") == etag {
+		t.Fatalf("PATCH with current ETag: %d, ETag %q", w.Code, w.Header().Get("ETag"))
+	}
+	if w := send(http.MethodPatch, url, `{"event":"lost"}`, map[string]string{"If-Match": etag}); w.Code != http.StatusPreconditionFailed || !strings.Contains(w.Body.String(), CodePrecondition) {
+		t.Fatalf("stale PATCH expected 412, got %d: %s", w.Code, w.Body)
+	}
+	if w := send(http.MethodGet, url, "", map[string]string{"If-None-Match": etag}); w.Code != http.StatusOK {
+		t.Fatalf("changed event expected 200, got %d", w.Code)
+	}
+	if w := send(http.MethodDelete, url, "", map[string]string{"If-Match": etag}); w.Code != http.StatusPreconditionFailed {
+		t.Fatalf("stale DELETE expected 412, got %d", w.Code)
+	}
+	if w := send(http.MethodPost, "/update_event", `{"user_id":1,"event_id":"`+created.EventId.String()+`","event":"lost"}`, map[string]string{"If-Match": etag}); w.Code != http.StatusPreconditionFailed {
+		t.Fatalf("legacy stale update expected 412, got %d", w.Code)
+	}
+}
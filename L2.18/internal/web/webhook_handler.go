@@ -0,0 +1,190 @@
+package web
+
+import (
+	"calendar/internal/auth"
+	"calendar/internal/webhook"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+	"net/http"
+)
+
+// SetWebhooks включает маршруты /webhooks; nil оставляет их выключенными
+func (h *CalendarHandler) SetWebhooks(d *webhook.Dispatcher) {
+	h.webhooks = d
+}
+
+// CreateWebhook godoc
+// @Summary      Register webhook
+// @Description  Subscribes a URL to changes of the user's events (as organizer or attendee). Every change is POSTed as JSON
+// @Description  with X-Calendar-Delivery, X-Calendar-Event, X-Calendar-Timestamp and X-Calendar-Signature headers;
+// @Description  the signature is "sha256=" + hex HMAC-SHA256 of "<timestamp>.<body>" with the secret.
+// @Description  Without secret a random one is generated; the secret is returned only in this response.
+// @Tags         webhooks
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        webhook  body      webhook.SubscriptionRequest  true  "URL, optional secret and event types (created, updated, deleted; all by default)"
+// @Success      201      {object}  webhook.Subscription  "created webhook with its secret" // note: response wrapped as {"result": <webhook.Subscription>}
+// @Failure 	 400  {object} ErrorResponse "malformed body"
+// @Failure 	 401  {object} ErrorResponse "missing or invalid token"
+// @Failure 	 403  {object} ErrorResponse "user_id does not match token"
+// @Failure 	 413  {object} ErrorResponse "request body is too large"
+// @Failure 	 422  {object} ErrorResponse "invalid url, secret or events, or too many webhooks"
+// @Failure 	 429  {object} ErrorResponse "rate limit exceeded, see Retry-After"
+// @Failure 	 500  {object} ErrorResponse "internal server error"
+// @Router       /webhooks [post]
+func (h *CalendarHandler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	var req webhook.SubscriptionRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeBodyError(w, h.log(r), err, "bad webhook request")
+		return
+	}
+	user, err := auth.ResolveUser(r.Context(), req.UserID)
+	if err != nil {
+		h.log(r).Warn("access denied", zap.Error(err))
+		writeError(w, "user_id does not match token", http.StatusForbidden)
+		return
+	}
+	req.UserID = user
+	sub, err := h.webhooks.Subscribe(&req)
+	if err != nil {
+		errParser(w, h.log(r), err, "create webhook failed")
+		return
+	}
+	h.log(r).Info("webhook created", zap.String("webhook_id", sub.ID), zap.Int("user_id", user))
+	writeJsonStatus(w, http.StatusCreated, sub)
+}
+
+// ListWebhooks godoc
+// @Summary      List webhooks
+// @Description  Webhooks of the user in order of creation, without secrets.
+// @Tags         webhooks
+// @Security     BearerAuth
+// @Produce      json
+// @Param        user_id  query  int  true  "User ID"
+// @Success      200  {array}   webhook.Subscription  "webhooks" // note: response wrapped as {"result": [...]}
+// @Failure 	 400  {object} ErrorResponse "invalid user_id"
+// @Failure 	 401  {object} ErrorResponse "missing or invalid token"
+// @Failure 	 403  {object} ErrorResponse "user_id does not match token"
+// @Failure 	 429  {object} ErrorResponse "rate limit exceeded, see Retry-After"
+// @Router       /webhooks [get]
+func (h *CalendarHandler) ListWebhooks(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.queryUser(w, r)
+	if !ok {
+		return
+	}
+	writeJson(w, h.webhooks.Subscriptions(user))
+}
+
+// DeleteWebhook godoc
+// @Summary      Delete webhook
+// @Description  Removes the webhook together with its pending deliveries, delivery history and dead letters.
+// @Tags         webhooks
+// @Security     BearerAuth
+// @Param        webhook_id  path   string  true  "Webhook ID"
+// @Param        user_id     query  int     true  "User ID"
+// @Success      204
+// @Failure 	 400  {object} ErrorResponse "invalid user_id"
+// @Failure 	 401  {object} ErrorResponse "missing or invalid token"
+// @Failure 	 403  {object} ErrorResponse "user_id does not match token"
+// @Failure 	 404  {object} ErrorResponse "webhook not found"
+// @Failure 	 429  {object} ErrorResponse "rate limit exceeded, see Retry-After"
+// @Failure 	 500  {object} ErrorResponse "internal server error"
+// @Router       /webhooks/{webhook_id} [delete]
+func (h *CalendarHandler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.queryUser(w, r)
+	if !ok {
+		return
+	}
+	id := chi.URLParam(r, "webhook_id")
+	if err := h.webhooks.Unsubscribe(user, id); err != nil {
+		errParser(w, h.log(r), err, "delete webhook failed")
+		return
+	}
+	h.log(r).Info("webhook deleted", zap.String("webhook_id", id))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// WebhookDeliveries godoc
+// @Summary      Webhook deliveries
+// @Description  Deliveries of the webhook, newest first, with every attempt: time, response status or error and duration.
+// @Description  Pending deliveries have next_attempt; delivered ones are kept up to the configured history size.
+// @Tags         webhooks
+// @Security     BearerAuth
+// @Produce      json
+// @Param        webhook_id  path   string  true   "Webhook ID"
+// @Param        user_id     query  int     true   "User ID"
+// @Param        status      query  string  false  "Only deliveries in this state: pending, delivered or dead"
+// @Success      200  {array}   webhook.Delivery  "deliveries" // note: response wrapped as {"result": [...]}
+// @Failure 	 400  {object} ErrorResponse "invalid user_id"
+// @Failure 	 401  {object} ErrorResponse "missing or invalid token"
+// @Failure 	 403  {object} ErrorResponse "user_id does not match token"
+// @Failure 	 404  {object} ErrorResponse "webhook not found"
+// @Failure 	 422  {object} ErrorResponse "invalid status"
+// @Failure 	 429  {object} ErrorResponse "rate limit exceeded, see Retry-After"
+// @Router       /webhooks/{webhook_id}/deliveries [get]
+func (h *CalendarHandler) WebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.queryUser(w, r)
+	if !ok {
+		return
+	}
+	list, err := h.webhooks.Deliveries(user, chi.URLParam(r, "webhook_id"), r.URL.Query().Get("status"))
+	if err != nil {
+		errParser(w, h.log(r), err, "webhook deliveries load failed")
+		return
+	}
+	writeJson(w, list)
+}
+
+// WebhookDeadLetters godoc
+// @Summary      Dead letters
+// @Description  Deliveries of all the user's webhooks that failed every attempt, newest first. Retry them with .../retry.
+// @Tags         webhooks
+// @Security     BearerAuth
+// @Produce      json
+// @Param        user_id  query  int  true  "User ID"
+// @Success      200  {array}   webhook.Delivery  "dead letters" // note: response wrapped as {"result": [...]}
+// @Failure 	 400  {object} ErrorResponse "invalid user_id"
+// @Failure 	 401  {object} ErrorResponse "missing or invalid token"
+// @Failure 	 403  {object} ErrorResponse "user_id does not match token"
+// @Failure 	 429  {object} ErrorResponse "rate limit exceeded, see Retry-After"
+// @Router       /webhooks/dead_letters [get]
+func (h *CalendarHandler) WebhookDeadLetters(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.queryUser(w, r)
+	if !ok {
+		return
+	}
+	writeJson(w, h.webhooks.DeadLetters(user))
+}
+
+// RetryWebhookDelivery godoc
+// @Summary      Retry dead letter
+// @Description  Puts a dead delivery back into the queue with a fresh set of attempts. The payload and its id stay the same.
+// @Tags         webhooks
+// @Security     BearerAuth
+// @Produce      json
+// @Param        webhook_id   path   string  true  "Webhook ID"
+// @Param        delivery_id  path   string  true  "Delivery ID"
+// @Param        user_id      query  int     true  "User ID"
+// @Success      200  {object}  webhook.Delivery  "queued delivery" // note: response wrapped as {"result": <webhook.Delivery>}
+// @Failure 	 400  {object} ErrorResponse "invalid user_id"
+// @Failure 	 401  {object} ErrorResponse "missing or invalid token"
+// @Failure 	 403  {object} ErrorResponse "user_id does not match token"
+// @Failure 	 404  {object} ErrorResponse "webhook or delivery not found"
+// @Failure 	 409  {object} ErrorResponse "delivery is not dead"
+// @Failure 	 429  {object} ErrorResponse "rate limit exceeded, see Retry-After"
+// @Failure 	 500  {object} ErrorResponse "internal server error"
+// @Router       /webhooks/{webhook_id}/deliveries/{delivery_id}/retry [post]
+func (h *CalendarHandler) RetryWebhookDelivery(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.queryUser(w, r)
+	if !ok {
+		return
+	}
+	del, err := h.webhooks.Redeliver(user, chi.URLParam(r, "webhook_id"), chi.URLParam(r, "delivery_id"))
+	if err != nil {
+		errParser(w, h.log(r), err, "retry webhook delivery failed")
+		return
+	}
+	h.log(r).Info("webhook delivery queued", zap.String("delivery_id", del.ID))
+	writeJson(w, del)
+}
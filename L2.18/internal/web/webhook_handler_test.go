@@ -0,0 +1,122 @@
+package web
+
+import (
+	"calendar/internal/repository"
+	"calendar/internal/webhook"
+	"context"
+	"encoding/json"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func decodeResult(t *testing.T, w *httptest.ResponseRecorder, v any) {
+	t.Helper()
+	out := struct {
+		Result any `json:"result"`
+	}{Result: v}
+	if err := json.NewDecoder(w.Body).Decode(&out); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+}
+
+func TestWebhooks(t *testing.T) {
+	var received, failing atomic.Int32
+	failing.Store(1)
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failing.Load() == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		received.Add(1)
+	}))
+	defer target.Close()
+
+	d, _ := webhook.NewDispatcher(webhook.Options{MaxAttempts: 1, AllowedNetworks: []netip.Prefix{netip.MustParsePrefix("127.0.0.0/8")}}, zap.NewNop())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go d.Run(ctx)
+	repo := repository.NewInMemoryRepo()
+	repo.SetPublisher(d)
+	h := NewCalendarHandler(repo, zap.NewNop())
+	h.SetWebhooks(d)
+	r := chi.NewRouter()
+	RegisterRoutes(r, h, nil, Limits{})
+
+	w := doV2(t, r, http.MethodPost, "/webhooks", `{"user_id":1,"url":"`+target.URL+`","events":["created"]}`)
+	var sub webhook.Subscription
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create webhook failed: %d %s", w.Code, w.Body)
+	}
+	decodeResult(t, w, &sub)
+	if sub.Secret == "" || sub.ID == "" {
+		t.Fatalf("unexpected webhook %+v", sub)
+	}
+	if w := doV2(t, r, http.MethodPost, "/webhooks", `{"user_id":1,"url":"mailto:me@example.com"}`); w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422 for a bad url, got %d", w.Code)
+	}
+	var subs []webhook.Subscription
+	decodeResult(t, doV2(t, r, http.MethodGet, "/webhooks?user_id=1", ""), &subs)
+	if len(subs) != 1 || subs[0].Secret != "" {
+		t.Fatalf("unexpected webhooks %+v", subs)
+	}
+
+	// первое изменение не доставлено и сразу попадает в dead letters
+	doV2(t, r, http.MethodPost, "/create_event", `{"user_id":1,"date":"2025-05-05","event":"planning"}`)
+	var dead []webhook.Delivery
+	deadline := time.Now().Add(5 * time.Second)
+	for len(dead) == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+		decodeResult(t, doV2(t, r, http.MethodGet, "/webhooks/dead_letters?user_id=1", ""), &dead)
+	}
+	if len(dead) != 1 || dead[0].Attempts[0].Status != http.StatusInternalServerError {
+		t.Fatalf("unexpected dead letters %+v", dead)
+	}
+
+	failing.Store(0)
+	retry := "/webhooks/" + sub.ID + "/deliveries/" + dead[0].ID + "/retry?user_id=1"
+	if w := doV2(t, r, http.MethodPost, retry, ""); w.Code != http.StatusOK {
+		t.Fatalf("retry failed: %d %s", w.Code, w.Body)
+	}
+	for received.Load() == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if received.Load() != 1 {
+		t.Fatal("retried delivery not received")
+	}
+
+	var list []webhook.Delivery
+	decodeResult(t, doV2(t, r, http.MethodGet, "/webhooks/"+sub.ID+"/deliveries?user_id=1", ""), &list)
+	if len(list) != 1 || list[0].Status != webhook.StatusDelivered || len(list[0].Attempts) != 2 {
+		t.Fatalf("unexpected deliveries %+v", list)
+	}
+	for url, want := range map[string]int{
+		"/webhooks/" + sub.ID + "/deliveries?user_id=1&status=lost": http.StatusUnprocessableEntity,
+		"/webhooks/" + sub.ID + "/deliveries?user_id=2":             http.StatusNotFound,
+	} {
+		if w := doV2(t, r, http.MethodGet, url, ""); w.Code != want {
+			t.Errorf("%s: expected %d, got %d", url, want, w.Code)
+		}
+	}
+	if w := doV2(t, r, http.MethodPost, retry, ""); w.Code != http.StatusConflict {
+		t.Fatalf("expected 409 for a delivered delivery, got %d", w.Code)
+	}
+
+	if w := doV2(t, r, http.MethodDelete, "/webhooks/"+sub.ID+"?user_id=1", ""); w.Code != http.StatusNoContent {
+		t.Fatalf("delete failed: %d %s", w.Code, w.Body)
+	}
+	if w := doV2(t, r, http.MethodDelete, "/webhooks/"+sub.ID+"?user_id=1", ""); w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 after delete, got %d", w.Code)
+	}
+}
+
+func TestWebhooksDisabled(t *testing.T) {
+	if w := doV2(t, newV2Router(), http.MethodGet, "/webhooks?user_id=1", ""); w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 without webhooks, got %d", w.Code)
+	}
+}
@@ -0,0 +1,582 @@
+package webhook
+
+import (
+	"bytes"
+	"calendar/internal/app"
+	"calendar/internal/repository"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	defaultWorkers     = 4
+	defaultTimeout     = 10 * time.Second
+	defaultMaxAttempts = 8
+	defaultBackoff     = 10 * time.Second
+	defaultMaxBackoff  = time.Hour
+	defaultHistory     = 100
+	defaultDeadLetters = 1000
+)
+
+// Options — настройки доставки; нулевые значения заменяются значениями по умолчанию
+type Options struct {
+	StatePath   string // подписки и доставки переживают перезапуск; пусто — только в памяти
+	Workers     int
+	Timeout     time.Duration
+	MaxAttempts int
+	Backoff     time.Duration // пауза перед первым повтором, дальше удваивается до MaxBackoff
+	MaxBackoff  time.Duration
+	History     int // сколько доставленных помнить на подписку
+	DeadLetters int // сколько недоставленных помнить на подписку
+	// AllowedNetworks — внутренние сети, куда всё же можно доставлять. Остальные loopback, частные
+	// и link-local адреса запрещены, чтобы вебхуком нельзя было обращаться к внутренним сервисам.
+	AllowedNetworks []netip.Prefix
+}
+
+// Dispatcher хранит подписки и доставляет им изменения хранилища. Publish только складывает изменения
+// во входящие, а Run раскладывает их по подпискам и отправляет, повторяя неудачные с растущей паузой.
+type Dispatcher struct {
+	opts   Options
+	client *http.Client
+	logger *zap.Logger
+	now    func() time.Time
+
+	inboxMu sync.Mutex
+	inbox   []repository.Change
+	wake    chan struct{}
+
+	mu         sync.Mutex
+	subs       map[string]*Subscription
+	deliveries map[string][]*Delivery // по подписке, от старых к новым
+	inflight   map[string]bool
+	dirty      bool // есть изменения, не записанные в StatePath
+}
+
+func NewDispatcher(opts Options, logger *zap.Logger) (*Dispatcher, error) {
+	if opts.Workers <= 0 {
+		opts.Workers = defaultWorkers
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultTimeout
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = defaultMaxAttempts
+	}
+	if opts.Backoff <= 0 {
+		opts.Backoff = defaultBackoff
+	}
+	if opts.MaxBackoff < opts.Backoff {
+		opts.MaxBackoff = max(defaultMaxBackoff, opts.Backoff)
+	}
+	if opts.History <= 0 {
+		opts.History = defaultHistory
+	}
+	if opts.DeadLetters <= 0 {
+		opts.DeadLetters = defaultDeadLetters
+	}
+	d := &Dispatcher{
+		opts:       opts,
+		logger:     logger,
+		now:        time.Now,
+		wake:       make(chan struct{}, 1),
+		subs:       make(map[string]*Subscription),
+		deliveries: make(map[string][]*Delivery),
+		inflight:   make(map[string]bool),
+	}
+	d.client = NewClient(opts.Timeout, opts.AllowedNetworks)
+	if err := d.loadState(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// ErrAddressNotAllowed — получатель разрешился во внутренний адрес, не указанный в списке разрешённых сетей
+var ErrAddressNotAllowed = errors.New("address is not allowed")
+
+// NewClient — HTTP-клиент для доставки на внешние адреса: loopback, частные и link-local адреса, кроме сетей
+// allowed, запрещены. Адрес проверяется при соединении, уже после разрешения имени, иначе DNS мог бы вернуть
+// внутренний адрес. Прокси из окружения не используется, иначе проверялся бы адрес прокси, а не получателя.
+func NewClient(timeout time.Duration, allowed []netip.Prefix) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = (&net.Dialer{Timeout: timeout, Control: checkAddress(allowed)}).DialContext
+	return &http.Client{
+		Transport: transport,
+		Timeout:   timeout,
+		// перенаправление считается неудачей: адрес нужно исправить, а не ходить за ответом по другому
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+}
+
+// checkAddress — Control для net.Dialer: не даёт соединиться с внутренним адресом
+func checkAddress(allowed []netip.Prefix) func(network, address string, _ syscall.RawConn) error {
+	return func(network, address string, _ syscall.RawConn) error {
+		addrPort, err := netip.ParseAddrPort(address)
+		if err != nil {
+			return err
+		}
+		ip := addrPort.Addr().Unmap()
+		if !internalAddr(ip) || slices.ContainsFunc(allowed, func(p netip.Prefix) bool { return p.Contains(ip) }) {
+			return nil
+		}
+		return fmt.Errorf("%w: %s is a loopback, private or link-local address", ErrAddressNotAllowed, ip)
+	}
+}
+
+func internalAddr(ip netip.Addr) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsUnspecified()
+}
+
+// Publish принимает изменения от хранилища; вызывается под его блокировкой, поэтому только запоминает их
+func (d *Dispatcher) Publish(changes []repository.Change) {
+	d.inboxMu.Lock()
+	d.inbox = append(d.inbox, changes...)
+	d.inboxMu.Unlock()
+	d.notify()
+}
+
+func (d *Dispatcher) notify() {
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run доставляет изменения, пока не отменён ctx; начатые запросы прерываются и повторяются после перезапуска
+func (d *Dispatcher) Run(ctx context.Context) {
+	jobs := make(chan *Delivery)
+	var wg sync.WaitGroup
+	for range d.opts.Workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for del := range jobs {
+				d.deliver(ctx, del)
+			}
+		}()
+	}
+	defer func() {
+		close(jobs)
+		wg.Wait()
+		d.accept()
+		d.flush()
+	}()
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		d.accept()
+		d.flush()
+		for _, del := range d.due() {
+			select {
+			case jobs <- del:
+			case <-ctx.Done():
+				return
+			}
+		}
+		timer.Reset(d.untilNext())
+		select {
+		case <-ctx.Done():
+			return
+		case <-d.wake:
+		case <-timer.C:
+		}
+	}
+}
+
+// accept раскладывает входящие изменения по подпискам
+func (d *Dispatcher) accept() {
+	d.inboxMu.Lock()
+	changes := d.inbox
+	d.inbox = nil
+	d.inboxMu.Unlock()
+	if len(changes) == 0 {
+		return
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	now := d.now()
+	for i := range changes {
+		c := &changes[i]
+		for _, sub := range d.subs {
+			if !sub.wants(c) {
+				continue
+			}
+			id := uuid.NewString()
+			payload, err := json.Marshal(Payload{ID: id, Type: c.Type, OccurredAt: now, Event: c.Event})
+			if err != nil {
+				d.logger.Error("webhook payload encoding failed", zap.Error(err))
+				continue
+			}
+			next := now
+			d.deliveries[sub.ID] = append(d.deliveries[sub.ID], &Delivery{
+				ID:             id,
+				SubscriptionID: sub.ID,
+				UserID:         sub.UserID,
+				Type:           c.Type,
+				EventId:        c.Event.EventId.String(),
+				Status:         StatusPending,
+				MaxAttempts:    d.opts.MaxAttempts,
+				NextAttempt:    &next,
+				CreatedAt:      now,
+				Payload:        payload,
+			})
+			d.dirty = true
+		}
+	}
+}
+
+// due отбирает доставки, которым пора уйти, и помечает их отправляемыми
+func (d *Dispatcher) due() []*Delivery {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	now := d.now()
+	var list []*Delivery
+	for _, dels := range d.deliveries {
+		for _, del := range dels {
+			if del.Status == StatusPending && !d.inflight[del.ID] && !del.NextAttempt.After(now) {
+				d.inflight[del.ID] = true
+				list = append(list, del)
+			}
+		}
+	}
+	slices.SortFunc(list, func(a, b *Delivery) int { return a.NextAttempt.Compare(*b.NextAttempt) })
+	return list
+}
+
+// untilNext — сколько ждать ближайшего повтора; без ожидающих доставок цикл будит только Publish
+func (d *Dispatcher) untilNext() time.Duration {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	wait := time.Hour
+	for _, dels := range d.deliveries {
+		for _, del := range dels {
+			if del.Status == StatusPending && !d.inflight[del.ID] {
+				wait = min(wait, max(del.NextAttempt.Sub(d.now()), 0))
+			}
+		}
+	}
+	return wait
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, del *Delivery) {
+	d.mu.Lock()
+	sub, ok := d.subs[del.SubscriptionID]
+	var target, secret string
+	if ok {
+		target, secret = sub.URL, sub.Secret
+	}
+	payload, typ := del.Payload, del.Type
+	d.mu.Unlock()
+	if !ok {
+		d.finish(del, nil) // подписку удалили, пока доставка ждала
+		return
+	}
+
+	started := d.now()
+	attempt := Attempt{At: started}
+	status, err := d.send(ctx, target, secret, del.ID, typ, payload)
+	if ctx.Err() != nil {
+		// остановка сервера — попытка не считается
+		d.finish(del, nil)
+		return
+	}
+	attempt.Status, attempt.DurationMs = status, d.now().Sub(started).Milliseconds()
+	if err != nil {
+		attempt.Error = err.Error()
+	}
+	d.finish(del, &attempt)
+}
+
+func (d *Dispatcher) send(ctx context.Context, target, secret, id, typ string, payload []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(payload))
+	if err != nil {
+		return 0, err
+	}
+	ts := d.now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "calendar-webhooks")
+	req.Header.Set(DeliveryHeader, id)
+	req.Header.Set(EventHeader, typ)
+	req.Header.Set(TimestampHeader, strconv.FormatInt(ts, 10))
+	req.Header.Set(SignatureHeader, Sign(secret, ts, payload))
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("responded %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// finish записывает итог попытки: успех, повтор через паузу или dead letter; nil — попытки не было
+func (d *Dispatcher) finish(del *Delivery, attempt *Attempt) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.inflight, del.ID)
+	if _, ok := d.subs[del.SubscriptionID]; !ok || attempt == nil {
+		return
+	}
+	del.Attempts = append(del.Attempts, *attempt)
+	log := d.logger.With(zap.String("webhook_id", del.SubscriptionID), zap.String("delivery_id", del.ID), zap.Int("attempt", len(del.Attempts)))
+	switch {
+	case attempt.Error == "":
+		del.Status, del.NextAttempt = StatusDelivered, nil
+		log.Debug("webhook delivered")
+	case len(del.Attempts) >= del.MaxAttempts:
+		del.Status, del.NextAttempt = StatusDead, nil
+		log.Error("webhook delivery failed, moved to dead letters", zap.String("error", attempt.Error))
+	default:
+		next := d.now().Add(d.backoff(len(del.Attempts)))
+		del.NextAttempt = &next
+		log.Warn("webhook delivery failed, will retry", zap.String("error", attempt.Error), zap.Time("next_attempt", next))
+		d.notify() // цикл Run ждёт, не зная об этом повторе
+	}
+	d.prune(del.SubscriptionID)
+	d.dirty = true
+}
+
+// backoff — пауза после n-й неудачной попытки
+func (d *Dispatcher) backoff(n int) time.Duration {
+	wait := d.opts.Backoff
+	for i := 1; i < n && wait < d.opts.MaxBackoff; i++ {
+		wait *= 2
+	}
+	return min(wait, d.opts.MaxBackoff)
+}
+
+// prune забывает самые старые завершённые доставки сверх History и DeadLetters
+func (d *Dispatcher) prune(subID string) {
+	dels := d.deliveries[subID]
+	delivered, dead := 0, 0
+	var kept []*Delivery
+	for i := len(dels) - 1; i >= 0; i-- {
+		switch dels[i].Status {
+		case StatusDelivered:
+			if delivered++; delivered > d.opts.History {
+				continue
+			}
+		case StatusDead:
+			if dead++; dead > d.opts.DeadLetters {
+				continue
+			}
+		}
+		kept = append(kept, dels[i])
+	}
+	slices.Reverse(kept)
+	d.deliveries[subID] = kept
+}
+
+// Subscribe регистрирует подписку; секрет возвращается только здесь
+func (d *Dispatcher) Subscribe(req *SubscriptionRequest) (Subscription, error) {
+	sub, err := newSubscription(req, d.now())
+	if err != nil {
+		return Subscription{}, err
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	count := 0
+	for _, s := range d.subs {
+		if s.UserID == req.UserID {
+			count++
+		}
+	}
+	if count >= maxSubscriptions {
+		return Subscription{}, fmt.Errorf("at most %d webhooks per user: %w", maxSubscriptions, app.ErrBusinessLogic)
+	}
+	d.subs[sub.ID] = sub
+	if err := d.saveLocked(); err != nil {
+		delete(d.subs, sub.ID)
+		return Subscription{}, err
+	}
+	return *sub, nil
+}
+
+// Unsubscribe удаляет подписку вместе с её доставками
+func (d *Dispatcher) Unsubscribe(userID int, id string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if _, err := d.subscription(userID, id); err != nil {
+		return err
+	}
+	delete(d.subs, id)
+	delete(d.deliveries, id)
+	return d.saveLocked()
+}
+
+// Subscriptions — подписки пользователя по времени создания, без секретов
+func (d *Dispatcher) Subscriptions(userID int) []Subscription {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	list := []Subscription{}
+	for _, s := range d.subs {
+		if s.UserID == userID {
+			list = append(list, s.public())
+		}
+	}
+	slices.SortFunc(list, func(a, b Subscription) int { return a.CreatedAt.Compare(b.CreatedAt) })
+	return list
+}
+
+// Deliveries — доставки подписки, сначала новые; status, если задан, оставляет только доставки в этом состоянии
+func (d *Dispatcher) Deliveries(userID int, id, status string) ([]Delivery, error) {
+	if status != "" && status != StatusPending && status != StatusDelivered && status != StatusDead {
+		return nil, app.InvalidField("status", "must be pending, delivered or dead")
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if _, err := d.subscription(userID, id); err != nil {
+		return nil, err
+	}
+	return d.collect(d.deliveries[id], status), nil
+}
+
+// DeadLetters — недоставленные изменения всех подписок пользователя, сначала новые
+func (d *Dispatcher) DeadLetters(userID int) []Delivery {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var all []*Delivery
+	for id, s := range d.subs {
+		if s.UserID == userID {
+			all = append(all, d.deliveries[id]...)
+		}
+	}
+	slices.SortFunc(all, func(a, b *Delivery) int { return a.CreatedAt.Compare(b.CreatedAt) })
+	return d.collect(all, StatusDead)
+}
+
+func (d *Dispatcher) collect(dels []*Delivery, status string) []Delivery {
+	list := []Delivery{}
+	for i := len(dels) - 1; i >= 0; i-- {
+		if status == "" || dels[i].Status == status {
+			list = append(list, dels[i].clone())
+		}
+	}
+	return list
+}
+
+// Redeliver возвращает dead letter в очередь с новым запасом попыток
+func (d *Dispatcher) Redeliver(userID int, id, deliveryID string) (Delivery, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if _, err := d.subscription(userID, id); err != nil {
+		return Delivery{}, err
+	}
+	i := slices.IndexFunc(d.deliveries[id], func(del *Delivery) bool { return del.ID == deliveryID })
+	if i < 0 {
+		return Delivery{}, fmt.Errorf("delivery %s: %w", deliveryID, app.ErrNotFound)
+	}
+	del := d.deliveries[id][i]
+	if del.Status != StatusDead {
+		return Delivery{}, fmt.Errorf("delivery %s is %s, only dead deliveries can be retried: %w", deliveryID, del.Status, app.ErrConflict)
+	}
+	next := d.now()
+	del.Status, del.NextAttempt, del.MaxAttempts = StatusPending, &next, len(del.Attempts)+d.opts.MaxAttempts
+	if err := d.saveLocked(); err != nil {
+		return Delivery{}, err
+	}
+	d.notify()
+	return del.clone(), nil
+}
+
+// subscription ищет подписку пользователя; чужая выглядит как несуществующая
+func (d *Dispatcher) subscription(userID int, id string) (*Subscription, error) {
+	sub, ok := d.subs[id]
+	if !ok || sub.UserID != userID {
+		return nil, fmt.Errorf("webhook %s: %w", id, app.ErrNotFound)
+	}
+	return sub, nil
+}
+
+// state — содержимое StatePath
+type state struct {
+	Subscriptions []*Subscription `json:"subscriptions"`
+	Deliveries    []*Delivery     `json:"deliveries"`
+}
+
+func (d *Dispatcher) loadState() error {
+	if d.opts.StatePath == "" {
+		return nil
+	}
+	data, err := os.ReadFile(d.opts.StatePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var st state
+	if err := json.Unmarshal(data, &st); err != nil {
+		return fmt.Errorf("webhook state %s: %w", d.opts.StatePath, err)
+	}
+	for _, s := range st.Subscriptions {
+		d.subs[s.ID] = s
+	}
+	for _, del := range st.Deliveries {
+		if _, ok := d.subs[del.SubscriptionID]; ok {
+			d.deliveries[del.SubscriptionID] = append(d.deliveries[del.SubscriptionID], del)
+		}
+	}
+	return nil
+}
+
+// flush записывает состояние, если оно менялось; ошибка только пишется в лог и запись повторяется позже
+func (d *Dispatcher) flush() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !d.dirty {
+		return
+	}
+	if err := d.saveLocked(); err != nil {
+		d.logger.Error("webhook state save failed", zap.Error(err))
+	}
+}
+
+func (d *Dispatcher) saveLocked() error {
+	if d.opts.StatePath == "" {
+		d.dirty = false
+		return nil
+	}
+	st := state{Subscriptions: []*Subscription{}, Deliveries: []*Delivery{}}
+	for _, s := range d.subs {
+		st.Subscriptions = append(st.Subscriptions, s)
+		st.Deliveries = append(st.Deliveries, d.deliveries[s.ID]...)
+	}
+	data, err := json.Marshal(st)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(d.opts.StatePath), 0755); err != nil {
+		return err
+	}
+	// в файле секреты подписок
+	tmp := d.opts.StatePath + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, d.opts.StatePath); err != nil {
+		return err
+	}
+	d.dirty = false
+	return nil
+}
@@ -0,0 +1,283 @@
+package webhook
+
+import (
+	"calendar/internal/app"
+	"calendar/internal/repository"
+	"context"
+	"encoding/json"
+	"errors"
+	"go.uber.org/zap"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type receiver struct {
+	mu       sync.Mutex
+	payloads []Payload
+	failing  atomic.Bool
+	secret   string
+	t        *testing.T
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	if err := Verify(rc.secret, r.Header.Get(TimestampHeader), r.Header.Get(SignatureHeader), body, time.Now(), time.Minute); err != nil {
+		rc.t.Errorf("bad signature: %v", err)
+	}
+	if rc.failing.Load() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	var p Payload
+	if err := json.Unmarshal(body, &p); err != nil || p.ID != r.Header.Get(DeliveryHeader) || p.Type != r.Header.Get(EventHeader) {
+		rc.t.Errorf("unexpected payload %s: %v", body, err)
+	}
+	rc.mu.Lock()
+	rc.payloads = append(rc.payloads, p)
+	rc.mu.Unlock()
+}
+
+func (rc *receiver) received() []Payload {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return append([]Payload(nil), rc.payloads...)
+}
+
+func eventually(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// loopback разрешает доставку на httptest-сервер
+var loopback = []netip.Prefix{netip.MustParsePrefix("127.0.0.0/8"), netip.MustParsePrefix("::1/128")}
+
+func start(t *testing.T, d *Dispatcher) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		d.Run(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+}
+
+func change(typ string, e *app.Event) []repository.Change {
+	return []repository.Change{{Type: typ, Event: *e}}
+}
+
+func TestDispatcherDelivers(t *testing.T) {
+	rc := &receiver{secret: "0123456789abcdef", t: t}
+	srv := httptest.NewServer(rc)
+	defer srv.Close()
+	d, _ := NewDispatcher(Options{AllowedNetworks: loopback}, zap.NewNop())
+
+	sub, err := d.Subscribe(&SubscriptionRequest{UserID: 2, URL: srv.URL, Secret: rc.secret, Events: []string{"created", "deleted"}})
+	if err != nil || sub.Secret != rc.secret {
+		t.Fatalf("Subscribe failed: %+v, %v", sub, err)
+	}
+	other, _ := d.Subscribe(&SubscriptionRequest{UserID: 3, URL: srv.URL + "/other"})
+	if len(other.Secret) != 64 || len(other.Events) != 3 {
+		t.Fatalf("defaults not applied: %+v", other)
+	}
+	start(t, d)
+
+	e, _ := app.NewEvent(&app.EventRequest{UserID: 1, Date: "2025-05-05", EventText: "planning", Attendees: []app.Attendee{{UserID: 2}}})
+	d.Publish(change(repository.ChangeCreated, e))
+	d.Publish(change(repository.ChangeUpdated, e))
+	d.Publish(change(repository.ChangeDeleted, e))
+
+	// участник получает created и deleted, updated он не выбрал; пользователь 3 событие не видит
+	eventually(t, func() bool { return len(rc.received()) == 2 })
+	got := rc.received()
+	if got[0].Event.EventId != e.EventId || got[0].Type == got[1].Type {
+		t.Fatalf("unexpected payloads %+v", got)
+	}
+	eventually(t, func() bool {
+		list, _ := d.Deliveries(2, sub.ID, StatusDelivered)
+		return len(list) == 2
+	})
+	list, _ := d.Deliveries(2, sub.ID, "")
+	if len(list[0].Attempts) != 1 || list[0].Attempts[0].Status != http.StatusOK || list[0].NextAttempt != nil {
+		t.Fatalf("unexpected delivery %+v", list[0])
+	}
+	if list, _ := d.Deliveries(3, other.ID, ""); len(list) != 0 {
+		t.Fatalf("delivery of an invisible event: %+v", list)
+	}
+	if _, err := d.Deliveries(3, sub.ID, ""); !errors.Is(err, app.ErrNotFound) {
+		t.Fatalf("expected not found for a foreign webhook, got %v", err)
+	}
+}
+
+func TestDispatcherRetriesAndDeadLetters(t *testing.T) {
+	rc := &receiver{secret: "0123456789abcdef", t: t}
+	rc.failing.Store(true)
+	srv := httptest.NewServer(rc)
+	defer srv.Close()
+	path := filepath.Join(t.TempDir(), "webhooks.json")
+	opts := Options{StatePath: path, MaxAttempts: 3, Backoff: 10 * time.Millisecond, AllowedNetworks: loopback}
+	d, _ := NewDispatcher(opts, zap.NewNop())
+	sub, _ := d.Subscribe(&SubscriptionRequest{UserID: 1, URL: srv.URL, Secret: rc.secret})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		d.Run(ctx)
+	}()
+	e, _ := app.NewEvent(&app.EventRequest{UserID: 1, Date: "2025-05-05", EventText: "planning"})
+	d.Publish(change(repository.ChangeCreated, e))
+	eventually(t, func() bool { return len(d.DeadLetters(1)) == 1 })
+	cancel()
+	<-done
+
+	dead := d.DeadLetters(1)[0]
+	if len(dead.Attempts) != 3 || dead.Attempts[2].Status != http.StatusServiceUnavailable || dead.Attempts[0].Error == "" {
+		t.Fatalf("unexpected dead letter %+v", dead)
+	}
+	if gap := dead.Attempts[2].At.Sub(dead.Attempts[1].At); gap < 20*time.Millisecond {
+		t.Fatalf("backoff not doubled: %v", gap)
+	}
+
+	// подписка и dead letter переживают перезапуск, секрет наружу не отдаётся
+	d2, err := NewDispatcher(opts, zap.NewNop())
+	if err != nil {
+		t.Fatalf("reload failed: %v", err)
+	}
+	if subs := d2.Subscriptions(1); len(subs) != 1 || subs[0].Secret != "" || subs[0].URL != srv.URL {
+		t.Fatalf("unexpected subscriptions after reload %+v", subs)
+	}
+	if len(d2.DeadLetters(1)) != 1 {
+		t.Fatal("dead letter lost after reload")
+	}
+
+	rc.failing.Store(false)
+	start(t, d2)
+	if _, err := d2.Redeliver(1, sub.ID, "missing"); !errors.Is(err, app.ErrNotFound) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+	retried, err := d2.Redeliver(1, sub.ID, dead.ID)
+	if err != nil || retried.Status != StatusPending || retried.MaxAttempts != 6 {
+		t.Fatalf("Redeliver failed: %+v, %v", retried, err)
+	}
+	eventually(t, func() bool { return len(rc.received()) == 1 })
+	if got := rc.received()[0]; got.ID != dead.ID {
+		t.Fatalf("redelivered payload changed its id: %+v", got)
+	}
+	eventually(t, func() bool { return len(d2.DeadLetters(1)) == 0 })
+	if _, err := d2.Redeliver(1, sub.ID, dead.ID); !errors.Is(err, app.ErrConflict) {
+		t.Fatalf("expected conflict for a delivered delivery, got %v", err)
+	}
+
+	if err := d2.Unsubscribe(1, sub.ID); err != nil {
+		t.Fatalf("Unsubscribe failed: %v", err)
+	}
+	if len(d2.Subscriptions(1)) != 0 {
+		t.Fatal("subscription not removed")
+	}
+}
+
+func TestSubscribeValidation(t *testing.T) {
+	d, _ := NewDispatcher(Options{}, zap.NewNop())
+	for _, req := range []SubscriptionRequest{
+		{UserID: 1},
+		{UserID: 1, URL: "ftp://example.com/hook"},
+		{UserID: 1, URL: "/relative"},
+		{UserID: 1, URL: "https://example.com", Secret: "short"},
+		{UserID: 1, URL: "https://example.com", Events: []string{"moved"}},
+	} {
+		if _, err := d.Subscribe(&req); !errors.Is(err, app.ErrInvalidInput) {
+			t.Errorf("expected invalid input for %+v, got %v", req, err)
+		}
+	}
+	for range maxSubscriptions {
+		if _, err := d.Subscribe(&SubscriptionRequest{UserID: 1, URL: "https://example.com"}); err != nil {
+			t.Fatalf("Subscribe failed: %v", err)
+		}
+	}
+	if _, err := d.Subscribe(&SubscriptionRequest{UserID: 1, URL: "https://example.com"}); !errors.Is(err, app.ErrBusinessLogic) {
+		t.Fatalf("expected the limit to be enforced, got %v", err)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	body := []byte(`{"id":"1"}`)
+	now := time.Unix(1700000000, 0)
+	sig := Sign("secret", now.Unix(), body)
+	if err := Verify("secret", "1700000000", sig, body, now.Add(time.Minute), 5*time.Minute); err != nil {
+		t.Fatalf("valid signature rejected: %v", err)
+	}
+	for _, tc := range []struct {
+		secret, ts string
+		body       []byte
+		at         time.Time
+	}{
+		{"other", "1700000000", body, now},
+		{"secret", "1700000001", body, now},
+		{"secret", "1700000000", []byte(`{"id":"2"}`), now},
+		{"secret", "1700000000", body, now.Add(time.Hour)},
+		{"secret", "soon", body, now},
+	} {
+		if err := Verify(tc.secret, tc.ts, sig, tc.body, tc.at, 5*time.Minute); !errors.Is(err, ErrSignature) {
+			t.Errorf("expected ErrSignature for %+v, got %v", tc, err)
+		}
+	}
+}
+
+func TestDispatcherRejectsInternalAddresses(t *testing.T) {
+	rc := &receiver{secret: "0123456789abcdef", t: t}
+	srv := httptest.NewServer(rc)
+	defer srv.Close()
+	d, _ := NewDispatcher(Options{MaxAttempts: 1, Timeout: time.Second}, zap.NewNop())
+	// localhost разрешается в 127.0.0.1 — проверяется адрес после DNS, а не имя
+	local := strings.Replace(srv.URL, "127.0.0.1", "localhost", 1)
+	for _, target := range []string{srv.URL, local, "http://169.254.169.254/latest/meta-data", "http://10.0.0.1/hook"} {
+		if _, err := d.Subscribe(&SubscriptionRequest{UserID: 1, URL: target, Secret: rc.secret}); err != nil {
+			t.Fatalf("Subscribe %s failed: %v", target, err)
+		}
+	}
+	start(t, d)
+
+	e, _ := app.NewEvent(&app.EventRequest{UserID: 1, Date: "2025-05-05"})
+	d.Publish(change(repository.ChangeCreated, e))
+	eventually(t, func() bool { return len(d.DeadLetters(1)) == 4 })
+	for _, dead := range d.DeadLetters(1) {
+		if a := dead.Attempts[0]; a.Status != 0 || !strings.Contains(a.Error, ErrAddressNotAllowed.Error()) {
+			t.Fatalf("unexpected attempt %+v", a)
+		}
+	}
+	if len(rc.received()) != 0 {
+		t.Fatal("internal address received a delivery")
+	}
+
+	allowed := checkAddress([]netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")})
+	for address, ok := range map[string]bool{
+		"10.1.2.3:80":           true,
+		"192.168.0.1:80":        false,
+		"[::ffff:127.0.0.1]:80": false,
+		"[fe80::1]:443":         false,
+		"0.0.0.0:80":            false,
+		"93.184.215.14:443":     true,
+		"[2606:2800::1]:443":    true,
+	} {
+		if err := allowed("tcp", address, nil); (err == nil) != ok {
+			t.Errorf("checkAddress(%s): %v", address, err)
+		}
+	}
+}
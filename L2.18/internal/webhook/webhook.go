@@ -0,0 +1,198 @@
+package webhook
+
+import (
+	"calendar/internal/app"
+	"calendar/internal/repository"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
+	"unicode/utf8"
+)
+
+// Заголовки доставки
+const (
+	DeliveryHeader  = "X-Calendar-Delivery"
+	EventHeader     = "X-Calendar-Event"
+	TimestampHeader = "X-Calendar-Timestamp"
+	SignatureHeader = "X-Calendar-Signature"
+)
+
+// Состояния доставки
+const (
+	StatusPending   = "pending"
+	StatusDelivered = "delivered"
+	StatusDead      = "dead" // попытки кончились, доставка в списке dead letters
+)
+
+const (
+	maxSubscriptions = 10 // подписок на пользователя
+	minSecretLen     = 16
+	maxSecretLen     = 256
+	maxURLLen        = 2048
+)
+
+var (
+	// ErrSignature — подпись не совпала или устарела
+	ErrSignature = errors.New("invalid webhook signature")
+
+	// eventTypes — типы изменений, на которые можно подписаться
+	eventTypes = []string{repository.ChangeCreated, repository.ChangeUpdated, repository.ChangeDeleted}
+)
+
+// Subscription — адрес, куда отправляются изменения событий пользователя (организатора или участника)
+type Subscription struct {
+	ID        string    `json:"id"`
+	UserID    int       `json:"user_id"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events"`           // created | updated | deleted
+	Secret    string    `json:"secret,omitempty"` // в ответах API только при создании
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// SubscriptionRequest — новая подписка; без secret он генерируется, без events — все типы изменений
+type SubscriptionRequest struct {
+	UserID int      `json:"user_id"`
+	URL    string   `json:"url"`
+	Secret string   `json:"secret,omitempty"`
+	Events []string `json:"events,omitempty"`
+}
+
+// Delivery — отправка одного изменения одной подписке со всеми попытками
+type Delivery struct {
+	ID             string          `json:"id"`
+	SubscriptionID string          `json:"webhook_id"`
+	UserID         int             `json:"user_id"`
+	Type           string          `json:"type"`
+	EventId        string          `json:"event_id"`
+	Status         string          `json:"status"` // pending | delivered | dead
+	MaxAttempts    int             `json:"max_attempts"`
+	Attempts       []Attempt       `json:"attempts"`
+	NextAttempt    *time.Time      `json:"next_attempt,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+	Payload        json.RawMessage `json:"payload" swaggertype:"object"`
+}
+
+// Attempt — одна попытка: код ответа (0 — ответа не было) или ошибка
+type Attempt struct {
+	At         time.Time `json:"at"`
+	Status     int       `json:"status,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	DurationMs int64     `json:"duration_ms"`
+}
+
+// Payload — тело доставки. ID совпадает с заголовком X-Calendar-Delivery и не меняется при повторах,
+// по нему получатель отбрасывает дубли.
+type Payload struct {
+	ID         string    `json:"id"`
+	Type       string    `json:"type"`
+	OccurredAt time.Time `json:"occurred_at"`
+	Event      app.Event `json:"event"`
+}
+
+// Sign — значение X-Calendar-Signature: HMAC-SHA256 от "<timestamp>.<тело>" в hex
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10) + "."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify проверяет подпись доставки на стороне получателя; maxAge ограничивает возраст X-Calendar-Timestamp,
+// чтобы перехваченный запрос нельзя было повторить позже (0 — не проверять)
+func Verify(secret, timestamp, signature string, body []byte, now time.Time, maxAge time.Duration) error {
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: bad timestamp", ErrSignature)
+	}
+	if maxAge > 0 && now.Sub(time.Unix(ts, 0)).Abs() > maxAge {
+		return fmt.Errorf("%w: timestamp is too old", ErrSignature)
+	}
+	if !hmac.Equal([]byte(Sign(secret, ts, body)), []byte(signature)) {
+		return ErrSignature
+	}
+	return nil
+}
+
+// newSubscription проверяет запрос и заполняет значения по умолчанию
+func newSubscription(req *SubscriptionRequest, now time.Time) (*Subscription, error) {
+	u, err := url.Parse(req.URL)
+	switch {
+	case req.URL == "":
+		return nil, app.InvalidField("url", "url is required")
+	case len(req.URL) > maxURLLen:
+		return nil, app.InvalidField("url", fmt.Sprintf("must be at most %d characters", maxURLLen))
+	case err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "":
+		return nil, app.InvalidField("url", "must be an absolute http or https URL")
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		b := make([]byte, 32)
+		rand.Read(b)
+		secret = hex.EncodeToString(b)
+	}
+	if n := utf8.RuneCountInString(secret); n < minSecretLen || n > maxSecretLen {
+		return nil, app.InvalidField("secret", fmt.Sprintf("must be %d to %d characters", minSecretLen, maxSecretLen))
+	}
+
+	events := slices.Clone(eventTypes)
+	if len(req.Events) > 0 {
+		events = nil
+		for _, t := range req.Events {
+			if !slices.Contains(eventTypes, t) {
+				return nil, app.InvalidField("events", fmt.Sprintf("unknown event type %q, expected created, updated or deleted", t))
+			}
+			if !slices.Contains(events, t) {
+				events = append(events, t)
+			}
+		}
+	}
+
+	return &Subscription{
+		ID:        uuid.NewString(),
+		UserID:    req.UserID,
+		URL:       req.URL,
+		Events:    events,
+		Secret:    secret,
+		CreatedAt: now,
+	}, nil
+}
+
+// public — подписка без секрета для ответов API
+func (s *Subscription) public() Subscription {
+	out := *s
+	out.Secret = ""
+	out.Events = slices.Clone(s.Events)
+	return out
+}
+
+// wants — нужна ли подписке доставка изменения: событие видно её пользователю и тип выбран
+func (s *Subscription) wants(c *repository.Change) bool {
+	if !slices.Contains(s.Events, c.Type) {
+		return false
+	}
+	if c.Event.UserID == s.UserID {
+		return true
+	}
+	return c.Event.Attendee(s.UserID) != nil
+}
+
+// clone — копия доставки для ответов API, не разделяющая попытки с оригиналом
+func (d *Delivery) clone() Delivery {
+	out := *d
+	out.Attempts = slices.Clone(d.Attempts)
+	if d.NextAttempt != nil {
+		next := *d.NextAttempt
+		out.NextAttempt = &next
+	}
+	return out
+}